@@ -0,0 +1,289 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"slices"
+	"time"
+
+	"github.com/ethereum/go-ethereum/cmd/utils"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/golem-base/snapshot"
+	"github.com/ethereum/go-ethereum/golem-base/storageutil"
+	"github.com/ethereum/go-ethereum/golem-base/wal"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/urfave/cli/v2"
+)
+
+var (
+	exportWALFromFlag = &cli.Uint64Flag{
+		Name:  "from",
+		Usage: "First block to export (default = the first block that was not exported yet)",
+	}
+	exportWALToFlag = &cli.Uint64Flag{
+		Name:  "to",
+		Usage: "Last block to export (default = the head block)",
+	}
+	exportSnapshotBlockFlag = &cli.Uint64Flag{
+		Name:  "block",
+		Usage: "Block whose entities are exported (default = the head block)",
+	}
+
+	golembaseCommand = &cli.Command{
+		Name:  "golembase",
+		Usage: "A set of commands for Golem Base",
+		Subcommands: []*cli.Command{
+			{
+				Name:   "export-wal",
+				Usage:  "Write the Golem Base write-ahead log of the stored chain history",
+				Action: exportWAL,
+				Flags: slices.Concat([]cli.Flag{
+					utils.GolemBaseWriteAheadLogDir,
+					exportWALFromFlag,
+					exportWALToFlag,
+					utils.CacheFlag,
+				}, utils.NetworkFlags, utils.DatabaseFlags),
+				Description: `
+geth golembase export-wal --golembase.writeaheadlog <dir> [--from N] [--to M]
+replays the stored blocks and receipts through the same logic the node uses
+to write the write-ahead log, to rebuild a lost write-ahead log or to write it
+for the blocks before --golembase.writeaheadlog was enabled. The node must not
+be running, the chain data is opened read-only.
+
+Blocks that were already exported are skipped, so an interrupted export can be
+resumed by running the command again. Without --from, the export continues after
+the last exported block, or starts with block 1. The undo operations of a block
+are only written if the state of its parent is still available.`,
+			},
+			{
+				Name:      "export-snapshot",
+				Usage:     "Write all Golem Base entities at a block to a snapshot file",
+				ArgsUsage: "<filename>",
+				Action:    exportSnapshot,
+				Flags: slices.Concat([]cli.Flag{
+					exportSnapshotBlockFlag,
+					utils.CacheFlag,
+				}, utils.NetworkFlags, utils.DatabaseFlags),
+				Description: `
+geth golembase export-snapshot [--block N] <filename>
+writes all entities at the block with their metadata and payloads to a snapshot
+file, together with the number and hash of the block. The file is compressed if
+its name ends with ".gz". The ETLs load a snapshot as their starting point and
+continue with the write-ahead log after its block.
+
+The node must not be running, the chain data is opened read-only. The state of
+the block must be available, which is only the case for recent blocks unless
+the node keeps the full state history (--gcmode archive).`,
+			},
+		},
+	}
+)
+
+func exportWAL(ctx *cli.Context) error {
+	stack, _ := makeConfigNode(ctx)
+	defer stack.Close()
+
+	walDir := stack.Config().GolemBaseWriteAheadLogDir
+	if walDir == "" {
+		utils.Fatalf("The --%s flag is required", utils.GolemBaseWriteAheadLogDir.Name)
+	}
+
+	chain, db := utils.MakeChain(ctx, stack, true)
+	defer db.Close()
+
+	head := chain.CurrentBlock().Number.Uint64()
+
+	last := head
+	if ctx.IsSet(exportWALToFlag.Name) {
+		last = ctx.Uint64(exportWALToFlag.Name)
+		if last > head {
+			utils.Fatalf("Export error: block number %d larger than head block %d", last, head)
+		}
+	}
+
+	first := uint64(1)
+	if ctx.IsSet(exportWALFromFlag.Name) {
+		first = max(ctx.Uint64(exportWALFromFlag.Name), 1)
+	} else {
+		var err error
+		first, err = firstBlockToExport(chain, walDir)
+		if err != nil {
+			utils.Fatalf("Export error: %v", err)
+		}
+	}
+
+	start := time.Now()
+
+	exported, err := exportWALBlocks(chain, walDir, first, last)
+	if err != nil {
+		utils.Fatalf("Export error: %v", err)
+	}
+
+	fmt.Printf("Exported %d blocks to the write-ahead log in %v\n", exported, time.Since(start))
+	return nil
+}
+
+// isExported reports whether the block file of the canonical block was already written.
+func isExported(walDir string, number uint64, hash common.Hash) (bool, error) {
+	bi, err := wal.ReadBlockInfo(walDir, number)
+	if errors.Is(err, os.ErrNotExist) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return bi.Hash == hash, nil
+}
+
+// firstBlockToExport returns the block after the last exported canonical block. Exported blocks
+// that are not canonical any more are exported again, which turns them into reverts.
+func firstBlockToExport(chain *core.BlockChain, walDir string) (uint64, error) {
+	number, ok, err := wal.LastBlockNumber(walDir)
+	if err != nil || !ok {
+		return 1, err
+	}
+
+	for ; number > 0; number-- {
+		header := chain.GetHeaderByNumber(number)
+		if header == nil {
+			continue
+		}
+
+		exported, err := isExported(walDir, number, header.Hash())
+		if err != nil {
+			return 0, err
+		}
+		if exported {
+			return number + 1, nil
+		}
+	}
+
+	return 1, nil
+}
+
+// parentStateForExport returns the state of the parent of the block, or nil if it was pruned.
+func parentStateForExport(chain *core.BlockChain, block *types.Block) storageutil.StateAccess {
+	parent := chain.GetHeader(block.ParentHash(), block.NumberU64()-1)
+	if parent == nil || !chain.HasState(parent.Root) {
+		return nil
+	}
+
+	parentState, err := chain.StateAt(parent.Root)
+	if err != nil {
+		return nil
+	}
+
+	return parentState
+}
+
+func exportWALBlocks(chain *core.BlockChain, walDir string, first, last uint64) (int, error) {
+	log.Info("Exporting the Golem Base write-ahead log", "first", first, "last", last, "dir", walDir)
+
+	err := wal.UnmarkPruned(walDir, first)
+	if err != nil {
+		return 0, err
+	}
+
+	chainID := chain.Config().ChainID
+
+	exported := 0
+	reported := time.Now()
+	withoutUndo := 0
+
+	for number := first; number <= last; number++ {
+		block := chain.GetBlockByNumber(number)
+		if block == nil {
+			return exported, fmt.Errorf("block %d not found", number)
+		}
+
+		done, err := isExported(walDir, number, block.Hash())
+		if err != nil {
+			return exported, err
+		}
+		if done {
+			continue
+		}
+
+		receipts := chain.GetReceiptsByHash(block.Hash())
+		if len(receipts) != len(block.Transactions()) {
+			return exported, fmt.Errorf("receipts of block %d not found", number)
+		}
+
+		parentState := parentStateForExport(chain, block)
+		if parentState == nil {
+			withoutUndo++
+		}
+
+		err = wal.WriteLogForBlock(walDir, block, chainID, receipts, parentState)
+		if err != nil {
+			return exported, fmt.Errorf("failed to write block %d: %w", number, err)
+		}
+
+		exported++
+
+		if time.Since(reported) > 8*time.Second {
+			log.Info("Exporting the Golem Base write-ahead log", "block", number, "last", last, "exported", exported)
+			reported = time.Now()
+		}
+	}
+
+	if withoutUndo > 0 {
+		log.Warn("Exported blocks without undo operations, the state of their parents was pruned", "blocks", withoutUndo)
+	}
+
+	return exported, nil
+}
+
+func exportSnapshot(ctx *cli.Context) error {
+	if ctx.Args().Len() != 1 {
+		utils.Fatalf("This command requires an argument.")
+	}
+	path := ctx.Args().First()
+
+	stack, _ := makeConfigNode(ctx)
+	defer stack.Close()
+
+	chain, db := utils.MakeChain(ctx, stack, true)
+	defer db.Close()
+
+	header := chain.CurrentBlock()
+	if ctx.IsSet(exportSnapshotBlockFlag.Name) {
+		number := ctx.Uint64(exportSnapshotBlockFlag.Name)
+		header = chain.GetHeaderByNumber(number)
+		if header == nil {
+			utils.Fatalf("Export error: block %d not found", number)
+		}
+	}
+
+	if !chain.HasState(header.Root) {
+		utils.Fatalf("Export error: the state of block %d is not available", header.Number.Uint64())
+	}
+
+	state, err := chain.StateAt(header.Root)
+	if err != nil {
+		utils.Fatalf("Export error: %v", err)
+	}
+
+	start := time.Now()
+
+	sh := snapshot.Header{
+		ChainID:     chain.Config().ChainID,
+		BlockNumber: header.Number.Uint64(),
+		BlockHash:   header.Hash(),
+		ParentHash:  header.ParentHash,
+		Entities:    snapshot.Count(state),
+	}
+
+	log.Info("Exporting a Golem Base snapshot", "block", sh.BlockNumber, "hash", sh.BlockHash, "entities", sh.Entities, "file", path)
+
+	err = snapshot.WriteFile(path, sh, snapshot.FromState(state))
+	if err != nil {
+		utils.Fatalf("Export error: %v", err)
+	}
+
+	fmt.Printf("Exported %d entities of block %d to %s in %v\n", sh.Entities, sh.BlockNumber, path, time.Since(start))
+	return nil
+}
@@ -179,6 +179,9 @@ var (
 		utils.BeaconGenesisTimeFlag,
 		utils.BeaconCheckpointFlag,
 		utils.GolemBaseWriteAheadLogDir,
+		utils.GolemBaseWriteAheadLogRetainBlocksFlag,
+		utils.GolemBaseWriteAheadLogPruneAcknowledgedFlag,
+		utils.GolemBaseWriteAheadLogSegmentsFlag,
 	}, utils.NetworkFlags, utils.DatabaseFlags)
 
 	rpcFlags = []cli.Flag{
@@ -266,6 +269,8 @@ func init() {
 		utils.ShowDeprecated,
 		// See snapshot.go
 		snapshotCommand,
+		// See golembasecmd.go
+		golembaseCommand,
 		// See verkle.go
 		verkleCommand,
 	}
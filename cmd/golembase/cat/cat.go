@@ -5,7 +5,8 @@ import (
 	"os"
 	"os/signal"
 
-	"github.com/ethereum/go-ethereum/rpc"
+	"github.com/ethereum/go-ethereum/common"
+	golembase "github.com/ethereum/go-ethereum/golem-base/client"
 	"github.com/urfave/cli/v2"
 )
 
@@ -35,22 +36,15 @@ func Cat() *cli.Command {
 				return fmt.Errorf("key is required")
 			}
 			// Connect to the geth node
-			rpcClient, err := rpc.Dial(cfg.NodeURL)
+			client, err := golembase.Dial(ctx, cfg.NodeURL)
 			if err != nil {
-				return fmt.Errorf("failed to connect to node: %w", err)
+				return err
 			}
-			defer rpcClient.Close()
+			defer client.Close()
 
-			var v []byte
-
-			err = rpcClient.CallContext(
-				ctx,
-				&v,
-				"golembase_getStorageValue",
-				key,
-			)
+			v, err := client.GetStorageValue(ctx, common.HexToHash(key))
 			if err != nil {
-				return fmt.Errorf("failed to get storage value: %w", err)
+				return err
 			}
 
 			fmt.Println("data:", string(v))
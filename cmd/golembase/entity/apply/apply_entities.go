@@ -0,0 +1,160 @@
+package apply
+
+import (
+	"fmt"
+	"os"
+	"os/signal"
+	"path/filepath"
+
+	"github.com/ethereum/go-ethereum/cmd/golembase/account/pkg/useraccount"
+	"github.com/ethereum/go-ethereum/core/types"
+	golembase "github.com/ethereum/go-ethereum/golem-base/client"
+	"github.com/urfave/cli/v2"
+)
+
+// defaultMaxSize keeps the storage transactions below the 512 KiB transaction size limit of the transaction pool.
+const defaultMaxSize = 480 * 1024
+
+func Apply() *cli.Command {
+	cfg := struct {
+		nodeURL string
+		file    string
+		dryRun  bool
+		maxGas  uint64
+		maxSize uint64
+	}{}
+	return &cli.Command{
+		Name:  "apply",
+		Usage: "Apply the creates, updates, deletes and extends of a manifest file in as few transactions as possible",
+		Flags: []cli.Flag{
+			&cli.StringFlag{
+				Name:        "node-url",
+				Usage:       "The URL of the node to connect to",
+				Value:       "http://localhost:8545",
+				EnvVars:     []string{"NODE_URL"},
+				Destination: &cfg.nodeURL,
+			},
+			&cli.StringFlag{
+				Name:        "file",
+				Aliases:     []string{"f"},
+				Usage:       "YAML or JSON manifest with the operations to apply",
+				Required:    true,
+				Destination: &cfg.file,
+			},
+			&cli.BoolFlag{
+				Name:        "dry-run",
+				Usage:       "only show the changes and the transactions, without sending them",
+				Destination: &cfg.dryRun,
+			},
+			&cli.Uint64Flag{
+				Name:        "max-gas",
+				Usage:       "maximum gas of a transaction, defaults to the gas limit of the latest block",
+				EnvVars:     []string{"MAX_GAS"},
+				Destination: &cfg.maxGas,
+			},
+			&cli.Uint64Flag{
+				Name:        "max-size",
+				Usage:       "maximum size of a storage transaction in bytes",
+				Value:       defaultMaxSize,
+				EnvVars:     []string{"MAX_SIZE"},
+				Destination: &cfg.maxSize,
+			},
+		},
+		Action: func(c *cli.Context) error {
+			ctx, cancel := signal.NotifyContext(c.Context, os.Interrupt)
+			defer cancel()
+
+			m, err := loadManifest(cfg.file)
+			if err != nil {
+				return err
+			}
+
+			batch, err := m.batch(filepath.Dir(cfg.file))
+			if err != nil {
+				return err
+			}
+
+			if batch.Len() == 0 {
+				fmt.Println("The manifest has no operations")
+				return nil
+			}
+
+			userAccount, err := useraccount.Load()
+			if err != nil {
+				return fmt.Errorf("failed to load user account: %w", err)
+			}
+
+			// Connect to the geth node
+			client, err := golembase.Dial(ctx, cfg.nodeURL)
+			if err != nil {
+				return err
+			}
+			defer client.Close()
+
+			client.SetPrivateKey(userAccount.PrivateKey)
+
+			if cfg.maxGas == 0 {
+				header, err := client.HeaderByNumber(ctx, nil)
+				if err != nil {
+					return fmt.Errorf("failed to get latest block: %w", err)
+				}
+				cfg.maxGas = header.GasLimit
+			}
+
+			batches, err := batch.Pack(golembase.PackLimits{MaxGas: cfg.maxGas, MaxSize: cfg.maxSize})
+			if err != nil {
+				return err
+			}
+
+			err = printDiff(ctx, os.Stdout, client, userAccount.Address, batch)
+			if err != nil {
+				return err
+			}
+
+			fmt.Printf("%d operations in %d transactions\n", batch.Len(), len(batches))
+
+			if cfg.dryRun {
+				return nil
+			}
+
+			// all transactions are sent before waiting for the first one, so that they can be
+			// included in the same blocks
+			txs := []*types.Transaction{}
+			for i, b := range batches {
+				tx, err := client.SendBatch(ctx, b)
+				if err != nil {
+					err = fmt.Errorf("failed to send transaction %d of %d: %w", i+1, len(batches), err)
+					if len(txs) == 0 {
+						return err
+					}
+					// the transactions sent so far are still applied
+					fmt.Println(err)
+					break
+				}
+				txs = append(txs, tx)
+			}
+
+			failed := 0
+			for i, tx := range txs {
+				receipt, err := client.WaitForReceipt(ctx, tx.Hash())
+				if err != nil {
+					fmt.Printf("Transaction %d of %d failed: %v\n", i+1, len(batches), err)
+					failed++
+					continue
+				}
+
+				fmt.Printf("Transaction %d of %d applied %d operations in block %d, tx %s\n", i+1, len(batches), batches[i].Len(), receipt.BlockNumber, tx.Hash().Hex())
+
+				for _, key := range receipt.CreatedKeys() {
+					fmt.Println("Entity created", "key", key)
+				}
+			}
+
+			if failed > 0 || len(txs) < len(batches) {
+				return fmt.Errorf("%d of %d transactions were not applied", len(batches)-len(txs)+failed, len(batches))
+			}
+
+			return nil
+		},
+	}
+}
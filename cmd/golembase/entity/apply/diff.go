@@ -0,0 +1,111 @@
+package apply
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"slices"
+
+	"github.com/ethereum/go-ethereum/common"
+	golembase "github.com/ethereum/go-ethereum/golem-base/client"
+	"github.com/ethereum/go-ethereum/golem-base/storageutil/entity"
+)
+
+// annotationLines returns the annotations as `key = value` lines, string values quoted.
+func annotationLines(stringAnnotations []entity.StringAnnotation, numericAnnotations []entity.NumericAnnotation) []string {
+	lines := []string{}
+	for _, a := range stringAnnotations {
+		lines = append(lines, fmt.Sprintf("%s = %q", a.Key, a.Value))
+	}
+	for _, a := range numericAnnotations {
+		lines = append(lines, fmt.Sprintf("%s = %d", a.Key, a.Value))
+	}
+	return lines
+}
+
+func printAnnotationDiff(w io.Writer, old, new []string) {
+	for _, l := range old {
+		if !slices.Contains(new, l) {
+			fmt.Fprintf(w, "    - %s\n", l)
+		}
+	}
+	for _, l := range new {
+		if !slices.Contains(old, l) {
+			fmt.Fprintf(w, "    + %s\n", l)
+		}
+	}
+}
+
+// printDiff writes the changes the batch makes to the entities of the node. The entities that
+// are updated, deleted or extended have to exist and be owned by the owner, otherwise the
+// storage transaction would fail. The meta data of deleted entities is left in the state,
+// so their existence is checked with the entities of the owner.
+func printDiff(ctx context.Context, w io.Writer, client *golembase.Client, owner common.Address, b *golembase.Batch) error {
+	tx := b.StorageTransaction()
+
+	owned := map[common.Hash]bool{}
+	if len(tx.Update)+len(tx.Delete)+len(tx.Extend) > 0 {
+		keys, err := client.GetEntitiesOfOwner(ctx, owner)
+		if err != nil {
+			return err
+		}
+		for _, key := range keys {
+			owned[key] = true
+		}
+	}
+
+	existing := func(key common.Hash) (*entity.EntityMetaData, error) {
+		if !owned[key] {
+			return nil, fmt.Errorf("entity %s does not exist or is not owned by %s", key.Hex(), owner.Hex())
+		}
+		return client.GetEntityMetaData(ctx, key)
+	}
+
+	for _, op := range tx.Create {
+		fmt.Fprintf(w, "+ create entity: payload %d bytes, expires after %d blocks\n", len(op.Payload), op.TTL)
+		printAnnotationDiff(w, nil, annotationLines(op.StringAnnotations, op.NumericAnnotations))
+	}
+
+	for _, op := range tx.Update {
+		md, err := existing(op.EntityKey)
+		if err != nil {
+			return err
+		}
+
+		payload, err := client.GetStorageValue(ctx, op.EntityKey)
+		if err != nil {
+			return err
+		}
+
+		fmt.Fprintf(w, "~ update %s\n", op.EntityKey.Hex())
+		if bytes.Equal(payload, op.Payload) {
+			fmt.Fprintf(w, "    payload unchanged, %d bytes\n", len(payload))
+		} else {
+			fmt.Fprintf(w, "    payload %d -> %d bytes\n", len(payload), len(op.Payload))
+		}
+		fmt.Fprintf(w, "    expires at block %d -> after %d blocks\n", md.ExpiresAtBlock, op.TTL)
+		printAnnotationDiff(w, annotationLines(md.StringAnnotations, md.NumericAnnotations), annotationLines(op.StringAnnotations, op.NumericAnnotations))
+	}
+
+	for _, key := range tx.Delete {
+		md, err := existing(key)
+		if err != nil {
+			return err
+		}
+
+		fmt.Fprintf(w, "- delete %s\n", key.Hex())
+		printAnnotationDiff(w, annotationLines(md.StringAnnotations, md.NumericAnnotations), nil)
+	}
+
+	for _, op := range tx.Extend {
+		md, err := existing(op.EntityKey)
+		if err != nil {
+			return err
+		}
+
+		fmt.Fprintf(w, "> extend %s by %d blocks: expires at block %d -> %d\n", op.EntityKey.Hex(), op.NumberOfBlocks, md.ExpiresAtBlock, md.ExpiresAtBlock+op.NumberOfBlocks)
+	}
+
+	return nil
+}
@@ -0,0 +1,176 @@
+package apply
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	golembase "github.com/ethereum/go-ethereum/golem-base/client"
+	"gopkg.in/yaml.v3"
+)
+
+// manifest describes the operations applied by `golembase entity apply`.
+// It is read as YAML, which JSON files are as well.
+type manifest struct {
+	Create []manifestEntity `yaml:"create"`
+	Update []manifestUpdate `yaml:"update"`
+	Delete []string         `yaml:"delete"`
+	Extend []manifestExtend `yaml:"extend"`
+}
+
+// manifestEntity is a created entity, or the new state of an updated one.
+// The payload is either given as text or read from a file relative to the manifest.
+type manifestEntity struct {
+	Payload            *string           `yaml:"payload"`
+	PayloadFile        string            `yaml:"payloadFile"`
+	TTL                uint64            `yaml:"ttl"`
+	StringAnnotations  map[string]string `yaml:"stringAnnotations"`
+	NumericAnnotations map[string]uint64 `yaml:"numericAnnotations"`
+}
+
+type manifestUpdate struct {
+	Key            string `yaml:"key"`
+	manifestEntity `yaml:",inline"`
+}
+
+type manifestExtend struct {
+	Key    string `yaml:"key"`
+	Blocks uint64 `yaml:"blocks"`
+}
+
+func loadManifest(path string) (*manifest, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read manifest: %w", err)
+	}
+
+	dec := yaml.NewDecoder(bytes.NewReader(data))
+	dec.KnownFields(true)
+
+	m := &manifest{}
+	err = dec.Decode(m)
+	if err != nil && !errors.Is(err, io.EOF) {
+		return nil, fmt.Errorf("failed to parse manifest %s: %w", path, err)
+	}
+
+	return m, nil
+}
+
+func parseKey(s string) (common.Hash, error) {
+	b, err := hexutil.Decode(s)
+	if err != nil || len(b) != common.HashLength {
+		return common.Hash{}, fmt.Errorf("invalid entity key %q", s)
+	}
+	return common.BytesToHash(b), nil
+}
+
+// payload returns the payload of the entity, reading payload files relative to dir.
+func (e manifestEntity) payload(dir string) ([]byte, error) {
+	switch {
+	case e.Payload != nil && e.PayloadFile != "":
+		return nil, errors.New("payload and payloadFile are mutually exclusive")
+	case e.PayloadFile != "":
+		path := e.PayloadFile
+		if !filepath.IsAbs(path) {
+			path = filepath.Join(dir, path)
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read payload file: %w", err)
+		}
+		return data, nil
+	case e.Payload != nil:
+		return []byte(*e.Payload), nil
+	default:
+		return []byte{}, nil
+	}
+}
+
+// sortedKeys returns the annotation keys in a stable order, maps have none.
+func sortedKeys[V any](m map[string]V) []string {
+	return slices.Sorted(maps.Keys(m))
+}
+
+// batch returns the operations of the manifest as a single batch. Payload files are read
+// relative to dir.
+func (m *manifest) batch(dir string) (*golembase.Batch, error) {
+	b := golembase.NewBatch()
+
+	for i, e := range m.Create {
+		payload, err := e.payload(dir)
+		if err != nil {
+			return nil, fmt.Errorf("create %d: %w", i, err)
+		}
+
+		if e.TTL == 0 {
+			return nil, fmt.Errorf("create %d: ttl is required", i)
+		}
+
+		op := golembase.NewCreate(payload, e.TTL)
+		for _, k := range sortedKeys(e.StringAnnotations) {
+			op.StringAnnotation(k, e.StringAnnotations[k])
+		}
+		for _, k := range sortedKeys(e.NumericAnnotations) {
+			op.NumericAnnotation(k, e.NumericAnnotations[k])
+		}
+
+		b.Create(op)
+	}
+
+	for i, e := range m.Update {
+		key, err := parseKey(e.Key)
+		if err != nil {
+			return nil, fmt.Errorf("update %d: %w", i, err)
+		}
+
+		payload, err := e.payload(dir)
+		if err != nil {
+			return nil, fmt.Errorf("update %d: %w", i, err)
+		}
+
+		if e.TTL == 0 {
+			return nil, fmt.Errorf("update %d: ttl is required", i)
+		}
+
+		op := golembase.NewUpdate(key, payload, e.TTL)
+		for _, k := range sortedKeys(e.StringAnnotations) {
+			op.StringAnnotation(k, e.StringAnnotations[k])
+		}
+		for _, k := range sortedKeys(e.NumericAnnotations) {
+			op.NumericAnnotation(k, e.NumericAnnotations[k])
+		}
+
+		b.Update(op)
+	}
+
+	for i, s := range m.Delete {
+		key, err := parseKey(s)
+		if err != nil {
+			return nil, fmt.Errorf("delete %d: %w", i, err)
+		}
+
+		b.Delete(key)
+	}
+
+	for i, e := range m.Extend {
+		key, err := parseKey(e.Key)
+		if err != nil {
+			return nil, fmt.Errorf("extend %d: %w", i, err)
+		}
+
+		if e.Blocks == 0 {
+			return nil, fmt.Errorf("extend %d: blocks is required", i)
+		}
+
+		b.Extend(key, e.Blocks)
+	}
+
+	return b, nil
+}
@@ -1,8 +1,10 @@
 package entity
 
 import (
+	"github.com/ethereum/go-ethereum/cmd/golembase/entity/apply"
 	"github.com/ethereum/go-ethereum/cmd/golembase/entity/create"
 	"github.com/ethereum/go-ethereum/cmd/golembase/entity/delete"
+	"github.com/ethereum/go-ethereum/cmd/golembase/entity/extend"
 	"github.com/ethereum/go-ethereum/cmd/golembase/entity/update"
 	"github.com/urfave/cli/v2"
 )
@@ -15,6 +17,8 @@ func Entity() *cli.Command {
 			create.Create(),
 			delete.Delete(),
 			update.Update(),
+			extend.Extend(),
+			apply.Apply(),
 		},
 	}
 }
@@ -0,0 +1,76 @@
+package extend
+
+import (
+	"fmt"
+	"os"
+	"os/signal"
+
+	"github.com/ethereum/go-ethereum/cmd/golembase/account/pkg/useraccount"
+	"github.com/ethereum/go-ethereum/common"
+	golembase "github.com/ethereum/go-ethereum/golem-base/client"
+	"github.com/urfave/cli/v2"
+)
+
+func Extend() *cli.Command {
+	cfg := struct {
+		nodeURL string
+		key     string
+		blocks  uint64
+	}{}
+	return &cli.Command{
+		Name:  "extend",
+		Usage: "Extend the TTL of an existing entity",
+		Flags: []cli.Flag{
+			&cli.StringFlag{
+				Name:        "node-url",
+				Usage:       "The URL of the node to connect to",
+				Value:       "http://localhost:8545",
+				EnvVars:     []string{"NODE_URL"},
+				Destination: &cfg.nodeURL,
+			},
+			&cli.StringFlag{
+				Name:        "key",
+				Usage:       "key of the entity to extend",
+				Required:    true,
+				EnvVars:     []string{"ENTITY_KEY"},
+				Destination: &cfg.key,
+			},
+			&cli.Uint64Flag{
+				Name:        "blocks",
+				Usage:       "number of blocks to extend the TTL of the entity by",
+				Required:    true,
+				EnvVars:     []string{"ENTITY_EXTEND_BLOCKS"},
+				Destination: &cfg.blocks,
+			},
+		},
+		Action: func(c *cli.Context) error {
+			ctx, cancel := signal.NotifyContext(c.Context, os.Interrupt)
+			defer cancel()
+
+			userAccount, err := useraccount.Load()
+			if err != nil {
+				return fmt.Errorf("failed to load user account: %w", err)
+			}
+
+			// Connect to the geth node
+			client, err := golembase.Dial(ctx, cfg.nodeURL)
+			if err != nil {
+				return err
+			}
+			defer client.Close()
+
+			client.SetPrivateKey(userAccount.PrivateKey)
+
+			key := common.HexToHash(cfg.key)
+
+			expiresAtBlock, err := client.Extend(ctx, key, cfg.blocks)
+			if err != nil {
+				return err
+			}
+
+			fmt.Println("Entity extended", "key", key, "expires at block", expiresAtBlock)
+
+			return nil
+		},
+	}
+}
@@ -2,19 +2,12 @@ package update
 
 import (
 	"fmt"
-	"math/big"
 	"os"
 	"os/signal"
 
-	"github.com/ethereum/go-ethereum/accounts/abi/bind"
 	"github.com/ethereum/go-ethereum/cmd/golembase/account/pkg/useraccount"
 	"github.com/ethereum/go-ethereum/common"
-	"github.com/ethereum/go-ethereum/core/types"
-	"github.com/ethereum/go-ethereum/ethclient"
-	"github.com/ethereum/go-ethereum/golem-base/address"
-	"github.com/ethereum/go-ethereum/golem-base/storagetx"
-	"github.com/ethereum/go-ethereum/golem-base/storageutil/entity"
-	"github.com/ethereum/go-ethereum/rlp"
+	golembase "github.com/ethereum/go-ethereum/golem-base/client"
 	"github.com/urfave/cli/v2"
 )
 
@@ -68,87 +61,21 @@ func Update() *cli.Command {
 			}
 
 			// Connect to the geth node
-			client, err := ethclient.DialContext(ctx, cfg.nodeURL)
+			client, err := golembase.Dial(ctx, cfg.nodeURL)
 			if err != nil {
-				return fmt.Errorf("failed to connect to node: %w", err)
+				return err
 			}
 			defer client.Close()
 
-			// Get the chain ID
-			chainID, err := client.ChainID(ctx)
-			if err != nil {
-				return fmt.Errorf("failed to get chain ID: %w", err)
-			}
-
-			// Get the nonce for the sender account
-			nonce, err := client.PendingNonceAt(ctx, userAccount.Address)
-			if err != nil {
-				return fmt.Errorf("failed to get nonce: %w", err)
-			}
-
-			// Create the storage transaction
-			storageTx := &storagetx.StorageTransaction{
-				Update: []storagetx.Update{
-					{
-						EntityKey: common.HexToHash(c.String("key")),
-						TTL:       c.Uint64("ttl"),
-						Payload:   []byte(c.String("data")),
-						StringAnnotations: []entity.StringAnnotation{
-							{
-								Key:   "foo",
-								Value: "bar",
-							},
-						},
-					},
-				},
-			}
-
-			// Encode the storage transaction
-			txData, err := rlp.EncodeToBytes(storageTx)
-			if err != nil {
-				return fmt.Errorf("failed to encode storage tx: %w", err)
-			}
-
-			// Create the GolemBaseUpdateStorageTx
-			tx := &types.DynamicFeeTx{
-				ChainID:   chainID,
-				Nonce:     nonce,
-				Gas:       1_000_000,
-				Data:      txData,
-				To:        &address.GolemBaseStorageProcessorAddress,
-				GasTipCap: big.NewInt(1e9), // 1 Gwei
-				GasFeeCap: big.NewInt(5e9), // 5 Gwei
-			}
-
-			// Use the London signer since we're using a dynamic fee transaction
-			signer := types.LatestSignerForChainID(chainID)
-
-			// Create and sign the transaction
-			signedTx, err := types.SignNewTx(userAccount.PrivateKey, signer, tx)
-			if err != nil {
-				return fmt.Errorf("failed to sign transaction: %w", err)
-			}
-
-			txHash := signedTx.Hash()
+			client.SetPrivateKey(userAccount.PrivateKey)
 
-			err = client.SendTransaction(ctx, signedTx)
+			receipt, err := client.Update(ctx, golembase.NewUpdate(common.HexToHash(c.String("key")), []byte(c.String("data")), c.Uint64("ttl")).StringAnnotation("foo", "bar"))
 			if err != nil {
-				return fmt.Errorf("failed to send tx: %w", err)
-			}
-
-			receipt, err := bind.WaitMinedHash(ctx, client, txHash)
-			if err != nil {
-				return fmt.Errorf("failed to wait for tx: %w", err)
-			}
-
-			if receipt.Status != types.ReceiptStatusSuccessful {
-				return fmt.Errorf("tx failed")
+				return err
 			}
 
-			for _, log := range receipt.Logs {
-				if log.Topics[0] == storagetx.GolemBaseStorageEntityUpdated {
-					fmt.Println("Entity updated", "key", log.Topics[1])
-				}
+			for _, e := range receipt.Updated {
+				fmt.Println("Entity updated", "key", e.Key)
 			}
 
 			return nil
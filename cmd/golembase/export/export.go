@@ -0,0 +1,189 @@
+package export
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+
+	golembase "github.com/ethereum/go-ethereum/golem-base/client"
+	"github.com/ethereum/go-ethereum/golem-base/golemtype"
+	"github.com/urfave/cli/v2"
+)
+
+func Export() *cli.Command {
+	cfg := struct {
+		nodeURL         string
+		format          string
+		outputFile      string
+		pageSize        uint64
+		omitPayload     bool
+		payloadEncoding string
+	}{}
+	return &cli.Command{
+		Name:      "export",
+		Usage:     "export the entities matching a query with their meta data",
+		ArgsUsage: "<query>",
+		Flags: []cli.Flag{
+			&cli.StringFlag{
+				Name:        "node-url",
+				Usage:       "The URL of the node to connect to",
+				Value:       "http://localhost:8545",
+				EnvVars:     []string{"NODE_URL"},
+				Destination: &cfg.nodeURL,
+			},
+			&cli.StringFlag{
+				Name:        "format",
+				Usage:       "output format: jsonl or csv",
+				Value:       "jsonl",
+				Destination: &cfg.format,
+			},
+			&cli.StringFlag{
+				Name:        "output",
+				Aliases:     []string{"o"},
+				Usage:       "file to write the entities to, - for stdout",
+				Value:       "-",
+				Destination: &cfg.outputFile,
+			},
+			&cli.Uint64Flag{
+				Name:        "page-size",
+				Usage:       "number of entities fetched per request",
+				Value:       1000,
+				Destination: &cfg.pageSize,
+			},
+			&cli.BoolFlag{
+				Name:        "omit-payload",
+				Usage:       "only export the keys and the meta data of the entities",
+				Destination: &cfg.omitPayload,
+			},
+			&cli.StringFlag{
+				Name:        "payload-encoding",
+				Usage:       "encoding of the payloads: base64, hex or text",
+				Value:       "base64",
+				Destination: &cfg.payloadEncoding,
+			},
+		},
+		Action: func(c *cli.Context) error {
+
+			ctx, stop := signal.NotifyContext(c.Context, os.Interrupt)
+			defer stop()
+
+			query := c.Args().First()
+			if query == "" {
+				return fmt.Errorf("query is required")
+			}
+
+			if cfg.pageSize == 0 {
+				return fmt.Errorf("page size must be greater than 0")
+			}
+
+			encodePayload, err := payloadEncoder(cfg.payloadEncoding)
+			if err != nil {
+				return err
+			}
+
+			out := os.Stdout
+			if cfg.outputFile != "-" {
+				f, err := os.Create(cfg.outputFile)
+				if err != nil {
+					return fmt.Errorf("failed to create output file: %w", err)
+				}
+				defer f.Close()
+				out = f
+			}
+
+			var w recordWriter
+			switch cfg.format {
+			case "jsonl":
+				w = newJSONLWriter(out)
+			case "csv":
+				w = newCSVWriter(out, !cfg.omitPayload)
+			default:
+				return fmt.Errorf("unsupported format %q", cfg.format)
+			}
+
+			client, err := golembase.Dial(ctx, cfg.nodeURL)
+			if err != nil {
+				return err
+			}
+			defer client.Close()
+
+			options := golemtype.QueryOptions{
+				Limit:       cfg.pageSize,
+				OmitPayload: cfg.omitPayload,
+				Projection:  golemtype.ProjectionMetaData,
+			}
+
+			count, block, err := exportEntities(ctx, client, query, options, w, encodePayload)
+			if err != nil {
+				return err
+			}
+
+			err = w.flush()
+			if err != nil {
+				return fmt.Errorf("failed to write entities: %w", err)
+			}
+
+			if out != os.Stdout {
+				err = out.Close()
+				if err != nil {
+					return fmt.Errorf("failed to close output file: %w", err)
+				}
+			}
+
+			fmt.Fprintf(os.Stderr, "Exported %d entities at block %d\n", count, block)
+
+			return nil
+		},
+	}
+}
+
+// exportEntities writes the entities matching the query to w, following the cursors of the
+// pages. All pages are read from the block of the first page, so the export is a consistent
+// view of the entities at that block. It returns the number of entities and the block.
+//
+// Nodes that are not archive nodes only keep the state of recent blocks, so an export
+// taking longer than that fails once the state of the block of the first page is pruned.
+func exportEntities(
+	ctx context.Context,
+	client *golembase.Client,
+	query string,
+	options golemtype.QueryOptions,
+	w recordWriter,
+	encodePayload func([]byte) (string, error),
+) (int, uint64, error) {
+	count := 0
+	var block uint64
+
+	for page := 1; ; page++ {
+		res, err := client.QueryEntities(ctx, query, &options)
+		if err != nil {
+			if page > 1 {
+				return 0, 0, fmt.Errorf(
+					"failed to read page %d at block %d, the node may no longer have the state of the block, use an archive node or a larger page size: %w",
+					page, block, err,
+				)
+			}
+			return 0, 0, err
+		}
+		block = res.BlockNumber
+
+		for _, r := range res.Results {
+			rec, err := newRecord(r, !options.OmitPayload, encodePayload)
+			if err != nil {
+				return 0, 0, fmt.Errorf("failed to export entity %s: %w", r.Key.Hex(), err)
+			}
+
+			err = w.write(rec)
+			if err != nil {
+				return 0, 0, fmt.Errorf("failed to write entity %s: %w", r.Key.Hex(), err)
+			}
+			count++
+		}
+
+		if res.Cursor == "" {
+			return count, block, nil
+		}
+		options.Cursor = res.Cursor
+	}
+}
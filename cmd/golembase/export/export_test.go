@@ -0,0 +1,138 @@
+package export
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/cmd/golembase/pkg/clienttest"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/golem-base/golemtype"
+	"github.com/ethereum/go-ethereum/golem-base/storageutil/entity"
+	"github.com/ethereum/go-ethereum/rpc"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeGolemBase serves pages of its entities from golembase_queryEntitiesPage,
+// with the index of the next entity as the cursor.
+type fakeGolemBase struct {
+	entities []golemtype.SearchResultWithMetaData
+	// failFromPage makes the pages from this one on fail, 0 for never
+	failFromPage int
+
+	pages   int
+	cursors []string
+}
+
+func (f *fakeGolemBase) QueryEntitiesPage(ctx context.Context, query string, block *rpc.BlockNumberOrHash, options *golemtype.QueryOptions) (*golemtype.QueryResult, error) {
+	f.pages++
+	f.cursors = append(f.cursors, options.Cursor)
+
+	if f.failFromPage != 0 && f.pages >= f.failFromPage {
+		return nil, errors.New("failed to get state: missing trie node")
+	}
+
+	from := 0
+	if options.Cursor != "" {
+		var err error
+		from, err = strconv.Atoi(options.Cursor)
+		if err != nil {
+			return nil, err
+		}
+	}
+	to := min(from+int(options.Limit), len(f.entities))
+
+	res := &golemtype.QueryResult{
+		BlockNumber: 42,
+		Results:     f.entities[from:to],
+	}
+	if to < len(f.entities) {
+		res.Cursor = strconv.Itoa(to)
+	}
+	return res, nil
+}
+
+func testEntity(i int) golemtype.SearchResultWithMetaData {
+	return golemtype.SearchResultWithMetaData{
+		SearchResult: golemtype.SearchResult{
+			Key:   common.Hash{31: byte(i + 1)},
+			Value: []byte("payload " + strconv.Itoa(i)),
+		},
+		EntityMetaData: &entity.EntityMetaData{
+			Owner:              common.Address{1},
+			ExpiresAtBlock:     uint64(100 + i),
+			StringAnnotations:  []entity.StringAnnotation{{Key: "type", Value: "order"}},
+			NumericAnnotations: []entity.NumericAnnotation{{Key: "index", Value: uint64(i)}},
+		},
+	}
+}
+
+func TestExportFollowsCursors(t *testing.T) {
+	f := &fakeGolemBase{}
+	for i := range 5 {
+		f.entities = append(f.entities, testEntity(i))
+	}
+	client := clienttest.NewClient(t, map[string]any{"golembase": f})
+
+	encodePayload, err := payloadEncoder("text")
+	require.NoError(t, err)
+
+	out := &bytes.Buffer{}
+	w := newJSONLWriter(out)
+
+	count, block, err := exportEntities(context.Background(), client, `type = "order"`, golemtype.QueryOptions{Limit: 2}, w, encodePayload)
+	require.NoError(t, err)
+	require.NoError(t, w.flush())
+
+	require.Equal(t, 5, count)
+	require.Equal(t, uint64(42), block)
+	require.Equal(t, []string{"", "2", "4"}, f.cursors)
+
+	lines := strings.Split(strings.TrimSuffix(out.String(), "\n"), "\n")
+	require.Len(t, lines, 5)
+	require.Contains(t, lines[4], `"payload":"payload 4"`)
+}
+
+func TestExportEmptyResult(t *testing.T) {
+	f := &fakeGolemBase{}
+	client := clienttest.NewClient(t, map[string]any{"golembase": f})
+
+	out := &bytes.Buffer{}
+	w := newCSVWriter(out, false)
+
+	count, _, err := exportEntities(context.Background(), client, `type = "order"`, golemtype.QueryOptions{Limit: 2, OmitPayload: true}, w, nil)
+	require.NoError(t, err)
+	require.NoError(t, w.flush())
+
+	require.Equal(t, 0, count)
+	require.Equal(t, 1, f.pages)
+	require.Equal(t, "key,owner,expires_at_block,string_annotations,numeric_annotations\n", out.String())
+}
+
+func TestExportFailingLaterPage(t *testing.T) {
+	f := &fakeGolemBase{failFromPage: 2}
+	for i := range 5 {
+		f.entities = append(f.entities, testEntity(i))
+	}
+	client := clienttest.NewClient(t, map[string]any{"golembase": f})
+
+	encodePayload, err := payloadEncoder("base64")
+	require.NoError(t, err)
+
+	_, _, err = exportEntities(context.Background(), client, `type = "order"`, golemtype.QueryOptions{Limit: 2}, newJSONLWriter(&bytes.Buffer{}), encodePayload)
+	require.ErrorContains(t, err, "failed to read page 2 at block 42")
+	require.ErrorContains(t, err, "archive node")
+	require.ErrorContains(t, err, "missing trie node")
+}
+
+func TestExportFailingFirstPage(t *testing.T) {
+	f := &fakeGolemBase{failFromPage: 1}
+	client := clienttest.NewClient(t, map[string]any{"golembase": f})
+
+	_, _, err := exportEntities(context.Background(), client, `type = "order"`, golemtype.QueryOptions{Limit: 2}, newJSONLWriter(&bytes.Buffer{}), nil)
+	require.ErrorContains(t, err, "missing trie node")
+	require.NotContains(t, err.Error(), "archive node")
+}
@@ -0,0 +1,172 @@
+package export
+
+import (
+	"bufio"
+	"encoding/base64"
+	"encoding/csv"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"unicode/utf8"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/golem-base/golemtype"
+	"github.com/ethereum/go-ethereum/golem-base/storageutil/entity"
+)
+
+// record is an exported entity. Its JSON form is a line of the jsonl format.
+type record struct {
+	Key                common.Hash                `json:"key"`
+	Owner              common.Address             `json:"owner"`
+	ExpiresAtBlock     uint64                     `json:"expiresAtBlock"`
+	StringAnnotations  []entity.StringAnnotation  `json:"stringAnnotations"`
+	NumericAnnotations []entity.NumericAnnotation `json:"numericAnnotations"`
+	Payload            *string                    `json:"payload,omitempty"`
+}
+
+func newRecord(r golemtype.SearchResultWithMetaData, withPayload bool, encodePayload func([]byte) (string, error)) (record, error) {
+	rec := record{
+		Key:                r.Key,
+		StringAnnotations:  []entity.StringAnnotation{},
+		NumericAnnotations: []entity.NumericAnnotation{},
+	}
+
+	if r.EntityMetaData != nil {
+		rec.Owner = r.Owner
+		rec.ExpiresAtBlock = r.ExpiresAtBlock
+		if r.StringAnnotations != nil {
+			rec.StringAnnotations = r.StringAnnotations
+		}
+		if r.NumericAnnotations != nil {
+			rec.NumericAnnotations = r.NumericAnnotations
+		}
+	}
+
+	if withPayload {
+		payload, err := encodePayload(r.Value)
+		if err != nil {
+			return record{}, err
+		}
+		rec.Payload = &payload
+	}
+
+	return rec, nil
+}
+
+// payloadEncoder returns the function encoding the payloads in the given encoding.
+// The text encoding fails for payloads that are not valid UTF-8.
+func payloadEncoder(encoding string) (func([]byte) (string, error), error) {
+	switch encoding {
+	case "base64":
+		return func(b []byte) (string, error) { return base64.StdEncoding.EncodeToString(b), nil }, nil
+	case "hex":
+		return func(b []byte) (string, error) { return hex.EncodeToString(b), nil }, nil
+	case "text":
+		return func(b []byte) (string, error) {
+			if !utf8.Valid(b) {
+				return "", fmt.Errorf("payload is not valid UTF-8, use the base64 or hex encoding")
+			}
+			return string(b), nil
+		}, nil
+	default:
+		return nil, fmt.Errorf("unsupported payload encoding %q", encoding)
+	}
+}
+
+type recordWriter interface {
+	write(rec record) error
+	flush() error
+}
+
+type jsonlWriter struct {
+	w   *bufio.Writer
+	enc *json.Encoder
+}
+
+func newJSONLWriter(w io.Writer) *jsonlWriter {
+	bw := bufio.NewWriter(w)
+	return &jsonlWriter{w: bw, enc: json.NewEncoder(bw)}
+}
+
+func (w *jsonlWriter) write(rec record) error {
+	return w.enc.Encode(rec)
+}
+
+func (w *jsonlWriter) flush() error {
+	return w.w.Flush()
+}
+
+// csvWriter writes a header and a row per entity. The annotations are written as JSON objects
+// mapping the keys of the annotations to their values.
+type csvWriter struct {
+	w           *csv.Writer
+	withPayload bool
+	wroteHeader bool
+}
+
+func newCSVWriter(w io.Writer, withPayload bool) *csvWriter {
+	return &csvWriter{w: csv.NewWriter(w), withPayload: withPayload}
+}
+
+// writeHeader writes the header before the first row, or on flush if there are no rows.
+func (w *csvWriter) writeHeader() error {
+	if w.wroteHeader {
+		return nil
+	}
+	w.wroteHeader = true
+
+	header := []string{"key", "owner", "expires_at_block", "string_annotations", "numeric_annotations"}
+	if w.withPayload {
+		header = append(header, "payload")
+	}
+	return w.w.Write(header)
+}
+
+func (w *csvWriter) write(rec record) error {
+	err := w.writeHeader()
+	if err != nil {
+		return err
+	}
+
+	stringAnnotations := map[string]string{}
+	for _, a := range rec.StringAnnotations {
+		stringAnnotations[a.Key] = a.Value
+	}
+	numericAnnotations := map[string]uint64{}
+	for _, a := range rec.NumericAnnotations {
+		numericAnnotations[a.Key] = a.Value
+	}
+
+	sa, err := json.Marshal(stringAnnotations)
+	if err != nil {
+		return err
+	}
+	na, err := json.Marshal(numericAnnotations)
+	if err != nil {
+		return err
+	}
+
+	row := []string{
+		rec.Key.Hex(),
+		rec.Owner.Hex(),
+		strconv.FormatUint(rec.ExpiresAtBlock, 10),
+		string(sa),
+		string(na),
+	}
+	if w.withPayload && rec.Payload != nil {
+		row = append(row, *rec.Payload)
+	}
+
+	return w.w.Write(row)
+}
+
+func (w *csvWriter) flush() error {
+	err := w.writeHeader()
+	if err != nil {
+		return err
+	}
+	w.w.Flush()
+	return w.w.Error()
+}
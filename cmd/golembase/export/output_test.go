@@ -0,0 +1,120 @@
+package export
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/golem-base/golemtype"
+	"github.com/stretchr/testify/require"
+)
+
+func TestJSONLWriter(t *testing.T) {
+	encodePayload, err := payloadEncoder("hex")
+	require.NoError(t, err)
+
+	rec, err := newRecord(testEntity(1), true, encodePayload)
+	require.NoError(t, err)
+
+	out := &bytes.Buffer{}
+	w := newJSONLWriter(out)
+	require.NoError(t, w.write(rec))
+	require.NoError(t, w.flush())
+
+	require.JSONEq(t, `{
+		"key": "0x0000000000000000000000000000000000000000000000000000000000000002",
+		"owner": "0x0100000000000000000000000000000000000000",
+		"expiresAtBlock": 101,
+		"stringAnnotations": [{"key": "type", "value": "order"}],
+		"numericAnnotations": [{"key": "index", "value": 1}],
+		"payload": "7061796c6f61642031"
+	}`, out.String())
+}
+
+func TestJSONLWriterWithoutMetaData(t *testing.T) {
+	rec, err := newRecord(golemtype.SearchResultWithMetaData{
+		SearchResult: golemtype.SearchResult{Key: common.Hash{1}},
+	}, false, nil)
+	require.NoError(t, err)
+
+	out := &bytes.Buffer{}
+	w := newJSONLWriter(out)
+	require.NoError(t, w.write(rec))
+	require.NoError(t, w.flush())
+
+	require.JSONEq(t, `{
+		"key": "0x0100000000000000000000000000000000000000000000000000000000000000",
+		"owner": "0x0000000000000000000000000000000000000000",
+		"expiresAtBlock": 0,
+		"stringAnnotations": [],
+		"numericAnnotations": []
+	}`, out.String())
+}
+
+func TestCSVWriter(t *testing.T) {
+	encodePayload, err := payloadEncoder("base64")
+	require.NoError(t, err)
+
+	rec, err := newRecord(testEntity(1), true, encodePayload)
+	require.NoError(t, err)
+
+	out := &bytes.Buffer{}
+	w := newCSVWriter(out, true)
+	require.NoError(t, w.write(rec))
+	require.NoError(t, w.flush())
+
+	require.Equal(t,
+		"key,owner,expires_at_block,string_annotations,numeric_annotations,payload\n"+
+			"0x0000000000000000000000000000000000000000000000000000000000000002,0x0100000000000000000000000000000000000000,101,"+
+			`"{""type"":""order""}","{""index"":1}",cGF5bG9hZCAx`+"\n",
+		out.String(),
+	)
+}
+
+func TestCSVWriterOmitPayload(t *testing.T) {
+	rec, err := newRecord(testEntity(1), false, nil)
+	require.NoError(t, err)
+
+	out := &bytes.Buffer{}
+	w := newCSVWriter(out, false)
+	require.NoError(t, w.write(rec))
+	require.NoError(t, w.write(rec))
+	require.NoError(t, w.flush())
+
+	require.Equal(t,
+		"key,owner,expires_at_block,string_annotations,numeric_annotations\n"+
+			"0x0000000000000000000000000000000000000000000000000000000000000002,0x0100000000000000000000000000000000000000,101,"+
+			`"{""type"":""order""}","{""index"":1}"`+"\n"+
+			"0x0000000000000000000000000000000000000000000000000000000000000002,0x0100000000000000000000000000000000000000,101,"+
+			`"{""type"":""order""}","{""index"":1}"`+"\n",
+		out.String(),
+	)
+}
+
+func TestPayloadEncoder(t *testing.T) {
+	for _, tc := range []struct {
+		encoding string
+		payload  []byte
+		expected string
+	}{
+		{encoding: "base64", payload: []byte{0xff, 0x00}, expected: "/wA="},
+		{encoding: "hex", payload: []byte{0xff, 0x00}, expected: "ff00"},
+		{encoding: "text", payload: []byte("héllo"), expected: "héllo"},
+		{encoding: "text", payload: nil, expected: ""},
+	} {
+		encodePayload, err := payloadEncoder(tc.encoding)
+		require.NoError(t, err)
+
+		encoded, err := encodePayload(tc.payload)
+		require.NoError(t, err)
+		require.Equal(t, tc.expected, encoded, tc.encoding)
+	}
+
+	encodePayload, err := payloadEncoder("text")
+	require.NoError(t, err)
+	_, err = encodePayload([]byte{0xff})
+	require.ErrorContains(t, err, "not valid UTF-8")
+
+	_, err = payloadEncoder("base32")
+	require.ErrorContains(t, err, "unsupported payload encoding")
+}
@@ -0,0 +1,160 @@
+package inspect
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"os"
+	"os/signal"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	golembase "github.com/ethereum/go-ethereum/golem-base/client"
+	"github.com/ethereum/go-ethereum/rpc"
+	"github.com/urfave/cli/v2"
+)
+
+func Inspect() *cli.Command {
+	cfg := struct {
+		nodeURL      string
+		previewBytes int
+		timeWindow   uint64
+	}{}
+	return &cli.Command{
+		Name:      "inspect",
+		Usage:     "show the owner, expiration, annotations and a payload preview of an entity",
+		ArgsUsage: "<key>",
+		Flags: []cli.Flag{
+			&cli.StringFlag{
+				Name:        "node-url",
+				Usage:       "The URL of the node to connect to",
+				Value:       "http://localhost:8545",
+				EnvVars:     []string{"NODE_URL"},
+				Destination: &cfg.nodeURL,
+			},
+			&cli.IntFlag{
+				Name:        "preview-bytes",
+				Usage:       "maximum number of payload bytes to preview, 0 for no preview",
+				Value:       512,
+				Destination: &cfg.previewBytes,
+			},
+			&cli.Uint64Flag{
+				Name:        "block-time-window",
+				Usage:       "number of recent blocks the block time is averaged over to estimate the expiration time",
+				Value:       100,
+				Destination: &cfg.timeWindow,
+			},
+		},
+		Action: func(c *cli.Context) error {
+
+			ctx, stop := signal.NotifyContext(c.Context, os.Interrupt)
+			defer stop()
+
+			key := c.Args().First()
+			if key == "" {
+				return fmt.Errorf("key is required")
+			}
+			entityKey := common.HexToHash(key)
+
+			client, err := golembase.Dial(ctx, cfg.nodeURL)
+			if err != nil {
+				return err
+			}
+			defer client.Close()
+
+			return inspectEntity(ctx, client, os.Stdout, entityKey, cfg.previewBytes, cfg.timeWindow)
+		},
+	}
+}
+
+// inspectEntity writes the owner, the expiration, the annotations and a payload preview of
+// the entity to out, all read from the latest block.
+func inspectEntity(ctx context.Context, client *golembase.Client, out io.Writer, entityKey common.Hash, previewBytes int, timeWindow uint64) error {
+	head, err := client.HeaderByNumber(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to get latest header: %w", err)
+	}
+
+	// read everything from the same block
+	atHead := client.AtBlock(rpc.BlockNumberOrHashWithHash(head.Hash(), false))
+
+	// the node fails for entities that do not exist at the block
+	md, err := atHead.GetEntityMetaData(ctx, entityKey)
+	var rpcErr rpc.Error
+	if errors.As(err, &rpcErr) {
+		return fmt.Errorf("entity %s does not exist", entityKey.Hex())
+	}
+	if err != nil {
+		return err
+	}
+
+	payload, err := atHead.GetStorageValue(ctx, entityKey)
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintf(out, "Key:        %s\n", entityKey.Hex())
+	fmt.Fprintf(out, "Owner:      %s\n", md.Owner.Hex())
+
+	headNumber := head.Number.Uint64()
+	blocksLeft := uint64(0)
+	if md.ExpiresAtBlock > headNumber {
+		blocksLeft = md.ExpiresAtBlock - headNumber
+	}
+	fmt.Fprintf(out, "Expires at: block %d, in %d blocks", md.ExpiresAtBlock, blocksLeft)
+
+	blockTime, err := averageBlockTime(ctx, client, headNumber, head.Time, timeWindow)
+	if err != nil {
+		return err
+	}
+	if blockTime > 0 {
+		left := time.Duration(blocksLeft) * blockTime
+		expiresAt := time.Unix(int64(head.Time), 0).Add(left)
+		fmt.Fprintf(out, " (~%s, around %s)", left.Round(time.Second), expiresAt.UTC().Format(time.RFC3339))
+	}
+	fmt.Fprintln(out)
+
+	fmt.Fprintln(out, "Annotations:")
+	if len(md.StringAnnotations)+len(md.NumericAnnotations) == 0 {
+		fmt.Fprintln(out, "  (none)")
+	}
+	for _, a := range md.StringAnnotations {
+		fmt.Fprintf(out, "  %s = %q\n", a.Key, a.Value)
+	}
+	for _, a := range md.NumericAnnotations {
+		fmt.Fprintf(out, "  %s = %d\n", a.Key, a.Value)
+	}
+
+	fmt.Fprintf(out, "Payload:    %d bytes, %s\n", len(payload), contentType(payload))
+	if previewBytes > 0 && len(payload) > 0 {
+		fmt.Fprintln(out, preview(payload, previewBytes))
+	}
+
+	return nil
+}
+
+// averageBlockTime estimates the block time from the timestamps of the last window blocks.
+// The genesis block is left out, its timestamp is unrelated to the production of blocks.
+// It returns zero if there are no blocks to compare with or their timestamps do not advance.
+func averageBlockTime(ctx context.Context, client *golembase.Client, headNumber, headTime, window uint64) (time.Duration, error) {
+	if headNumber < 2 {
+		return 0, nil
+	}
+	window = min(window, headNumber-1)
+	if window == 0 {
+		return 0, nil
+	}
+
+	from, err := client.HeaderByNumber(ctx, new(big.Int).SetUint64(headNumber-window))
+	if err != nil {
+		return 0, fmt.Errorf("failed to get header: %w", err)
+	}
+
+	if headTime <= from.Time {
+		return 0, nil
+	}
+
+	return time.Duration(headTime-from.Time) * time.Second / time.Duration(window), nil
+}
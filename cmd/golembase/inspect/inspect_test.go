@@ -0,0 +1,149 @@
+package inspect
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/cmd/golembase/pkg/clienttest"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/golem-base/storageutil/entity"
+	"github.com/ethereum/go-ethereum/rpc"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeEth serves the headers of a chain whose blocks are two seconds apart.
+type fakeEth struct {
+	head uint64
+}
+
+func (f *fakeEth) header(number uint64) *types.Header {
+	return &types.Header{
+		Number:     new(big.Int).SetUint64(number),
+		Time:       1_700_000_000 + 2*number,
+		Difficulty: new(big.Int),
+	}
+}
+
+func (f *fakeEth) GetBlockByNumber(ctx context.Context, number rpc.BlockNumber, fullTx bool) (*types.Header, error) {
+	if number == rpc.LatestBlockNumber {
+		return f.header(f.head), nil
+	}
+	return f.header(uint64(number)), nil
+}
+
+// fakeGolemBase serves the entities of the fake state. Like the node for entities deleted
+// before the storage rules, it keeps the meta data of deleted entities but does not return it.
+type fakeGolemBase struct {
+	metaData map[common.Hash]*entity.EntityMetaData
+	payloads map[common.Hash][]byte
+	deleted  map[common.Hash]bool
+
+	blocks []*rpc.BlockNumberOrHash
+}
+
+func (f *fakeGolemBase) GetEntityMetaData(ctx context.Context, key common.Hash, block *rpc.BlockNumberOrHash) (*entity.EntityMetaData, error) {
+	f.blocks = append(f.blocks, block)
+	md, ok := f.metaData[key]
+	if !ok || f.deleted[key] {
+		return nil, fmt.Errorf("entity %s not found", key.Hex())
+	}
+	return md, nil
+}
+
+func (f *fakeGolemBase) GetStorageValue(ctx context.Context, key common.Hash, block *rpc.BlockNumberOrHash) ([]byte, error) {
+	f.blocks = append(f.blocks, block)
+	return f.payloads[key], nil
+}
+
+var (
+	testKey   = common.Hash{1}
+	testOwner = common.Address{2}
+)
+
+func newTestState() *fakeGolemBase {
+	return &fakeGolemBase{
+		metaData: map[common.Hash]*entity.EntityMetaData{
+			testKey: {
+				Owner:              testOwner,
+				ExpiresAtBlock:     40,
+				StringAnnotations:  []entity.StringAnnotation{{Key: "type", Value: "order"}},
+				NumericAnnotations: []entity.NumericAnnotation{{Key: "amount", Value: 7}},
+			},
+		},
+		payloads: map[common.Hash][]byte{
+			testKey: []byte(`{"id":1}`),
+		},
+		deleted: map[common.Hash]bool{},
+	}
+}
+
+func TestInspect(t *testing.T) {
+	eth := &fakeEth{head: 10}
+	gb := newTestState()
+	client := clienttest.NewClient(t, map[string]any{"eth": eth, "golembase": gb})
+
+	out := &bytes.Buffer{}
+	err := inspectEntity(context.Background(), client, out, testKey, 512, 100)
+	require.NoError(t, err)
+
+	require.Equal(t, `Key:        0x0100000000000000000000000000000000000000000000000000000000000000
+Owner:      0x0200000000000000000000000000000000000000
+Expires at: block 40, in 30 blocks (~1m0s, around 2023-11-14T22:14:40Z)
+Annotations:
+  type = "order"
+  amount = 7
+Payload:    8 bytes, application/json
+{
+  "id": 1
+}
+`, out.String())
+
+	// all lookups are made at the hash of the head
+	headHash := eth.header(eth.head).Hash()
+	for _, block := range gb.blocks {
+		require.NotNil(t, block)
+		hash, ok := block.Hash()
+		require.True(t, ok)
+		require.Equal(t, headHash, hash)
+	}
+}
+
+func TestInspectWithoutAnnotationsAndPreview(t *testing.T) {
+	eth := &fakeEth{head: 1}
+	gb := newTestState()
+	gb.metaData[testKey].StringAnnotations = nil
+	gb.metaData[testKey].NumericAnnotations = nil
+	client := clienttest.NewClient(t, map[string]any{"eth": eth, "golembase": gb})
+
+	out := &bytes.Buffer{}
+	err := inspectEntity(context.Background(), client, out, testKey, 0, 100)
+	require.NoError(t, err)
+
+	require.Equal(t, `Key:        0x0100000000000000000000000000000000000000000000000000000000000000
+Owner:      0x0200000000000000000000000000000000000000
+Expires at: block 40, in 39 blocks
+Annotations:
+  (none)
+Payload:    8 bytes, application/json
+`, out.String())
+}
+
+func TestInspectMissingEntity(t *testing.T) {
+	client := clienttest.NewClient(t, map[string]any{"eth": &fakeEth{head: 10}, "golembase": newTestState()})
+
+	err := inspectEntity(context.Background(), client, &bytes.Buffer{}, common.Hash{3}, 512, 100)
+	require.EqualError(t, err, "entity 0x0300000000000000000000000000000000000000000000000000000000000000 does not exist")
+}
+
+func TestInspectDeletedEntity(t *testing.T) {
+	gb := newTestState()
+	gb.deleted[testKey] = true
+	client := clienttest.NewClient(t, map[string]any{"eth": &fakeEth{head: 10}, "golembase": gb})
+
+	err := inspectEntity(context.Background(), client, &bytes.Buffer{}, testKey, 512, 100)
+	require.EqualError(t, err, "entity 0x0100000000000000000000000000000000000000000000000000000000000000 does not exist")
+}
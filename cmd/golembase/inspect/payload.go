@@ -0,0 +1,65 @@
+package inspect
+
+import (
+	"bytes"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"unicode/utf8"
+)
+
+// contentType sniffs the content type of a payload. JSON is detected on top of the types
+// http.DetectContentType knows, which reports it as plain text.
+func contentType(payload []byte) string {
+	if len(payload) == 0 {
+		return "empty"
+	}
+
+	if json.Valid(payload) {
+		trimmed := bytes.TrimSpace(payload)
+		if len(trimmed) > 0 && (trimmed[0] == '{' || trimmed[0] == '[') {
+			return "application/json"
+		}
+	}
+
+	return http.DetectContentType(payload)
+}
+
+func isText(contentType string) bool {
+	return strings.HasPrefix(contentType, "text/") || contentType == "application/json"
+}
+
+// preview returns at most limit bytes of the payload, as text if the payload is text and as a
+// hex dump otherwise. JSON payloads are indented if they are shown completely.
+func preview(payload []byte, limit int) string {
+	ct := contentType(payload)
+	shown := payload[:min(len(payload), limit)]
+
+	var sb strings.Builder
+
+	switch {
+	case ct == "application/json" && len(shown) == len(payload):
+		var indented bytes.Buffer
+		err := json.Indent(&indented, payload, "", "  ")
+		if err != nil {
+			return string(payload)
+		}
+		sb.Write(indented.Bytes())
+	case isText(ct):
+		// do not cut a multi-byte character in half
+		for i := 1; i < utf8.UTFMax && len(shown) < len(payload) && !utf8.Valid(shown); i++ {
+			shown = shown[:len(shown)-1]
+		}
+		sb.Write(shown)
+	default:
+		sb.WriteString(strings.TrimSuffix(hex.Dump(shown), "\n"))
+	}
+
+	if len(shown) < len(payload) {
+		fmt.Fprintf(&sb, "\n... %d more bytes", len(payload)-len(shown))
+	}
+
+	return sb.String()
+}
@@ -0,0 +1,59 @@
+package inspect
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestContentType(t *testing.T) {
+	for _, tc := range []struct {
+		payload  []byte
+		expected string
+	}{
+		{payload: nil, expected: "empty"},
+		{payload: []byte(` {"a": 1}`), expected: "application/json"},
+		{payload: []byte(`[1, 2]`), expected: "application/json"},
+		{payload: []byte(`42`), expected: "text/plain; charset=utf-8"},
+		{payload: []byte("hello"), expected: "text/plain; charset=utf-8"},
+		{payload: []byte{0x00, 0x01, 0x02}, expected: "application/octet-stream"},
+	} {
+		require.Equal(t, tc.expected, contentType(tc.payload), string(tc.payload))
+	}
+}
+
+func TestPreview(t *testing.T) {
+	for _, tc := range []struct {
+		name     string
+		payload  []byte
+		limit    int
+		expected string
+	}{
+		{
+			name:     "complete JSON is indented",
+			payload:  []byte(`{"a":1}`),
+			limit:    100,
+			expected: "{\n  \"a\": 1\n}",
+		},
+		{
+			name:     "cut JSON is shown as text",
+			payload:  []byte(`{"a":1}`),
+			limit:    4,
+			expected: "{\"a\"\n... 3 more bytes",
+		},
+		{
+			name:     "multi-byte characters are not cut",
+			payload:  []byte("aé"),
+			limit:    2,
+			expected: "a\n... 2 more bytes",
+		},
+		{
+			name:     "binary payloads are hex dumped",
+			payload:  []byte{0x00, 0x01, 0x02, 0x03},
+			limit:    2,
+			expected: "00000000  00 01                                             |..|\n... 2 more bytes",
+		},
+	} {
+		require.Equal(t, tc.expected, preview(tc.payload, tc.limit), tc.name)
+	}
+}
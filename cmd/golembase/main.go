@@ -8,7 +8,11 @@ import (
 	"github.com/ethereum/go-ethereum/cmd/golembase/blocks"
 	"github.com/ethereum/go-ethereum/cmd/golembase/cat"
 	"github.com/ethereum/go-ethereum/cmd/golembase/entity"
+	"github.com/ethereum/go-ethereum/cmd/golembase/export"
+	"github.com/ethereum/go-ethereum/cmd/golembase/inspect"
 	"github.com/ethereum/go-ethereum/cmd/golembase/query"
+	"github.com/ethereum/go-ethereum/cmd/golembase/wal"
+	"github.com/ethereum/go-ethereum/cmd/golembase/watch"
 	"github.com/urfave/cli/v2"
 )
 
@@ -25,6 +29,10 @@ func main() {
 			blocks.Blocks(),
 			cat.Cat(),
 			query.Query(),
+			export.Export(),
+			inspect.Inspect(),
+			watch.Watch(),
+			wal.WAL(),
 		},
 	}
 
@@ -0,0 +1,28 @@
+// Package clienttest holds the code the tests of the golembase commands share: a client of
+// fake services served in-process.
+package clienttest
+
+import (
+	"testing"
+
+	golembase "github.com/ethereum/go-ethereum/golem-base/client"
+	"github.com/ethereum/go-ethereum/rpc"
+	"github.com/stretchr/testify/require"
+)
+
+// NewClient returns a client of an in-process RPC server serving the services, keyed by their
+// namespace, e.g. "eth" or "golembase". The server and the client are closed with the test.
+func NewClient(t *testing.T, services map[string]any) *golembase.Client {
+	t.Helper()
+
+	srv := rpc.NewServer()
+	for namespace, service := range services {
+		require.NoError(t, srv.RegisterName(namespace, service))
+	}
+	t.Cleanup(srv.Stop)
+
+	c := rpc.DialInProc(srv)
+	t.Cleanup(c.Close)
+
+	return golembase.NewClient(c)
+}
@@ -0,0 +1,70 @@
+package query
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/ethereum/go-ethereum/golem-base/golemtype"
+)
+
+func printJSON(w io.Writer, res golemtype.QueryResult) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(res)
+}
+
+func printTable(w io.Writer, res golemtype.QueryResult, withPayload, withMetaData bool) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+
+	header := []string{"KEY"}
+	if withMetaData {
+		header = append(header, "OWNER", "EXPIRES AT", "ANNOTATIONS")
+	}
+	if withPayload {
+		header = append(header, "PAYLOAD")
+	}
+	fmt.Fprintln(tw, strings.Join(header, "\t"))
+
+	for _, r := range res.Results {
+		row := []string{r.Key.Hex()}
+		if withMetaData && r.EntityMetaData != nil {
+			row = append(row, r.Owner.Hex(), fmt.Sprint(r.ExpiresAtBlock), formatAnnotations(r))
+		}
+		if withPayload {
+			row = append(row, formatPayload(r.Value))
+		}
+		fmt.Fprintln(tw, strings.Join(row, "\t"))
+	}
+
+	err := tw.Flush()
+	if err != nil {
+		return err
+	}
+
+	if res.Cursor != "" {
+		fmt.Fprintln(w, "next page:", res.Cursor)
+	}
+
+	return nil
+}
+
+func formatAnnotations(r golemtype.SearchResultWithMetaData) string {
+	annotations := []string{}
+	for _, a := range r.StringAnnotations {
+		annotations = append(annotations, fmt.Sprintf("%s=%q", a.Key, a.Value))
+	}
+	for _, a := range r.NumericAnnotations {
+		annotations = append(annotations, fmt.Sprintf("%s=%d", a.Key, a.Value))
+	}
+	return strings.Join(annotations, ", ")
+}
+
+// formatPayload keeps the table on one line per entity.
+func formatPayload(payload []byte) string {
+	s := strings.ReplaceAll(string(payload), "\n", `\n`)
+	s = strings.ReplaceAll(s, "\t", `\t`)
+	return s
+}
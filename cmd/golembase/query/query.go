@@ -5,14 +5,17 @@ import (
 	"os"
 	"os/signal"
 
+	golembase "github.com/ethereum/go-ethereum/golem-base/client"
 	"github.com/ethereum/go-ethereum/golem-base/golemtype"
-	"github.com/ethereum/go-ethereum/rpc"
 	"github.com/urfave/cli/v2"
 )
 
 func Query() *cli.Command {
 	cfg := struct {
-		nodeURL string
+		nodeURL  string
+		options  golemtype.QueryOptions
+		metaData bool
+		output   string
 	}{}
 	return &cli.Command{
 		Name:  "query",
@@ -25,6 +28,49 @@ func Query() *cli.Command {
 				EnvVars:     []string{"NODE_URL"},
 				Destination: &cfg.nodeURL,
 			},
+			&cli.Uint64Flag{
+				Name:        "limit",
+				Usage:       "maximum number of results, 0 means no limit",
+				Destination: &cfg.options.Limit,
+			},
+			&cli.StringFlag{
+				Name:        "cursor",
+				Usage:       "cursor of the page to fetch, as printed after the previous page",
+				Destination: &cfg.options.Cursor,
+			},
+			&cli.StringFlag{
+				Name:        "order-by",
+				Usage:       "order of the results: key, expiresAtBlock or numericAnnotation",
+				Value:       golemtype.OrderByKey,
+				Destination: &cfg.options.OrderBy,
+			},
+			&cli.StringFlag{
+				Name:        "order-by-annotation",
+				Usage:       "numeric annotation to order by when ordering by numericAnnotation",
+				Destination: &cfg.options.OrderByAnnotation,
+			},
+			&cli.BoolFlag{
+				Name:        "descending",
+				Usage:       "reverse the order of the results",
+				Destination: &cfg.options.Descending,
+			},
+			&cli.BoolFlag{
+				Name:        "omit-payload",
+				Usage:       "do not print the payloads",
+				Destination: &cfg.options.OmitPayload,
+			},
+			&cli.BoolFlag{
+				Name:        "metadata",
+				Usage:       "include the owner, expiration block and annotations of the entities",
+				Destination: &cfg.metaData,
+			},
+			&cli.StringFlag{
+				Name:        "output",
+				Aliases:     []string{"o"},
+				Usage:       "output format: table or json",
+				Value:       "table",
+				Destination: &cfg.output,
+			},
 		},
 		Action: func(c *cli.Context) error {
 
@@ -35,31 +81,32 @@ func Query() *cli.Command {
 			if query == "" {
 				return fmt.Errorf("query is required")
 			}
+
+			if cfg.output != "table" && cfg.output != "json" {
+				return fmt.Errorf("unsupported output format %q", cfg.output)
+			}
+
+			if cfg.metaData {
+				cfg.options.Projection = golemtype.ProjectionMetaData
+			}
+
 			// Connect to the geth node
-			rpcClient, err := rpc.Dial(cfg.nodeURL)
+			client, err := golembase.Dial(ctx, cfg.nodeURL)
 			if err != nil {
-				return fmt.Errorf("failed to connect to node: %w", err)
+				return err
 			}
-			defer rpcClient.Close()
-
-			res := []golemtype.SearchResult{}
+			defer client.Close()
 
-			err = rpcClient.CallContext(
-				ctx,
-				&res,
-				"golembase_queryEntities",
-				query,
-			)
+			res, err := client.QueryEntities(ctx, query, &cfg.options)
 			if err != nil {
-				return fmt.Errorf("failed to get entities to by numeric annotation: %w", err)
+				return err
 			}
 
-			for _, r := range res {
-				fmt.Println(r.Key)
-				fmt.Println("  payload:", string(r.Value))
+			if cfg.output == "json" {
+				return printJSON(os.Stdout, *res)
 			}
 
-			return nil
+			return printTable(os.Stdout, *res, !cfg.options.OmitPayload, cfg.metaData)
 		},
 	}
 }
@@ -0,0 +1,106 @@
+package wal
+
+import (
+	"fmt"
+	"os"
+	"os/signal"
+
+	golemwal "github.com/ethereum/go-ethereum/golem-base/wal"
+	"github.com/urfave/cli/v2"
+)
+
+func WAL() *cli.Command {
+	return &cli.Command{
+		Name:  "wal",
+		Usage: "manage the write-ahead log",
+		Subcommands: []*cli.Command{
+			toSegments(),
+			toJSON(),
+		},
+	}
+}
+
+func toSegments() *cli.Command {
+	cfg := struct {
+		jsonDir        string
+		segmentDir     string
+		maxSegmentSize int64
+	}{}
+	return &cli.Command{
+		Name:  "to-segments",
+		Usage: "convert the JSON files of the write-ahead log to binary segments, continuing after the last converted block",
+		Flags: []cli.Flag{
+			&cli.PathFlag{
+				Name:        "json",
+				Usage:       "dir of the JSON write-ahead log",
+				Required:    true,
+				Destination: &cfg.jsonDir,
+			},
+			&cli.PathFlag{
+				Name:        "segments",
+				Usage:       "dir of the segments",
+				Required:    true,
+				Destination: &cfg.segmentDir,
+			},
+			&cli.Int64Flag{
+				Name:        "max-segment-size",
+				Usage:       "size in bytes after which a new segment is started",
+				Value:       golemwal.DefaultMaxSegmentSize,
+				Destination: &cfg.maxSegmentSize,
+			},
+		},
+		Action: func(c *cli.Context) error {
+
+			ctx, stop := signal.NotifyContext(c.Context, os.Interrupt)
+			defer stop()
+
+			converted, err := golemwal.ConvertJSONToSegments(ctx, cfg.jsonDir, cfg.segmentDir, cfg.maxSegmentSize)
+			if err != nil {
+				return fmt.Errorf("failed to convert the write-ahead log: %w", err)
+			}
+
+			fmt.Printf("converted %d blocks\n", converted)
+
+			return nil
+		},
+	}
+}
+
+func toJSON() *cli.Command {
+	cfg := struct {
+		segmentDir string
+		jsonDir    string
+	}{}
+	return &cli.Command{
+		Name:  "to-json",
+		Usage: "convert the binary segments of the write-ahead log to JSON files",
+		Flags: []cli.Flag{
+			&cli.PathFlag{
+				Name:        "segments",
+				Usage:       "dir of the segments",
+				Required:    true,
+				Destination: &cfg.segmentDir,
+			},
+			&cli.PathFlag{
+				Name:        "json",
+				Usage:       "dir of the JSON write-ahead log",
+				Required:    true,
+				Destination: &cfg.jsonDir,
+			},
+		},
+		Action: func(c *cli.Context) error {
+
+			ctx, stop := signal.NotifyContext(c.Context, os.Interrupt)
+			defer stop()
+
+			converted, err := golemwal.ConvertSegmentsToJSON(ctx, cfg.segmentDir, cfg.jsonDir)
+			if err != nil {
+				return fmt.Errorf("failed to convert the write-ahead log: %w", err)
+			}
+
+			fmt.Printf("converted %d blocks\n", converted)
+
+			return nil
+		},
+	}
+}
@@ -0,0 +1,159 @@
+package watch
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/common"
+	golembase "github.com/ethereum/go-ethereum/golem-base/client"
+	"github.com/ethereum/go-ethereum/golem-base/golemtype"
+	"github.com/ethereum/go-ethereum/golem-base/storageutil/entity"
+	"github.com/urfave/cli/v2"
+)
+
+func Watch() *cli.Command {
+	cfg := struct {
+		nodeURL string
+		owner   string
+		output  string
+	}{}
+	return &cli.Command{
+		Name:      "watch",
+		Usage:     "follow the changes of the entities matching a query",
+		ArgsUsage: "[query]",
+		Flags: []cli.Flag{
+			&cli.StringFlag{
+				Name:        "node-url",
+				Usage:       "The websocket or IPC URL of the node to connect to",
+				Value:       "ws://localhost:8545",
+				EnvVars:     []string{"NODE_WS_URL"},
+				Destination: &cfg.nodeURL,
+			},
+			&cli.StringFlag{
+				Name:        "owner",
+				Usage:       "only follow the entities of this owner",
+				Destination: &cfg.owner,
+			},
+			&cli.StringFlag{
+				Name:        "output",
+				Aliases:     []string{"o"},
+				Usage:       "output format: text or json, one event per line",
+				Value:       "text",
+				Destination: &cfg.output,
+			},
+		},
+		Action: func(c *cli.Context) error {
+
+			ctx, stop := signal.NotifyContext(c.Context, os.Interrupt)
+			defer stop()
+
+			if cfg.output != "text" && cfg.output != "json" {
+				return fmt.Errorf("unsupported output format %q", cfg.output)
+			}
+
+			filter := &golemtype.EntityFilter{Query: c.Args().First()}
+
+			if cfg.owner != "" {
+				if !common.IsHexAddress(cfg.owner) {
+					return fmt.Errorf("invalid owner address %q", cfg.owner)
+				}
+				filter.Owner = pointerOf(common.HexToAddress(cfg.owner))
+			}
+
+			client, err := golembase.Dial(ctx, cfg.nodeURL)
+			if err != nil {
+				return err
+			}
+			defer client.Close()
+
+			return watchEntities(ctx, client, filter, cfg.output, os.Stdout)
+		},
+	}
+}
+
+// watchEntities writes the entity events selected by the filter to out, as text or as
+// JSON, until the context is done.
+func watchEntities(ctx context.Context, client *golembase.Client, filter *golemtype.EntityFilter, output string, out io.Writer) error {
+	events := make(chan golemtype.EntityEvent)
+	sub, err := client.SubscribeEntities(ctx, filter, events)
+	if err != nil {
+		return err
+	}
+	defer sub.Unsubscribe()
+
+	enc := json.NewEncoder(out)
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case err := <-sub.Err():
+			return fmt.Errorf("subscription failed: %w", err)
+		case ev := <-events:
+			if output == "json" {
+				err = enc.Encode(ev)
+			} else {
+				err = printEvent(out, ev)
+			}
+			if err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// printEvent writes an event as a single line: the block, the type of the change, the entity
+// and its meta data, with the previous value of the fields changed by an update.
+func printEvent(w io.Writer, ev golemtype.EntityEvent) error {
+	md := ev.MetaData
+
+	fields := []string{
+		fmt.Sprintf("block %d", ev.BlockNumber),
+		fmt.Sprintf("%-7s", ev.Type),
+		ev.EntityKey.Hex(),
+	}
+
+	prev := ev.PreviousMetaData
+	if prev != nil && prev.Owner != md.Owner {
+		fields = append(fields, fmt.Sprintf("owner=%s->%s", prev.Owner.Hex(), md.Owner.Hex()))
+	} else {
+		fields = append(fields, "owner="+md.Owner.Hex())
+	}
+
+	if prev != nil && prev.ExpiresAtBlock != md.ExpiresAtBlock {
+		fields = append(fields, fmt.Sprintf("expiresAt=%d->%d", prev.ExpiresAtBlock, md.ExpiresAtBlock))
+	} else {
+		fields = append(fields, fmt.Sprintf("expiresAt=%d", md.ExpiresAtBlock))
+	}
+
+	annotations := formatAnnotations(md)
+	if prev != nil {
+		if previous := formatAnnotations(*prev); previous != annotations {
+			annotations = previous + " -> " + annotations
+		}
+	}
+	fields = append(fields, annotations)
+
+	_, err := fmt.Fprintln(w, strings.Join(fields, " "))
+	return err
+}
+
+func formatAnnotations(md entity.EntityMetaData) string {
+	annotations := []string{}
+	for _, a := range md.StringAnnotations {
+		annotations = append(annotations, fmt.Sprintf("%s=%q", a.Key, a.Value))
+	}
+	for _, a := range md.NumericAnnotations {
+		annotations = append(annotations, fmt.Sprintf("%s=%d", a.Key, a.Value))
+	}
+	return "{" + strings.Join(annotations, ", ") + "}"
+}
+
+func pointerOf[T any](v T) *T {
+	return &v
+}
@@ -0,0 +1,145 @@
+package watch
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/cmd/golembase/pkg/clienttest"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/golem-base/golemtype"
+	"github.com/ethereum/go-ethereum/golem-base/storageutil/entity"
+	"github.com/ethereum/go-ethereum/rpc"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeGolemBase sends its events to every golembase_subscribe("entities") subscription.
+type fakeGolemBase struct {
+	events []golemtype.EntityEvent
+	filter *golemtype.EntityFilter
+}
+
+func (f *fakeGolemBase) Entities(ctx context.Context, filter *golemtype.EntityFilter) (*rpc.Subscription, error) {
+	if filter != nil && filter.Query == "(" {
+		return nil, errors.New("invalid query")
+	}
+	f.filter = filter
+
+	notifier, _ := rpc.NotifierFromContext(ctx)
+	sub := notifier.CreateSubscription()
+	for _, ev := range f.events {
+		err := notifier.Notify(sub.ID, ev)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return sub, nil
+}
+
+// linesWriter sends every write to a channel, so that the output can be read while the
+// events are being written.
+type linesWriter chan string
+
+func (w linesWriter) Write(p []byte) (int, error) {
+	w <- string(p)
+	return len(p), nil
+}
+
+var (
+	testKey      = common.Hash{1}
+	testOwner    = common.Address{2}
+	testNewOwner = common.Address{3}
+)
+
+func testEvents() []golemtype.EntityEvent {
+	created := entity.EntityMetaData{
+		Owner:             testOwner,
+		ExpiresAtBlock:    100,
+		StringAnnotations: []entity.StringAnnotation{{Key: "type", Value: "order"}},
+	}
+	updated := entity.EntityMetaData{
+		Owner:              testNewOwner,
+		ExpiresAtBlock:     200,
+		StringAnnotations:  []entity.StringAnnotation{{Key: "type", Value: "order"}},
+		NumericAnnotations: []entity.NumericAnnotation{{Key: "amount", Value: 7}},
+	}
+
+	return []golemtype.EntityEvent{
+		{Type: golemtype.EntityCreated, EntityKey: testKey, BlockNumber: 1, MetaData: created},
+		{Type: golemtype.EntityUpdated, EntityKey: testKey, BlockNumber: 2, MetaData: updated, PreviousMetaData: &created},
+		{Type: golemtype.EntityDeleted, EntityKey: testKey, BlockNumber: 3, MetaData: updated},
+	}
+}
+
+func watch(t *testing.T, f *fakeGolemBase, filter *golemtype.EntityFilter, output string) []string {
+	client := clienttest.NewClient(t, map[string]any{"golembase": f})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	out := make(linesWriter)
+	done := make(chan error, 1)
+	go func() {
+		done <- watchEntities(ctx, client, filter, output, out)
+	}()
+
+	lines := []string{}
+	for range f.events {
+		select {
+		case line := <-out:
+			lines = append(lines, line)
+		case err := <-done:
+			t.Fatalf("watch stopped: %v", err)
+		case <-time.After(5 * time.Second):
+			t.Fatal("timed out waiting for the events")
+		}
+	}
+
+	cancel()
+	require.NoError(t, <-done)
+
+	return lines
+}
+
+func TestWatchText(t *testing.T) {
+	f := &fakeGolemBase{events: testEvents()}
+	filter := &golemtype.EntityFilter{Query: `type = "order"`, Owner: &testOwner}
+
+	lines := watch(t, f, filter, "text")
+
+	require.Equal(t, []string{
+		`block 1 created 0x0100000000000000000000000000000000000000000000000000000000000000 owner=0x0200000000000000000000000000000000000000 expiresAt=100 {type="order"}` + "\n",
+		`block 2 updated 0x0100000000000000000000000000000000000000000000000000000000000000 owner=0x0200000000000000000000000000000000000000->0x0300000000000000000000000000000000000000 expiresAt=100->200 {type="order"} -> {type="order", amount=7}` + "\n",
+		`block 3 deleted 0x0100000000000000000000000000000000000000000000000000000000000000 owner=0x0300000000000000000000000000000000000000 expiresAt=200 {type="order", amount=7}` + "\n",
+	}, lines)
+	require.Equal(t, filter, f.filter)
+}
+
+func TestWatchJSON(t *testing.T) {
+	f := &fakeGolemBase{events: testEvents()}
+
+	lines := watch(t, f, &golemtype.EntityFilter{}, "json")
+
+	require.Len(t, lines, 3)
+	require.JSONEq(t, `{
+		"type": "deleted",
+		"entityKey": "0x0100000000000000000000000000000000000000000000000000000000000000",
+		"blockNumber": 3,
+		"blockHash": "0x0000000000000000000000000000000000000000000000000000000000000000",
+		"metaData": {
+			"expiresAtBlock": 200,
+			"stringAnnotations": [{"key": "type", "value": "order"}],
+			"numericAnnotations": [{"key": "amount", "value": 7}],
+			"owner": "0x0300000000000000000000000000000000000000"
+		}
+	}`, lines[2])
+}
+
+func TestWatchInvalidFilter(t *testing.T) {
+	client := clienttest.NewClient(t, map[string]any{"golembase": &fakeGolemBase{}})
+
+	err := watchEntities(context.Background(), client, &golemtype.EntityFilter{Query: "("}, "text", &bytes.Buffer{})
+	require.ErrorContains(t, err, "invalid query")
+}
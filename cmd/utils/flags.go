@@ -58,6 +58,7 @@ import (
 	"github.com/ethereum/go-ethereum/ethdb"
 	"github.com/ethereum/go-ethereum/ethdb/remotedb"
 	"github.com/ethereum/go-ethereum/ethstats"
+	"github.com/ethereum/go-ethereum/golem-base/storageutil"
 	"github.com/ethereum/go-ethereum/golem-base/wal"
 	"github.com/ethereum/go-ethereum/graphql"
 	"github.com/ethereum/go-ethereum/internal/ethapi"
@@ -869,6 +870,21 @@ var (
 		Usage:    "Path to the write-ahead log directory for the Golem Base",
 		Category: flags.MiscCategory,
 	}
+	GolemBaseWriteAheadLogRetainBlocksFlag = &cli.Uint64Flag{
+		Name:     "golembase.writeaheadlog.retainblocks",
+		Usage:    "Number of most recent blocks kept in the Golem Base write-ahead log, older blocks are pruned (0 = keep all blocks)",
+		Category: flags.MiscCategory,
+	}
+	GolemBaseWriteAheadLogPruneAcknowledgedFlag = &cli.BoolFlag{
+		Name:     "golembase.writeaheadlog.pruneacknowledged",
+		Usage:    "Prune the blocks of the Golem Base write-ahead log once all registered consumers have acknowledged them",
+		Category: flags.MiscCategory,
+	}
+	GolemBaseWriteAheadLogSegmentsFlag = &flags.DirectoryFlag{
+		Name:     "golembase.writeaheadlog.segments",
+		Usage:    "Path to a directory the Golem Base write-ahead log is also written to in the binary segment format",
+		Category: flags.MiscCategory,
+	}
 
 	// Console
 	JSpathFlag = &flags.DirectoryFlag{
@@ -1538,6 +1554,18 @@ func SetNodeConfig(ctx *cli.Context, cfg *node.Config) {
 
 		cfg.GolemBaseWriteAheadLogDir = ctx.String(GolemBaseWriteAheadLogDir.Name)
 	}
+	if ctx.IsSet(GolemBaseWriteAheadLogRetainBlocksFlag.Name) {
+		cfg.GolemBaseWriteAheadLogRetainBlocks = ctx.Uint64(GolemBaseWriteAheadLogRetainBlocksFlag.Name)
+	}
+	if ctx.IsSet(GolemBaseWriteAheadLogPruneAcknowledgedFlag.Name) {
+		cfg.GolemBaseWriteAheadLogPruneAcknowledged = ctx.Bool(GolemBaseWriteAheadLogPruneAcknowledgedFlag.Name)
+	}
+	if ctx.IsSet(GolemBaseWriteAheadLogSegmentsFlag.Name) {
+		if !ctx.IsSet(GolemBaseWriteAheadLogDir.Name) {
+			Fatalf("The --%s flag requires --%s", GolemBaseWriteAheadLogSegmentsFlag.Name, GolemBaseWriteAheadLogDir.Name)
+		}
+		cfg.GolemBaseWriteAheadLogSegmentsDir = ctx.String(GolemBaseWriteAheadLogSegmentsFlag.Name)
+	}
 
 	// deprecation notice for log debug flags (TODO: find a more appropriate place to put these?)
 	if ctx.IsSet(LogBacktraceAtFlag.Name) {
@@ -2418,8 +2446,9 @@ func MakeChain(ctx *cli.Context, stack *node.Node, readonly bool) (*core.BlockCh
 
 	walDir := stack.Config().GolemBaseWriteAheadLogDir
 	if walDir != "" {
-		chain, err := core.NewBlockChainWithOnNewBlock(chainDb, cache, gspec, nil, engine, vmcfg, nil, func(block *types.Block, receipts []*types.Receipt) error {
-			return wal.WriteLogForBlock(walDir, block, config.ChainID, receipts)
+		// the WAL is only pruned by the node
+		chain, err := core.NewBlockChainWithOnNewBlock(chainDb, cache, gspec, nil, engine, vmcfg, nil, func(block *types.Block, receipts []*types.Receipt, parentState storageutil.StateAccess) error {
+			return wal.WriteLogForBlock(walDir, block, config.ChainID, receipts, parentState)
 		})
 		if err != nil {
 			Fatalf("Can't create BlockChain with onNewBlock: %v", err)
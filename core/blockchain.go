@@ -44,6 +44,7 @@ import (
 	"github.com/ethereum/go-ethereum/core/vm"
 	"github.com/ethereum/go-ethereum/ethdb"
 	"github.com/ethereum/go-ethereum/event"
+	"github.com/ethereum/go-ethereum/golem-base/storageutil"
 	"github.com/ethereum/go-ethereum/internal/syncx"
 	"github.com/ethereum/go-ethereum/internal/version"
 	"github.com/ethereum/go-ethereum/log"
@@ -269,7 +270,7 @@ type BlockChain struct {
 	vmConfig   vm.Config
 	logger     *tracing.Hooks
 
-	onNewBlock func(block *types.Block, receipts []*types.Receipt) error
+	onNewBlock func(block *types.Block, receipts []*types.Receipt, parentState storageutil.StateAccess) error
 }
 
 // NewBlockChain returns a fully initialised block chain using information
@@ -282,7 +283,7 @@ func NewBlockChain(db ethdb.Database, cacheConfig *CacheConfig, genesis *Genesis
 // NewBlockChain returns a fully initialised block chain using information
 // available in the database. It initialises the default Ethereum Validator
 // and Processor.
-func NewBlockChainWithOnNewBlock(db ethdb.Database, cacheConfig *CacheConfig, genesis *Genesis, overrides *ChainOverrides, engine consensus.Engine, vmConfig vm.Config, txLookupLimit *uint64, onNewBlock func(block *types.Block, receipts []*types.Receipt) error) (*BlockChain, error) {
+func NewBlockChainWithOnNewBlock(db ethdb.Database, cacheConfig *CacheConfig, genesis *Genesis, overrides *ChainOverrides, engine consensus.Engine, vmConfig vm.Config, txLookupLimit *uint64, onNewBlock func(block *types.Block, receipts []*types.Receipt, parentState storageutil.StateAccess) error) (*BlockChain, error) {
 	if cacheConfig == nil {
 		cacheConfig = defaultCacheConfig
 	}
@@ -1074,7 +1075,7 @@ func (bc *BlockChain) writeHeadBlock(block *types.Block) {
 
 	if bc.onNewBlock != nil {
 		receipts := bc.GetReceiptsByHash(block.Hash())
-		err := bc.onNewBlock(block, receipts)
+		err := bc.onNewBlock(block, receipts, bc.parentStateOf(block))
 		if err != nil {
 			log.Crit("Failed to call onNewBlock", "err", err)
 		}
@@ -1102,6 +1103,25 @@ func (bc *BlockChain) writeHeadBlock(block *types.Block) {
 	headBlockGauge.Update(int64(block.NumberU64()))
 }
 
+// parentStateOf returns the state of the parent of the block, or nil if the block
+// has no parent or its state is not available.
+func (bc *BlockChain) parentStateOf(block *types.Block) storageutil.StateAccess {
+	if block.NumberU64() == 0 {
+		return nil
+	}
+	parent := bc.GetHeader(block.ParentHash(), block.NumberU64()-1)
+	if parent == nil {
+		log.Warn("Parent of new block not found", "number", block.NumberU64(), "hash", block.Hash())
+		return nil
+	}
+	parentState, err := bc.StateAt(parent.Root)
+	if err != nil {
+		log.Warn("State of the parent of new block not available", "number", block.NumberU64(), "hash", block.Hash(), "err", err)
+		return nil
+	}
+	return parentState
+}
+
 // stopWithoutSaving stops the blockchain service. If any imports are currently in progress
 // it will abort them using the procInterrupt. This method stops all running
 // goroutines, but does not do all the post-stop work of persisting data.
@@ -621,10 +621,19 @@ func (st *stateTransition) innerExecute() (*ExecutionResult, error) {
 
 			if len(st.msg.Data) > 0 {
 				var logs []*types.Log
-				// run the storage transaction
-				logs, vmerr = storagetx.ExecuteTransaction(st.msg.Data, st.msg.BlockNumber, st.msg.TransactionHash, msg.From, st.evm.StateDB)
-				if err != nil {
-					return nil, fmt.Errorf("failed to execute storage transaction: %w", err)
+				if st.evm.ChainConfig().IsGolemBaseStorageRules(st.evm.Context.Time) {
+					var storageGas uint64
+					snapshot := st.evm.StateDB.Snapshot()
+					// run the storage transaction, charging the gas of its operations
+					logs, storageGas, vmerr = storagetx.ExecuteTransaction(st.msg.Data, st.gasRemaining, st.msg.BlockNumber, st.msg.TransactionHash, msg.From, st.evm.ChainConfig().GolemBase, st.evm.StateDB)
+					st.gasRemaining -= storageGas
+					if vmerr != nil {
+						// the storage transaction is atomic, discard all of its changes
+						st.evm.StateDB.RevertToSnapshot(snapshot)
+					}
+				} else {
+					// run the storage transaction the way it was run before the storage rules
+					logs, vmerr = storagetx.ExecuteLegacyTransaction(st.msg.Data, st.msg.BlockNumber, st.msg.TransactionHash, msg.From, st.evm.StateDB)
 				}
 
 				if vmerr == nil {
@@ -636,7 +645,7 @@ func (st *stateTransition) innerExecute() (*ExecutionResult, error) {
 			}
 		case msg.IsDepositTx:
 
-			logs, err := housekeepingtx.ExecuteTransaction(st.msg.BlockNumber, st.msg.TransactionHash, st.evm.StateDB)
+			logs, err := housekeepingtx.ExecuteTransaction(st.msg.BlockNumber, st.msg.TransactionHash, st.evm.ChainConfig().IsGolemBaseStorageRules(st.evm.Context.Time), st.evm.StateDB)
 			if err != nil {
 				return nil, fmt.Errorf("failed to execute housekeeping transaction: %w", err)
 			}
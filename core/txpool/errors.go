@@ -56,4 +56,8 @@ var (
 	// input transaction of non-blob type when a blob transaction from this sender
 	// remains pending (and vice-versa).
 	ErrAlreadyReserved = errors.New("address already reserved")
+
+	// ErrInvalidStorageTransaction is returned if a Golem Base storage transaction
+	// cannot be decoded or violates the storage limits of the chain.
+	ErrInvalidStorageTransaction = errors.New("invalid storage transaction")
 )
@@ -28,6 +28,8 @@ import (
 	"github.com/ethereum/go-ethereum/core/state"
 	"github.com/ethereum/go-ethereum/core/types"
 	"github.com/ethereum/go-ethereum/crypto/kzg4844"
+	"github.com/ethereum/go-ethereum/golem-base/address"
+	"github.com/ethereum/go-ethereum/golem-base/storagetx"
 	"github.com/ethereum/go-ethereum/log"
 	"github.com/ethereum/go-ethereum/params"
 )
@@ -122,6 +124,14 @@ func ValidateTransaction(tx *types.Transaction, head *types.Header, signer types
 	if tx.Value().Sign() < 0 {
 		return ErrNegativeValue
 	}
+	// Reject Golem Base storage transactions violating the storage limits of the chain,
+	// which only apply once the storage rules are active in the pending block
+	if to := tx.To(); to != nil && *to == address.GolemBaseStorageProcessorAddress && len(tx.Data()) > 0 && opts.Config.IsGolemBaseStorageRules(head.Time+1) {
+		nextBlock := new(big.Int).Add(head.Number, common.Big1).Uint64()
+		if err := storagetx.ValidateTransaction(tx.Data(), nextBlock, opts.Config.GolemBase); err != nil {
+			return fmt.Errorf("%w: %w", ErrInvalidStorageTransaction, err)
+		}
+	}
 	// Ensure the transaction doesn't exceed the current block limit gas
 	if EffectiveGasLimit(opts.Config, head.GasLimit, opts.EffectiveGasCeil) < tx.Gas() {
 		return ErrGasLimit
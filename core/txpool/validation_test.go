@@ -0,0 +1,96 @@
+// Copyright 2025 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package txpool
+
+import (
+	"errors"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/golem-base/address"
+	"github.com/ethereum/go-ethereum/golem-base/storagetx"
+	"github.com/ethereum/go-ethereum/params"
+	"github.com/ethereum/go-ethereum/rlp"
+)
+
+// Tests that the storage limits are checked from the head before the switch to the
+// storage rules on, since the pending block already applies them.
+func TestValidateStorageTransactionAtStorageRulesSwitch(t *testing.T) {
+	switchTime := uint64(1000)
+
+	config := *params.TestChainConfig
+	config.GolemBase = &params.GolemBaseConfig{
+		StorageRulesTime:         &switchTime,
+		MaxTTL:                   10,
+		MaxPayloadSize:           1024,
+		MaxAnnotations:           10,
+		MaxAnnotationKeyLength:   32,
+		MaxAnnotationValueLength: 32,
+	}
+
+	data, err := rlp.EncodeToBytes(&storagetx.StorageTransaction{
+		Create: []storagetx.Create{{TTL: 100, Payload: []byte("payload")}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	key, _ := crypto.GenerateKey()
+	signer := types.LatestSigner(&config)
+	tx := types.MustSignNewTx(key, signer, &types.DynamicFeeTx{
+		ChainID:   config.ChainID,
+		Gas:       1_000_000,
+		GasTipCap: big.NewInt(1),
+		GasFeeCap: big.NewInt(1),
+		To:        &address.GolemBaseStorageProcessorAddress,
+		Data:      data,
+	})
+
+	opts := &ValidationOptions{
+		Config: &config,
+		Accept: 1 << types.DynamicFeeTxType,
+		// the size limit of the legacy pool
+		MaxSize: 128 * 1024,
+		MinTip:  big.NewInt(0),
+	}
+
+	for _, tc := range []struct {
+		headTime uint64
+		invalid  bool
+	}{
+		{headTime: switchTime - 2, invalid: false},
+		{headTime: switchTime - 1, invalid: true},
+		{headTime: switchTime, invalid: true},
+	} {
+		head := &types.Header{
+			Number:     big.NewInt(1),
+			Time:       tc.headTime,
+			Difficulty: big.NewInt(0),
+			GasLimit:   30_000_000,
+		}
+
+		err := ValidateTransaction(tx, head, signer, opts)
+		if invalid := errors.Is(err, ErrInvalidStorageTransaction); invalid != tc.invalid {
+			t.Errorf("head time %d: expected invalid storage transaction %v, got error %v", tc.headTime, tc.invalid, err)
+		}
+		if !tc.invalid && err != nil {
+			t.Errorf("head time %d: unexpected error %v", tc.headTime, err)
+		}
+	}
+}
@@ -1,10 +1,19 @@
 package eth
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"iter"
+	"math"
 	"slices"
 
 	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/state"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/golem-base/entityevents"
 	"github.com/ethereum/go-ethereum/golem-base/golemtype"
 	"github.com/ethereum/go-ethereum/golem-base/query"
 	"github.com/ethereum/go-ethereum/golem-base/storageutil/entity"
@@ -12,23 +21,56 @@ import (
 	"github.com/ethereum/go-ethereum/golem-base/storageutil/entity/annotationindex"
 	"github.com/ethereum/go-ethereum/golem-base/storageutil/entity/entitiesofowner"
 	"github.com/ethereum/go-ethereum/golem-base/storageutil/entity/entityexpiration"
+	"github.com/ethereum/go-ethereum/golem-base/storageutil/entity/numericrangeindex"
+	"github.com/ethereum/go-ethereum/golem-base/storageutil/entity/stringprefixindex"
 	"github.com/ethereum/go-ethereum/golem-base/storageutil/keyset"
+	"github.com/ethereum/go-ethereum/golem-base/wal"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/rpc"
 )
 
 // golemBaseAPI offers helper utils
 type golemBaseAPI struct {
-	eth *Ethereum
+	eth          *Ethereum
+	queryResults *query.ResultCache
+	entityEvents *entityEventsFeed
 }
 
+// queryResultCacheSize is the number of paged queries whose ordered results are kept.
+const queryResultCacheSize = 64
+
 func NewGolemBaseAPI(eth *Ethereum) *golemBaseAPI {
 	return &golemBaseAPI{
-		eth: eth,
+		eth:          eth,
+		queryResults: query.NewResultCache(queryResultCacheSize),
+		entityEvents: newEntityEventsFeed(eth),
+	}
+}
+
+// stateAt returns the state at the given block number or hash.
+// If no block is given, the state of the latest block is returned, like eth_call does.
+func (api *golemBaseAPI) stateAt(ctx context.Context, blockNrOrHash *rpc.BlockNumberOrHash) (*state.StateDB, error) {
+	stateDb, _, err := api.stateAndHeaderAt(ctx, blockNrOrHash)
+	return stateDb, err
+}
+
+// stateAndHeaderAt is like stateAt, but also returns the header of the block.
+func (api *golemBaseAPI) stateAndHeaderAt(ctx context.Context, blockNrOrHash *rpc.BlockNumberOrHash) (*state.StateDB, *types.Header, error) {
+	bNrOrHash := rpc.BlockNumberOrHashWithNumber(rpc.LatestBlockNumber)
+	if blockNrOrHash != nil {
+		bNrOrHash = *blockNrOrHash
+	}
+
+	stateDb, header, err := api.eth.APIBackend.StateAndHeaderByNumberOrHash(ctx, bNrOrHash)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to get state: %w", err)
 	}
+
+	return stateDb, header, nil
 }
 
-func (api *golemBaseAPI) GetStorageValue(key common.Hash) ([]byte, error) {
-	header := api.eth.blockchain.CurrentBlock()
-	stateDb, err := api.eth.BlockChain().StateAt(header.Root)
+func (api *golemBaseAPI) GetStorageValue(ctx context.Context, key common.Hash, blockNrOrHash *rpc.BlockNumberOrHash) ([]byte, error) {
+	stateDb, err := api.stateAt(ctx, blockNrOrHash)
 	if err != nil {
 		return nil, err
 	}
@@ -36,19 +78,22 @@ func (api *golemBaseAPI) GetStorageValue(key common.Hash) ([]byte, error) {
 	return entity.GetPayload(stateDb, key), nil
 }
 
-func (api *golemBaseAPI) GetEntityMetaData(key common.Hash) (*entity.EntityMetaData, error) {
-	header := api.eth.blockchain.CurrentBlock()
-	stateDb, err := api.eth.BlockChain().StateAt(header.Root)
+func (api *golemBaseAPI) GetEntityMetaData(ctx context.Context, key common.Hash, blockNrOrHash *rpc.BlockNumberOrHash) (*entity.EntityMetaData, error) {
+	stateDb, err := api.stateAt(ctx, blockNrOrHash)
 	if err != nil {
-		return nil, fmt.Errorf("failed to get state: %w", err)
+		return nil, err
+	}
+
+	// the meta data of entities deleted before the storage rules is left in the state
+	if !allentities.Contains(stateDb, key) {
+		return nil, fmt.Errorf("entity %s not found", key.Hex())
 	}
 
 	return entity.GetEntityMetaData(stateDb, key)
 }
 
-func (api *golemBaseAPI) GetEntitiesToExpireAtBlock(blockNumber uint64) ([]common.Hash, error) {
-	header := api.eth.blockchain.CurrentBlock()
-	stateDb, err := api.eth.BlockChain().StateAt(header.Root)
+func (api *golemBaseAPI) GetEntitiesToExpireAtBlock(ctx context.Context, blockNumber uint64, blockNrOrHash *rpc.BlockNumberOrHash) ([]common.Hash, error) {
+	stateDb, err := api.stateAt(ctx, blockNrOrHash)
 	if err != nil {
 		return nil, err
 	}
@@ -56,77 +101,118 @@ func (api *golemBaseAPI) GetEntitiesToExpireAtBlock(blockNumber uint64) ([]commo
 	return slices.Collect(entityexpiration.IteratorOfEntitiesToExpireAtBlock(stateDb, blockNumber)), nil
 }
 
-func (api *golemBaseAPI) GetEntitiesForStringAnnotationValue(key, value string) ([]common.Hash, error) {
-	header := api.eth.blockchain.CurrentBlock()
-	stateDb, err := api.eth.BlockChain().StateAt(header.Root)
+func (api *golemBaseAPI) GetEntitiesForStringAnnotationValue(ctx context.Context, key, value string, blockNrOrHash *rpc.BlockNumberOrHash) ([]common.Hash, error) {
+	stateDb, err := api.stateAt(ctx, blockNrOrHash)
 	if err != nil {
 		return nil, err
 	}
 
-	entitySetKey := annotationindex.StringAnnotationIndexKey(key, value)
+	return golemBaseDataSource{stateDb: stateDb}.GetKeysForStringAnnotation(key, value)
+}
 
-	return slices.Collect(keyset.Iterate(stateDb, entitySetKey)), nil
+func (api *golemBaseAPI) GetEntitiesForNumericAnnotationValue(ctx context.Context, key string, value uint64, blockNrOrHash *rpc.BlockNumberOrHash) ([]common.Hash, error) {
+	stateDb, err := api.stateAt(ctx, blockNrOrHash)
+	if err != nil {
+		return nil, err
+	}
+
+	return golemBaseDataSource{stateDb: stateDb}.GetKeysForNumericAnnotation(key, value)
 }
 
-func (api *golemBaseAPI) GetEntitiesForNumericAnnotationValue(key string, value uint64) ([]common.Hash, error) {
-	header := api.eth.blockchain.CurrentBlock()
-	stateDb, err := api.eth.BlockChain().StateAt(header.Root)
+// QueryEntities returns all the entities matching the query with their payloads, ordered by key.
+// Use QueryEntitiesPage to order and page through the results.
+func (api *golemBaseAPI) QueryEntities(ctx context.Context, req string, blockNrOrHash *rpc.BlockNumberOrHash) ([]golemtype.SearchResult, error) {
+	result, err := api.QueryEntitiesPage(ctx, req, blockNrOrHash, nil)
 	if err != nil {
 		return nil, err
 	}
 
-	entityKeys := annotationindex.NumericAnnotationIndexKey(key, value)
+	searchResults := make([]golemtype.SearchResult, 0, len(result.Results))
+	for _, r := range result.Results {
+		searchResults = append(searchResults, r.SearchResult)
+	}
 
-	return slices.Collect(keyset.Iterate(stateDb, entityKeys)), nil
+	return searchResults, nil
 }
 
-func (api *golemBaseAPI) QueryEntities(req string) ([]golemtype.SearchResult, error) {
+// QueryEntitiesPage returns the entities matching the query. The options control the
+// ordering, the size of the pages and whether the payloads and meta data are included.
+// If the options contain a cursor, the page is read from the block the cursor belongs to
+// and the block parameter is ignored.
+func (api *golemBaseAPI) QueryEntitiesPage(ctx context.Context, req string, blockNrOrHash *rpc.BlockNumberOrHash, options *golemtype.QueryOptions) (*golemtype.QueryResult, error) {
 
-	expr, err := query.Parse(req)
+	q, err := query.NewEntitiesQuery(req, options)
 	if err != nil {
-		return nil, fmt.Errorf("failed to parse query: %w", err)
+		return nil, err
 	}
 
-	ds := &golemBaseDataSource{api: api}
-	entites, err := expr.Evaluate(ds)
+	// all the pages are read from the same block
+	if cursorBlockHash, ok := q.CursorBlockHash(); ok {
+		cursorBlock := rpc.BlockNumberOrHashWithHash(cursorBlockHash, false)
+		blockNrOrHash = &cursorBlock
+	}
+
+	// all the lookups of the query are done against the same state
+	stateDb, header, err := api.stateAndHeaderAt(ctx, blockNrOrHash)
 	if err != nil {
-		return nil, fmt.Errorf("failed to evaluate query: %w", err)
+		return nil, err
 	}
 
-	var searchResults []golemtype.SearchResult
+	return q.Run(golemBaseDataSource{stateDb: stateDb}, header.Hash(), header.Number.Uint64(), api.queryResults)
+}
 
-	for _, key := range entites {
-		v, err := api.GetStorageValue(key)
-		if err != nil {
-			return nil, fmt.Errorf("failed to get storage value for key %s: %w", key.Hex(), err)
-		}
-		searchResults = append(searchResults, golemtype.SearchResult{
-			Key:   key,
-			Value: v,
-		})
+// golemBaseDataSource evaluates queries against the annotation indexes and entities of a single state.
+type golemBaseDataSource struct {
+	stateDb *state.StateDB
+}
+
+func (ds golemBaseDataSource) GetKeysForStringAnnotation(key, value string) ([]common.Hash, error) {
+	entitySetKey := annotationindex.StringAnnotationIndexKey(key, value)
+	return slices.Collect(keyset.Iterate(ds.stateDb, entitySetKey)), nil
+}
+
+func (ds golemBaseDataSource) GetKeysForNumericAnnotation(key string, value uint64) ([]common.Hash, error) {
+	entitySetKey := annotationindex.NumericAnnotationIndexKey(key, value)
+	return slices.Collect(keyset.Iterate(ds.stateDb, entitySetKey)), nil
+}
+
+func (ds golemBaseDataSource) GetKeysForNumericAnnotationRange(key string, from, to uint64) ([]common.Hash, error) {
+	keys := []common.Hash{}
+	for value := range numericrangeindex.IterateRange(ds.stateDb, key, from, to) {
+		entitySetKey := annotationindex.NumericAnnotationIndexKey(key, value)
+		keys = slices.AppendSeq(keys, keyset.Iterate(ds.stateDb, entitySetKey))
 	}
+	return keys, nil
+}
 
-	return searchResults, nil
+func (ds golemBaseDataSource) NumericAnnotationValues(key string, from uint64, descending bool) iter.Seq[uint64] {
+	if descending {
+		return numericrangeindex.IterateRangeDescending(ds.stateDb, key, 0, from)
+	}
+	return numericrangeindex.IterateRange(ds.stateDb, key, from, math.MaxUint64)
+}
 
+func (ds golemBaseDataSource) GetStringAnnotationValuesWithPrefix(key, prefix string) ([]string, error) {
+	return slices.Collect(stringprefixindex.IterateWithPrefix(ds.stateDb, key, prefix)), nil
 }
 
-type golemBaseDataSource struct {
-	api *golemBaseAPI
+func (ds golemBaseDataSource) GetAllKeys() ([]common.Hash, error) {
+	return slices.Collect(allentities.Iterate(ds.stateDb)), nil
 }
 
-func (ds *golemBaseDataSource) GetKeysForStringAnnotation(key, value string) ([]common.Hash, error) {
-	return ds.api.GetEntitiesForStringAnnotationValue(key, value)
+func (ds golemBaseDataSource) GetPayload(key common.Hash) ([]byte, error) {
+	return entity.GetPayload(ds.stateDb, key), nil
 }
 
-func (ds *golemBaseDataSource) GetKeysForNumericAnnotation(key string, value uint64) ([]common.Hash, error) {
-	return ds.api.GetEntitiesForNumericAnnotationValue(key, value)
+func (ds golemBaseDataSource) GetEntityMetaData(key common.Hash) (*entity.EntityMetaData, error) {
+	return entity.GetEntityMetaData(ds.stateDb, key)
 }
 
 // GetEntityCount returns the total number of entities in the storage.
-func (api *golemBaseAPI) GetEntityCount() (uint64, error) {
-	stateDb, err := api.eth.BlockChain().StateAt(api.eth.BlockChain().CurrentHeader().Root)
+func (api *golemBaseAPI) GetEntityCount(ctx context.Context, blockNrOrHash *rpc.BlockNumberOrHash) (uint64, error) {
+	stateDb, err := api.stateAt(ctx, blockNrOrHash)
 	if err != nil {
-		return 0, fmt.Errorf("failed to get state: %w", err)
+		return 0, err
 	}
 
 	// Use keyset.Size to get the count of entities from the global registry
@@ -136,10 +222,10 @@ func (api *golemBaseAPI) GetEntityCount() (uint64, error) {
 }
 
 // GetAllEntityKeys returns all entity keys in the storage.
-func (api *golemBaseAPI) GetAllEntityKeys() ([]common.Hash, error) {
-	stateDb, err := api.eth.BlockChain().StateAt(api.eth.BlockChain().CurrentHeader().Root)
+func (api *golemBaseAPI) GetAllEntityKeys(ctx context.Context, blockNrOrHash *rpc.BlockNumberOrHash) ([]common.Hash, error) {
+	stateDb, err := api.stateAt(ctx, blockNrOrHash)
 	if err != nil {
-		return nil, fmt.Errorf("failed to get state: %w", err)
+		return nil, err
 	}
 
 	// Use the iterator from allentities package to gather all entity hashes
@@ -152,11 +238,157 @@ func (api *golemBaseAPI) GetAllEntityKeys() ([]common.Hash, error) {
 	return entityKeys, nil
 }
 
-func (api *golemBaseAPI) GetEntitiesOfOwner(owner common.Address) ([]common.Hash, error) {
-	stateDb, err := api.eth.BlockChain().StateAt(api.eth.BlockChain().CurrentHeader().Root)
+func (api *golemBaseAPI) GetEntitiesOfOwner(ctx context.Context, owner common.Address, blockNrOrHash *rpc.BlockNumberOrHash) ([]common.Hash, error) {
+	stateDb, err := api.stateAt(ctx, blockNrOrHash)
 	if err != nil {
-		return nil, fmt.Errorf("failed to get state: %w", err)
+		return nil, err
 	}
 
 	return slices.Collect(entitiesofowner.Iterate(stateDb, owner)), nil
 }
+
+var errWriteAheadLogDisabled = errors.New("the Golem Base write-ahead log is not written by this node")
+
+// Operations streams the operations of the write-ahead log, starting with the block fromBlock
+// and following the head of the chain. prevBlockHash is the hash of the parent of fromBlock
+// the subscriber has processed; if it was abandoned by a reorg, the subscriber first receives
+// the reverts of the abandoned blocks. If it is omitted, the canonical parent is assumed.
+// The genesis block is not part of the write-ahead log, so the stream starts with block 1 at the earliest.
+// It is used with golembase_subscribe("operations", fromBlock, prevBlockHash).
+func (api *golemBaseAPI) Operations(ctx context.Context, fromBlock hexutil.Uint64, prevBlockHash *common.Hash) (*rpc.Subscription, error) {
+	notifier, supported := rpc.NotifierFromContext(ctx)
+	if !supported {
+		return &rpc.Subscription{}, rpc.ErrNotificationsUnsupported
+	}
+
+	walDir := api.eth.golemBaseWALDir
+	if walDir == "" {
+		return nil, errWriteAheadLogDisabled
+	}
+
+	if fromBlock == 0 {
+		fromBlock = 1
+	}
+
+	err := wal.CheckPruned(walDir, uint64(fromBlock))
+	if err != nil {
+		return nil, err
+	}
+
+	var prevHash common.Hash
+	if prevBlockHash != nil {
+		prevHash = *prevBlockHash
+	} else {
+		parent := api.eth.blockchain.GetHeaderByNumber(uint64(fromBlock) - 1)
+		if parent == nil {
+			return nil, fmt.Errorf("block %d not found", uint64(fromBlock)-1)
+		}
+		prevHash = parent.Hash()
+	}
+
+	rpcSub := notifier.CreateSubscription()
+
+	go func() {
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		heads := make(chan core.ChainHeadEvent, 16)
+		headsSub := api.eth.blockchain.SubscribeChainHeadEvent(heads)
+		defer headsSub.Unsubscribe()
+
+		// the WAL is written before the head is updated, so a new head means a new block in the WAL
+		newBlocks := make(chan struct{}, 1)
+
+		go func() {
+			defer cancel()
+			for {
+				select {
+				case <-heads:
+					select {
+					case newBlocks <- struct{}{}:
+					default:
+					}
+				case <-rpcSub.Err():
+					return
+				case <-headsSub.Err():
+					return
+				}
+			}
+		}()
+
+		for bw, err := range wal.NewNotifiedIterator(ctx, walDir, uint64(fromBlock), prevHash, newBlocks) {
+			if err != nil {
+				log.Warn("Failed to read the Golem Base write-ahead log", "err", err)
+				return
+			}
+
+			bo, err := wal.ReadBlockOperations(bw)
+			if err != nil {
+				log.Warn("Failed to read the Golem Base write-ahead log", "err", err)
+				return
+			}
+
+			err = notifier.Notify(rpcSub.ID, bo)
+			if err != nil {
+				return
+			}
+		}
+	}()
+
+	return rpcSub, nil
+}
+
+// Entities streams the created, updated, deleted and expired entities of new blocks, together with
+// their meta data. The filter selects the entities by a query expression on their annotations and
+// by their owner; an update is sent if the entity matches before or after it.
+// Events of blocks abandoned by a reorg are not retracted, and events the filter fails to evaluate
+// are logged and skipped.
+// It is used with golembase_subscribe("entities", filter).
+func (api *golemBaseAPI) Entities(ctx context.Context, filter *golemtype.EntityFilter) (*rpc.Subscription, error) {
+	notifier, supported := rpc.NotifierFromContext(ctx)
+	if !supported {
+		return &rpc.Subscription{}, rpc.ErrNotificationsUnsupported
+	}
+
+	f, err := entityevents.NewFilter(filter)
+	if err != nil {
+		return nil, err
+	}
+
+	rpcSub := notifier.CreateSubscription()
+
+	blocks := make(chan []golemtype.EntityEvent, 16)
+	blocksSub := api.entityEvents.subscribe(blocks)
+
+	go func() {
+		defer blocksSub.Unsubscribe()
+
+		for {
+			select {
+			case events := <-blocks:
+				for _, ee := range events {
+					match, err := f.Match(ee)
+					if err != nil {
+						log.Warn("Failed to filter the Golem Base entity event", "entity", ee.EntityKey, "err", err)
+						continue
+					}
+
+					if !match {
+						continue
+					}
+
+					err = notifier.Notify(rpcSub.ID, ee)
+					if err != nil {
+						return
+					}
+				}
+			case <-rpcSub.Err():
+				return
+			case <-blocksSub.Err():
+				return
+			}
+		}
+	}()
+
+	return rpcSub, nil
+}
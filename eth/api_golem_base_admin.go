@@ -0,0 +1,58 @@
+package eth
+
+import (
+	"sync"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/golem-base/wal"
+)
+
+// golemBaseAdminAPI manages the consumers of the Golem Base write-ahead log. Registered consumers
+// hold back the pruning of the write-ahead log, so the methods are served in the golembaseadmin
+// namespace, which is only exposed over HTTP and websockets when it is enabled explicitly.
+type golemBaseAdminAPI struct {
+	eth *Ethereum
+
+	// consumersMu serializes the registrations, so that no more than wal.MaxConsumers are registered
+	consumersMu sync.Mutex
+}
+
+func NewGolemBaseAdminAPI(eth *Ethereum) *golemBaseAdminAPI {
+	return &golemBaseAdminAPI{
+		eth: eth,
+	}
+}
+
+// AcknowledgeOperations records that the consumer has processed the operations of the write-ahead log
+// up to and including the block, registering the consumer if it is not registered yet.
+// With --golembase.writeaheadlog.pruneacknowledged, blocks are only pruned once all registered consumers
+// have acknowledged them.
+func (api *golemBaseAdminAPI) AcknowledgeOperations(consumer string, blockNumber hexutil.Uint64, blockHash common.Hash) error {
+	walDir := api.eth.golemBaseWALDir
+	if walDir == "" {
+		return errWriteAheadLogDisabled
+	}
+
+	api.consumersMu.Lock()
+	defer api.consumersMu.Unlock()
+
+	return wal.WriteCheckpoint(walDir, consumer, wal.Checkpoint{
+		BlockNumber: uint64(blockNumber),
+		BlockHash:   blockHash,
+	})
+}
+
+// RemoveOperationsConsumer unregisters the consumer, so that it no longer holds back the pruning
+// of the write-ahead log.
+func (api *golemBaseAdminAPI) RemoveOperationsConsumer(consumer string) error {
+	walDir := api.eth.golemBaseWALDir
+	if walDir == "" {
+		return errWriteAheadLogDisabled
+	}
+
+	api.consumersMu.Lock()
+	defer api.consumersMu.Unlock()
+
+	return wal.RemoveCheckpoint(walDir, consumer)
+}
@@ -0,0 +1,123 @@
+package eth
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/event"
+	"github.com/ethereum/go-ethereum/golem-base/entityevents"
+	"github.com/ethereum/go-ethereum/golem-base/golemtype"
+	"github.com/ethereum/go-ethereum/golem-base/wal"
+	"github.com/ethereum/go-ethereum/log"
+)
+
+// entityEventsFeed computes the entity events of every new block once and sends them to all
+// subscriptions of golembase_subscribe("entities"), which filter them on their own.
+// It only follows the chain while there are subscriptions.
+type entityEventsFeed struct {
+	eth  *Ethereum
+	feed event.FeedOf[[]golemtype.EntityEvent]
+
+	mu   sync.Mutex
+	subs int
+	stop chan struct{}
+}
+
+func newEntityEventsFeed(eth *Ethereum) *entityEventsFeed {
+	return &entityEventsFeed{eth: eth}
+}
+
+// subscribe sends the entity events of every new block to ch, starting to follow the chain
+// for the first subscription.
+func (f *entityEventsFeed) subscribe(ch chan<- []golemtype.EntityEvent) event.Subscription {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	sub := &entityEventsSubscription{
+		Subscription: f.feed.Subscribe(ch),
+		feed:         f,
+	}
+
+	f.subs++
+	if f.subs == 1 {
+		f.stop = make(chan struct{})
+		go f.loop(f.stop)
+	}
+
+	return sub
+}
+
+func (f *entityEventsFeed) unsubscribe() {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.subs--
+	if f.subs == 0 {
+		close(f.stop)
+	}
+}
+
+func (f *entityEventsFeed) loop(stop chan struct{}) {
+	blocks := make(chan core.ChainEvent, 16)
+	blocksSub := f.eth.blockchain.SubscribeChainEvent(blocks)
+	defer blocksSub.Unsubscribe()
+
+	for {
+		select {
+		case ev := <-blocks:
+			events, err := f.entityEventsForBlock(ev.Header)
+			if err != nil {
+				log.Warn("Failed to get the Golem Base entity events", "block", ev.Header.Number, "err", err)
+				continue
+			}
+
+			f.feed.Send(events)
+		case <-stop:
+			return
+		case <-blocksSub.Err():
+			return
+		}
+	}
+}
+
+func (f *entityEventsFeed) entityEventsForBlock(header *types.Header) ([]golemtype.EntityEvent, error) {
+	bc := f.eth.blockchain
+
+	block := bc.GetBlock(header.Hash(), header.Number.Uint64())
+	if block == nil {
+		return nil, fmt.Errorf("block %s not found", header.Hash().Hex())
+	}
+
+	parent := bc.GetHeader(header.ParentHash, header.Number.Uint64()-1)
+	if parent == nil {
+		return nil, fmt.Errorf("parent block %s not found", header.ParentHash.Hex())
+	}
+
+	parentState, err := bc.StateAt(parent.Root)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get state of parent block: %w", err)
+	}
+
+	operations, err := wal.OperationsForBlock(block, bc.Config().ChainID, bc.GetReceiptsByHash(block.Hash()))
+	if err != nil {
+		return nil, err
+	}
+
+	return entityevents.ForBlock(block.NumberU64(), block.Hash(), operations, parentState)
+}
+
+// entityEventsSubscription stops following the chain once the last subscription is unsubscribed.
+type entityEventsSubscription struct {
+	event.Subscription
+	feed *entityEventsFeed
+	once sync.Once
+}
+
+func (s *entityEventsSubscription) Unsubscribe() {
+	s.once.Do(func() {
+		s.Subscription.Unsubscribe()
+		s.feed.unsubscribe()
+	})
+}
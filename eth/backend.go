@@ -49,6 +49,7 @@ import (
 	"github.com/ethereum/go-ethereum/eth/tracers"
 	"github.com/ethereum/go-ethereum/ethdb"
 	"github.com/ethereum/go-ethereum/event"
+	"github.com/ethereum/go-ethereum/golem-base/storageutil"
 	"github.com/ethereum/go-ethereum/golem-base/wal"
 	"github.com/ethereum/go-ethereum/internal/ethapi"
 	"github.com/ethereum/go-ethereum/internal/sequencerapi"
@@ -113,6 +114,11 @@ type Ethereum struct {
 	shutdownTracker *shutdowncheck.ShutdownTracker // Tracks if and when the node has shutdown ungracefully
 
 	nodeCloser func() error
+
+	golemBaseWALDir    string      // Directory of the Golem Base write-ahead log, empty if it is not written
+	golemBaseWALPruner *wal.Pruner // Prunes the Golem Base write-ahead log, nil if it is not written
+
+	golemBaseWALSegments *wal.SegmentMirror // Writes the Golem Base write-ahead log as segments, nil if they are not written
 }
 
 // New creates a new Ethereum object (including the initialisation of the common Ethereum object),
@@ -260,16 +266,41 @@ func New(stack *node.Node, config *ethconfig.Config) (*Ethereum, error) {
 	overrides.ApplySuperchainUpgrades = config.ApplySuperchainUpgrades
 
 	walDir := stack.Config().GolemBaseWriteAheadLogDir
+	eth.golemBaseWALDir = walDir
 
 	if walDir != "" {
-		eth.blockchain, err = core.NewBlockChainWithOnNewBlock(chainDb, cacheConfig, config.Genesis, &overrides, eth.engine, vmConfig, &config.TransactionHistory, func(block *types.Block, receipts []*types.Receipt) error {
-			return wal.WriteLogForBlock(walDir, block, chainConfig.ChainID, receipts)
+		retention := wal.RetentionPolicy{
+			RetainBlocks:      stack.Config().GolemBaseWriteAheadLogRetainBlocks,
+			PruneAcknowledged: stack.Config().GolemBaseWriteAheadLogPruneAcknowledged,
+		}
+		if segmentsDir := stack.Config().GolemBaseWriteAheadLogSegmentsDir; segmentsDir != "" {
+			eth.golemBaseWALSegments, err = wal.OpenSegmentMirror(walDir, segmentsDir, wal.DefaultMaxSegmentSize)
+			if err != nil {
+				return nil, fmt.Errorf("failed to open the Golem Base write-ahead log segments: %w", err)
+			}
+		}
+		eth.golemBaseWALPruner = wal.NewPruner(walDir, retention)
+		eth.blockchain, err = core.NewBlockChainWithOnNewBlock(chainDb, cacheConfig, config.Genesis, &overrides, eth.engine, vmConfig, &config.TransactionHistory, func(block *types.Block, receipts []*types.Receipt, parentState storageutil.StateAccess) error {
+			err := wal.WriteLogForBlock(walDir, block, chainConfig.ChainID, receipts, parentState)
+			if err != nil {
+				return err
+			}
+			if eth.golemBaseWALSegments != nil {
+				// the segments catch up with the next block if appending fails
+				_, err := eth.golemBaseWALSegments.CatchUp(context.Background())
+				if err != nil {
+					log.Warn("Failed to write the Golem Base write-ahead log segments", "block", block.NumberU64(), "err", err)
+				}
+			}
+			eth.golemBaseWALPruner.AfterBlock(block.NumberU64())
+			return nil
 		})
 	} else {
 		eth.blockchain, err = core.NewBlockChain(chainDb, cacheConfig, config.Genesis, &overrides, eth.engine, vmConfig, &config.TransactionHistory)
 	}
 
 	if err != nil {
+		eth.closeGolemBaseWAL()
 		return nil, err
 	}
 	if chainConfig := eth.blockchain.Config(); chainConfig.Optimism != nil { // config.Genesis.Config.ChainID cannot be used because it's based on CLI flags only, thus default to mainnet L1
@@ -439,6 +470,10 @@ func (s *Ethereum) APIs() []rpc.API {
 			Namespace: "golembase",
 			Service:   NewGolemBaseAPI(s),
 		},
+		{
+			Namespace: "golembaseadmin",
+			Service:   NewGolemBaseAdminAPI(s),
+		},
 	}...)
 }
 
@@ -521,6 +556,19 @@ func (s *Ethereum) setupDiscovery() error {
 	return nil
 }
 
+// closeGolemBaseWAL stops pruning and closes the segments of the Golem Base write-ahead log.
+func (s *Ethereum) closeGolemBaseWAL() {
+	if s.golemBaseWALPruner != nil {
+		s.golemBaseWALPruner.Close()
+	}
+	if s.golemBaseWALSegments != nil {
+		err := s.golemBaseWALSegments.Close()
+		if err != nil {
+			log.Warn("Failed to close the Golem Base write-ahead log segments", "err", err)
+		}
+	}
+}
+
 // Stop implements node.Lifecycle, terminating all internal goroutines used by the
 // Ethereum protocol.
 func (s *Ethereum) Stop() error {
@@ -533,6 +581,7 @@ func (s *Ethereum) Stop() error {
 	close(s.closeBloomHandler)
 	s.txPool.Close()
 	s.blockchain.Stop()
+	s.closeGolemBaseWAL()
 	s.engine.Close()
 	if s.seqRPCService != nil {
 		s.seqRPCService.Close()
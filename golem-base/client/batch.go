@@ -0,0 +1,140 @@
+package client
+
+import (
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/golem-base/storagetx"
+	"github.com/ethereum/go-ethereum/golem-base/storageutil/entity"
+)
+
+// CreateOp builds the creation of an entity.
+type CreateOp struct {
+	op storagetx.Create
+}
+
+// NewCreate starts building the creation of an entity with the payload, expiring after ttl blocks.
+func NewCreate(payload []byte, ttl uint64) *CreateOp {
+	return &CreateOp{op: storagetx.Create{
+		TTL:                ttl,
+		Payload:            payload,
+		StringAnnotations:  []entity.StringAnnotation{},
+		NumericAnnotations: []entity.NumericAnnotation{},
+	}}
+}
+
+// StringAnnotation adds a string annotation to the entity.
+func (o *CreateOp) StringAnnotation(key, value string) *CreateOp {
+	o.op.StringAnnotations = append(o.op.StringAnnotations, entity.StringAnnotation{Key: key, Value: value})
+	return o
+}
+
+// NumericAnnotation adds a numeric annotation to the entity.
+func (o *CreateOp) NumericAnnotation(key string, value uint64) *CreateOp {
+	o.op.NumericAnnotations = append(o.op.NumericAnnotations, entity.NumericAnnotation{Key: key, Value: value})
+	return o
+}
+
+// Operation returns the built operation.
+func (o *CreateOp) Operation() storagetx.Create {
+	return o.op
+}
+
+// UpdateOp builds the update of an entity, which replaces its payload, annotations and expiration.
+type UpdateOp struct {
+	op storagetx.Update
+}
+
+// NewUpdate starts building the update of an entity to the payload, expiring after ttl blocks.
+func NewUpdate(key common.Hash, payload []byte, ttl uint64) *UpdateOp {
+	return &UpdateOp{op: storagetx.Update{
+		EntityKey:          key,
+		TTL:                ttl,
+		Payload:            payload,
+		StringAnnotations:  []entity.StringAnnotation{},
+		NumericAnnotations: []entity.NumericAnnotation{},
+	}}
+}
+
+// StringAnnotation adds a string annotation to the updated entity.
+func (o *UpdateOp) StringAnnotation(key, value string) *UpdateOp {
+	o.op.StringAnnotations = append(o.op.StringAnnotations, entity.StringAnnotation{Key: key, Value: value})
+	return o
+}
+
+// NumericAnnotation adds a numeric annotation to the updated entity.
+func (o *UpdateOp) NumericAnnotation(key string, value uint64) *UpdateOp {
+	o.op.NumericAnnotations = append(o.op.NumericAnnotations, entity.NumericAnnotation{Key: key, Value: value})
+	return o
+}
+
+// Operation returns the built operation.
+func (o *UpdateOp) Operation() storagetx.Update {
+	return o.op
+}
+
+// Batch collects operations into a single storage transaction, which applies all of them or none.
+// The zero value is an empty batch.
+type Batch struct {
+	tx storagetx.StorageTransaction
+}
+
+// NewBatch returns an empty batch.
+func NewBatch() *Batch {
+	return &Batch{}
+}
+
+// Create adds the creation of entities.
+func (b *Batch) Create(ops ...*CreateOp) *Batch {
+	for _, o := range ops {
+		b.tx.Create = append(b.tx.Create, o.op)
+	}
+	return b
+}
+
+// Update adds the update of entities.
+func (b *Batch) Update(ops ...*UpdateOp) *Batch {
+	for _, o := range ops {
+		b.tx.Update = append(b.tx.Update, o.op)
+	}
+	return b
+}
+
+// Delete adds the deletion of entities.
+func (b *Batch) Delete(keys ...common.Hash) *Batch {
+	b.tx.Delete = append(b.tx.Delete, keys...)
+	return b
+}
+
+// Extend adds the extension of the expiration of an entity by a number of blocks.
+func (b *Batch) Extend(key common.Hash, numberOfBlocks uint64) *Batch {
+	b.tx.Extend = append(b.tx.Extend, storagetx.ExtendTTL{
+		EntityKey:      key,
+		NumberOfBlocks: numberOfBlocks,
+	})
+	return b
+}
+
+// TransferOwnership adds the transfer of the ownership of an entity.
+func (b *Batch) TransferOwnership(key common.Hash, newOwner common.Address) *Batch {
+	b.tx.TransferOwnership = append(b.tx.TransferOwnership, storagetx.TransferOwnership{
+		EntityKey: key,
+		NewOwner:  newOwner,
+	})
+	return b
+}
+
+// Patch adds a patch of an entity.
+func (b *Batch) Patch(patch storagetx.Patch) *Batch {
+	b.tx.Patch = append(b.tx.Patch, patch)
+	return b
+}
+
+// Len returns the number of operations of the batch.
+func (b *Batch) Len() int {
+	return len(b.tx.Create) + len(b.tx.Update) + len(b.tx.Delete) +
+		len(b.tx.TransferOwnership) + len(b.tx.Extend) + len(b.tx.Patch)
+}
+
+// StorageTransaction returns the storage transaction of the batch.
+func (b *Batch) StorageTransaction() *storagetx.StorageTransaction {
+	return &b.tx
+}
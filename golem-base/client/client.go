@@ -0,0 +1,112 @@
+// Package client is a typed Go client for Golem Base nodes. It wraps an ethclient.Client with
+// builders for storage transactions, the submission of storage transactions and the decoding of
+// their receipts, and typed wrappers of the golembase_* RPC methods.
+package client
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"errors"
+	"fmt"
+	"math/big"
+	"sync"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/ethclient"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+// ErrNoPrivateKey is returned when a storage transaction is sent by a client without a private key.
+var ErrNoPrivateKey = errors.New("the client has no private key to sign transactions with")
+
+// TransactionOptions are the gas limit and fees of the storage transactions sent by a client.
+type TransactionOptions struct {
+	// Gas is the gas limit of the transactions, zero for the gas the batch uses.
+	Gas uint64
+	// GasTipCap is the tip of the transactions, nil for the tip suggested by the node.
+	GasTipCap *big.Int
+	// GasFeeCap is the maximum fee per gas of the transactions, nil for the tip plus twice the
+	// base fee of the latest block, which leaves room for the base fee to rise until they are mined.
+	GasFeeCap *big.Int
+}
+
+// DefaultTransactionOptions are the transaction options of a new client: the gas the batch uses
+// and the fees suggested by the node.
+func DefaultTransactionOptions() TransactionOptions {
+	return TransactionOptions{}
+}
+
+// Client is a client of a Golem Base node. The methods of the embedded ethclient.Client are
+// available as well.
+type Client struct {
+	*ethclient.Client
+	rpc *rpc.Client
+
+	// TransactionOptions are used for the storage transactions sent by the client.
+	TransactionOptions TransactionOptions
+
+	// block is the block the golembase_* methods read the state of, nil for the latest block
+	block *rpc.BlockNumberOrHash
+
+	sender *sender
+}
+
+// sender signs and sends the transactions of a client, it is shared by the views of a client
+// at different blocks.
+type sender struct {
+	// mu serializes sending transactions, so that concurrent batches get consecutive nonces
+	mu         sync.Mutex
+	privateKey *ecdsa.PrivateKey
+	address    common.Address
+}
+
+// Dial connects to the node at the URL, which can be an HTTP, websocket or IPC endpoint.
+// Subscriptions require a websocket or IPC endpoint.
+func Dial(ctx context.Context, url string) (*Client, error) {
+	c, err := rpc.DialContext(ctx, url)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to node: %w", err)
+	}
+	return NewClient(c), nil
+}
+
+// NewClient creates a client using the given RPC client.
+func NewClient(c *rpc.Client) *Client {
+	return &Client{
+		Client:             ethclient.NewClient(c),
+		rpc:                c,
+		TransactionOptions: DefaultTransactionOptions(),
+		sender:             &sender{},
+	}
+}
+
+// SetPrivateKey sets the key the storage transactions of the client are signed with.
+func (c *Client) SetPrivateKey(key *ecdsa.PrivateKey) {
+	c.sender.mu.Lock()
+	defer c.sender.mu.Unlock()
+
+	c.sender.privateKey = key
+	c.sender.address = crypto.PubkeyToAddress(key.PublicKey)
+}
+
+// Address returns the address of the private key of the client, which owns the entities it creates.
+func (c *Client) Address() common.Address {
+	c.sender.mu.Lock()
+	defer c.sender.mu.Unlock()
+
+	return c.sender.address
+}
+
+// AtBlock returns a view of the client whose golembase_* methods read the state of the given block.
+// The view shares the connection and the private key with the client.
+func (c *Client) AtBlock(block rpc.BlockNumberOrHash) *Client {
+	view := *c
+	view.block = &block
+	return &view
+}
+
+// RPCClient returns the underlying RPC client.
+func (c *Client) RPCClient() *rpc.Client {
+	return c.rpc
+}
@@ -0,0 +1,204 @@
+package client
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/golem-base/storagetx"
+	"github.com/ethereum/go-ethereum/params"
+	"github.com/ethereum/go-ethereum/rlp"
+)
+
+// ErrGasOverflow is returned for batches whose gas does not fit into uint64.
+var ErrGasOverflow = errors.New("the gas of the storage operations overflows")
+
+// packOverhead bounds the bytes of the list headers of an encoded storage transaction:
+// the transaction and its six lists of operations, with at most 9 bytes per header.
+const packOverhead = 7 * 9
+
+// txCost is the part of the gas of a transaction that depends on its operations: the storage gas
+// and the bytes of the encoded storage transaction, which the intrinsic gas is charged for.
+type txCost struct {
+	storage uint64
+	zero    uint64
+	nonZero uint64
+}
+
+func costOf(data []byte, storage uint64) txCost {
+	zero := uint64(bytes.Count(data, []byte{0}))
+	return txCost{storage: storage, zero: zero, nonZero: uint64(len(data)) - zero}
+}
+
+func (c txCost) add(o txCost) txCost {
+	return txCost{storage: c.storage + o.storage, zero: c.zero + o.zero, nonZero: c.nonZero + o.nonZero}
+}
+
+func (c txCost) size() uint64 {
+	return c.zero + c.nonZero
+}
+
+// gas is the gas of the transaction: its intrinsic gas and the storage gas, but at least the
+// floor of the data gas of EIP-7623.
+func (c txCost) gas() uint64 {
+	intrinsic := params.TxGas + c.nonZero*params.TxDataNonZeroGasEIP2028 + c.zero*params.TxDataZeroGas
+	floor := params.TxGas + (c.nonZero*params.TxTokenPerNonZeroByte+c.zero)*params.TxCostFloorPerToken
+	return max(intrinsic+c.storage, floor)
+}
+
+// Gas returns the gas the storage transaction of the batch uses without the slots of the
+// stored entities it rewrites or clears, which depend on the state. SendBatch uses the larger
+// of it and the estimate of the node when TransactionOptions.Gas is zero.
+func (b *Batch) Gas() (uint64, error) {
+	data, err := rlp.EncodeToBytes(&b.tx)
+	if err != nil {
+		return 0, fmt.Errorf("failed to encode storage transaction: %w", err)
+	}
+
+	storage, ok := b.tx.Gas()
+	if !ok {
+		return 0, ErrGasOverflow
+	}
+
+	return costOf(data, storage).gas(), nil
+}
+
+// PackLimits bound the storage transactions of the batches returned by Pack.
+type PackLimits struct {
+	// MaxGas is the maximum gas of a transaction, usually the gas limit of the blocks.
+	MaxGas uint64
+	// MaxSize is the maximum size of an encoded storage transaction, zero for no limit.
+	MaxSize uint64
+}
+
+// packItem is a single operation of a batch with its cost.
+type packItem struct {
+	add  func(b *Batch)
+	cost txCost
+}
+
+func newPackItem[T any](op T, tx storagetx.StorageTransaction, add func(b *Batch)) (packItem, error) {
+	data, err := rlp.EncodeToBytes(op)
+	if err != nil {
+		return packItem{}, fmt.Errorf("failed to encode operation: %w", err)
+	}
+
+	storage, ok := tx.Gas()
+	if !ok {
+		return packItem{}, ErrGasOverflow
+	}
+
+	return packItem{add: add, cost: costOf(data, storage)}, nil
+}
+
+func (b *Batch) packItems() ([]packItem, error) {
+	items := []packItem{}
+
+	appendItem := func(item packItem, err error) error {
+		if err != nil {
+			return err
+		}
+		items = append(items, item)
+		return nil
+	}
+
+	for _, op := range b.tx.Create {
+		err := appendItem(newPackItem(op, storagetx.StorageTransaction{Create: []storagetx.Create{op}}, func(b *Batch) {
+			b.tx.Create = append(b.tx.Create, op)
+		}))
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	for _, op := range b.tx.Update {
+		err := appendItem(newPackItem(op, storagetx.StorageTransaction{Update: []storagetx.Update{op}}, func(b *Batch) {
+			b.tx.Update = append(b.tx.Update, op)
+		}))
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	for _, key := range b.tx.Delete {
+		err := appendItem(newPackItem(key, storagetx.StorageTransaction{Delete: []common.Hash{key}}, func(b *Batch) {
+			b.tx.Delete = append(b.tx.Delete, key)
+		}))
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	for _, op := range b.tx.TransferOwnership {
+		err := appendItem(newPackItem(op, storagetx.StorageTransaction{TransferOwnership: []storagetx.TransferOwnership{op}}, func(b *Batch) {
+			b.tx.TransferOwnership = append(b.tx.TransferOwnership, op)
+		}))
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	for _, op := range b.tx.Extend {
+		err := appendItem(newPackItem(op, storagetx.StorageTransaction{Extend: []storagetx.ExtendTTL{op}}, func(b *Batch) {
+			b.tx.Extend = append(b.tx.Extend, op)
+		}))
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	for _, op := range b.tx.Patch {
+		err := appendItem(newPackItem(op, storagetx.StorageTransaction{Patch: []storagetx.Patch{op}}, func(b *Batch) {
+			b.tx.Patch = append(b.tx.Patch, op)
+		}))
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return items, nil
+}
+
+// Pack splits the operations of the batch into as few batches as the limits allow, filling every
+// batch before starting the next one. The operations keep the order in which a single storage
+// transaction applies them: creates, updates, deletes, ownership transfers, extensions and patches.
+// Each of the returned batches is applied atomically on its own. The limits bound the gas of
+// Batch.Gas, the slots of stored entities that deletes, transfers, extensions and patches
+// rewrite or clear are charged on top of it.
+func (b *Batch) Pack(limits PackLimits) ([]*Batch, error) {
+	items, err := b.packItems()
+	if err != nil {
+		return nil, err
+	}
+
+	fits := func(c txCost) bool {
+		c = c.add(txCost{nonZero: packOverhead})
+		return c.gas() <= limits.MaxGas && (limits.MaxSize == 0 || c.size() <= limits.MaxSize)
+	}
+
+	batches := []*Batch{}
+	current := NewBatch()
+	cost := txCost{}
+
+	for i, item := range items {
+		if !fits(item.cost) {
+			return nil, fmt.Errorf("operation %d does not fit into a transaction on its own: gas %d, size %d", i, item.cost.add(txCost{nonZero: packOverhead}).gas(), item.cost.size())
+		}
+
+		if !fits(cost.add(item.cost)) {
+			batches = append(batches, current)
+			current = NewBatch()
+			cost = txCost{}
+		}
+
+		item.add(current)
+		cost = cost.add(item.cost)
+	}
+
+	if current.Len() > 0 {
+		batches = append(batches, current)
+	}
+
+	return batches, nil
+}
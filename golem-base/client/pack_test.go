@@ -0,0 +1,73 @@
+package client_test
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/golem-base/client"
+	"github.com/ethereum/go-ethereum/golem-base/storagetx"
+	"github.com/ethereum/go-ethereum/rlp"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPack(t *testing.T) {
+	b := client.NewBatch()
+	for i := range 50 {
+		b.Create(client.NewCreate(bytes.Repeat([]byte{byte(i + 1)}, 1000), 100).StringAnnotation("type", "record"))
+	}
+	b.Delete(common.Hash{1}, common.Hash{2})
+	b.Extend(common.Hash{3}, 10)
+
+	single, err := client.NewBatch().Create(client.NewCreate(bytes.Repeat([]byte{1}, 1000), 100).StringAnnotation("type", "record")).Gas()
+	require.NoError(t, err)
+
+	limits := client.PackLimits{MaxGas: 10 * single, MaxSize: 20_000}
+
+	batches, err := b.Pack(limits)
+	require.NoError(t, err)
+	require.Greater(t, len(batches), 1)
+
+	total := 0
+	packed := &storagetx.StorageTransaction{}
+	for i, batch := range batches {
+		gas, err := batch.Gas()
+		require.NoError(t, err)
+		require.LessOrEqual(t, gas, limits.MaxGas)
+
+		data, err := rlp.EncodeToBytes(batch.StorageTransaction())
+		require.NoError(t, err)
+		require.LessOrEqual(t, uint64(len(data)), limits.MaxSize)
+
+		// only the last batch may have room for another create
+		if i < len(batches)-2 {
+			require.GreaterOrEqual(t, batch.Len(), 9)
+		}
+
+		total += batch.Len()
+		packed.Create = append(packed.Create, batch.StorageTransaction().Create...)
+		packed.Delete = append(packed.Delete, batch.StorageTransaction().Delete...)
+		packed.Extend = append(packed.Extend, batch.StorageTransaction().Extend...)
+	}
+
+	require.Equal(t, b.Len(), total)
+	require.Equal(t, b.StorageTransaction().Create, packed.Create)
+	require.Equal(t, b.StorageTransaction().Delete, packed.Delete)
+	require.Equal(t, b.StorageTransaction().Extend, packed.Extend)
+}
+
+func TestPackOperationTooLarge(t *testing.T) {
+	b := client.NewBatch().Create(client.NewCreate(make([]byte, 10_000), 100))
+
+	_, err := b.Pack(client.PackLimits{MaxGas: 30_000_000, MaxSize: 5_000})
+	require.Error(t, err)
+}
+
+func TestPackSingleBatch(t *testing.T) {
+	b := client.NewBatch().Create(client.NewCreate([]byte("a"), 10)).Delete(common.Hash{1})
+
+	batches, err := b.Pack(client.PackLimits{MaxGas: 30_000_000})
+	require.NoError(t, err)
+	require.Len(t, batches, 1)
+	require.Equal(t, b.StorageTransaction(), batches[0].StorageTransaction())
+}
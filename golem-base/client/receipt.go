@@ -0,0 +1,119 @@
+package client
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/golem-base/address"
+	"github.com/ethereum/go-ethereum/golem-base/storagetx"
+)
+
+// ErrTransactionFailed is returned for storage transactions that were mined but failed,
+// in which case none of their operations were applied.
+var ErrTransactionFailed = errors.New("storage transaction failed")
+
+// CreatedEntity is an entity created by a storage transaction.
+type CreatedEntity struct {
+	Key            common.Hash
+	ExpiresAtBlock uint64
+}
+
+// UpdatedEntity is an entity updated by a storage transaction.
+type UpdatedEntity struct {
+	Key            common.Hash
+	ExpiresAtBlock uint64
+}
+
+// ExtendedEntity is an entity whose expiration was extended by a storage transaction.
+type ExtendedEntity struct {
+	Key               common.Hash
+	OldExpiresAtBlock uint64
+	NewExpiresAtBlock uint64
+}
+
+// TransferredEntity is an entity whose ownership was transferred by a storage transaction.
+type TransferredEntity struct {
+	Key      common.Hash
+	NewOwner common.Address
+}
+
+// PatchedEntity is an entity patched by a storage transaction.
+type PatchedEntity struct {
+	Key            common.Hash
+	ExpiresAtBlock uint64
+}
+
+// Receipt is the receipt of a storage transaction with the entities of its GolemBaseStorageEntity* logs,
+// in the order of the operations of the transaction.
+type Receipt struct {
+	*types.Receipt
+	Created []CreatedEntity
+	Updated []UpdatedEntity
+	Deleted []common.Hash
+	// Expired are the entities removed by the housekeeping transaction, only found in its receipt.
+	Expired     []common.Hash
+	Extended    []ExtendedEntity
+	Transferred []TransferredEntity
+	Patched     []PatchedEntity
+}
+
+// CreatedKeys returns the keys of the created entities.
+func (r *Receipt) CreatedKeys() []common.Hash {
+	keys := make([]common.Hash, 0, len(r.Created))
+	for _, e := range r.Created {
+		keys = append(keys, e.Key)
+	}
+	return keys
+}
+
+// DecodeReceipt decodes the GolemBaseStorageEntity* logs of the receipt of a storage transaction.
+// Logs of other contracts are ignored.
+func DecodeReceipt(receipt *types.Receipt) (*Receipt, error) {
+	r := &Receipt{
+		Receipt:     receipt,
+		Created:     []CreatedEntity{},
+		Updated:     []UpdatedEntity{},
+		Deleted:     []common.Hash{},
+		Expired:     []common.Hash{},
+		Extended:    []ExtendedEntity{},
+		Transferred: []TransferredEntity{},
+		Patched:     []PatchedEntity{},
+	}
+
+	for _, log := range receipt.Logs {
+		if log.Address != address.GolemBaseStorageProcessorAddress || len(log.Topics) < 2 {
+			continue
+		}
+
+		key := log.Topics[1]
+
+		switch log.Topics[0] {
+		case storagetx.GolemBaseStorageEntityCreated:
+			r.Created = append(r.Created, CreatedEntity{Key: key, ExpiresAtBlock: new(big.Int).SetBytes(log.Data).Uint64()})
+		case storagetx.GolemBaseStorageEntityUpdated:
+			r.Updated = append(r.Updated, UpdatedEntity{Key: key, ExpiresAtBlock: new(big.Int).SetBytes(log.Data).Uint64()})
+		case storagetx.GolemBaseStorageEntityDeleted:
+			r.Deleted = append(r.Deleted, key)
+		case storagetx.GolemBaseStorageEntityExpired:
+			r.Expired = append(r.Expired, key)
+		case storagetx.GolemBaseStorageEntityTTLExtended:
+			if len(log.Data) != 64 {
+				return nil, fmt.Errorf("invalid extend log of entity %s", key.Hex())
+			}
+			r.Extended = append(r.Extended, ExtendedEntity{
+				Key:               key,
+				OldExpiresAtBlock: new(big.Int).SetBytes(log.Data[:32]).Uint64(),
+				NewExpiresAtBlock: new(big.Int).SetBytes(log.Data[32:]).Uint64(),
+			})
+		case storagetx.GolemBaseStorageEntityOwnershipTransferred:
+			r.Transferred = append(r.Transferred, TransferredEntity{Key: key, NewOwner: common.BytesToAddress(log.Data)})
+		case storagetx.GolemBaseStorageEntityPatched:
+			r.Patched = append(r.Patched, PatchedEntity{Key: key, ExpiresAtBlock: new(big.Int).SetBytes(log.Data).Uint64()})
+		}
+	}
+
+	return r, nil
+}
@@ -0,0 +1,92 @@
+package client_test
+
+import (
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/state"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/golem-base/client"
+	"github.com/ethereum/go-ethereum/golem-base/housekeepingtx"
+	"github.com/ethereum/go-ethereum/golem-base/storagetx"
+	"github.com/ethereum/go-ethereum/rlp"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDecodeReceipt(t *testing.T) {
+	db, err := state.New(types.EmptyRootHash, state.NewDatabaseForTesting())
+	require.NoError(t, err)
+
+	owner := common.HexToAddress("0x1")
+	newOwner := common.HexToAddress("0x2")
+
+	run := func(blockNumber uint64, b *client.Batch) *client.Receipt {
+		t.Helper()
+
+		logs, err := b.StorageTransaction().Run(blockNumber, common.BigToHash(common.Big1), owner, nil, db)
+		require.NoError(t, err)
+
+		r, err := client.DecodeReceipt(&types.Receipt{Logs: logs})
+		require.NoError(t, err)
+		return r
+	}
+
+	created := run(10, client.NewBatch().Create(
+		client.NewCreate([]byte("a"), 100).StringAnnotation("type", "a"),
+		client.NewCreate([]byte("b"), 200).NumericAnnotation("size", 2),
+	))
+	require.Len(t, created.Created, 2)
+	require.Equal(t, uint64(110), created.Created[0].ExpiresAtBlock)
+	require.Equal(t, uint64(210), created.Created[1].ExpiresAtBlock)
+	require.Equal(t, []common.Hash{created.Created[0].Key, created.Created[1].Key}, created.CreatedKeys())
+
+	a, b := created.Created[0].Key, created.Created[1].Key
+
+	changed := run(20, client.NewBatch().
+		Update(client.NewUpdate(a, []byte("a2"), 50)).
+		Extend(b, 5).
+		Patch(storagetx.Patch{EntityKey: b, TTL: 30}),
+	)
+	require.Equal(t, []client.UpdatedEntity{{Key: a, ExpiresAtBlock: 70}}, changed.Updated)
+	require.Equal(t, []client.ExtendedEntity{{Key: b, OldExpiresAtBlock: 210, NewExpiresAtBlock: 215}}, changed.Extended)
+	require.Equal(t, []client.PatchedEntity{{Key: b, ExpiresAtBlock: 50}}, changed.Patched)
+	require.Empty(t, changed.Created)
+
+	removed := run(30, client.NewBatch().Delete(a).TransferOwnership(b, newOwner))
+	require.Equal(t, []common.Hash{a}, removed.Deleted)
+	require.Equal(t, []client.TransferredEntity{{Key: b, NewOwner: newOwner}}, removed.Transferred)
+
+	logs, err := housekeepingtx.ExecuteTransaction(50, common.Hash{}, true, db)
+	require.NoError(t, err)
+
+	expired, err := client.DecodeReceipt(&types.Receipt{Logs: logs})
+	require.NoError(t, err)
+	require.Equal(t, []common.Hash{b}, expired.Expired)
+	require.Empty(t, expired.Deleted)
+}
+
+func TestDecodeReceiptIgnoresOtherLogs(t *testing.T) {
+	r, err := client.DecodeReceipt(&types.Receipt{Logs: []*types.Log{
+		{Address: common.HexToAddress("0x1234"), Topics: []common.Hash{storagetx.GolemBaseStorageEntityDeleted, {1}}},
+	}})
+	require.NoError(t, err)
+	require.Empty(t, r.Deleted)
+}
+
+func TestBatchEncoding(t *testing.T) {
+	b := client.NewBatch().
+		Create(client.NewCreate([]byte("payload"), 10).StringAnnotation("k", "v").NumericAnnotation("n", 1)).
+		Delete(common.Hash{1}).
+		Extend(common.Hash{2}, 3)
+
+	require.Equal(t, 3, b.Len())
+
+	data, err := rlp.EncodeToBytes(b.StorageTransaction())
+	require.NoError(t, err)
+
+	decoded := &storagetx.StorageTransaction{}
+	require.NoError(t, rlp.DecodeBytes(data, decoded))
+	require.Equal(t, b.StorageTransaction().Create, decoded.Create)
+	require.Equal(t, []common.Hash{{1}}, decoded.Delete)
+	require.Equal(t, []storagetx.ExtendTTL{{EntityKey: common.Hash{2}, NumberOfBlocks: 3}}, decoded.Extend)
+}
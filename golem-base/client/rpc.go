@@ -0,0 +1,126 @@
+package client
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/golem-base/golemtype"
+	"github.com/ethereum/go-ethereum/golem-base/storageutil/entity"
+	"github.com/ethereum/go-ethereum/golem-base/wal"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+// GetStorageValue returns the payload of the entity.
+func (c *Client) GetStorageValue(ctx context.Context, key common.Hash) ([]byte, error) {
+	var payload []byte
+	err := c.rpc.CallContext(ctx, &payload, "golembase_getStorageValue", key, c.block)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get storage value: %w", err)
+	}
+	return payload, nil
+}
+
+// GetEntityMetaData returns the owner, expiration block and annotations of the entity.
+// It fails if the entity does not exist.
+func (c *Client) GetEntityMetaData(ctx context.Context, key common.Hash) (*entity.EntityMetaData, error) {
+	md := &entity.EntityMetaData{}
+	err := c.rpc.CallContext(ctx, md, "golembase_getEntityMetaData", key, c.block)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get entity meta data: %w", err)
+	}
+	return md, nil
+}
+
+// GetEntitiesToExpireAtBlock returns the keys of the entities expiring at the block number.
+func (c *Client) GetEntitiesToExpireAtBlock(ctx context.Context, blockNumber uint64) ([]common.Hash, error) {
+	return c.callKeys(ctx, "golembase_getEntitiesToExpireAtBlock", blockNumber, c.block)
+}
+
+// GetEntitiesForStringAnnotationValue returns the keys of the entities with the value of the string annotation.
+func (c *Client) GetEntitiesForStringAnnotationValue(ctx context.Context, key, value string) ([]common.Hash, error) {
+	return c.callKeys(ctx, "golembase_getEntitiesForStringAnnotationValue", key, value, c.block)
+}
+
+// GetEntitiesForNumericAnnotationValue returns the keys of the entities with the value of the numeric annotation.
+func (c *Client) GetEntitiesForNumericAnnotationValue(ctx context.Context, key string, value uint64) ([]common.Hash, error) {
+	return c.callKeys(ctx, "golembase_getEntitiesForNumericAnnotationValue", key, value, c.block)
+}
+
+// GetAllEntityKeys returns the keys of all entities.
+func (c *Client) GetAllEntityKeys(ctx context.Context) ([]common.Hash, error) {
+	return c.callKeys(ctx, "golembase_getAllEntityKeys", c.block)
+}
+
+// GetEntitiesOfOwner returns the keys of the entities owned by the address.
+func (c *Client) GetEntitiesOfOwner(ctx context.Context, owner common.Address) ([]common.Hash, error) {
+	return c.callKeys(ctx, "golembase_getEntitiesOfOwner", owner, c.block)
+}
+
+func (c *Client) callKeys(ctx context.Context, method string, args ...interface{}) ([]common.Hash, error) {
+	keys := []common.Hash{}
+	err := c.rpc.CallContext(ctx, &keys, method, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to call %s: %w", method, err)
+	}
+	return keys, nil
+}
+
+// GetEntityCount returns the number of entities.
+func (c *Client) GetEntityCount(ctx context.Context) (uint64, error) {
+	var count uint64
+	err := c.rpc.CallContext(ctx, &count, "golembase_getEntityCount", c.block)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get entity count: %w", err)
+	}
+	return count, nil
+}
+
+// QueryEntities returns a page of the entities matching the query. The options may be nil.
+// The pages of a query are read from the block of the first page, so a client at a block
+// only has to be used for the first page.
+func (c *Client) QueryEntities(ctx context.Context, query string, options *golemtype.QueryOptions) (*golemtype.QueryResult, error) {
+	res := &golemtype.QueryResult{}
+	err := c.rpc.CallContext(ctx, res, "golembase_queryEntitiesPage", query, c.block, options)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query entities: %w", err)
+	}
+	return res, nil
+}
+
+// SubscribeOperations subscribes to the operations of the write-ahead log from the block, following
+// the head of the chain. prevBlockHash is the hash of the last processed block before fromBlock,
+// nil for the canonical parent; blocks abandoned by a reorg are then first reverted.
+// It requires a websocket or IPC connection.
+func (c *Client) SubscribeOperations(ctx context.Context, fromBlock uint64, prevBlockHash *common.Hash, ch chan<- wal.BlockOperations) (*rpc.ClientSubscription, error) {
+	sub, err := c.rpc.Subscribe(ctx, "golembase", ch, "operations", hexutil.Uint64(fromBlock), prevBlockHash)
+	if err != nil {
+		return nil, fmt.Errorf("failed to subscribe to operations: %w", err)
+	}
+	return sub, nil
+}
+
+// AcknowledgeOperations records that the consumer has processed the operations up to and including the block.
+func (c *Client) AcknowledgeOperations(ctx context.Context, consumer string, cp wal.Checkpoint) error {
+	return wal.AcknowledgeOperations(ctx, c.rpc, consumer, cp)
+}
+
+// RemoveOperationsConsumer unregisters the consumer, so that it no longer holds back the pruning of the write-ahead log.
+func (c *Client) RemoveOperationsConsumer(ctx context.Context, consumer string) error {
+	err := c.rpc.CallContext(ctx, nil, "golembaseadmin_removeOperationsConsumer", consumer)
+	if err != nil {
+		return fmt.Errorf("failed to remove operations consumer: %w", err)
+	}
+	return nil
+}
+
+// SubscribeEntities subscribes to the entity events of new blocks selected by the filter, which may be nil.
+// It requires a websocket or IPC connection.
+func (c *Client) SubscribeEntities(ctx context.Context, filter *golemtype.EntityFilter, ch chan<- golemtype.EntityEvent) (*rpc.ClientSubscription, error) {
+	sub, err := c.rpc.Subscribe(ctx, "golembase", ch, "entities", filter)
+	if err != nil {
+		return nil, fmt.Errorf("failed to subscribe to entities: %w", err)
+	}
+	return sub, nil
+}
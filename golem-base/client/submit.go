@@ -0,0 +1,168 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/golem-base/address"
+	"github.com/ethereum/go-ethereum/rlp"
+)
+
+// SendBatch signs the storage transaction of the batch with the private key of the client and sends
+// it to the node, without waiting for it to be mined.
+func (c *Client) SendBatch(ctx context.Context, b *Batch) (*types.Transaction, error) {
+	if b.Len() == 0 {
+		return nil, errors.New("the batch has no operations")
+	}
+
+	data, err := rlp.EncodeToBytes(b.StorageTransaction())
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode storage transaction: %w", err)
+	}
+
+	c.sender.mu.Lock()
+	defer c.sender.mu.Unlock()
+
+	if c.sender.privateKey == nil {
+		return nil, ErrNoPrivateKey
+	}
+
+	chainID, err := c.ChainID(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get chain ID: %w", err)
+	}
+
+	nonce, err := c.PendingNonceAt(ctx, c.sender.address)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get nonce: %w", err)
+	}
+
+	opts := c.TransactionOptions
+
+	if opts.Gas == 0 {
+		opts.Gas, err = b.Gas()
+		if err != nil {
+			return nil, err
+		}
+
+		// the slots the operations rewrite or clear depend on the state, let the node
+		// estimate them, and keep the gas without them if it can't
+		estimate, err := c.EstimateGas(ctx, ethereum.CallMsg{
+			From: c.sender.address,
+			To:   &address.GolemBaseStorageProcessorAddress,
+			Data: data,
+		})
+		if err == nil {
+			opts.Gas = max(opts.Gas, estimate)
+		}
+	}
+
+	if opts.GasTipCap == nil {
+		opts.GasTipCap, err = c.SuggestGasTipCap(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to suggest gas tip cap: %w", err)
+		}
+	}
+
+	if opts.GasFeeCap == nil {
+		head, err := c.HeaderByNumber(ctx, nil)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get latest header: %w", err)
+		}
+
+		opts.GasFeeCap = new(big.Int).Set(opts.GasTipCap)
+		if head.BaseFee != nil {
+			opts.GasFeeCap.Add(opts.GasFeeCap, new(big.Int).Mul(head.BaseFee, big.NewInt(2)))
+		}
+	}
+
+	// Use the London signer since we're using a dynamic fee transaction
+	signer := types.LatestSignerForChainID(chainID)
+
+	tx, err := types.SignNewTx(c.sender.privateKey, signer, &types.DynamicFeeTx{
+		ChainID:   chainID,
+		Nonce:     nonce,
+		Gas:       opts.Gas,
+		GasTipCap: opts.GasTipCap,
+		GasFeeCap: opts.GasFeeCap,
+		To:        &address.GolemBaseStorageProcessorAddress,
+		Data:      data,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to sign transaction: %w", err)
+	}
+
+	err = c.SendTransaction(ctx, tx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to send transaction: %w", err)
+	}
+
+	return tx, nil
+}
+
+// WaitForReceipt waits for the storage transaction to be mined and decodes its receipt.
+// If the transaction failed, the receipt is returned together with ErrTransactionFailed.
+func (c *Client) WaitForReceipt(ctx context.Context, txHash common.Hash) (*Receipt, error) {
+	receipt, err := bind.WaitMinedHash(ctx, c, txHash)
+	if err != nil {
+		return nil, fmt.Errorf("failed to wait for transaction: %w", err)
+	}
+
+	r, err := DecodeReceipt(receipt)
+	if err != nil {
+		return nil, err
+	}
+
+	if receipt.Status != types.ReceiptStatusSuccessful {
+		return r, fmt.Errorf("%w: %s", ErrTransactionFailed, txHash.Hex())
+	}
+
+	return r, nil
+}
+
+// SubmitBatch sends the storage transaction of the batch and waits for its receipt.
+func (c *Client) SubmitBatch(ctx context.Context, b *Batch) (*Receipt, error) {
+	tx, err := c.SendBatch(ctx, b)
+	if err != nil {
+		return nil, err
+	}
+
+	return c.WaitForReceipt(ctx, tx.Hash())
+}
+
+// Create creates the entities in a single transaction and returns their keys.
+func (c *Client) Create(ctx context.Context, ops ...*CreateOp) ([]common.Hash, error) {
+	r, err := c.SubmitBatch(ctx, NewBatch().Create(ops...))
+	if err != nil {
+		return nil, err
+	}
+	return r.CreatedKeys(), nil
+}
+
+// Update updates the entities in a single transaction.
+func (c *Client) Update(ctx context.Context, ops ...*UpdateOp) (*Receipt, error) {
+	return c.SubmitBatch(ctx, NewBatch().Update(ops...))
+}
+
+// Delete deletes the entities in a single transaction.
+func (c *Client) Delete(ctx context.Context, keys ...common.Hash) (*Receipt, error) {
+	return c.SubmitBatch(ctx, NewBatch().Delete(keys...))
+}
+
+// Extend extends the expiration of the entity by a number of blocks and returns its new expiration block.
+func (c *Client) Extend(ctx context.Context, key common.Hash, numberOfBlocks uint64) (uint64, error) {
+	r, err := c.SubmitBatch(ctx, NewBatch().Extend(key, numberOfBlocks))
+	if err != nil {
+		return 0, err
+	}
+	if len(r.Extended) != 1 {
+		return 0, fmt.Errorf("no extend log for entity %s", key.Hex())
+	}
+	return r.Extended[0].NewExpiresAtBlock, nil
+}
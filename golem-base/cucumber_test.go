@@ -3,6 +3,8 @@ package golembase_test
 import (
 	"bytes"
 	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"log"
 	"math/big"
@@ -10,6 +12,7 @@ import (
 	"os/exec"
 	"path/filepath"
 	"reflect"
+	"slices"
 	"strconv"
 	"strings"
 	"testing"
@@ -20,7 +23,10 @@ import (
 	"github.com/cucumber/godog/colors"
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/golem-base/client"
 	"github.com/ethereum/go-ethereum/golem-base/golemtype"
+	"github.com/ethereum/go-ethereum/golem-base/snapshot"
+	"github.com/ethereum/go-ethereum/golem-base/storagetx"
 	"github.com/ethereum/go-ethereum/golem-base/storageutil/entity"
 	"github.com/ethereum/go-ethereum/golem-base/testutil"
 	"github.com/ethereum/go-ethereum/golem-base/wal"
@@ -137,6 +143,7 @@ func InitializeScenario(ctx *godog.ScenarioContext) {
 	ctx.Step(`^I have created an entity$`, iHaveCreatedAnEntity)
 	ctx.Step(`^I submit a transaction to delete the entity$`, iSubmitATransactionToDeleteTheEntity)
 	ctx.Step(`^the entity should be deleted$`, theEntityShouldBeDeleted)
+	ctx.Step(`^the meta data of the entity should not be found$`, theMetaDataOfTheEntityShouldNotBeFound)
 	ctx.Step(`^I submit a transaction to update the entity, changing the paylod$`, iSubmitATransactionToUpdateTheEntityChangingThePaylod)
 	ctx.Step(`^the payload of the entity should be changed$`, thePayloadOfTheEntityShouldBeChanged)
 	ctx.Step(`^I submit a transaction to update the entity, changing the annotations$`, iSubmitATransactionToUpdateTheEntityChangingTheAnnotations)
@@ -154,6 +161,7 @@ func InitializeScenario(ctx *godog.ScenarioContext) {
 	ctx.Step(`^the write-ahead log for the create should be created$`, theWriteaheadLogForTheCreateShouldBeCreated)
 	ctx.Step(`^the write-ahead log for the update should be created$`, theWriteaheadLogForTheUpdateShouldBeCreated)
 	ctx.Step(`^the write-ahead log for the delete should be created$`, theWriteaheadLogForTheDeleteShouldBeCreated)
+	ctx.Step(`^the write-ahead log for the expiration should be created$`, theWriteaheadLogForTheExpirationShouldBeCreated)
 	ctx.Step(`^the number of entities should be (\d+)$`, theNumberOfEntitiesShouldBe)
 	ctx.Step(`^the entity should be in the list of all entities$`, theEntityShouldBeInTheListOfAllEntities)
 	ctx.Step(`^the list of all entities should be empty$`, theListOfAllEntitiesShouldBeEmpty)
@@ -161,6 +169,85 @@ func InitializeScenario(ctx *godog.ScenarioContext) {
 	ctx.Step(`^I should see an error containing "([^"]*)"$`, iShouldSeeAnErrorContaining)
 	ctx.Step(`^the entity should be in the list of entities of the owner$`, theEntityShouldBeInTheListOfEntitiesOfTheOwner)
 	ctx.Step(`^the sender should be the owner of the entity$`, theSenderShouldBeTheOwnerOfTheEntity)
+	ctx.Step(`^there is another account$`, thereIsAnotherAccount)
+	ctx.Step(`^the other account submits a transaction to update the entity$`, theOtherAccountSubmitsATransactionToUpdateTheEntity)
+	ctx.Step(`^the other account submits a transaction to delete the entity$`, theOtherAccountSubmitsATransactionToDeleteTheEntity)
+	ctx.Step(`^I try to delete the entity$`, iTryToDeleteTheEntity)
+	ctx.Step(`^the transaction should fail$`, theTransactionShouldFail)
+	ctx.Step(`^the payload of the entity should not be changed$`, thePayloadOfTheEntityShouldNotBeChanged)
+	ctx.Step(`^I (?:submit a transaction to transfer|have transferred) the ownership of the entity to the other account$`, iSubmitATransactionToTransferTheOwnershipOfTheEntityToTheOtherAccount)
+	ctx.Step(`^the other account should be the owner of the entity$`, theOtherAccountShouldBeTheOwnerOfTheEntity)
+	ctx.Step(`^the entity should be in the list of entities of the other account$`, theEntityShouldBeInTheListOfEntitiesOfTheOtherAccount)
+	ctx.Step(`^the write-ahead log for the ownership transfer should be created$`, theWriteaheadLogForTheOwnershipTransferShouldBeCreated)
+	ctx.Step(`^the payload of the entity at the creation block should be the original payload$`, thePayloadOfTheEntityAtTheCreationBlockShouldBeTheOriginalPayload)
+	ctx.Step(`^the payload of the entity at the latest block should be changed$`, thePayloadOfTheEntityAtTheLatestBlockShouldBeChanged)
+	ctx.Step(`^the query at the creation block should find the entity$`, theQueryAtTheCreationBlockShouldFindTheEntity)
+	ctx.Step(`^the number of entities at the creation block should be (\d+)$`, theNumberOfEntitiesAtTheCreationBlockShouldBe)
+	ctx.Step(`^the entity should be in the list of all entities at the creation block$`, theEntityShouldBeInTheListOfAllEntitiesAtTheCreationBlock)
+	ctx.Step(`^the entity should be in the list of entities of the owner at the creation block$`, theEntityShouldBeInTheListOfEntitiesOfTheOwnerAtTheCreationBlock)
+	ctx.Step(`^I search for entities with the query "([^"]*)" and the options:$`, iSearchForEntitiesWithTheQueryAndTheOptions)
+	ctx.Step(`^I fetch the next page of the results$`, iFetchTheNextPageOfTheResults)
+	ctx.Step(`^I fetch the next page of the results for the query "([^"]*)"$`, iFetchTheNextPageOfTheResultsForTheQuery)
+	ctx.Step(`^I submit a transaction to extend the TTL of the entity by (\d+) blocks$`, iSubmitATransactionToExtendTheTTLOfTheEntityByBlocks)
+	ctx.Step(`^the other account submits a transaction to extend the TTL of the entity by (\d+) blocks$`, theOtherAccountSubmitsATransactionToExtendTheTTLOfTheEntityByBlocks)
+	ctx.Step(`^I try to extend the TTL of the entity by (\d+) blocks$`, iTryToExtendTheTTLOfTheEntityByBlocks)
+	ctx.Step(`^the entity should expire (\d+) blocks later$`, theEntityShouldExpireBlocksLater)
+	ctx.Step(`^the entity should be in the list of entities to expire at the new expiration block$`, theEntityShouldBeInTheListOfEntitiesToExpireAtTheNewExpirationBlock)
+	ctx.Step(`^the write-ahead log for the extension should be created$`, theWriteaheadLogForTheExtensionShouldBeCreated)
+	ctx.Step(`^I try to create an entity with a TTL of (\d+) blocks$`, iTryToCreateAnEntityWithATTLOfBlocks)
+	ctx.Step(`^I try to create an entity with (\d+) string annotations$`, iTryToCreateAnEntityWithStringAnnotations)
+	ctx.Step(`^I submit a transaction to patch the entity, setting the string annotation "([^"]*)" to "([^"]*)"$`, iSubmitATransactionToPatchTheEntitySettingTheStringAnnotationTo)
+	ctx.Step(`^I submit a transaction to patch the entity, removing the numeric annotation "([^"]*)"$`, iSubmitATransactionToPatchTheEntityRemovingTheNumericAnnotation)
+	ctx.Step(`^I try to patch the entity, removing the numeric annotation "([^"]*)"$`, iTryToPatchTheEntityRemovingTheNumericAnnotation)
+	ctx.Step(`^I try to patch the entity, setting the string annotation "([^"]*)" to "([^"]*)"$`, iTryToPatchTheEntitySettingTheStringAnnotationTo)
+	ctx.Step(`^I submit a transaction to patch the entity, replacing the payload with "([^"]*)"$`, iSubmitATransactionToPatchTheEntityReplacingThePayloadWith)
+	ctx.Step(`^the entity should have the string annotation "([^"]*)" with the value "([^"]*)"$`, theEntityShouldHaveTheStringAnnotationWithTheValue)
+	ctx.Step(`^the entity should not have the numeric annotation "([^"]*)"$`, theEntityShouldNotHaveTheNumericAnnotation)
+	ctx.Step(`^the payload of the entity should be "([^"]*)"$`, thePayloadOfTheEntityShouldBe)
+	ctx.Step(`^the write-ahead log for the patch should be created$`, theWriteaheadLogForThePatchShouldBeCreated)
+	ctx.Step(`^the results should be ordered by the numeric annotation "([^"]*)"$`, theResultsShouldBeOrderedByTheNumericAnnotation)
+	ctx.Step(`^there should be no more results$`, thereShouldBeNoMoreResults)
+	ctx.Step(`^the results should not contain payloads$`, theResultsShouldNotContainPayloads)
+	ctx.Step(`^the results should contain the meta data of the entities$`, theResultsShouldContainTheMetaDataOfTheEntities)
+	ctx.Step(`^the results should not contain the meta data of the entities$`, theResultsShouldNotContainTheMetaDataOfTheEntities)
+	ctx.Step(`^I create an entity with a payload of (\d+)K and a TTL of (\d+) blocks$`, iCreateAnEntityWithAPayloadOfKAndATTLOfBlocks)
+	ctx.Step(`^the gas used by the transaction should cover the storage operations$`, theGasUsedByTheTransactionShouldCoverTheStorageOperations)
+	ctx.Step(`^I create an entity without enough gas for the storage operations$`, iCreateAnEntityWithoutEnoughGasForTheStorageOperations)
+	ctx.Step(`^I have subscribed to the operations of new blocks$`, iHaveSubscribedToTheOperationsOfNewBlocks)
+	ctx.Step(`^I subscribe to the operations from block (\d+)$`, iSubscribeToTheOperationsFromBlock)
+	ctx.Step(`^I should receive the create operation of the entity$`, iShouldReceiveTheCreateOperationOfTheEntity)
+	ctx.Step(`^I acknowledge the operations of the last block as consumer "([^"]*)"$`, iAcknowledgeTheOperationsOfTheLastBlockAsConsumer)
+	ctx.Step(`^the write-ahead log should have a checkpoint of consumer "([^"]*)" for the last block$`, theWriteaheadLogShouldHaveACheckpointOfConsumerForTheLastBlock)
+	ctx.Step(`^I remove the consumer "([^"]*)"$`, iRemoveTheConsumer)
+	ctx.Step(`^the write-ahead log should not have a checkpoint of consumer "([^"]*)"$`, theWriteaheadLogShouldNotHaveACheckpointOfConsumer)
+	ctx.Step(`^I stop the node and export the write-ahead log$`, iStopTheNodeAndExportTheWriteaheadLog)
+	ctx.Step(`^I stop the node and export the write-ahead log up to the block before the entity$`, iStopTheNodeAndExportTheWriteaheadLogUpToTheBlockBeforeTheEntity)
+	ctx.Step(`^I export the write-ahead log again$`, iExportTheWriteaheadLogAgain)
+	ctx.Step(`^the exported write-ahead log should contain the operations of the write-ahead log of the node$`, theExportedWriteaheadLogShouldContainTheOperationsOfTheWriteaheadLogOfTheNode)
+	ctx.Step(`^I stop the node and export a snapshot$`, iStopTheNodeAndExportASnapshot)
+	ctx.Step(`^I stop the node and export a snapshot of the block before the entity$`, iStopTheNodeAndExportASnapshotOfTheBlockBeforeTheEntity)
+	ctx.Step(`^the snapshot should be of the last block of the write-ahead log$`, theSnapshotShouldBeOfTheLastBlockOfTheWriteaheadLog)
+	ctx.Step(`^the snapshot should be of the block before the entity$`, theSnapshotShouldBeOfTheBlockBeforeTheEntity)
+	ctx.Step(`^the snapshot should contain the entity$`, theSnapshotShouldContainTheEntity)
+	ctx.Step(`^the snapshot should not contain the entity$`, theSnapshotShouldNotContainTheEntity)
+	ctx.Step(`^I create (\d+) entities in a batch with the Go client$`, iCreateEntitiesInABatchWithTheGoClient)
+	ctx.Step(`^I have created (\d+) entities with the Go client$`, iCreateEntitiesInABatchWithTheGoClient)
+	ctx.Step(`^the receipt should contain the keys of the (\d+) created entities$`, theReceiptShouldContainTheKeysOfTheCreatedEntities)
+	ctx.Step(`^the Go client should return the payloads and annotations of the created entities$`, theGoClientShouldReturnThePayloadsAndAnnotationsOfTheCreatedEntities)
+	ctx.Step(`^the Go client should find the created entities with the query '([^']*)'$`, theGoClientShouldFindTheCreatedEntitiesWithTheQuery)
+	ctx.Step(`^I update the first entity and extend the second entity by (\d+) blocks in a batch with the Go client$`, iUpdateTheFirstEntityAndExtendTheSecondEntityByBlocksInABatchWithTheGoClient)
+	ctx.Step(`^the receipt should contain the update of the first entity and the extension of the second entity$`, theReceiptShouldContainTheUpdateOfTheFirstEntityAndTheExtensionOfTheSecondEntity)
+	ctx.Step(`^I delete the entities with the Go client$`, iDeleteTheEntitiesWithTheGoClient)
+	ctx.Step(`^the receipt should contain the deletion of the entities$`, theReceiptShouldContainTheDeletionOfTheEntities)
+	ctx.Step(`^the Go client should not find any entities of the account$`, theGoClientShouldNotFindAnyEntitiesOfTheAccount)
+	ctx.Step(`^I delete an entity that does not exist with the Go client$`, iDeleteAnEntityThatDoesNotExistWithTheGoClient)
+	ctx.Step(`^the Go client should report a failed transaction$`, theGoClientShouldReportAFailedTransaction)
+	ctx.Step(`^I create (\d+) entities in transactions of at most (\d+) gas with the Go client$`, iCreateEntitiesInTransactionsOfAtMostGasWithTheGoClient)
+	ctx.Step(`^the entities should have been created in several transactions of at most (\d+) gas$`, theEntitiesShouldHaveBeenCreatedInSeveralTransactionsOfAtMostGas)
+	ctx.Step(`^I have subscribed to the entity events with the query '([^']*)'$`, iHaveSubscribedToTheEntityEventsWithTheQuery)
+	ctx.Step(`^I have subscribed to the entity events of my account$`, iHaveSubscribedToTheEntityEventsOfMyAccount)
+	ctx.Step(`^I should receive the (created|updated|deleted|expired) event of the entity$`, iShouldReceiveTheEventOfTheEntity)
+	ctx.Step(`^I should not receive an event of the entity$`, iShouldNotReceiveAnEventOfTheEntity)
 }
 
 func iSearchForEntitiesWithTheInvalidQuery(ctx context.Context, query *godog.DocString) error {
@@ -453,7 +540,7 @@ func iSearchForEntitiesWithTheStringAnnotationEqualTo(ctx context.Context, key,
 		return fmt.Errorf("failed to get entities to by numeric annotation: %w", err)
 	}
 
-	w.SearchResult = res
+	w.SearchResult = withoutMetaData(res)
 
 	return nil
 
@@ -522,7 +609,7 @@ func iSearchForEntitiesWithTheNumericAnnotationEqualTo(ctx context.Context, key
 		return fmt.Errorf("failed to get entities to by numeric annotation: %w", err)
 	}
 
-	w.SearchResult = res
+	w.SearchResult = withoutMetaData(res)
 
 	return nil
 
@@ -591,6 +678,28 @@ func theEntityShouldBeDeleted(ctx context.Context) error {
 	return nil
 }
 
+func theMetaDataOfTheEntityShouldNotBeFound(ctx context.Context) error {
+	w := testutil.GetWorld(ctx)
+
+	var md entity.EntityMetaData
+
+	err := w.GethInstance.RPCClient.CallContext(
+		ctx,
+		&md,
+		"golembase_getEntityMetaData",
+		w.CreatedEntityKey.Hex(),
+	)
+	if err == nil {
+		return fmt.Errorf("expected the meta data of the deleted entity not to be found, got %v", md)
+	}
+
+	if !strings.Contains(err.Error(), "not found") {
+		return fmt.Errorf("unexpected error: %w", err)
+	}
+
+	return nil
+}
+
 func iSubmitATransactionToUpdateTheEntityChangingThePaylod(ctx context.Context) error {
 	w := testutil.GetWorld(ctx)
 
@@ -822,7 +931,7 @@ func iSearchForEntitiesWithTheQuery(ctx context.Context, queryDoc *godog.DocStri
 		return fmt.Errorf("failed to get entities to by numeric annotation: %w", err)
 	}
 
-	w.SearchResult = res
+	w.SearchResult = withoutMetaData(res)
 
 	return nil
 }
@@ -954,6 +1063,10 @@ func theExpiredEntityShouldBeDeleted(ctx context.Context) error {
 		return fmt.Errorf("no logs found in housekeeping tx")
 	}
 
+	if firstTx.Logs[0].Topics[0] != storagetx.GolemBaseStorageEntityExpired {
+		return fmt.Errorf("expected an expired log but got topic %s", firstTx.Logs[0].Topics[0].Hex())
+	}
+
 	key := firstTx.Logs[0].Topics[1]
 
 	if key != w.CreatedEntityKey {
@@ -1081,6 +1194,30 @@ func theWriteaheadLogForTheDeleteShouldBeCreated(ctx context.Context) error {
 
 }
 
+func theWriteaheadLogForTheExpirationShouldBeCreated(ctx context.Context) error {
+	w := testutil.GetWorld(ctx)
+
+	wl, err := w.ReadWAL(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to read write-ahead log: %w", err)
+	}
+
+	err = checkIfEqual(
+		wl[1:],
+		[]wal.Operation{
+			{
+				Expire: &w.CreatedEntityKey,
+			},
+		},
+	)
+
+	if err != nil {
+		return fmt.Errorf("check expired: failed to check if write-ahead log is equal: %w", err)
+	}
+
+	return nil
+}
+
 func theNumberOfEntitiesShouldBe(ctx context.Context, expected int) error {
 	w := testutil.GetWorld(ctx)
 
@@ -1178,3 +1315,1467 @@ func theSenderShouldBeTheOwnerOfTheEntity(ctx context.Context) error {
 
 	return nil
 }
+
+func thereIsAnotherAccount(ctx context.Context) error {
+	w := testutil.GetWorld(ctx)
+
+	err := w.CreateOtherAccount(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to create other account: %w", err)
+	}
+
+	return nil
+}
+
+func theOtherAccountSubmitsATransactionToUpdateTheEntity(ctx context.Context) error {
+	w := testutil.GetWorld(ctx)
+
+	return w.AsOtherAccount(func() error {
+		_, w.LastError = w.UpdateEntity(
+			ctx,
+			w.CreatedEntityKey,
+			100,
+			[]byte("new payload"),
+			[]entity.StringAnnotation{},
+			[]entity.NumericAnnotation{},
+		)
+		return nil
+	})
+}
+
+func theOtherAccountSubmitsATransactionToDeleteTheEntity(ctx context.Context) error {
+	w := testutil.GetWorld(ctx)
+
+	return w.AsOtherAccount(func() error {
+		_, w.LastError = w.DeleteEntity(
+			ctx,
+			w.CreatedEntityKey,
+		)
+		return nil
+	})
+}
+
+func iTryToDeleteTheEntity(ctx context.Context) error {
+	w := testutil.GetWorld(ctx)
+
+	_, w.LastError = w.DeleteEntity(
+		ctx,
+		w.CreatedEntityKey,
+	)
+
+	return nil
+}
+
+func theTransactionShouldFail(ctx context.Context) error {
+	w := testutil.GetWorld(ctx)
+
+	if w.LastError == nil {
+		return fmt.Errorf("expected the transaction to fail, but it succeeded")
+	}
+
+	return nil
+}
+
+func thePayloadOfTheEntityShouldNotBeChanged(ctx context.Context) error {
+	w := testutil.GetWorld(ctx)
+
+	var v []byte
+
+	err := w.GethInstance.RPCClient.CallContext(
+		ctx,
+		&v,
+		"golembase_getStorageValue",
+		w.CreatedEntityKey,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to get storage value: %w", err)
+	}
+
+	if string(v) != "test payload" {
+		return fmt.Errorf("unexpected storage value: %s", string(v))
+	}
+
+	return nil
+}
+
+func iSubmitATransactionToTransferTheOwnershipOfTheEntityToTheOtherAccount(ctx context.Context) error {
+	w := testutil.GetWorld(ctx)
+
+	_, err := w.TransferEntityOwnership(
+		ctx,
+		w.CreatedEntityKey,
+		w.OtherAccount.Address,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to transfer ownership of the entity: %w", err)
+	}
+
+	return nil
+}
+
+func theOtherAccountShouldBeTheOwnerOfTheEntity(ctx context.Context) error {
+	w := testutil.GetWorld(ctx)
+
+	var ap entity.EntityMetaData
+
+	err := w.GethInstance.RPCClient.CallContext(ctx, &ap, "golembase_getEntityMetaData", w.CreatedEntityKey.Hex())
+	if err != nil {
+		return fmt.Errorf("failed to get entity metadata: %w", err)
+	}
+
+	if ap.Owner != w.OtherAccount.Address {
+		return fmt.Errorf("expected owner to be %s, but got %s", w.OtherAccount.Address.Hex(), ap.Owner.Hex())
+	}
+
+	return nil
+}
+
+func theEntityShouldBeInTheListOfEntitiesOfTheOtherAccount(ctx context.Context) error {
+	w := testutil.GetWorld(ctx)
+
+	var entityKeys []common.Hash
+	err := w.GethInstance.RPCClient.CallContext(ctx, &entityKeys, "golembase_getEntitiesOfOwner", w.OtherAccount.Address)
+	if err != nil {
+		return fmt.Errorf("failed to get entities of owner: %w", err)
+	}
+
+	if len(entityKeys) != 1 || entityKeys[0] != w.CreatedEntityKey {
+		return fmt.Errorf("expected entities of the other account to be [%s], but got %v", w.CreatedEntityKey.Hex(), entityKeys)
+	}
+
+	err = w.GethInstance.RPCClient.CallContext(ctx, &entityKeys, "golembase_getEntitiesOfOwner", w.FundedAccount.Address)
+	if err != nil {
+		return fmt.Errorf("failed to get entities of owner: %w", err)
+	}
+
+	if len(entityKeys) != 0 {
+		return fmt.Errorf("expected previous owner to have no entities, but got %v", entityKeys)
+	}
+
+	return nil
+}
+
+func theWriteaheadLogForTheOwnershipTransferShouldBeCreated(ctx context.Context) error {
+	w := testutil.GetWorld(ctx)
+
+	wl, err := w.ReadWAL(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to read write-ahead log: %w", err)
+	}
+
+	err = checkIfEqual(
+		wl[1:],
+		[]wal.Operation{
+			{
+				TransferOwnership: &wal.TransferOwnership{
+					EntityKey: w.CreatedEntityKey,
+					NewOwner:  w.OtherAccount.Address,
+				},
+			},
+		},
+	)
+
+	if err != nil {
+		return fmt.Errorf("failed to check if write-ahead log is equal: %w", err)
+	}
+
+	return nil
+}
+
+func thePayloadOfTheEntityAtTheCreationBlockShouldBeTheOriginalPayload(ctx context.Context) error {
+	w := testutil.GetWorld(ctx)
+
+	var v []byte
+
+	err := w.GethInstance.RPCClient.CallContext(
+		ctx,
+		&v,
+		"golembase_getStorageValue",
+		w.CreatedEntityKey,
+		rpc.BlockNumberOrHashWithHash(w.CreatedAtBlockHash, true),
+	)
+	if err != nil {
+		return fmt.Errorf("failed to get storage value: %w", err)
+	}
+
+	if string(v) != "test payload" {
+		return fmt.Errorf("unexpected storage value: %s", string(v))
+	}
+
+	return nil
+}
+
+func thePayloadOfTheEntityAtTheLatestBlockShouldBeChanged(ctx context.Context) error {
+	w := testutil.GetWorld(ctx)
+
+	var v []byte
+
+	err := w.GethInstance.RPCClient.CallContext(
+		ctx,
+		&v,
+		"golembase_getStorageValue",
+		w.CreatedEntityKey,
+		rpc.BlockNumberOrHashWithNumber(rpc.LatestBlockNumber),
+	)
+	if err != nil {
+		return fmt.Errorf("failed to get storage value: %w", err)
+	}
+
+	if string(v) != "new payload" {
+		return fmt.Errorf("unexpected storage value: %s", string(v))
+	}
+
+	return nil
+}
+
+func theQueryAtTheCreationBlockShouldFindTheEntity(ctx context.Context) error {
+	w := testutil.GetWorld(ctx)
+
+	res := []golemtype.SearchResult{}
+
+	err := w.GethInstance.RPCClient.CallContext(
+		ctx,
+		&res,
+		"golembase_queryEntities",
+		`test_key = "test_value"`,
+		rpc.BlockNumberOrHashWithHash(w.CreatedAtBlockHash, true),
+	)
+	if err != nil {
+		return fmt.Errorf("failed to query entities: %w", err)
+	}
+
+	if len(res) != 1 || res[0].Key != w.CreatedEntityKey {
+		return fmt.Errorf("expected to find entity %s, but got %v", w.CreatedEntityKey.Hex(), res)
+	}
+
+	return nil
+}
+
+func theNumberOfEntitiesAtTheCreationBlockShouldBe(ctx context.Context, expected int) error {
+	w := testutil.GetWorld(ctx)
+
+	var count uint64
+	err := w.GethInstance.RPCClient.CallContext(
+		ctx,
+		&count,
+		"golembase_getEntityCount",
+		rpc.BlockNumberOrHashWithHash(w.CreatedAtBlockHash, true),
+	)
+	if err != nil {
+		return fmt.Errorf("failed to get entity count: %w", err)
+	}
+
+	if int(count) != expected {
+		return fmt.Errorf("expected %d entities, but got %d", expected, count)
+	}
+
+	return nil
+}
+
+func theEntityShouldBeInTheListOfAllEntitiesAtTheCreationBlock(ctx context.Context) error {
+	w := testutil.GetWorld(ctx)
+
+	var entityKeys []common.Hash
+	err := w.GethInstance.RPCClient.CallContext(
+		ctx,
+		&entityKeys,
+		"golembase_getAllEntityKeys",
+		rpc.BlockNumberOrHashWithHash(w.CreatedAtBlockHash, true),
+	)
+	if err != nil {
+		return fmt.Errorf("failed to get all entity keys: %w", err)
+	}
+
+	if !slices.Contains(entityKeys, w.CreatedEntityKey) {
+		return fmt.Errorf("entity with key %s not found in the list of all entities", w.CreatedEntityKey.Hex())
+	}
+
+	return nil
+}
+
+func theEntityShouldBeInTheListOfEntitiesOfTheOwnerAtTheCreationBlock(ctx context.Context) error {
+	w := testutil.GetWorld(ctx)
+
+	var entityKeys []common.Hash
+	err := w.GethInstance.RPCClient.CallContext(
+		ctx,
+		&entityKeys,
+		"golembase_getEntitiesOfOwner",
+		w.FundedAccount.Address,
+		rpc.BlockNumberOrHashWithHash(w.CreatedAtBlockHash, true),
+	)
+	if err != nil {
+		return fmt.Errorf("failed to get entities of owner: %w", err)
+	}
+
+	if !slices.Contains(entityKeys, w.CreatedEntityKey) {
+		return fmt.Errorf("entity with key %s not found in the list of entities of the owner", w.CreatedEntityKey.Hex())
+	}
+
+	var md entity.EntityMetaData
+	err = w.GethInstance.RPCClient.CallContext(
+		ctx,
+		&md,
+		"golembase_getEntityMetaData",
+		w.CreatedEntityKey,
+		rpc.BlockNumberOrHashWithHash(w.CreatedAtBlockHash, true),
+	)
+	if err != nil {
+		return fmt.Errorf("failed to get entity metadata: %w", err)
+	}
+
+	if md.Owner != w.FundedAccount.Address {
+		return fmt.Errorf("expected owner to be %s, but got %s", w.FundedAccount.Address.Hex(), md.Owner.Hex())
+	}
+
+	return nil
+}
+
+func queryEntitiesPage(ctx context.Context, query string, options golemtype.QueryOptions) error {
+	w := testutil.GetWorld(ctx)
+
+	res := golemtype.QueryResult{}
+
+	err := w.GethInstance.RPCClient.CallContext(
+		ctx,
+		&res,
+		"golembase_queryEntitiesPage",
+		query,
+		nil,
+		options,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to query entities: %w", err)
+	}
+
+	w.LastQuery = query
+	w.LastQueryOptions = options
+	w.LastQueryCursor = res.Cursor
+	w.SearchResult = res.Results
+
+	return nil
+}
+
+func iSearchForEntitiesWithTheQueryAndTheOptions(ctx context.Context, query string, optionsDoc *godog.DocString) error {
+	options := golemtype.QueryOptions{}
+	err := json.Unmarshal([]byte(optionsDoc.Content), &options)
+	if err != nil {
+		return fmt.Errorf("failed to parse query options: %w", err)
+	}
+
+	return queryEntitiesPage(ctx, query, options)
+}
+
+func iFetchTheNextPageOfTheResults(ctx context.Context) error {
+	w := testutil.GetWorld(ctx)
+
+	if w.LastQueryCursor == "" {
+		return fmt.Errorf("the last query has no more results")
+	}
+
+	options := w.LastQueryOptions
+	options.Cursor = w.LastQueryCursor
+
+	return queryEntitiesPage(ctx, w.LastQuery, options)
+}
+
+func iFetchTheNextPageOfTheResultsForTheQuery(ctx context.Context, query string) error {
+	w := testutil.GetWorld(ctx)
+
+	options := w.LastQueryOptions
+	options.Cursor = w.LastQueryCursor
+
+	w.LastError = w.GethInstance.RPCClient.CallContext(
+		ctx,
+		nil,
+		"golembase_queryEntitiesPage",
+		query,
+		nil,
+		options,
+	)
+
+	return nil
+}
+
+func theResultsShouldBeOrderedByTheNumericAnnotation(ctx context.Context, annotation string) error {
+	w := testutil.GetWorld(ctx)
+
+	values := []uint64{}
+	for _, r := range w.SearchResult {
+		md := entity.EntityMetaData{}
+		err := w.GethInstance.RPCClient.CallContext(
+			ctx,
+			&md,
+			"golembase_getEntityMetaData",
+			r.Key,
+		)
+		if err != nil {
+			return fmt.Errorf("failed to get entity meta data: %w", err)
+		}
+
+		for _, a := range md.NumericAnnotations {
+			if a.Key == annotation {
+				values = append(values, a.Value)
+			}
+		}
+	}
+
+	if !slices.IsSorted(values) {
+		return fmt.Errorf("results are not ordered by %s: %v", annotation, values)
+	}
+
+	return nil
+}
+
+func thereShouldBeNoMoreResults(ctx context.Context) error {
+	w := testutil.GetWorld(ctx)
+
+	if w.LastQueryCursor != "" {
+		return fmt.Errorf("expected no cursor, but got %s", w.LastQueryCursor)
+	}
+
+	return nil
+}
+
+func theResultsShouldNotContainPayloads(ctx context.Context) error {
+	w := testutil.GetWorld(ctx)
+
+	for _, r := range w.SearchResult {
+		if len(r.Value) != 0 {
+			return fmt.Errorf("expected no payload for entity %s, but got %q", r.Key.Hex(), r.Value)
+		}
+	}
+
+	return nil
+}
+
+func theResultsShouldContainTheMetaDataOfTheEntities(ctx context.Context) error {
+	w := testutil.GetWorld(ctx)
+
+	for _, r := range w.SearchResult {
+		if r.EntityMetaData == nil {
+			return fmt.Errorf("expected meta data for entity %s", r.Key.Hex())
+		}
+
+		if r.Owner != w.FundedAccount.Address {
+			return fmt.Errorf("expected owner to be %s, but got %s", w.FundedAccount.Address.Hex(), r.Owner.Hex())
+		}
+
+		if r.ExpiresAtBlock == 0 {
+			return fmt.Errorf("expected expiration block for entity %s", r.Key.Hex())
+		}
+
+		if len(r.NumericAnnotations) == 0 {
+			return fmt.Errorf("expected numeric annotations for entity %s", r.Key.Hex())
+		}
+
+		if len(r.Value) == 0 {
+			return fmt.Errorf("expected payload for entity %s", r.Key.Hex())
+		}
+	}
+
+	return nil
+}
+
+func theResultsShouldNotContainTheMetaDataOfTheEntities(ctx context.Context) error {
+	w := testutil.GetWorld(ctx)
+
+	for _, r := range w.SearchResult {
+		if r.EntityMetaData != nil {
+			return fmt.Errorf("expected no meta data for entity %s, but got %v", r.Key.Hex(), r.EntityMetaData)
+		}
+	}
+
+	return nil
+}
+
+// gasTestEntity is the entity created by the gas metering scenarios.
+func gasTestEntity(kilobytes int, ttl uint64) storagetx.Create {
+	return storagetx.Create{
+		TTL:     ttl,
+		Payload: bytes.Repeat([]byte{1}, 1024*kilobytes),
+		StringAnnotations: []entity.StringAnnotation{
+			{Key: "test_key", Value: "test_value"},
+		},
+		NumericAnnotations: []entity.NumericAnnotation{
+			{Key: "test_number", Value: 42},
+		},
+	}
+}
+
+func iCreateAnEntityWithAPayloadOfKAndATTLOfBlocks(ctx context.Context, kilobytes int, ttl int) error {
+	w := testutil.GetWorld(ctx)
+
+	create := gasTestEntity(kilobytes, uint64(ttl))
+
+	_, err := w.CreateEntity(ctx, create.TTL, create.Payload, create.StringAnnotations, create.NumericAnnotations)
+	if err != nil {
+		return fmt.Errorf("failed to create entity: %w", err)
+	}
+
+	return nil
+}
+
+func theGasUsedByTheTransactionShouldCoverTheStorageOperations(ctx context.Context) error {
+	w := testutil.GetWorld(ctx)
+
+	tx := &storagetx.StorageTransaction{
+		Create: []storagetx.Create{gasTestEntity(1, 1000)},
+	}
+
+	storageGas, _ := tx.Gas()
+
+	// the intrinsic gas is paid on top of the gas of the storage operations
+	if w.LastReceipt.GasUsed <= storageGas {
+		return fmt.Errorf("expected more than %d gas to be used, but got %d", storageGas, w.LastReceipt.GasUsed)
+	}
+
+	return nil
+}
+
+func iCreateAnEntityWithoutEnoughGasForTheStorageOperations(ctx context.Context) error {
+	w := testutil.GetWorld(ctx)
+
+	create := gasTestEntity(1, 1000)
+
+	// enough for the intrinsic gas, but not for the storage operations
+	_, err := w.CreateEntityWithGas(ctx, 80_000, create.TTL, create.Payload, create.StringAnnotations, create.NumericAnnotations)
+	w.LastError = err
+
+	return nil
+}
+
+func iSubmitATransactionToExtendTheTTLOfTheEntityByBlocks(ctx context.Context, blocks int) error {
+	w := testutil.GetWorld(ctx)
+
+	_, err := w.ExtendEntity(
+		ctx,
+		w.CreatedEntityKey,
+		uint64(blocks),
+	)
+	if err != nil {
+		return fmt.Errorf("failed to extend the TTL of the entity: %w", err)
+	}
+
+	return nil
+}
+
+func theOtherAccountSubmitsATransactionToExtendTheTTLOfTheEntityByBlocks(ctx context.Context, blocks int) error {
+	w := testutil.GetWorld(ctx)
+
+	return w.AsOtherAccount(func() error {
+		_, w.LastError = w.ExtendEntity(
+			ctx,
+			w.CreatedEntityKey,
+			uint64(blocks),
+		)
+		return nil
+	})
+}
+
+func iTryToExtendTheTTLOfTheEntityByBlocks(ctx context.Context, blocks int) error {
+	w := testutil.GetWorld(ctx)
+
+	_, w.LastError = w.ExtendEntity(
+		ctx,
+		w.CreatedEntityKey,
+		uint64(blocks),
+	)
+
+	return nil
+}
+
+// extendedExpiration returns the old and the new expiration block from the TTL extension log of the last receipt.
+func extendedExpiration(receipt *types.Receipt) (uint64, uint64, error) {
+	for _, l := range receipt.Logs {
+		if len(l.Topics) < 2 || l.Topics[0] != storagetx.GolemBaseStorageEntityTTLExtended {
+			continue
+		}
+
+		if len(l.Data) != 64 {
+			return 0, 0, fmt.Errorf("unexpected length of the TTL extension log data: %d", len(l.Data))
+		}
+
+		oldExpiresAtBlock := new(big.Int).SetBytes(l.Data[:32]).Uint64()
+		newExpiresAtBlock := new(big.Int).SetBytes(l.Data[32:]).Uint64()
+
+		return oldExpiresAtBlock, newExpiresAtBlock, nil
+	}
+
+	return 0, 0, fmt.Errorf("no TTL extension log found")
+}
+
+func theEntityShouldExpireBlocksLater(ctx context.Context, blocks int) error {
+	w := testutil.GetWorld(ctx)
+
+	oldExpiresAtBlock, newExpiresAtBlock, err := extendedExpiration(w.LastReceipt)
+	if err != nil {
+		return err
+	}
+
+	if newExpiresAtBlock != oldExpiresAtBlock+uint64(blocks) {
+		return fmt.Errorf("expected the entity to expire at block %d, but the log says %d", oldExpiresAtBlock+uint64(blocks), newExpiresAtBlock)
+	}
+
+	var md entity.EntityMetaData
+
+	err = w.GethInstance.RPCClient.CallContext(ctx, &md, "golembase_getEntityMetaData", w.CreatedEntityKey.Hex())
+	if err != nil {
+		return fmt.Errorf("failed to get entity metadata: %w", err)
+	}
+
+	if md.ExpiresAtBlock != newExpiresAtBlock {
+		return fmt.Errorf("expected the entity to expire at block %d, but got %d", newExpiresAtBlock, md.ExpiresAtBlock)
+	}
+
+	return nil
+}
+
+func theEntityShouldBeInTheListOfEntitiesToExpireAtTheNewExpirationBlock(ctx context.Context) error {
+	w := testutil.GetWorld(ctx)
+
+	oldExpiresAtBlock, newExpiresAtBlock, err := extendedExpiration(w.LastReceipt)
+	if err != nil {
+		return err
+	}
+
+	toExpire := []common.Hash{}
+
+	err = w.GethInstance.RPCClient.CallContext(ctx, &toExpire, "golembase_getEntitiesToExpireAtBlock", newExpiresAtBlock)
+	if err != nil {
+		return fmt.Errorf("failed to get entities to expire: %w", err)
+	}
+
+	if len(toExpire) != 1 || toExpire[0] != w.CreatedEntityKey {
+		return fmt.Errorf("expected entities to expire at block %d to be [%s], but got %v", newExpiresAtBlock, w.CreatedEntityKey.Hex(), toExpire)
+	}
+
+	err = w.GethInstance.RPCClient.CallContext(ctx, &toExpire, "golembase_getEntitiesToExpireAtBlock", oldExpiresAtBlock)
+	if err != nil {
+		return fmt.Errorf("failed to get entities to expire: %w", err)
+	}
+
+	if len(toExpire) != 0 {
+		return fmt.Errorf("expected no entities to expire at block %d, but got %v", oldExpiresAtBlock, toExpire)
+	}
+
+	return nil
+}
+
+func theWriteaheadLogForTheExtensionShouldBeCreated(ctx context.Context) error {
+	w := testutil.GetWorld(ctx)
+
+	_, newExpiresAtBlock, err := extendedExpiration(w.LastReceipt)
+	if err != nil {
+		return err
+	}
+
+	wl, err := w.ReadWAL(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to read write-ahead log: %w", err)
+	}
+
+	err = checkIfEqual(
+		wl[1:],
+		[]wal.Operation{
+			{
+				Extend: &wal.ExtendTTL{
+					EntityKey:      w.CreatedEntityKey,
+					ExpiresAtBlock: newExpiresAtBlock,
+				},
+			},
+		},
+	)
+
+	if err != nil {
+		return fmt.Errorf("failed to check if write-ahead log is equal: %w", err)
+	}
+
+	return nil
+}
+
+func iTryToCreateAnEntityWithATTLOfBlocks(ctx context.Context, ttl int) error {
+	w := testutil.GetWorld(ctx)
+
+	_, w.LastError = w.CreateEntity(ctx, uint64(ttl), []byte("test payload"), nil, nil)
+
+	return nil
+}
+
+func iTryToCreateAnEntityWithStringAnnotations(ctx context.Context, count int) error {
+	w := testutil.GetWorld(ctx)
+
+	annotations := make([]entity.StringAnnotation, count)
+	for i := range annotations {
+		annotations[i] = entity.StringAnnotation{Key: fmt.Sprintf("key%d", i), Value: "value"}
+	}
+
+	_, w.LastError = w.CreateEntity(ctx, 100, []byte("test payload"), annotations, nil)
+
+	return nil
+}
+
+func patchEntity(ctx context.Context, patch storagetx.Patch) error {
+	w := testutil.GetWorld(ctx)
+
+	patch.EntityKey = w.CreatedEntityKey
+
+	_, err := w.PatchEntity(ctx, patch)
+	if err != nil {
+		return fmt.Errorf("failed to patch the entity: %w", err)
+	}
+
+	return nil
+}
+
+func iSubmitATransactionToPatchTheEntitySettingTheStringAnnotationTo(ctx context.Context, key, value string) error {
+	return patchEntity(ctx, storagetx.Patch{
+		SetStringAnnotations: []entity.StringAnnotation{{Key: key, Value: value}},
+	})
+}
+
+func iSubmitATransactionToPatchTheEntityRemovingTheNumericAnnotation(ctx context.Context, key string) error {
+	return patchEntity(ctx, storagetx.Patch{
+		RemoveNumericAnnotations: []string{key},
+	})
+}
+
+func iTryToPatchTheEntityRemovingTheNumericAnnotation(ctx context.Context, key string) error {
+	w := testutil.GetWorld(ctx)
+
+	w.LastError = patchEntity(ctx, storagetx.Patch{
+		RemoveNumericAnnotations: []string{key},
+	})
+
+	return nil
+}
+
+func iTryToPatchTheEntitySettingTheStringAnnotationTo(ctx context.Context, key, value string) error {
+	w := testutil.GetWorld(ctx)
+
+	w.LastError = patchEntity(ctx, storagetx.Patch{
+		SetStringAnnotations: []entity.StringAnnotation{{Key: key, Value: value}},
+	})
+
+	return nil
+}
+
+func iSubmitATransactionToPatchTheEntityReplacingThePayloadWith(ctx context.Context, payload string) error {
+	return patchEntity(ctx, storagetx.Patch{
+		ReplacePayload: true,
+		Payload:        []byte(payload),
+	})
+}
+
+func getCreatedEntityMetaData(ctx context.Context) (*entity.EntityMetaData, error) {
+	w := testutil.GetWorld(ctx)
+
+	md := &entity.EntityMetaData{}
+
+	err := w.GethInstance.RPCClient.CallContext(ctx, md, "golembase_getEntityMetaData", w.CreatedEntityKey.Hex())
+	if err != nil {
+		return nil, fmt.Errorf("failed to get entity metadata: %w", err)
+	}
+
+	return md, nil
+}
+
+func theEntityShouldHaveTheStringAnnotationWithTheValue(ctx context.Context, key, value string) error {
+	md, err := getCreatedEntityMetaData(ctx)
+	if err != nil {
+		return err
+	}
+
+	for _, a := range md.StringAnnotations {
+		if a.Key == key {
+			if a.Value != value {
+				return fmt.Errorf("expected string annotation %q to be %q, but got %q", key, value, a.Value)
+			}
+			return nil
+		}
+	}
+
+	return fmt.Errorf("string annotation %q not found in %v", key, md.StringAnnotations)
+}
+
+func theEntityShouldNotHaveTheNumericAnnotation(ctx context.Context, key string) error {
+	md, err := getCreatedEntityMetaData(ctx)
+	if err != nil {
+		return err
+	}
+
+	for _, a := range md.NumericAnnotations {
+		if a.Key == key {
+			return fmt.Errorf("unexpected numeric annotation %q with the value %d", key, a.Value)
+		}
+	}
+
+	return nil
+}
+
+func thePayloadOfTheEntityShouldBe(ctx context.Context, payload string) error {
+	w := testutil.GetWorld(ctx)
+
+	var v []byte
+
+	err := w.GethInstance.RPCClient.CallContext(
+		ctx,
+		&v,
+		"golembase_getStorageValue",
+		w.CreatedEntityKey,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to get storage value: %w", err)
+	}
+
+	if string(v) != payload {
+		return fmt.Errorf("unexpected storage value: %s", string(v))
+	}
+
+	return nil
+}
+
+func theWriteaheadLogForThePatchShouldBeCreated(ctx context.Context) error {
+	w := testutil.GetWorld(ctx)
+
+	md, err := getCreatedEntityMetaData(ctx)
+	if err != nil {
+		return err
+	}
+
+	wl, err := w.ReadWAL(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to read write-ahead log: %w", err)
+	}
+
+	err = checkIfEqual(
+		wl[1:],
+		[]wal.Operation{
+			{
+				Patch: &wal.Patch{
+					EntityKey:            w.CreatedEntityKey,
+					ExpiresAtBlock:       md.ExpiresAtBlock,
+					SetStringAnnotations: []entity.StringAnnotation{{Key: "test_key", Value: "patched"}},
+				},
+			},
+		},
+	)
+
+	if err != nil {
+		return fmt.Errorf("failed to check if write-ahead log is equal: %w", err)
+	}
+
+	return nil
+}
+
+func iHaveSubscribedToTheOperationsOfNewBlocks(ctx context.Context) error {
+	w := testutil.GetWorld(ctx)
+
+	blockNumber, err := w.GethInstance.ETHClient.BlockNumber(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to get block number: %w", err)
+	}
+
+	return w.SubscribeToOperations(ctx, blockNumber+1)
+}
+
+func iSubscribeToTheOperationsFromBlock(ctx context.Context, fromBlock int) error {
+	w := testutil.GetWorld(ctx)
+	return w.SubscribeToOperations(ctx, uint64(fromBlock))
+}
+
+func iShouldReceiveTheCreateOperationOfTheEntity(ctx context.Context) error {
+	w := testutil.GetWorld(ctx)
+
+	for {
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("did not receive the create operation of entity %s", w.CreatedEntityKey.Hex())
+		case bo := <-w.ReceivedOperations:
+			for _, op := range bo.Operations {
+				if op.Create == nil || op.Create.EntityKey != w.CreatedEntityKey {
+					continue
+				}
+
+				if !bytes.Equal(op.Create.Payload, []byte("test payload")) {
+					return fmt.Errorf("unexpected payload %q", op.Create.Payload)
+				}
+
+				if op.Create.Owner != w.FundedAccount.Address {
+					return fmt.Errorf("unexpected owner %s", op.Create.Owner.Hex())
+				}
+
+				return nil
+			}
+		}
+	}
+}
+
+func iAcknowledgeTheOperationsOfTheLastBlockAsConsumer(ctx context.Context, consumer string) error {
+	w := testutil.GetWorld(ctx)
+
+	header, err := w.GethInstance.ETHClient.HeaderByNumber(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to get last header: %w", err)
+	}
+
+	w.LastError = wal.AcknowledgeOperations(ctx, w.GethInstance.RPCClient, consumer, wal.Checkpoint{
+		BlockNumber: header.Number.Uint64(),
+		BlockHash:   header.Hash(),
+	})
+
+	return nil
+}
+
+func theWriteaheadLogShouldHaveACheckpointOfConsumerForTheLastBlock(ctx context.Context, consumer string) error {
+	w := testutil.GetWorld(ctx)
+
+	if w.LastError != nil {
+		return fmt.Errorf("failed to acknowledge the operations: %w", w.LastError)
+	}
+
+	header, err := w.GethInstance.ETHClient.HeaderByNumber(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to get last header: %w", err)
+	}
+
+	checkpoints, err := wal.ReadCheckpoints(w.GethInstance.WALDir)
+	if err != nil {
+		return fmt.Errorf("failed to read checkpoints: %w", err)
+	}
+
+	cp, ok := checkpoints[consumer]
+	if !ok {
+		return fmt.Errorf("no checkpoint of consumer %s", consumer)
+	}
+
+	if cp.BlockNumber != header.Number.Uint64() || cp.BlockHash != header.Hash() {
+		return fmt.Errorf("unexpected checkpoint %d %s, expected %d %s", cp.BlockNumber, cp.BlockHash.Hex(), header.Number.Uint64(), header.Hash().Hex())
+	}
+
+	return nil
+}
+
+func iRemoveTheConsumer(ctx context.Context, consumer string) error {
+	w := testutil.GetWorld(ctx)
+
+	err := w.GethInstance.RPCClient.CallContext(ctx, nil, "golembaseadmin_removeOperationsConsumer", consumer)
+	if err != nil {
+		return fmt.Errorf("failed to remove consumer: %w", err)
+	}
+
+	return nil
+}
+
+func theWriteaheadLogShouldNotHaveACheckpointOfConsumer(ctx context.Context, consumer string) error {
+	w := testutil.GetWorld(ctx)
+
+	checkpoints, err := wal.ReadCheckpoints(w.GethInstance.WALDir)
+	if err != nil {
+		return fmt.Errorf("failed to read checkpoints: %w", err)
+	}
+
+	_, ok := checkpoints[consumer]
+	if ok {
+		return fmt.Errorf("consumer %s still has a checkpoint", consumer)
+	}
+
+	return nil
+}
+
+func stopTheNodeAndExportTheWriteaheadLog(ctx context.Context, args ...string) error {
+	w := testutil.GetWorld(ctx)
+
+	err := w.GethInstance.Stop()
+	if err != nil {
+		return err
+	}
+
+	w.ExportedWALDir = filepath.Join(filepath.Dir(w.GethInstance.WALDir), "exported-wal")
+
+	return w.GethInstance.ExportWAL(ctx, w.ExportedWALDir, args...)
+}
+
+func iStopTheNodeAndExportTheWriteaheadLog(ctx context.Context) error {
+	return stopTheNodeAndExportTheWriteaheadLog(ctx)
+}
+
+func iStopTheNodeAndExportTheWriteaheadLogUpToTheBlockBeforeTheEntity(ctx context.Context) error {
+	w := testutil.GetWorld(ctx)
+	blockBefore := w.LastReceipt.BlockNumber.Uint64() - 1
+
+	err := stopTheNodeAndExportTheWriteaheadLog(ctx, "--to", strconv.FormatUint(blockBefore, 10))
+	if err != nil {
+		return err
+	}
+
+	last, _, err := wal.LastBlockNumber(w.ExportedWALDir)
+	if err != nil {
+		return err
+	}
+
+	if last != blockBefore {
+		return fmt.Errorf("expected the export to stop at block %d, but it stopped at block %d", blockBefore, last)
+	}
+
+	return nil
+}
+
+func iExportTheWriteaheadLogAgain(ctx context.Context) error {
+	w := testutil.GetWorld(ctx)
+	return w.GethInstance.ExportWAL(ctx, w.ExportedWALDir)
+}
+
+func theExportedWriteaheadLogShouldContainTheOperationsOfTheWriteaheadLogOfTheNode(ctx context.Context) error {
+	w := testutil.GetWorld(ctx)
+
+	first, err := wal.ReadBlockInfo(w.GethInstance.WALDir, 1)
+	if err != nil {
+		return fmt.Errorf("failed to read the first block of the write-ahead log: %w", err)
+	}
+
+	expected, err := testutil.ReadWALDir(ctx, w.GethInstance.WALDir, first.ParentHash)
+	if err != nil {
+		return err
+	}
+
+	exported, err := testutil.ReadWALDir(ctx, w.ExportedWALDir, first.ParentHash)
+	if err != nil {
+		return err
+	}
+
+	if len(expected) == 0 {
+		return fmt.Errorf("the write-ahead log of the node contains no operations")
+	}
+
+	if !reflect.DeepEqual(expected, exported) {
+		return fmt.Errorf("exported operations differ:\nexpected: %v\nexported: %v", expected, exported)
+	}
+
+	return nil
+}
+
+func stopTheNodeAndExportASnapshot(ctx context.Context, args ...string) error {
+	w := testutil.GetWorld(ctx)
+
+	err := w.GethInstance.Stop()
+	if err != nil {
+		return err
+	}
+
+	w.SnapshotFile = filepath.Join(filepath.Dir(w.GethInstance.WALDir), "snapshot.jsonl.gz")
+
+	return w.GethInstance.ExportSnapshot(ctx, w.SnapshotFile, args...)
+}
+
+func iStopTheNodeAndExportASnapshot(ctx context.Context) error {
+	return stopTheNodeAndExportASnapshot(ctx)
+}
+
+func iStopTheNodeAndExportASnapshotOfTheBlockBeforeTheEntity(ctx context.Context) error {
+	w := testutil.GetWorld(ctx)
+	blockBefore := w.LastReceipt.BlockNumber.Uint64() - 1
+
+	return stopTheNodeAndExportASnapshot(ctx, "--block", strconv.FormatUint(blockBefore, 10))
+}
+
+func readSnapshot(ctx context.Context) (snapshot.Header, map[common.Hash]wal.Create, error) {
+	w := testutil.GetWorld(ctx)
+
+	r, err := snapshot.Open(w.SnapshotFile)
+	if err != nil {
+		return snapshot.Header{}, nil, err
+	}
+	defer r.Close()
+
+	entities := map[common.Hash]wal.Create{}
+	for e, err := range r.Entities() {
+		if err != nil {
+			return snapshot.Header{}, nil, err
+		}
+		entities[e.EntityKey] = e
+	}
+
+	return r.Header, entities, nil
+}
+
+func snapshotShouldBeOfBlock(ctx context.Context, number uint64) error {
+	w := testutil.GetWorld(ctx)
+
+	header, _, err := readSnapshot(ctx)
+	if err != nil {
+		return err
+	}
+
+	bi, err := wal.ReadBlockInfo(w.GethInstance.WALDir, number)
+	if err != nil {
+		return fmt.Errorf("failed to read block %d of the write-ahead log: %w", number, err)
+	}
+
+	if header.BlockInfo() != bi {
+		return fmt.Errorf("expected a snapshot of block %v, but got %v", bi, header.BlockInfo())
+	}
+
+	return nil
+}
+
+func theSnapshotShouldBeOfTheLastBlockOfTheWriteaheadLog(ctx context.Context) error {
+	w := testutil.GetWorld(ctx)
+
+	last, _, err := wal.LastBlockNumber(w.GethInstance.WALDir)
+	if err != nil {
+		return err
+	}
+
+	return snapshotShouldBeOfBlock(ctx, last)
+}
+
+func theSnapshotShouldBeOfTheBlockBeforeTheEntity(ctx context.Context) error {
+	w := testutil.GetWorld(ctx)
+	return snapshotShouldBeOfBlock(ctx, w.LastReceipt.BlockNumber.Uint64()-1)
+}
+
+func theSnapshotShouldContainTheEntity(ctx context.Context) error {
+	w := testutil.GetWorld(ctx)
+
+	_, entities, err := readSnapshot(ctx)
+	if err != nil {
+		return err
+	}
+
+	e, ok := entities[w.CreatedEntityKey]
+	if !ok {
+		return fmt.Errorf("entity %s not found in the snapshot", w.CreatedEntityKey.Hex())
+	}
+
+	expected := wal.Create{
+		EntityKey:          w.CreatedEntityKey,
+		ExpiresAtBlock:     w.LastReceipt.BlockNumber.Uint64() + 100,
+		Payload:            []byte("test payload"),
+		StringAnnotations:  []entity.StringAnnotation{{Key: "test_key", Value: "test_value"}},
+		NumericAnnotations: []entity.NumericAnnotation{{Key: "test_number", Value: 42}},
+		Owner:              w.FundedAccount.Address,
+	}
+
+	if !reflect.DeepEqual(expected, e) {
+		return fmt.Errorf("unexpected entity in the snapshot:\nexpected: %v\nactual: %v", expected, e)
+	}
+
+	return nil
+}
+
+func theSnapshotShouldNotContainTheEntity(ctx context.Context) error {
+	w := testutil.GetWorld(ctx)
+
+	_, entities, err := readSnapshot(ctx)
+	if err != nil {
+		return err
+	}
+
+	_, ok := entities[w.CreatedEntityKey]
+	if ok {
+		return fmt.Errorf("entity %s found in the snapshot", w.CreatedEntityKey.Hex())
+	}
+
+	return nil
+}
+
+func iCreateEntitiesInABatchWithTheGoClient(ctx context.Context, n int) error {
+	w := testutil.GetWorld(ctx)
+
+	b := client.NewBatch()
+	for i := range n {
+		b.Create(client.NewCreate([]byte(fmt.Sprintf("payload %d", i)), 100).
+			StringAnnotation("client", "batch").
+			NumericAnnotation("index", uint64(i)))
+	}
+
+	r, err := w.Client().SubmitBatch(ctx, b)
+	if err != nil {
+		return err
+	}
+
+	w.ClientReceipt = r
+	w.ClientEntityKeys = r.CreatedKeys()
+
+	return nil
+}
+
+func theReceiptShouldContainTheKeysOfTheCreatedEntities(ctx context.Context, n int) error {
+	w := testutil.GetWorld(ctx)
+
+	if len(w.ClientEntityKeys) != n {
+		return fmt.Errorf("expected %d created entities, got %d", n, len(w.ClientEntityKeys))
+	}
+
+	expiresAt := w.ClientReceipt.BlockNumber.Uint64() + 100
+	for _, e := range w.ClientReceipt.Created {
+		if e.ExpiresAtBlock != expiresAt {
+			return fmt.Errorf("entity %s expires at block %d, expected %d", e.Key.Hex(), e.ExpiresAtBlock, expiresAt)
+		}
+	}
+
+	return nil
+}
+
+func theGoClientShouldReturnThePayloadsAndAnnotationsOfTheCreatedEntities(ctx context.Context) error {
+	w := testutil.GetWorld(ctx)
+	c := w.Client()
+
+	for i, key := range w.ClientEntityKeys {
+		payload, err := c.GetStorageValue(ctx, key)
+		if err != nil {
+			return err
+		}
+
+		if string(payload) != fmt.Sprintf("payload %d", i) {
+			return fmt.Errorf("unexpected payload of entity %d: %q", i, payload)
+		}
+
+		md, err := c.GetEntityMetaData(ctx, key)
+		if err != nil {
+			return err
+		}
+
+		expected := entity.EntityMetaData{
+			ExpiresAtBlock:     w.ClientReceipt.Created[i].ExpiresAtBlock,
+			StringAnnotations:  []entity.StringAnnotation{{Key: "client", Value: "batch"}},
+			NumericAnnotations: []entity.NumericAnnotation{{Key: "index", Value: uint64(i)}},
+			Owner:              w.FundedAccount.Address,
+		}
+
+		if !reflect.DeepEqual(expected, *md) {
+			return fmt.Errorf("unexpected meta data of entity %d:\nexpected: %v\nactual: %v", i, expected, *md)
+		}
+	}
+
+	return nil
+}
+
+func theGoClientShouldFindTheCreatedEntitiesWithTheQuery(ctx context.Context, query string) error {
+	w := testutil.GetWorld(ctx)
+
+	res, err := w.Client().QueryEntities(ctx, query, &golemtype.QueryOptions{OmitPayload: true})
+	if err != nil {
+		return err
+	}
+
+	found := []common.Hash{}
+	for _, r := range res.Results {
+		found = append(found, r.Key)
+	}
+
+	expected := slices.Clone(w.ClientEntityKeys)
+	slices.SortFunc(expected, common.Hash.Cmp)
+
+	if !slices.Equal(expected, found) {
+		return fmt.Errorf("expected entities %v, found %v", expected, found)
+	}
+
+	return nil
+}
+
+func iUpdateTheFirstEntityAndExtendTheSecondEntityByBlocksInABatchWithTheGoClient(ctx context.Context, blocks int) error {
+	w := testutil.GetWorld(ctx)
+
+	r, err := w.Client().SubmitBatch(ctx, client.NewBatch().
+		Update(client.NewUpdate(w.ClientEntityKeys[0], []byte("updated"), 50).StringAnnotation("client", "updated")).
+		Extend(w.ClientEntityKeys[1], uint64(blocks)),
+	)
+	if err != nil {
+		return err
+	}
+
+	w.ClientReceipt = r
+
+	return nil
+}
+
+func theReceiptShouldContainTheUpdateOfTheFirstEntityAndTheExtensionOfTheSecondEntity(ctx context.Context) error {
+	w := testutil.GetWorld(ctx)
+	r := w.ClientReceipt
+
+	expectedUpdates := []client.UpdatedEntity{{Key: w.ClientEntityKeys[0], ExpiresAtBlock: r.BlockNumber.Uint64() + 50}}
+	if !reflect.DeepEqual(expectedUpdates, r.Updated) {
+		return fmt.Errorf("unexpected updates: %v", r.Updated)
+	}
+
+	if len(r.Extended) != 1 || r.Extended[0].Key != w.ClientEntityKeys[1] || r.Extended[0].NewExpiresAtBlock != r.Extended[0].OldExpiresAtBlock+10 {
+		return fmt.Errorf("unexpected extensions: %v", r.Extended)
+	}
+
+	return nil
+}
+
+func iDeleteTheEntitiesWithTheGoClient(ctx context.Context) error {
+	w := testutil.GetWorld(ctx)
+
+	r, err := w.Client().Delete(ctx, w.ClientEntityKeys...)
+	if err != nil {
+		return err
+	}
+
+	w.ClientReceipt = r
+
+	return nil
+}
+
+func theReceiptShouldContainTheDeletionOfTheEntities(ctx context.Context) error {
+	w := testutil.GetWorld(ctx)
+
+	if !slices.Equal(w.ClientEntityKeys, w.ClientReceipt.Deleted) {
+		return fmt.Errorf("expected deleted entities %v, got %v", w.ClientEntityKeys, w.ClientReceipt.Deleted)
+	}
+
+	return nil
+}
+
+func theGoClientShouldNotFindAnyEntitiesOfTheAccount(ctx context.Context) error {
+	w := testutil.GetWorld(ctx)
+
+	keys, err := w.Client().GetEntitiesOfOwner(ctx, w.FundedAccount.Address)
+	if err != nil {
+		return err
+	}
+
+	if len(keys) != 0 {
+		return fmt.Errorf("expected no entities, found %v", keys)
+	}
+
+	return nil
+}
+
+func iDeleteAnEntityThatDoesNotExistWithTheGoClient(ctx context.Context) error {
+	w := testutil.GetWorld(ctx)
+
+	w.ClientReceipt, w.LastError = w.Client().Delete(ctx, common.HexToHash("0x1234"))
+
+	return nil
+}
+
+func theGoClientShouldReportAFailedTransaction(ctx context.Context) error {
+	w := testutil.GetWorld(ctx)
+
+	if !errors.Is(w.LastError, client.ErrTransactionFailed) {
+		return fmt.Errorf("expected a failed transaction, got %v", w.LastError)
+	}
+
+	if w.ClientReceipt == nil || w.ClientReceipt.Status != types.ReceiptStatusFailed {
+		return errors.New("expected the receipt of the failed transaction")
+	}
+
+	return nil
+}
+
+func iHaveSubscribedToTheEntityEventsWithTheQuery(ctx context.Context, q string) error {
+	w := testutil.GetWorld(ctx)
+	return w.SubscribeToEntities(ctx, &golemtype.EntityFilter{Query: q})
+}
+
+func iHaveSubscribedToTheEntityEventsOfMyAccount(ctx context.Context) error {
+	w := testutil.GetWorld(ctx)
+	return w.SubscribeToEntities(ctx, &golemtype.EntityFilter{Owner: &w.FundedAccount.Address})
+}
+
+func iShouldReceiveTheEventOfTheEntity(ctx context.Context, eventType string) error {
+	w := testutil.GetWorld(ctx)
+
+	for {
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("did not receive the %s event of entity %s", eventType, w.CreatedEntityKey.Hex())
+		case ev := <-w.ReceivedEntityEvents:
+			if ev.EntityKey != w.CreatedEntityKey {
+				continue
+			}
+
+			if ev.Type != golemtype.EntityEventType(eventType) {
+				return fmt.Errorf("expected the %s event of the entity, received the %s event", eventType, ev.Type)
+			}
+
+			if ev.MetaData.Owner != w.FundedAccount.Address {
+				return fmt.Errorf("unexpected owner %s", ev.MetaData.Owner.Hex())
+			}
+
+			return nil
+		}
+	}
+}
+
+func iShouldNotReceiveAnEventOfTheEntity(ctx context.Context) error {
+	w := testutil.GetWorld(ctx)
+
+	// the events of a block are sent right after it is added to the chain
+	timeout := time.After(2 * time.Second)
+
+	for {
+		select {
+		case <-timeout:
+			return nil
+		case ev := <-w.ReceivedEntityEvents:
+			if ev.EntityKey == w.CreatedEntityKey {
+				return fmt.Errorf("received the %s event of the entity", ev.Type)
+			}
+		}
+	}
+}
+
+func iCreateEntitiesInTransactionsOfAtMostGasWithTheGoClient(ctx context.Context, n, maxGas int) error {
+	w := testutil.GetWorld(ctx)
+
+	b := client.NewBatch()
+	for i := range n {
+		b.Create(client.NewCreate([]byte(fmt.Sprintf("payload %d", i)), 100).
+			StringAnnotation("client", "packed").
+			NumericAnnotation("index", uint64(i)))
+	}
+
+	batches, err := b.Pack(client.PackLimits{MaxGas: uint64(maxGas)})
+	if err != nil {
+		return err
+	}
+
+	c := w.Client()
+
+	w.ClientEntityKeys = nil
+	for _, batch := range batches {
+		r, err := c.SubmitBatch(ctx, batch)
+		if err != nil {
+			return err
+		}
+
+		w.ClientPackedReceipts = append(w.ClientPackedReceipts, r)
+		w.ClientEntityKeys = append(w.ClientEntityKeys, r.CreatedKeys()...)
+	}
+
+	if len(w.ClientEntityKeys) != n {
+		return fmt.Errorf("expected %d created entities, got %d", n, len(w.ClientEntityKeys))
+	}
+
+	return nil
+}
+
+func theEntitiesShouldHaveBeenCreatedInSeveralTransactionsOfAtMostGas(ctx context.Context, maxGas int) error {
+	w := testutil.GetWorld(ctx)
+
+	if len(w.ClientPackedReceipts) < 2 {
+		return fmt.Errorf("expected several transactions, got %d", len(w.ClientPackedReceipts))
+	}
+
+	for _, r := range w.ClientPackedReceipts {
+		if r.GasUsed > uint64(maxGas) {
+			return fmt.Errorf("transaction %s used %d gas", r.TxHash.Hex(), r.GasUsed)
+		}
+	}
+
+	return nil
+}
+
+// withoutMetaData converts the results of golembase_queryEntities to the results of a page.
+func withoutMetaData(results []golemtype.SearchResult) []golemtype.SearchResultWithMetaData {
+	res := make([]golemtype.SearchResultWithMetaData, 0, len(results))
+	for _, r := range results {
+		res = append(res, golemtype.SearchResultWithMetaData{SearchResult: r})
+	}
+	return res
+}
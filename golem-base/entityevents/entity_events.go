@@ -0,0 +1,180 @@
+// Package entityevents derives the events of the golembase_subscribe("entities") subscription
+// from the operations of a block and selects them with the filters of the subscribers.
+package entityevents
+
+import (
+	"fmt"
+	"slices"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/golem-base/golemtype"
+	"github.com/ethereum/go-ethereum/golem-base/storageutil"
+	"github.com/ethereum/go-ethereum/golem-base/storageutil/entity"
+	"github.com/ethereum/go-ethereum/golem-base/storageutil/entity/allentities"
+	"github.com/ethereum/go-ethereum/golem-base/wal"
+)
+
+// metaDataTracker follows the meta data of the entities through the operations of a block,
+// starting from the state of the parent block.
+type metaDataTracker struct {
+	parentState storageutil.StateAccess
+	current     map[common.Hash]*entity.EntityMetaData
+	deleted     map[common.Hash]bool
+}
+
+func (t *metaDataTracker) get(key common.Hash) (entity.EntityMetaData, error) {
+	if md, ok := t.current[key]; ok {
+		return cloneMetaData(*md), nil
+	}
+
+	if t.deleted[key] || !allentities.Contains(t.parentState, key) {
+		return entity.EntityMetaData{}, fmt.Errorf("entity %s does not exist", key.Hex())
+	}
+
+	md, err := entity.GetEntityMetaData(t.parentState, key)
+	if err != nil {
+		return entity.EntityMetaData{}, fmt.Errorf("failed to get meta data of entity %s: %w", key.Hex(), err)
+	}
+
+	return *md, nil
+}
+
+func (t *metaDataTracker) set(key common.Hash, md entity.EntityMetaData) {
+	t.current[key] = &md
+	delete(t.deleted, key)
+}
+
+func (t *metaDataTracker) delete(key common.Hash) {
+	delete(t.current, key)
+	t.deleted[key] = true
+}
+
+func cloneMetaData(md entity.EntityMetaData) entity.EntityMetaData {
+	md.StringAnnotations = slices.Clone(md.StringAnnotations)
+	md.NumericAnnotations = slices.Clone(md.NumericAnnotations)
+	return md
+}
+
+// ForBlock returns the events of the operations of a block, in the order of the operations.
+// parentState is the state the operations were applied to, it provides the meta data of the
+// entities that existed before the block.
+func ForBlock(blockNumber uint64, blockHash common.Hash, operations []wal.Operation, parentState storageutil.StateAccess) ([]golemtype.EntityEvent, error) {
+	t := &metaDataTracker{
+		parentState: parentState,
+		current:     map[common.Hash]*entity.EntityMetaData{},
+		deleted:     map[common.Hash]bool{},
+	}
+
+	events := []golemtype.EntityEvent{}
+
+	event := func(eventType golemtype.EntityEventType, key common.Hash, md entity.EntityMetaData, previous *entity.EntityMetaData) {
+		events = append(events, golemtype.EntityEvent{
+			Type:             eventType,
+			EntityKey:        key,
+			BlockNumber:      blockNumber,
+			BlockHash:        blockHash,
+			MetaData:         md,
+			PreviousMetaData: previous,
+		})
+	}
+
+	// change applies a change to an existing entity and records it as an update
+	change := func(key common.Hash, apply func(md *entity.EntityMetaData)) error {
+		previous, err := t.get(key)
+		if err != nil {
+			return err
+		}
+
+		md := cloneMetaData(previous)
+		apply(&md)
+		t.set(key, md)
+
+		event(golemtype.EntityUpdated, key, md, &previous)
+		return nil
+	}
+
+	// remove removes an entity and records its last meta data
+	remove := func(eventType golemtype.EntityEventType, key common.Hash) error {
+		md, err := t.get(key)
+		if err != nil {
+			return err
+		}
+		t.delete(key)
+
+		event(eventType, key, md, nil)
+		return nil
+	}
+
+	for _, op := range operations {
+		var err error
+
+		switch {
+		case op.Create != nil:
+			md := entity.EntityMetaData{
+				ExpiresAtBlock:     op.Create.ExpiresAtBlock,
+				StringAnnotations:  op.Create.StringAnnotations,
+				NumericAnnotations: op.Create.NumericAnnotations,
+				Owner:              op.Create.Owner,
+			}
+			t.set(op.Create.EntityKey, md)
+			event(golemtype.EntityCreated, op.Create.EntityKey, md, nil)
+
+		case op.Update != nil:
+			err = change(op.Update.EntityKey, func(md *entity.EntityMetaData) {
+				md.ExpiresAtBlock = op.Update.ExpiresAtBlock
+				md.StringAnnotations = op.Update.StringAnnotations
+				md.NumericAnnotations = op.Update.NumericAnnotations
+			})
+
+		case op.Delete != nil:
+			err = remove(golemtype.EntityDeleted, *op.Delete)
+
+		case op.Expire != nil:
+			err = remove(golemtype.EntityExpired, *op.Expire)
+
+		case op.TransferOwnership != nil:
+			err = change(op.TransferOwnership.EntityKey, func(md *entity.EntityMetaData) {
+				md.Owner = op.TransferOwnership.NewOwner
+			})
+
+		case op.Extend != nil:
+			err = change(op.Extend.EntityKey, func(md *entity.EntityMetaData) {
+				md.ExpiresAtBlock = op.Extend.ExpiresAtBlock
+			})
+
+		case op.Patch != nil:
+			err = change(op.Patch.EntityKey, func(md *entity.EntityMetaData) {
+				applyPatch(md, op.Patch)
+			})
+		}
+
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return events, nil
+}
+
+// applyPatch applies the annotations and the expiration of a patch to the meta data,
+// the annotations are removed before they are set.
+func applyPatch(md *entity.EntityMetaData, p *wal.Patch) {
+	md.StringAnnotations = slices.DeleteFunc(md.StringAnnotations, func(a entity.StringAnnotation) bool {
+		return slices.Contains(p.RemoveStringAnnotations, a.Key)
+	})
+	md.NumericAnnotations = slices.DeleteFunc(md.NumericAnnotations, func(a entity.NumericAnnotation) bool {
+		return slices.Contains(p.RemoveNumericAnnotations, a.Key)
+	})
+
+	for _, set := range p.SetStringAnnotations {
+		md.StringAnnotations = slices.DeleteFunc(md.StringAnnotations, func(a entity.StringAnnotation) bool { return a.Key == set.Key })
+		md.StringAnnotations = append(md.StringAnnotations, set)
+	}
+
+	for _, set := range p.SetNumericAnnotations {
+		md.NumericAnnotations = slices.DeleteFunc(md.NumericAnnotations, func(a entity.NumericAnnotation) bool { return a.Key == set.Key })
+		md.NumericAnnotations = append(md.NumericAnnotations, set)
+	}
+
+	md.ExpiresAtBlock = p.ExpiresAtBlock
+}
@@ -0,0 +1,161 @@
+package entityevents_test
+
+import (
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/golem-base/entityevents"
+	"github.com/ethereum/go-ethereum/golem-base/golemtype"
+	"github.com/ethereum/go-ethereum/golem-base/storageutil/entity"
+	"github.com/ethereum/go-ethereum/golem-base/wal"
+	"github.com/stretchr/testify/require"
+)
+
+type mockStateAccess map[common.Address]map[common.Hash]common.Hash
+
+func (m mockStateAccess) GetState(addr common.Address, key common.Hash) common.Hash {
+	return m[addr][key]
+}
+
+func (m mockStateAccess) SetState(addr common.Address, key common.Hash, value common.Hash) common.Hash {
+	if _, ok := m[addr]; !ok {
+		m[addr] = map[common.Hash]common.Hash{}
+	}
+	m[addr][key] = value
+	return value
+}
+
+func TestForBlock(t *testing.T) {
+	owner := common.HexToAddress("0x1")
+	newOwner := common.HexToAddress("0x2")
+
+	existingKey := common.HexToHash("0x10")
+	expiringKey := common.HexToHash("0x11")
+	createdKey := common.HexToHash("0x20")
+
+	parentState := mockStateAccess{}
+
+	existing := entity.EntityMetaData{
+		ExpiresAtBlock:     100,
+		StringAnnotations:  []entity.StringAnnotation{{Key: "type", Value: "test"}},
+		NumericAnnotations: []entity.NumericAnnotation{{Key: "size", Value: 3}},
+		Owner:              owner,
+	}
+	expiring := entity.EntityMetaData{
+		ExpiresAtBlock:     50,
+		StringAnnotations:  []entity.StringAnnotation{},
+		NumericAnnotations: []entity.NumericAnnotation{},
+		Owner:              owner,
+	}
+
+	require.NoError(t, entity.Store(parentState, existingKey, owner, existing, []byte("existing")))
+	require.NoError(t, entity.Store(parentState, expiringKey, owner, expiring, []byte("expiring")))
+
+	operations := []wal.Operation{
+		{Expire: &expiringKey},
+		{Create: &wal.Create{EntityKey: createdKey, ExpiresAtBlock: 200, Owner: owner}},
+		{Patch: &wal.Patch{
+			EntityKey:                existingKey,
+			ExpiresAtBlock:           150,
+			SetStringAnnotations:     []entity.StringAnnotation{{Key: "type", Value: "patched"}},
+			RemoveNumericAnnotations: []string{"size"},
+		}},
+		{TransferOwnership: &wal.TransferOwnership{EntityKey: existingKey, NewOwner: newOwner}},
+		{Delete: &createdKey},
+	}
+
+	blockHash := common.HexToHash("0xb")
+
+	events, err := entityevents.ForBlock(50, blockHash, operations, parentState)
+	require.NoError(t, err)
+	require.Len(t, events, 5)
+
+	require.Equal(t, golemtype.EntityEvent{
+		Type:        golemtype.EntityExpired,
+		EntityKey:   expiringKey,
+		BlockNumber: 50,
+		BlockHash:   blockHash,
+		MetaData:    expiring,
+	}, events[0])
+
+	require.Equal(t, golemtype.EntityCreated, events[1].Type)
+	require.Equal(t, createdKey, events[1].EntityKey)
+
+	patched := entity.EntityMetaData{
+		ExpiresAtBlock:     150,
+		StringAnnotations:  []entity.StringAnnotation{{Key: "type", Value: "patched"}},
+		NumericAnnotations: []entity.NumericAnnotation{},
+		Owner:              owner,
+	}
+	require.Equal(t, golemtype.EntityUpdated, events[2].Type)
+	require.Equal(t, patched, events[2].MetaData)
+	require.Equal(t, &existing, events[2].PreviousMetaData)
+
+	transferred := patched
+	transferred.Owner = newOwner
+	require.Equal(t, golemtype.EntityUpdated, events[3].Type)
+	require.Equal(t, transferred, events[3].MetaData)
+	require.Equal(t, &patched, events[3].PreviousMetaData)
+
+	require.Equal(t, golemtype.EntityDeleted, events[4].Type)
+	require.Equal(t, createdKey, events[4].EntityKey)
+}
+
+func TestForBlockUnknownEntity(t *testing.T) {
+	key := common.HexToHash("0x30")
+
+	_, err := entityevents.ForBlock(1, common.Hash{}, []wal.Operation{{Delete: &key}}, mockStateAccess{})
+	require.Error(t, err)
+}
+
+func TestFilter(t *testing.T) {
+	owner := common.HexToAddress("0x1")
+	other := common.HexToAddress("0x2")
+
+	md := entity.EntityMetaData{
+		StringAnnotations:  []entity.StringAnnotation{{Key: "type", Value: "invoice-2"}},
+		NumericAnnotations: []entity.NumericAnnotation{{Key: "amount", Value: 30}},
+		Owner:              owner,
+	}
+	ev := golemtype.EntityEvent{Type: golemtype.EntityCreated, EntityKey: common.HexToHash("0x10"), MetaData: md}
+
+	for _, tc := range []struct {
+		filter *golemtype.EntityFilter
+		match  bool
+	}{
+		{nil, true},
+		{&golemtype.EntityFilter{}, true},
+		{&golemtype.EntityFilter{Query: `type = "invoice-2"`}, true},
+		{&golemtype.EntityFilter{Query: `type ~ "invoice-*" && amount >= 20`}, true},
+		{&golemtype.EntityFilter{Query: `type != "invoice-2" || amount < 10`}, false},
+		{&golemtype.EntityFilter{Query: `!(amount = 30)`}, false},
+		{&golemtype.EntityFilter{Owner: &owner}, true},
+		{&golemtype.EntityFilter{Query: `amount = 30`, Owner: &other}, false},
+	} {
+		f, err := entityevents.NewFilter(tc.filter)
+		require.NoError(t, err)
+
+		match, err := f.Match(ev)
+		require.NoError(t, err)
+		require.Equal(t, tc.match, match, "%+v", tc.filter)
+	}
+
+	_, err := entityevents.NewFilter(&golemtype.EntityFilter{Query: `type =`})
+	require.Error(t, err)
+}
+
+func TestFilterMatchesPreviousMetaData(t *testing.T) {
+	f, err := entityevents.NewFilter(&golemtype.EntityFilter{Query: `status = "open"`})
+	require.NoError(t, err)
+
+	open := entity.EntityMetaData{StringAnnotations: []entity.StringAnnotation{{Key: "status", Value: "open"}}}
+	closed := entity.EntityMetaData{StringAnnotations: []entity.StringAnnotation{{Key: "status", Value: "closed"}}}
+
+	match, err := f.Match(golemtype.EntityEvent{Type: golemtype.EntityUpdated, MetaData: closed, PreviousMetaData: &open})
+	require.NoError(t, err)
+	require.True(t, match)
+
+	match, err = f.Match(golemtype.EntityEvent{Type: golemtype.EntityUpdated, MetaData: closed, PreviousMetaData: &closed})
+	require.NoError(t, err)
+	require.False(t, match)
+}
@@ -0,0 +1,60 @@
+package entityevents
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/golem-base/golemtype"
+	"github.com/ethereum/go-ethereum/golem-base/query"
+	"github.com/ethereum/go-ethereum/golem-base/storageutil/entity"
+)
+
+// Filter selects entity events by the annotations and the owner of the entity.
+type Filter struct {
+	expr  *query.Expression
+	owner *common.Address
+}
+
+// NewFilter parses the query of the filter. A nil filter selects all events.
+func NewFilter(f *golemtype.EntityFilter) (*Filter, error) {
+	if f == nil {
+		return &Filter{}, nil
+	}
+
+	res := &Filter{owner: f.Owner}
+
+	if strings.TrimSpace(f.Query) != "" {
+		expr, err := query.Parse(f.Query)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse query: %w", err)
+		}
+		res.expr = expr
+	}
+
+	return res, nil
+}
+
+// Match reports whether the filter selects the event. An update is selected if the entity
+// matches the filter before or after the update, so that subscribers also see entities
+// leaving the selection.
+func (f *Filter) Match(ev golemtype.EntityEvent) (bool, error) {
+	ok, err := f.matchMetaData(ev.EntityKey, ev.MetaData)
+	if err != nil || ok || ev.PreviousMetaData == nil {
+		return ok, err
+	}
+
+	return f.matchMetaData(ev.EntityKey, *ev.PreviousMetaData)
+}
+
+func (f *Filter) matchMetaData(key common.Hash, md entity.EntityMetaData) (bool, error) {
+	if f.owner != nil && *f.owner != md.Owner {
+		return false, nil
+	}
+
+	if f.expr == nil {
+		return true, nil
+	}
+
+	return query.MatchEntity(f.expr, key, md)
+}
@@ -0,0 +1,41 @@
+// Package etltest holds the code the cucumber tests of the ETLs share: compiling the binaries,
+// the snapshot the ETLs start from and the steps changing the entity on the node.
+package etltest
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+)
+
+// CompileGeth compiles geth. The tests of the ETLs run in their directories in golem-base/etl.
+func CompileGeth() (string, func(), error) {
+	return Compile("geth", "../../../cmd/geth")
+}
+
+// Compile builds the binary name from the package pkg in a temporary directory and returns its path
+// together with a function removing it.
+func Compile(name string, pkg string) (string, func(), error) {
+	td, err := os.MkdirTemp("", name)
+	if err != nil {
+		return "", func() {}, fmt.Errorf("failed to create temp dir: %w", err)
+	}
+
+	binaryPath := filepath.Join(td, name)
+
+	cmd := exec.Command("go", "build", "-o", binaryPath, pkg)
+	out := &bytes.Buffer{}
+	cmd.Stdout = out
+	cmd.Stderr = out
+	err = cmd.Run()
+	if err != nil {
+		os.RemoveAll(td)
+		return "", func() {}, fmt.Errorf("failed to compile %s: %w\n%s", name, err, out.String())
+	}
+
+	return binaryPath, func() {
+		os.RemoveAll(td)
+	}, nil
+}
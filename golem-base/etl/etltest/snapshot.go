@@ -0,0 +1,60 @@
+package etltest
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/golem-base/snapshot"
+	"github.com/ethereum/go-ethereum/golem-base/storageutil/entity"
+	"github.com/ethereum/go-ethereum/golem-base/testutil"
+	"github.com/ethereum/go-ethereum/golem-base/wal"
+)
+
+// SnapshotEntity is the entity of the snapshot the ETL loads. It only exists in the snapshot,
+// so finding it in the database shows that the snapshot was loaded.
+var SnapshotEntity = wal.Create{
+	EntityKey:          common.HexToHash("0x5a"),
+	ExpiresAtBlock:     1000,
+	Payload:            []byte("snapshot payload"),
+	StringAnnotations:  []entity.StringAnnotation{{Key: "source", Value: "snapshot"}},
+	NumericAnnotations: []entity.NumericAnnotation{{Key: "version", Value: 1}},
+	Owner:              common.HexToAddress("0x5a5a"),
+}
+
+// WriteSnapshot writes a snapshot of the head block of geth that holds SnapshotEntity.
+func WriteSnapshot(ctx context.Context, world *testutil.World) (string, error) {
+	ec := world.GethInstance.ETHClient
+
+	chainID, err := ec.ChainID(ctx)
+	if err != nil {
+		return "", fmt.Errorf("failed to get chain id: %w", err)
+	}
+
+	head, err := ec.HeaderByNumber(ctx, nil)
+	if err != nil {
+		return "", fmt.Errorf("failed to get head header: %w", err)
+	}
+
+	path := filepath.Join(filepath.Dir(world.GethInstance.WALDir), "snapshot.jsonl.gz")
+
+	err = snapshot.WriteFile(
+		path,
+		snapshot.Header{
+			ChainID:     chainID,
+			BlockNumber: head.Number.Uint64(),
+			BlockHash:   head.Hash(),
+			ParentHash:  head.ParentHash,
+			Entities:    1,
+		},
+		func(yield func(e wal.Create, err error) bool) {
+			yield(SnapshotEntity, nil)
+		},
+	)
+	if err != nil {
+		return "", fmt.Errorf("failed to write snapshot: %w", err)
+	}
+
+	return path, nil
+}
@@ -0,0 +1,141 @@
+package etltest
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/cenkalti/backoff/v4"
+	"github.com/cucumber/godog"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/golem-base/storagetx"
+	"github.com/ethereum/go-ethereum/golem-base/storageutil/entity"
+	"github.com/ethereum/go-ethereum/golem-base/testutil"
+	"github.com/ethereum/go-ethereum/golem-base/wal"
+)
+
+// The entity is created with CreatedPayload and the Created annotations, updated to UpdatedPayload
+// and the Updated annotations, and patched to PatchedPayload, setting the Patched annotations and
+// removing numericTest.
+var (
+	CreatedPayload            = []byte("test")
+	CreatedStringAnnotations  = []entity.StringAnnotation{{Key: "stringTest", Value: "stringTest"}}
+	CreatedNumericAnnotations = []entity.NumericAnnotation{{Key: "numericTest", Value: 1234567890}}
+
+	UpdatedPayload            = []byte("test2")
+	UpdatedStringAnnotations  = []entity.StringAnnotation{{Key: "stringTest2", Value: "stringTest2"}}
+	UpdatedNumericAnnotations = []entity.NumericAnnotation{{Key: "numericTest2", Value: 12345678901}}
+
+	PatchedPayload            = []byte(`{"patched": true}`)
+	PatchedStringAnnotations  = []entity.StringAnnotation{{Key: "stringTest", Value: "patched"}}
+	PatchedNumericAnnotations = []entity.NumericAnnotation{{Key: "numericTest2", Value: 42}}
+)
+
+// NewOwnerAddress is the owner the ownership of the entity is transferred to.
+var NewOwnerAddress = common.HexToAddress("0x00000000000000000000000000000000000b0b00")
+
+// CreateEntity creates the entity with CreatedPayload and the Created annotations.
+func CreateEntity(ctx context.Context, w *testutil.World) (*types.Receipt, error) {
+	receipt, err := w.CreateEntity(ctx, 1000, CreatedPayload, CreatedStringAnnotations, CreatedNumericAnnotations)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create entity: %w", err)
+	}
+
+	return receipt, nil
+}
+
+// Steps are the steps that change the created entity on the node and check the acknowledgements
+// of the ETL, registered by the cucumber tests of every ETL.
+type Steps struct {
+	// World returns the world of the scenario.
+	World func(ctx context.Context) *testutil.World
+	// ConsumerName is the name the ETL acknowledges the processed blocks with.
+	ConsumerName string
+}
+
+// Register adds the steps to the scenario.
+func (s Steps) Register(sc *godog.ScenarioContext) {
+	sc.Step(`^A running Golembase node with WAL enabled$`, aRunningGolembaseNodeWithWALEnabled)
+	sc.Step(`^update the entity in Golembase$`, s.updateTheEntityInGolembase)
+	sc.Step(`^delete the entity in Golembase$`, s.deleteTheEntityInGolembase)
+	sc.Step(`^the ownership of the entity is transferred in Golembase$`, s.theOwnershipOfTheEntityIsTransferredInGolembase)
+	sc.Step(`^the entity is patched in Golembase$`, s.theEntityIsPatchedInGolembase)
+	sc.Step(`^the ETL should acknowledge the block of the entity$`, s.theETLShouldAcknowledgeTheBlockOfTheEntity)
+}
+
+func aRunningGolembaseNodeWithWALEnabled() error {
+	// this is a default when starting testutil.World, so we don't need to do anything here
+	return nil
+}
+
+func (s Steps) updateTheEntityInGolembase(ctx context.Context) error {
+	w := s.World(ctx)
+	_, err := w.UpdateEntity(ctx, w.CreatedEntityKey, 999, UpdatedPayload, UpdatedStringAnnotations, UpdatedNumericAnnotations)
+	if err != nil {
+		return fmt.Errorf("failed to update entity: %w", err)
+	}
+	return nil
+}
+
+func (s Steps) deleteTheEntityInGolembase(ctx context.Context) error {
+	w := s.World(ctx)
+	_, err := w.DeleteEntity(ctx, w.CreatedEntityKey)
+	if err != nil {
+		return fmt.Errorf("failed to delete entity: %w", err)
+	}
+	return nil
+}
+
+func (s Steps) theOwnershipOfTheEntityIsTransferredInGolembase(ctx context.Context) error {
+	w := s.World(ctx)
+	_, err := w.TransferEntityOwnership(ctx, w.CreatedEntityKey, NewOwnerAddress)
+	if err != nil {
+		return fmt.Errorf("failed to transfer ownership of entity: %w", err)
+	}
+	return nil
+}
+
+func (s Steps) theEntityIsPatchedInGolembase(ctx context.Context) error {
+	w := s.World(ctx)
+	_, err := w.PatchEntity(ctx, storagetx.Patch{
+		EntityKey:                w.CreatedEntityKey,
+		ReplacePayload:           true,
+		Payload:                  PatchedPayload,
+		SetStringAnnotations:     PatchedStringAnnotations,
+		SetNumericAnnotations:    PatchedNumericAnnotations,
+		RemoveNumericAnnotations: []string{"numericTest"},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to patch entity: %w", err)
+	}
+	return nil
+}
+
+func (s Steps) theETLShouldAcknowledgeTheBlockOfTheEntity(ctx context.Context) error {
+	w := s.World(ctx)
+	blockNumber := w.LastReceipt.BlockNumber.Uint64()
+
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	bo := backoff.WithContext(backoff.NewConstantBackOff(100*time.Millisecond), ctx)
+
+	return backoff.Retry(func() error {
+		checkpoints, err := wal.ReadCheckpoints(w.GethInstance.WALDir)
+		if err != nil {
+			return backoff.Permanent(fmt.Errorf("failed to read checkpoints: %w", err))
+		}
+
+		cp, ok := checkpoints[s.ConsumerName]
+		if !ok {
+			return fmt.Errorf("no checkpoint of the ETL")
+		}
+
+		if cp.BlockNumber < blockNumber {
+			return fmt.Errorf("ETL acknowledged block %d, expected at least %d", cp.BlockNumber, blockNumber)
+		}
+
+		return nil
+	}, bo)
+}
@@ -12,6 +12,7 @@ import (
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/ethclient"
 	"github.com/ethereum/go-ethereum/golem-base/etl/mongodb/mongogolem"
+	"github.com/ethereum/go-ethereum/golem-base/snapshot"
 	"github.com/ethereum/go-ethereum/golem-base/wal"
 	"github.com/urfave/cli/v2"
 	"go.mongodb.org/mongo-driver/mongo"
@@ -21,10 +22,14 @@ import (
 func main() {
 	log := slog.New(slog.NewTextHandler(os.Stdout, nil))
 	cfg := struct {
-		mongoURI    string
-		dbName      string
-		walDir      string
-		rpcEndpoint string
+		mongoURI       string
+		dbName         string
+		walDir         string
+		segmentsDir    string
+		rpcEndpoint    string
+		consumer       string
+		snapshot       string
+		payloadSchemas string
 	}{}
 
 	app := &cli.App{
@@ -46,11 +51,16 @@ func main() {
 			},
 			&cli.PathFlag{
 				Name:        "wal",
-				Usage:       "wal dir",
+				Usage:       "wal dir, if not set the operations are streamed from the RPC endpoint, which must support subscriptions",
 				EnvVars:     []string{"WAL_DIR"},
-				Required:    true,
 				Destination: &cfg.walDir,
 			},
+			&cli.PathFlag{
+				Name:        "wal-segments",
+				Usage:       "dir of the wal in the binary segment format, written by op-geth with --golembase.writeaheadlog.segments, read instead of the wal dir",
+				EnvVars:     []string{"WAL_SEGMENTS_DIR"},
+				Destination: &cfg.segmentsDir,
+			},
 			&cli.StringFlag{
 				Name:        "rpc-endpoint",
 				Usage:       "RPC Endpoint for op-geth",
@@ -58,6 +68,24 @@ func main() {
 				Required:    true,
 				Destination: &cfg.rpcEndpoint,
 			},
+			&cli.StringFlag{
+				Name:        "consumer",
+				Usage:       "name to acknowledge the processed blocks with, so that the node only prunes them from the wal once they were processed",
+				EnvVars:     []string{"CONSUMER_NAME"},
+				Destination: &cfg.consumer,
+			},
+			&cli.PathFlag{
+				Name:        "snapshot",
+				Usage:       "snapshot file to load when the database is empty, the processing continues with the block after the snapshot",
+				EnvVars:     []string{"SNAPSHOT_FILE"},
+				Destination: &cfg.snapshot,
+			},
+			&cli.PathFlag{
+				Name:        "payload-schemas",
+				Usage:       "YAML or JSON file declaring the JSON payloads of entities with a given string annotation, to validate the payloads and index their fields",
+				EnvVars:     []string{"PAYLOAD_SCHEMAS_FILE"},
+				Destination: &cfg.payloadSchemas,
+			},
 		},
 		Action: func(c *cli.Context) error {
 			ctx, cancel := signal.NotifyContext(c.Context, os.Interrupt)
@@ -91,6 +119,22 @@ func main() {
 				return fmt.Errorf("failed to ensure indexes: %w", err)
 			}
 
+			if cfg.payloadSchemas != "" {
+				schemas, err := mongogolem.LoadPayloadSchemas(cfg.payloadSchemas)
+				if err != nil {
+					return err
+				}
+				mongoDriver.SetPayloadSchemas(schemas)
+
+				log.Info("loaded payload schemas", "schemas", len(schemas))
+			}
+
+			// Indexes of fields that are no longer declared are dropped as well
+			err = mongoDriver.EnsurePayloadIndexes(ctx)
+			if err != nil {
+				return fmt.Errorf("failed to ensure payload indexes: %w", err)
+			}
+
 			log.Info("Ensured indexes")
 
 			ec, err := ethclient.Dial(cfg.rpcEndpoint)
@@ -110,7 +154,23 @@ func main() {
 
 			log.Info("has processing status", "hasProcessingStatus", hasProcessingStatus)
 
-			if !hasProcessingStatus {
+			switch {
+			case hasProcessingStatus && cfg.snapshot != "":
+				log.Info("processing status found, ignoring the snapshot", "snapshot", cfg.snapshot)
+			case cfg.snapshot != "":
+				log.Info("no processing status found, loading snapshot", "snapshot", cfg.snapshot)
+
+				// the snapshot records the chain id, which can differ from the network id
+				chainID, err := ec.ChainID(ctx)
+				if err != nil {
+					return fmt.Errorf("failed to get chain id: %w", err)
+				}
+
+				err = loadSnapshot(ctx, mongoDriver, cfg.snapshot, chainID, networkID)
+				if err != nil {
+					return fmt.Errorf("failed to load snapshot: %w", err)
+				}
+			case !hasProcessingStatus:
 				log.Info("no processing status found, inserting genesis block")
 
 				genesisHeader, err := ec.HeaderByNumber(ctx, big.NewInt(0))
@@ -133,16 +193,32 @@ func main() {
 				return fmt.Errorf("failed to get processing status: %w", err)
 			}
 
+			// The schemas may have changed since the entities were stored
+			err = mongoDriver.ValidateAllPayloads(ctx)
+			if err != nil {
+				return fmt.Errorf("failed to validate the payloads of the stored entities: %w", err)
+			}
+
 			blockNumber := processingStatus.LastProcessedBlockNumber
 			blockHash := processingStatus.LastProcessedBlockHash
 
-			for blockWal, err := range wal.NewIterator(ctx, cfg.walDir, uint64(blockNumber)+1, common.HexToHash(blockHash), true) {
+			blocks := wal.NewIterator(ctx, cfg.walDir, uint64(blockNumber)+1, common.HexToHash(blockHash), true)
+			switch {
+			case cfg.segmentsDir != "":
+				log.Info("reading the wal segments", "dir", cfg.segmentsDir)
+				blocks = wal.NewSegmentIterator(ctx, cfg.segmentsDir, uint64(blockNumber)+1, common.HexToHash(blockHash), true)
+			case cfg.walDir == "":
+				log.Info("no wal dir, streaming operations from the rpc endpoint")
+				blocks = wal.NewRPCIterator(ctx, ec.Client(), uint64(blockNumber)+1, common.HexToHash(blockHash))
+			}
+
+			for blockWal, err := range blocks {
 				if err != nil {
 					return fmt.Errorf("failed to iterate over wal: %w", err)
 				}
 
 				err = func() (err error) {
-					log.Info("processing block", "block", blockWal.BlockInfo.Number)
+					log.Info("processing block", "block", blockWal.BlockInfo.Number, "revert", blockWal.Revert)
 
 					// Create a session with a timeout for each block processing
 					sessCtx, cancel := context.WithTimeout(ctx, 30*time.Second)
@@ -156,6 +232,56 @@ func main() {
 
 					// Use WithTransaction to handle transactions
 					_, err = session.WithTransaction(sessCtx, func(txCtx mongo.SessionContext) (interface{}, error) {
+						blockNumber := int64(blockWal.BlockInfo.Number)
+
+						// the removals of a reverted block are dropped, its operations restore the state of its parent
+						if blockWal.Revert {
+							err := mongoDriver.DeleteEntityRemovalsOfBlock(txCtx, blockNumber)
+							if err != nil {
+								return nil, err
+							}
+						}
+
+						// removeEntity deletes the entity and records why it was removed
+						removeEntity := func(ctx context.Context, key common.Hash, reason string) error {
+							if blockWal.Revert {
+								err := mongoDriver.DeleteEntity(ctx, key.Hex())
+								if err != nil {
+									return fmt.Errorf("failed to delete entity: %w", err)
+								}
+								return nil
+							}
+
+							existingEntity, err := mongoDriver.GetEntity(ctx, key.Hex())
+							if err != nil {
+								return fmt.Errorf("failed to get existing entity: %w", err)
+							}
+
+							// write-ahead logs written before the expire operation have deletes for the
+							// entities the housekeeping removed because they expired at the block
+							if existingEntity.ExpiresAt <= blockNumber {
+								reason = mongogolem.RemovalExpired
+							}
+
+							err = mongoDriver.RecordEntityRemoval(ctx, mongogolem.EntityRemoval{
+								Key:          existingEntity.Key,
+								Reason:       reason,
+								BlockNumber:  blockNumber,
+								ExpiresAt:    existingEntity.ExpiresAt,
+								OwnerAddress: existingEntity.OwnerAddress,
+							})
+							if err != nil {
+								return err
+							}
+
+							err = mongoDriver.DeleteEntity(ctx, key.Hex())
+							if err != nil {
+								return fmt.Errorf("failed to delete entity: %w", err)
+							}
+
+							return nil
+						}
+
 						for op, err := range blockWal.OperationsIterator {
 							if err != nil {
 								return nil, fmt.Errorf("failed to iterate over operations: %w", err)
@@ -165,25 +291,7 @@ func main() {
 							case op.Create != nil:
 								log.Info("create", "entity", op.Create.EntityKey.Hex())
 
-								// Convert string and numeric annotations to maps
-								stringAnnotations := make(map[string]string)
-								for _, annotation := range op.Create.StringAnnotations {
-									stringAnnotations[annotation.Key] = annotation.Value
-								}
-
-								numericAnnotations := make(map[string]int64)
-								for _, annotation := range op.Create.NumericAnnotations {
-									numericAnnotations[annotation.Key] = int64(annotation.Value)
-								}
-
-								err = mongoDriver.InsertEntity(txCtx, mongogolem.Entity{
-									Key:                op.Create.EntityKey.Hex(),
-									ExpiresAt:          int64(op.Create.ExpiresAtBlock),
-									Payload:            op.Create.Payload,
-									StringAnnotations:  stringAnnotations,
-									NumericAnnotations: numericAnnotations,
-									OwnerAddress:       op.Create.Owner.Hex(),
-								})
+								err = mongoDriver.InsertEntity(txCtx, entityFromCreate(*op.Create))
 								if err != nil {
 									return nil, fmt.Errorf("failed to insert entity: %w", err)
 								}
@@ -230,20 +338,102 @@ func main() {
 							case op.Delete != nil:
 								log.Info("delete", "entity", op.Delete.Hex())
 
-								err = mongoDriver.DeleteEntity(txCtx, op.Delete.Hex())
+								err = removeEntity(txCtx, *op.Delete, mongogolem.RemovalDeleted)
+								if err != nil {
+									return nil, err
+								}
+
+							case op.Expire != nil:
+								log.Info("expire", "entity", op.Expire.Hex())
+
+								err = removeEntity(txCtx, *op.Expire, mongogolem.RemovalExpired)
 								if err != nil {
-									return nil, fmt.Errorf("failed to delete entity: %w", err)
+									return nil, err
+								}
+
+							case op.TransferOwnership != nil:
+								log.Info("transfer ownership", "entity", op.TransferOwnership.EntityKey.Hex(), "newOwner", op.TransferOwnership.NewOwner.Hex())
+
+								err = mongoDriver.UpdateEntityOwner(txCtx, op.TransferOwnership.EntityKey.Hex(), op.TransferOwnership.NewOwner.Hex())
+								if err != nil {
+									return nil, fmt.Errorf("failed to transfer entity ownership: %w", err)
+								}
+
+							case op.Extend != nil:
+								log.Info("extend", "entity", op.Extend.EntityKey.Hex(), "expiresAt", op.Extend.ExpiresAtBlock)
+
+								err = mongoDriver.UpdateEntityExpiresAt(txCtx, op.Extend.EntityKey.Hex(), int64(op.Extend.ExpiresAtBlock))
+								if err != nil {
+									return nil, fmt.Errorf("failed to extend entity: %w", err)
+								}
+
+							case op.Patch != nil:
+								log.Info("patch", "entity", op.Patch.EntityKey.Hex())
+
+								key := op.Patch.EntityKey.Hex()
+
+								err = mongoDriver.UpdateEntityExpiresAt(txCtx, key, int64(op.Patch.ExpiresAtBlock))
+								if err != nil {
+									return nil, fmt.Errorf("failed to update entity expiration: %w", err)
+								}
+
+								if op.Patch.ReplacePayload {
+									err = mongoDriver.UpdateEntityPayload(txCtx, key, op.Patch.Payload)
+									if err != nil {
+										return nil, fmt.Errorf("failed to update entity payload: %w", err)
+									}
+								}
+
+								for _, annotationKey := range op.Patch.RemoveStringAnnotations {
+									err = mongoDriver.RemoveStringAnnotation(txCtx, key, annotationKey)
+									if err != nil {
+										return nil, fmt.Errorf("failed to remove string annotation: %w", err)
+									}
+								}
+
+								for _, annotationKey := range op.Patch.RemoveNumericAnnotations {
+									err = mongoDriver.RemoveNumericAnnotation(txCtx, key, annotationKey)
+									if err != nil {
+										return nil, fmt.Errorf("failed to remove numeric annotation: %w", err)
+									}
+								}
+
+								stringAnnotations := make([]mongogolem.StringAnnotation, 0, len(op.Patch.SetStringAnnotations))
+								for _, annotation := range op.Patch.SetStringAnnotations {
+									stringAnnotations = append(stringAnnotations, mongogolem.StringAnnotation{Key: annotation.Key, Value: annotation.Value})
+								}
+
+								err = mongoDriver.AddStringAnnotations(txCtx, key, stringAnnotations)
+								if err != nil {
+									return nil, fmt.Errorf("failed to set string annotations: %w", err)
+								}
+
+								numericAnnotations := make([]mongogolem.NumericAnnotation, 0, len(op.Patch.SetNumericAnnotations))
+								for _, annotation := range op.Patch.SetNumericAnnotations {
+									numericAnnotations = append(numericAnnotations, mongogolem.NumericAnnotation{Key: annotation.Key, Value: int64(annotation.Value)})
+								}
+
+								err = mongoDriver.AddNumericAnnotations(txCtx, key, numericAnnotations)
+								if err != nil {
+									return nil, fmt.Errorf("failed to set numeric annotations: %w", err)
+								}
+
+								// The payload and the string annotations selecting its schema may have changed
+								err = mongoDriver.ValidatePayload(txCtx, key)
+								if err != nil {
+									return nil, fmt.Errorf("failed to validate entity payload: %w", err)
 								}
 							}
 
 							log.Info("operation", "operation", op)
 						}
 
-						// Update processing status
+						// Update processing status, a reverted block leaves its parent as the last processed block
+						processedNumber, processedHash := blockWal.ProcessedBlock()
 						err = mongoDriver.UpdateProcessingStatus(txCtx, mongogolem.ProcessingStatus{
 							Network:                  networkID.String(),
-							LastProcessedBlockNumber: int64(blockWal.BlockInfo.Number),
-							LastProcessedBlockHash:   blockWal.BlockInfo.Hash.String(),
+							LastProcessedBlockNumber: int64(processedNumber),
+							LastProcessedBlockHash:   processedHash.String(),
 						})
 						if err != nil {
 							return nil, fmt.Errorf("failed to update processing status: %w", err)
@@ -258,6 +448,23 @@ func main() {
 				if err != nil {
 					return fmt.Errorf("failed to process block: %w", err)
 				}
+
+				if cfg.consumer != "" {
+					processedNumber, processedHash := blockWal.ProcessedBlock()
+					checkpoint := wal.Checkpoint{
+						BlockNumber: processedNumber,
+						BlockHash:   processedHash,
+					}
+
+					if cfg.walDir != "" {
+						err = wal.WriteCheckpoint(cfg.walDir, cfg.consumer, checkpoint)
+					} else {
+						err = wal.AcknowledgeOperations(ctx, ec.Client(), cfg.consumer, checkpoint)
+					}
+					if err != nil {
+						return fmt.Errorf("failed to acknowledge block: %w", err)
+					}
+				}
 			}
 
 			return nil
@@ -270,3 +477,67 @@ func main() {
 		os.Exit(1)
 	}
 }
+
+func entityFromCreate(create wal.Create) mongogolem.Entity {
+	// Convert string and numeric annotations to maps
+	stringAnnotations := make(map[string]string)
+	for _, annotation := range create.StringAnnotations {
+		stringAnnotations[annotation.Key] = annotation.Value
+	}
+
+	numericAnnotations := make(map[string]int64)
+	for _, annotation := range create.NumericAnnotations {
+		numericAnnotations[annotation.Key] = int64(annotation.Value)
+	}
+
+	return mongogolem.Entity{
+		Key:                create.EntityKey.Hex(),
+		ExpiresAt:          int64(create.ExpiresAtBlock),
+		Payload:            create.Payload,
+		StringAnnotations:  stringAnnotations,
+		NumericAnnotations: numericAnnotations,
+		OwnerAddress:       create.Owner.Hex(),
+	}
+}
+
+// loadSnapshot inserts the entities of the snapshot and records its block as the last processed block.
+// A snapshot is too large for a single transaction, so the processing status is only inserted once
+// all entities were inserted, and the entities of an interrupted load are removed when it is retried.
+func loadSnapshot(ctx context.Context, mongoDriver *mongogolem.MongoGolem, path string, chainID, networkID *big.Int) error {
+	r, err := snapshot.Open(path)
+	if err != nil {
+		return err
+	}
+	defer r.Close()
+
+	if r.Header.ChainID != nil && r.Header.ChainID.Cmp(chainID) != 0 {
+		return fmt.Errorf("snapshot of chain %s, expected %s", r.Header.ChainID, chainID)
+	}
+
+	err = mongoDriver.DeleteAllEntities(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to remove entities of an interrupted load: %w", err)
+	}
+
+	for e, err := range r.Entities() {
+		if err != nil {
+			return err
+		}
+
+		err = mongoDriver.InsertEntity(ctx, entityFromCreate(e))
+		if err != nil {
+			return fmt.Errorf("failed to insert entity: %w", err)
+		}
+	}
+
+	err = mongoDriver.InsertProcessingStatus(ctx, mongogolem.ProcessingStatus{
+		Network:                  networkID.String(),
+		LastProcessedBlockNumber: int64(r.Header.BlockNumber),
+		LastProcessedBlockHash:   r.Header.BlockHash.String(),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to insert processing status: %w", err)
+	}
+
+	return nil
+}
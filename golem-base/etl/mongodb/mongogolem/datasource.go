@@ -0,0 +1,145 @@
+package mongogolem
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"regexp"
+	"slices"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/golem-base/query"
+	"github.com/ethereum/go-ethereum/golem-base/storageutil/entity"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+var _ query.EntityStore = &DataSource{}
+
+// DataSource evaluates queries against the entities stored by the MongoDB ETL.
+// Queries run with a snapshot session see the entities of a single block.
+type DataSource struct {
+	ctx context.Context
+	m   *MongoGolem
+}
+
+func NewDataSource(ctx context.Context, m *MongoGolem) *DataSource {
+	return &DataSource{
+		ctx: ctx,
+		m:   m,
+	}
+}
+
+// findKeys returns the keys of the entities matching the filter
+func (ds *DataSource) findKeys(filter bson.M) ([]common.Hash, error) {
+	cursor, err := ds.m.Collections().Entities.Find(ds.ctx, filter, options.Find().SetProjection(bson.M{"_id": 1}))
+	if err != nil {
+		return nil, fmt.Errorf("failed to find entities: %w", err)
+	}
+	defer cursor.Close(ds.ctx)
+
+	keys := []common.Hash{}
+	for cursor.Next(ds.ctx) {
+		var doc struct {
+			Key string `bson:"_id"`
+		}
+		err = cursor.Decode(&doc)
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode entity: %w", err)
+		}
+		keys = append(keys, common.HexToHash(doc.Key))
+	}
+
+	err = cursor.Err()
+	if err != nil {
+		return nil, fmt.Errorf("failed to find entities: %w", err)
+	}
+
+	return keys, nil
+}
+
+func (ds *DataSource) GetKeysForStringAnnotation(annotation string, value string) ([]common.Hash, error) {
+	return ds.findKeys(bson.M{"stringAnnotations." + annotation: value})
+}
+
+func (ds *DataSource) GetKeysForNumericAnnotation(annotation string, value uint64) ([]common.Hash, error) {
+	return ds.findKeys(bson.M{"numericAnnotations." + annotation: int64(value)})
+}
+
+func (ds *DataSource) GetKeysForNumericAnnotationRange(annotation string, from, to uint64) ([]common.Hash, error) {
+	keys := []common.Hash{}
+
+	// the numeric annotations are stored as int64
+	for _, r := range query.Int64Ranges(from, to) {
+		rangeKeys, err := ds.findKeys(bson.M{"numericAnnotations." + annotation: bson.M{"$gte": r.From, "$lte": r.To}})
+		if err != nil {
+			return nil, err
+		}
+		keys = append(keys, rangeKeys...)
+	}
+
+	return keys, nil
+}
+
+func (ds *DataSource) GetStringAnnotationValuesWithPrefix(annotation string, prefix string) ([]string, error) {
+	field := "stringAnnotations." + annotation
+
+	values, err := ds.m.Collections().Entities.Distinct(ds.ctx, field, bson.M{
+		field: bson.M{"$regex": "^" + regexp.QuoteMeta(prefix)},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get string annotation values: %w", err)
+	}
+
+	res := []string{}
+	for _, v := range values {
+		if s, ok := v.(string); ok {
+			res = append(res, s)
+		}
+	}
+
+	return res, nil
+}
+
+func (ds *DataSource) GetAllKeys() ([]common.Hash, error) {
+	return ds.findKeys(bson.M{})
+}
+
+func (ds *DataSource) GetPayload(key common.Hash) ([]byte, error) {
+	e, err := ds.m.GetEntity(ds.ctx, key.Hex())
+	if err != nil {
+		return nil, err
+	}
+	return e.Payload, nil
+}
+
+func (ds *DataSource) GetEntityMetaData(key common.Hash) (*entity.EntityMetaData, error) {
+	e, err := ds.m.GetEntity(ds.ctx, key.Hex())
+	if err != nil {
+		return nil, err
+	}
+
+	md := &entity.EntityMetaData{
+		ExpiresAtBlock:     uint64(e.ExpiresAt),
+		StringAnnotations:  []entity.StringAnnotation{},
+		NumericAnnotations: []entity.NumericAnnotation{},
+		Owner:              common.HexToAddress(e.OwnerAddress),
+	}
+
+	// the annotations are stored as maps, they are returned ordered by key
+	for _, k := range slices.Sorted(maps.Keys(e.StringAnnotations)) {
+		md.StringAnnotations = append(md.StringAnnotations, entity.StringAnnotation{
+			Key:   k,
+			Value: e.StringAnnotations[k],
+		})
+	}
+
+	for _, k := range slices.Sorted(maps.Keys(e.NumericAnnotations)) {
+		md.NumericAnnotations = append(md.NumericAnnotations, entity.NumericAnnotation{
+			Key:   k,
+			Value: uint64(e.NumericAnnotations[k]),
+		})
+	}
+
+	return md, nil
+}
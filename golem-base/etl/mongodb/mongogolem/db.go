@@ -10,7 +10,8 @@ import (
 )
 
 type MongoGolem struct {
-	db *mongo.Database
+	db      *mongo.Database
+	schemas PayloadSchemas
 }
 
 // New creates a new MongoGolem instance
@@ -24,13 +25,16 @@ func New(db *mongo.Database) *MongoGolem {
 func (m *MongoGolem) Collections() struct {
 	ProcessingStatus *mongo.Collection
 	Entities         *mongo.Collection
+	EntityRemovals   *mongo.Collection
 } {
 	return struct {
 		ProcessingStatus *mongo.Collection
 		Entities         *mongo.Collection
+		EntityRemovals   *mongo.Collection
 	}{
 		ProcessingStatus: m.db.Collection("processing_status"),
 		Entities:         m.db.Collection("entities"),
+		EntityRemovals:   m.db.Collection("entity_removals"),
 	}
 }
 
@@ -84,5 +88,23 @@ func (m *MongoGolem) EnsureIndexes(ctx context.Context) error {
 		return fmt.Errorf("failed to create wildcard index for numeric annotations: %w", err)
 	}
 
+	// Create key index for entity removals
+	removalKeyIndex := mongo.IndexModel{
+		Keys: bson.D{{Key: "key", Value: 1}},
+	}
+	_, err = cols.EntityRemovals.Indexes().CreateOne(ctx, removalKeyIndex)
+	if err != nil {
+		return fmt.Errorf("failed to create key index for entity removals: %w", err)
+	}
+
+	// Create block number index for entity removals
+	removalBlockIndex := mongo.IndexModel{
+		Keys: bson.D{{Key: "block_number", Value: 1}},
+	}
+	_, err = cols.EntityRemovals.Indexes().CreateOne(ctx, removalBlockIndex)
+	if err != nil {
+		return fmt.Errorf("failed to create block number index for entity removals: %w", err)
+	}
+
 	return nil
 }
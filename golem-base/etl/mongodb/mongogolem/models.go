@@ -23,6 +23,25 @@ type Entity struct {
 	CreatedAt          time.Time         `bson:"created_at"`
 	UpdatedAt          time.Time         `bson:"updated_at"`
 	OwnerAddress       string            `bson:"owner_address"`
+	PayloadSchema      string            `bson:"payload_schema,omitempty"`
+	PayloadValid       *bool             `bson:"payload_valid,omitempty"`
+	PayloadErrors      []string          `bson:"payload_errors,omitempty"`
+}
+
+// Reasons for the removal of an entity
+const (
+	RemovalDeleted = "deleted"
+	RemovalExpired = "expired"
+)
+
+// EntityRemoval records an entity that was deleted by its owner or expired
+type EntityRemoval struct {
+	Key          string    `bson:"key"`
+	Reason       string    `bson:"reason"`
+	BlockNumber  int64     `bson:"block_number"`
+	ExpiresAt    int64     `bson:"expires_at"`
+	OwnerAddress string    `bson:"owner_address"`
+	RemovedAt    time.Time `bson:"removed_at"`
 }
 
 // Annotation represents a key-value pair
@@ -103,6 +103,8 @@ func (m *MongoGolem) InsertEntity(ctx context.Context, entity Entity) error {
 		}
 	}
 
+	m.schemas.validate(entity.StringAnnotations, entity.Payload).apply(&entity)
+
 	_, err := cols.Entities.InsertOne(ctx, entity)
 	if err != nil {
 		return fmt.Errorf("failed to insert entity: %w", err)
@@ -133,6 +135,8 @@ func (m *MongoGolem) UpdateEntity(ctx context.Context, entity Entity) error {
 		}
 	}
 
+	m.schemas.validate(entity.StringAnnotations, entity.Payload).apply(&entity)
+
 	_, err := cols.Entities.ReplaceOne(
 		ctx,
 		bson.M{"_id": entity.Key},
@@ -145,6 +149,79 @@ func (m *MongoGolem) UpdateEntity(ctx context.Context, entity Entity) error {
 	return nil
 }
 
+// UpdateEntityOwner changes the owner address of an entity
+func (m *MongoGolem) UpdateEntityOwner(ctx context.Context, key string, ownerAddress string) error {
+	cols := m.Collections()
+
+	_, err := cols.Entities.UpdateOne(
+		ctx,
+		bson.M{"_id": key},
+		bson.M{
+			"$set": bson.M{
+				"owner_address": ownerAddress,
+				"updated_at":    time.Now(),
+			},
+		},
+	)
+	if err != nil {
+		return fmt.Errorf("failed to update entity owner: %w", err)
+	}
+
+	return nil
+}
+
+// UpdateEntityExpiresAt updates the expiration block of an entity
+func (m *MongoGolem) UpdateEntityExpiresAt(ctx context.Context, key string, expiresAt int64) error {
+	cols := m.Collections()
+
+	_, err := cols.Entities.UpdateOne(
+		ctx,
+		bson.M{"_id": key},
+		bson.M{
+			"$set": bson.M{
+				"expires_at": expiresAt,
+				"updated_at": time.Now(),
+			},
+		},
+	)
+	if err != nil {
+		return fmt.Errorf("failed to update entity expiration: %w", err)
+	}
+
+	return nil
+}
+
+// UpdateEntityPayload replaces the payload of an entity
+func (m *MongoGolem) UpdateEntityPayload(ctx context.Context, key string, payload []byte) error {
+	cols := m.Collections()
+
+	update := bson.M{
+		"content":    payload,
+		"updated_at": time.Now(),
+	}
+	unset := bson.M{}
+
+	// Try to deserialize the payload to JSON if it's not empty
+	var jsonData interface{}
+	if len(payload) > 0 && json.Unmarshal(payload, &jsonData) == nil {
+		update["content_json"] = jsonData
+	} else {
+		unset["content_json"] = ""
+	}
+
+	changes := bson.M{"$set": update}
+	if len(unset) > 0 {
+		changes["$unset"] = unset
+	}
+
+	_, err := cols.Entities.UpdateOne(ctx, bson.M{"_id": key}, changes)
+	if err != nil {
+		return fmt.Errorf("failed to update entity payload: %w", err)
+	}
+
+	return nil
+}
+
 // DeleteEntity deletes an entity by key
 func (m *MongoGolem) DeleteEntity(ctx context.Context, key string) error {
 	cols := m.Collections()
@@ -157,6 +234,44 @@ func (m *MongoGolem) DeleteEntity(ctx context.Context, key string) error {
 	return nil
 }
 
+// RecordEntityRemoval records the deletion or expiration of an entity
+func (m *MongoGolem) RecordEntityRemoval(ctx context.Context, removal EntityRemoval) error {
+	cols := m.Collections()
+
+	removal.RemovedAt = time.Now()
+
+	_, err := cols.EntityRemovals.InsertOne(ctx, removal)
+	if err != nil {
+		return fmt.Errorf("failed to record entity removal: %w", err)
+	}
+
+	return nil
+}
+
+// DeleteEntityRemovalsOfBlock deletes the removals recorded for a block
+func (m *MongoGolem) DeleteEntityRemovalsOfBlock(ctx context.Context, blockNumber int64) error {
+	cols := m.Collections()
+
+	_, err := cols.EntityRemovals.DeleteMany(ctx, bson.M{"block_number": blockNumber})
+	if err != nil {
+		return fmt.Errorf("failed to delete entity removals: %w", err)
+	}
+
+	return nil
+}
+
+// DeleteAllEntities deletes all entities
+func (m *MongoGolem) DeleteAllEntities(ctx context.Context) error {
+	cols := m.Collections()
+
+	_, err := cols.Entities.DeleteMany(ctx, bson.M{})
+	if err != nil {
+		return fmt.Errorf("failed to delete entities: %w", err)
+	}
+
+	return nil
+}
+
 // AddStringAnnotation adds a string annotation to an entity
 func (m *MongoGolem) AddStringAnnotation(ctx context.Context, entityKey string, annotation StringAnnotation) error {
 	cols := m.Collections()
@@ -0,0 +1,363 @@
+package mongogolem
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"maps"
+	"os"
+	"slices"
+	"strings"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"gopkg.in/yaml.v3"
+)
+
+// payloadIndexPrefix is the name prefix of the indexes created for the fields of payload schemas
+const payloadIndexPrefix = "payload_"
+
+// PayloadSchema declares the JSON payload of the entities with the given value of a string annotation
+type PayloadSchema struct {
+	Name       string                  `yaml:"name"`
+	Annotation string                  `yaml:"annotation"`
+	Value      string                  `yaml:"value"`
+	Fields     map[string]PayloadField `yaml:"fields"`
+}
+
+// PayloadField declares a field of a JSON payload, nested fields are addressed with dotted paths
+type PayloadField struct {
+	Type     string `yaml:"type"`
+	Required bool   `yaml:"required"`
+	Index    bool   `yaml:"index"`
+}
+
+// PayloadSchemas are the payload schemas in the order of the schema file, an entity matching
+// several schemas is validated against the first one
+type PayloadSchemas []PayloadSchema
+
+var payloadFieldTypes = []string{"string", "number", "integer", "boolean", "object", "array"}
+
+// LoadPayloadSchemas reads the payload schemas from a YAML or JSON file
+func LoadPayloadSchemas(path string) (PayloadSchemas, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read payload schemas: %w", err)
+	}
+
+	return ParsePayloadSchemas(data)
+}
+
+// ParsePayloadSchemas parses and checks YAML or JSON payload schemas
+func ParsePayloadSchemas(data []byte) (PayloadSchemas, error) {
+	file := struct {
+		Schemas PayloadSchemas `yaml:"schemas"`
+	}{}
+
+	err := yaml.Unmarshal(data, &file)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse payload schemas: %w", err)
+	}
+
+	names := map[string]bool{}
+	for _, s := range file.Schemas {
+		switch {
+		case s.Name == "":
+			return nil, errors.New("payload schema without a name")
+		case names[s.Name]:
+			return nil, fmt.Errorf("duplicate payload schema %q", s.Name)
+		case s.Annotation == "":
+			return nil, fmt.Errorf("payload schema %q without an annotation", s.Name)
+		}
+		names[s.Name] = true
+
+		for path, f := range s.Fields {
+			if path == "" || slices.Contains(strings.Split(path, "."), "") {
+				return nil, fmt.Errorf("payload schema %q has an invalid field path %q", s.Name, path)
+			}
+			if !slices.Contains(payloadFieldTypes, f.Type) {
+				return nil, fmt.Errorf("payload schema %q field %q has unsupported type %q", s.Name, path, f.Type)
+			}
+		}
+	}
+
+	return file.Schemas, nil
+}
+
+// Match returns the first schema for the string annotations of an entity, or nil
+func (s PayloadSchemas) Match(stringAnnotations map[string]string) *PayloadSchema {
+	for i, schema := range s {
+		v, ok := stringAnnotations[schema.Annotation]
+		if ok && v == schema.Value {
+			return &s[i]
+		}
+	}
+	return nil
+}
+
+// Validate returns the reasons why the payload does not conform to the schema, ordered by field
+func (s *PayloadSchema) Validate(payload []byte) []string {
+	var doc interface{}
+	err := json.Unmarshal(payload, &doc)
+	if err != nil {
+		return []string{"payload is not valid JSON"}
+	}
+
+	obj, ok := doc.(map[string]interface{})
+	if !ok {
+		return []string{"payload is not a JSON object"}
+	}
+
+	problems := []string{}
+	for _, path := range slices.Sorted(maps.Keys(s.Fields)) {
+		f := s.Fields[path]
+
+		v, found := lookupField(obj, path)
+		switch {
+		case !found && f.Required:
+			problems = append(problems, fmt.Sprintf("%s: required field is missing", path))
+		case found && !hasFieldType(v, f.Type):
+			problems = append(problems, fmt.Sprintf("%s: expected %s", path, f.Type))
+		}
+	}
+
+	return problems
+}
+
+func lookupField(obj map[string]interface{}, path string) (interface{}, bool) {
+	var v interface{} = obj
+	for _, name := range strings.Split(path, ".") {
+		m, ok := v.(map[string]interface{})
+		if !ok {
+			return nil, false
+		}
+		v, ok = m[name]
+		if !ok {
+			return nil, false
+		}
+	}
+	return v, true
+}
+
+func hasFieldType(v interface{}, fieldType string) bool {
+	switch fieldType {
+	case "string":
+		_, ok := v.(string)
+		return ok
+	case "number":
+		_, ok := v.(float64)
+		return ok
+	case "integer":
+		n, ok := v.(float64)
+		return ok && n == float64(int64(n))
+	case "boolean":
+		_, ok := v.(bool)
+		return ok
+	case "object":
+		_, ok := v.(map[string]interface{})
+		return ok
+	case "array":
+		_, ok := v.([]interface{})
+		return ok
+	}
+	return false
+}
+
+// payloadValidation is how an entity conforms to the payload schemas
+type payloadValidation struct {
+	schema string
+	valid  bool
+	errors []string
+}
+
+func (s PayloadSchemas) validate(stringAnnotations map[string]string, payload []byte) payloadValidation {
+	schema := s.Match(stringAnnotations)
+	if schema == nil {
+		return payloadValidation{}
+	}
+
+	problems := schema.Validate(payload)
+
+	return payloadValidation{
+		schema: schema.Name,
+		valid:  len(problems) == 0,
+		errors: problems,
+	}
+}
+
+// apply records the validation on an entity before it is stored
+func (v payloadValidation) apply(entity *Entity) {
+	entity.PayloadSchema = v.schema
+	entity.PayloadValid = nil
+	entity.PayloadErrors = nil
+	if v.schema != "" {
+		entity.PayloadValid = &v.valid
+		entity.PayloadErrors = v.errors
+	}
+}
+
+// update returns the changes recording the validation on a stored entity
+func (v payloadValidation) update() bson.M {
+	if v.schema == "" {
+		return bson.M{"$unset": bson.M{"payload_schema": "", "payload_valid": "", "payload_errors": ""}}
+	}
+
+	if v.valid {
+		return bson.M{
+			"$set":   bson.M{"payload_schema": v.schema, "payload_valid": true},
+			"$unset": bson.M{"payload_errors": ""},
+		}
+	}
+
+	return bson.M{"$set": bson.M{"payload_schema": v.schema, "payload_valid": false, "payload_errors": v.errors}}
+}
+
+// SetPayloadSchemas sets the schemas the payloads of the stored entities are validated against
+func (m *MongoGolem) SetPayloadSchemas(schemas PayloadSchemas) {
+	m.schemas = schemas
+}
+
+// ValidatePayload validates the payload of a stored entity again, after its payload or
+// string annotations were changed in place
+func (m *MongoGolem) ValidatePayload(ctx context.Context, key string) error {
+	if len(m.schemas) == 0 {
+		return nil
+	}
+
+	entity, err := m.GetEntity(ctx, key)
+	if err != nil {
+		return err
+	}
+
+	v := m.schemas.validate(entity.StringAnnotations, entity.Payload)
+
+	_, err = m.Collections().Entities.UpdateOne(ctx, bson.M{"_id": key}, v.update())
+	if err != nil {
+		return fmt.Errorf("failed to update payload validation: %w", err)
+	}
+
+	return nil
+}
+
+// ValidateAllPayloads validates the payloads of all the stored entities that match a schema
+// or were validated before, so that changes of the schemas apply to the existing entities
+func (m *MongoGolem) ValidateAllPayloads(ctx context.Context) error {
+	filter := bson.A{bson.M{"payload_schema": bson.M{"$exists": true}}}
+	for _, s := range m.schemas {
+		filter = append(filter, bson.M{"stringAnnotations." + s.Annotation: s.Value})
+	}
+
+	cursor, err := m.Collections().Entities.Find(
+		ctx,
+		bson.M{"$or": filter},
+		options.Find().SetProjection(bson.M{"_id": 1}),
+	)
+	if err != nil {
+		return fmt.Errorf("failed to find entities to validate: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	keys := []string{}
+	for cursor.Next(ctx) {
+		var doc struct {
+			Key string `bson:"_id"`
+		}
+		err = cursor.Decode(&doc)
+		if err != nil {
+			return fmt.Errorf("failed to decode entity: %w", err)
+		}
+		keys = append(keys, doc.Key)
+	}
+
+	err = cursor.Err()
+	if err != nil {
+		return fmt.Errorf("failed to find entities to validate: %w", err)
+	}
+
+	for _, key := range keys {
+		entity, err := m.GetEntity(ctx, key)
+		if err != nil {
+			return err
+		}
+
+		v := m.schemas.validate(entity.StringAnnotations, entity.Payload)
+
+		stored := payloadValidation{schema: entity.PayloadSchema, errors: entity.PayloadErrors}
+		if entity.PayloadValid != nil {
+			stored.valid = *entity.PayloadValid
+		}
+		if v.schema == stored.schema && v.valid == stored.valid && slices.Equal(v.errors, stored.errors) {
+			continue
+		}
+
+		_, err = m.Collections().Entities.UpdateOne(ctx, bson.M{"_id": key}, v.update())
+		if err != nil {
+			return fmt.Errorf("failed to update payload validation: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// payloadIndexName is the name of the index of a field of a payload schema
+func payloadIndexName(schema, path string) string {
+	return payloadIndexPrefix + schema + "_" + path
+}
+
+// EnsurePayloadIndexes creates the indexes of the indexed fields of the payload schemas and
+// drops the indexes of fields that are no longer declared. The indexes only contain the
+// entities with valid payloads, queries have to filter on the schema to use them.
+func (m *MongoGolem) EnsurePayloadIndexes(ctx context.Context) error {
+	indexes := m.Collections().Entities.Indexes()
+
+	declared := map[string]bool{}
+	for _, s := range m.schemas {
+		for _, path := range slices.Sorted(maps.Keys(s.Fields)) {
+			if !s.Fields[path].Index {
+				continue
+			}
+
+			name := payloadIndexName(s.Name, path)
+			declared[name] = true
+
+			_, err := indexes.CreateOne(ctx, mongo.IndexModel{
+				Keys: bson.D{{Key: "content_json." + path, Value: 1}},
+				Options: options.Index().
+					SetName(name).
+					SetPartialFilterExpression(bson.M{"payload_schema": s.Name, "payload_valid": true}),
+			})
+			if err != nil {
+				return fmt.Errorf("failed to create index %s: %w", name, err)
+			}
+		}
+	}
+
+	cursor, err := indexes.List(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to list indexes: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	existing := []struct {
+		Name string `bson:"name"`
+	}{}
+	err = cursor.All(ctx, &existing)
+	if err != nil {
+		return fmt.Errorf("failed to list indexes: %w", err)
+	}
+
+	for _, index := range existing {
+		if !strings.HasPrefix(index.Name, payloadIndexPrefix) || declared[index.Name] {
+			continue
+		}
+
+		_, err = indexes.DropOne(ctx, index.Name)
+		if err != nil {
+			return fmt.Errorf("failed to drop index %s: %w", index.Name, err)
+		}
+	}
+
+	return nil
+}
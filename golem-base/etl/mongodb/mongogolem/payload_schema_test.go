@@ -0,0 +1,108 @@
+package mongogolem_test
+
+import (
+	"testing"
+
+	"github.com/ethereum/go-ethereum/golem-base/etl/mongodb/mongogolem"
+	"github.com/stretchr/testify/require"
+)
+
+const orderSchemas = `
+schemas:
+  - name: orders
+    annotation: type
+    value: order
+    fields:
+      customer: {type: string, required: true, index: true}
+      total: {type: number, index: true}
+      items: {type: array}
+      shipping.city: {type: string}
+  - name: any-order
+    annotation: type
+    value: order
+`
+
+func TestParsePayloadSchemas(t *testing.T) {
+	t.Run("yaml", func(t *testing.T) {
+		schemas, err := mongogolem.ParsePayloadSchemas([]byte(orderSchemas))
+		require.NoError(t, err)
+		require.Len(t, schemas, 2)
+		require.Equal(t, mongogolem.PayloadField{Type: "string", Required: true, Index: true}, schemas[0].Fields["customer"])
+	})
+
+	t.Run("json", func(t *testing.T) {
+		schemas, err := mongogolem.ParsePayloadSchemas([]byte(`{"schemas": [{"name": "orders", "annotation": "type", "value": "order", "fields": {"total": {"type": "integer"}}}]}`))
+		require.NoError(t, err)
+		require.Equal(t, "integer", schemas[0].Fields["total"].Type)
+	})
+
+	t.Run("duplicate name", func(t *testing.T) {
+		_, err := mongogolem.ParsePayloadSchemas([]byte("schemas: [{name: a, annotation: type}, {name: a, annotation: kind}]"))
+		require.ErrorContains(t, err, `duplicate payload schema "a"`)
+	})
+
+	t.Run("unsupported type", func(t *testing.T) {
+		_, err := mongogolem.ParsePayloadSchemas([]byte("schemas: [{name: a, annotation: type, fields: {x: {type: date}}}]"))
+		require.ErrorContains(t, err, `unsupported type "date"`)
+	})
+
+	t.Run("invalid field path", func(t *testing.T) {
+		_, err := mongogolem.ParsePayloadSchemas([]byte("schemas: [{name: a, annotation: type, fields: {x..y: {type: string}}}]"))
+		require.ErrorContains(t, err, "invalid field path")
+	})
+}
+
+func TestPayloadSchemasMatch(t *testing.T) {
+	schemas, err := mongogolem.ParsePayloadSchemas([]byte(orderSchemas))
+	require.NoError(t, err)
+
+	t.Run("first matching schema", func(t *testing.T) {
+		require.Equal(t, "orders", schemas.Match(map[string]string{"type": "order"}).Name)
+	})
+
+	t.Run("other value", func(t *testing.T) {
+		require.Nil(t, schemas.Match(map[string]string{"type": "invoice"}))
+	})
+
+	t.Run("no annotation", func(t *testing.T) {
+		require.Nil(t, schemas.Match(map[string]string{}))
+	})
+}
+
+func TestPayloadSchemaValidate(t *testing.T) {
+	schemas, err := mongogolem.ParsePayloadSchemas([]byte(orderSchemas))
+	require.NoError(t, err)
+
+	orders := schemas.Match(map[string]string{"type": "order"})
+
+	t.Run("valid", func(t *testing.T) {
+		require.Empty(t, orders.Validate([]byte(`{"customer": "acme", "total": 12.5, "items": [], "shipping": {"city": "Warsaw"}}`)))
+	})
+
+	t.Run("optional fields missing", func(t *testing.T) {
+		require.Empty(t, orders.Validate([]byte(`{"customer": "acme"}`)))
+	})
+
+	t.Run("required field missing and wrong types", func(t *testing.T) {
+		require.Equal(t, []string{
+			"customer: required field is missing",
+			"shipping.city: expected string",
+			"total: expected number",
+		}, orders.Validate([]byte(`{"total": "12", "shipping": {"city": 1}}`)))
+	})
+
+	t.Run("not an object", func(t *testing.T) {
+		require.Equal(t, []string{"payload is not a JSON object"}, orders.Validate([]byte(`[1, 2]`)))
+	})
+
+	t.Run("not json", func(t *testing.T) {
+		require.Equal(t, []string{"payload is not valid JSON"}, orders.Validate([]byte("test")))
+	})
+
+	t.Run("integer", func(t *testing.T) {
+		s, err := mongogolem.ParsePayloadSchemas([]byte("schemas: [{name: a, annotation: type, fields: {n: {type: integer}}}]"))
+		require.NoError(t, err)
+		require.Empty(t, s[0].Validate([]byte(`{"n": 3}`)))
+		require.Equal(t, []string{"n: expected integer"}, s[0].Validate([]byte(`{"n": 3.5}`)))
+	})
+}
@@ -0,0 +1,489 @@
+package main_test
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/cenkalti/backoff/v4"
+	"github.com/cucumber/godog"
+	"github.com/cucumber/godog/colors"
+	"github.com/ethereum/go-ethereum/golem-base/etl/etltest"
+	"github.com/ethereum/go-ethereum/golem-base/etl/postgres/etlworld"
+	"github.com/ethereum/go-ethereum/golem-base/etl/postgres/postgresgolem"
+	"github.com/ethereum/go-ethereum/golem-base/storageutil/entity"
+	"github.com/ethereum/go-ethereum/golem-base/testutil"
+	"github.com/google/go-cmp/cmp"
+	"github.com/spf13/pflag" // godog v0.11.0 and later
+)
+
+var opts = godog.Options{
+	Output:      colors.Uncolored(os.Stdout),
+	Format:      "progress",
+	Strict:      true,
+	Concurrency: 4,
+
+	Paths: []string{"features"},
+}
+
+func init() {
+	godog.BindCommandLineFlags("godog.", &opts)
+
+	if os.Getenv("CUCUMBER_WIP_ONLY") == "true" {
+		opts.Tags = "@wip"
+		opts.Concurrency = 1
+		opts.Format = "pretty"
+	}
+}
+
+func TestMain(m *testing.M) {
+	pflag.Parse()
+	opts.Paths = pflag.Args()
+
+	gethPath, cleanupCompiled, err := etltest.CompileGeth()
+	if err != nil {
+		log.Fatal(fmt.Errorf("failed to compile geth: %w", err))
+	}
+
+	postgresETLPath, cleanupCompiledPostgresETL, err := etltest.Compile("postgres-etl", ".")
+	if err != nil {
+		log.Fatal(fmt.Errorf("failed to compile geth: %w", err))
+	}
+
+	suite := godog.TestSuite{
+		Name: "cucumber",
+		ScenarioInitializer: func(sctx *godog.ScenarioContext) {
+			InitializeScenario(sctx)
+			sctx.Before(func(ctx context.Context, sc *godog.Scenario) (context.Context, error) {
+
+				streamOperations := false
+				loadSnapshot := false
+				for _, tag := range sc.Tags {
+					switch tag.Name {
+					case "@stream":
+						streamOperations = true
+					case "@snapshot":
+						loadSnapshot = true
+					}
+				}
+
+				world, err := etlworld.NewETLWorld(ctx, gethPath, postgresETLPath, streamOperations, loadSnapshot)
+				if err != nil {
+					return ctx, fmt.Errorf("failed to start geth instance: %w", err)
+				}
+
+				timeoutCtx, cancel := context.WithTimeout(ctx, 10*time.Second)
+
+				sctx.After(func(ctx context.Context, sc *godog.Scenario, err error) (context.Context, error) {
+					world.Shutdown()
+					cancel()
+					return ctx, world.AddLogsToTestError(err)
+				})
+
+				return etlworld.WithWorld(timeoutCtx, world), nil
+
+			})
+
+		},
+		// ScenarioInitializer:  InitializeScenario,
+		Options: &opts,
+	}
+
+	status := suite.Run()
+
+	// // Optional: Run `testing` package's logic besides godog.
+	// if st := m.Run(); st > status {
+	// 	status = st
+	// }
+
+	cleanupCompiled()
+	cleanupCompiledPostgresETL()
+
+	os.Exit(status)
+}
+
+func InitializeScenario(ctx *godog.ScenarioContext) {
+	etltest.Steps{
+		World:        func(ctx context.Context) *testutil.World { return etlworld.GetWorld(ctx).World },
+		ConsumerName: etlworld.ConsumerName,
+	}.Register(ctx)
+
+	ctx.Step(`^A running ETL to PostgreSQL$`, aRunningETLToPostgreSQL)
+	ctx.Step(`^I create a new entity in Golebase$`, iCreateANewEntityInGolebase)
+	ctx.Step(`^an existing entity in the PostgreSQL database$`, iCreateANewEntityInGolebase)
+	ctx.Step(`^I create an entity with a JSON payload in Golembase$`, iCreateAnEntityWithAJSONPayloadInGolembase)
+	ctx.Step(`^the entity should be created in the PostgreSQL database$`, theEntityShouldBeCreatedInThePostgreSQLDatabase)
+	ctx.Step(`^the annotations of the entity should be existing in the PostgreSQL database$`, theAnnotationsOfTheEntityShouldBeExistingInThePostgreSQLDatabase)
+	ctx.Step(`^the entity should be found by its annotations in the PostgreSQL database$`, theEntityShouldBeFoundByItsAnnotationsInThePostgreSQLDatabase)
+	ctx.Step(`^the entity should be found by a range of its numeric annotation in the PostgreSQL database$`, theEntityShouldBeFoundByARangeOfItsNumericAnnotationInThePostgreSQLDatabase)
+	ctx.Step(`^the payload of the entity should be stored as JSON in the PostgreSQL database$`, thePayloadOfTheEntityShouldBeStoredAsJSONInThePostgreSQLDatabase)
+	ctx.Step(`^the payload of the entity should not be stored as JSON in the PostgreSQL database$`, thePayloadOfTheEntityShouldNotBeStoredAsJSONInThePostgreSQLDatabase)
+	ctx.Step(`^the entity should be updated in the PostgreSQL database$`, theEntityShouldBeUpdatedInThePostgreSQLDatabase)
+	ctx.Step(`^the entity should be deleted in the PostgreSQL database$`, theEntityShouldBeDeletedInThePostgreSQLDatabase)
+	ctx.Step(`^the new owner address should be stored in the PostgreSQL database$`, theNewOwnerAddressShouldBeStoredInThePostgreSQLDatabase)
+	ctx.Step(`^the patch should be applied in the PostgreSQL database$`, thePatchShouldBeAppliedInThePostgreSQLDatabase)
+	ctx.Step(`^the entity of the snapshot should be in the PostgreSQL database$`, theEntityOfTheSnapshotShouldBeInThePostgreSQLDatabase)
+}
+
+func aRunningETLToPostgreSQL() error {
+	// this is a default when starting etlworld.World, so we don't need to do anything here
+	return nil
+}
+
+// eventually retries the check against the database until the ETL has processed the last block.
+func eventually(ctx context.Context, check func(q *postgresgolem.Queries) error) error {
+	w := etlworld.GetWorld(ctx)
+
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	bo := backoff.WithContext(backoff.NewConstantBackOff(100*time.Millisecond), ctx)
+
+	return backoff.Retry(func() error {
+		return w.WithDB(ctx, check)
+	}, bo)
+}
+
+// annotations returns the string and numeric annotations of the entity.
+func annotations(ctx context.Context, q *postgresgolem.Queries, key string, e postgresgolem.GetEntityRow) (map[string]string, map[string]int64, error) {
+	stringAnnotations := map[string]string{}
+	err := json.Unmarshal(e.StringAnnotations, &stringAnnotations)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to decode string annotations: %w", err)
+	}
+
+	rows, err := q.GetNumericAnnotations(ctx, key)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to get numeric annotations: %w", err)
+	}
+
+	numericAnnotations := map[string]int64{}
+	for _, row := range rows {
+		numericAnnotations[row.AnnotationKey] = row.Value
+	}
+
+	return stringAnnotations, numericAnnotations, nil
+}
+
+func iCreateANewEntityInGolebase(ctx context.Context) error {
+	_, err := etltest.CreateEntity(ctx, etlworld.GetWorld(ctx).World)
+	return err
+}
+
+func iCreateAnEntityWithAJSONPayloadInGolembase(ctx context.Context) error {
+	w := etlworld.GetWorld(ctx)
+	_, err := w.CreateEntity(ctx,
+		1000,
+		[]byte(`{"name": "test", "values": [1, 2, 3]}`),
+		[]entity.StringAnnotation{},
+		[]entity.NumericAnnotation{},
+	)
+	if err != nil {
+		return fmt.Errorf("failed to create entity: %w", err)
+	}
+
+	return nil
+}
+
+func theEntityShouldBeCreatedInThePostgreSQLDatabase(ctx context.Context) error {
+	w := etlworld.GetWorld(ctx)
+
+	return eventually(ctx, func(q *postgresgolem.Queries) error {
+		e, err := q.GetEntity(ctx, w.CreatedEntityKey.Hex())
+		if err != nil {
+			return fmt.Errorf("failed to get entity: %w", err)
+		}
+
+		if string(e.Payload) != string(etltest.CreatedPayload) {
+			return fmt.Errorf("expected payload to be %q, but got %q", etltest.CreatedPayload, e.Payload)
+		}
+
+		if e.OwnerAddress != w.FundedAccount.Address.Hex() {
+			return fmt.Errorf("expected owner address to be %s, but got %s", w.FundedAccount.Address.Hex(), e.OwnerAddress)
+		}
+
+		return nil
+	})
+}
+
+func theAnnotationsOfTheEntityShouldBeExistingInThePostgreSQLDatabase(ctx context.Context) error {
+	w := etlworld.GetWorld(ctx)
+
+	return eventually(ctx, func(q *postgresgolem.Queries) error {
+		e, err := q.GetEntity(ctx, w.CreatedEntityKey.Hex())
+		if err != nil {
+			return fmt.Errorf("failed to get entity: %w", err)
+		}
+
+		stringAnnotations, numericAnnotations, err := annotations(ctx, q, w.CreatedEntityKey.Hex(), e)
+		if err != nil {
+			return err
+		}
+
+		if diff := cmp.Diff(map[string]string{"stringTest": "stringTest"}, stringAnnotations); diff != "" {
+			return fmt.Errorf("string annotations are not equal: %s", diff)
+		}
+
+		if diff := cmp.Diff(map[string]int64{"numericTest": 1234567890}, numericAnnotations); diff != "" {
+			return fmt.Errorf("numeric annotations are not equal: %s", diff)
+		}
+
+		return nil
+	})
+}
+
+func theEntityShouldBeFoundByItsAnnotationsInThePostgreSQLDatabase(ctx context.Context) error {
+	w := etlworld.GetWorld(ctx)
+
+	return eventually(ctx, func(q *postgresgolem.Queries) error {
+		keys, err := q.GetEntitiesByStringAnnotation(ctx, postgresgolem.GetEntitiesByStringAnnotationParams{
+			AnnotationKey: "stringTest",
+			Value:         "stringTest",
+		})
+		if err != nil {
+			return fmt.Errorf("failed to get entities by string annotation: %w", err)
+		}
+
+		if diff := cmp.Diff([]string{w.CreatedEntityKey.Hex()}, keys); diff != "" {
+			return fmt.Errorf("unexpected entities for the string annotation: %s", diff)
+		}
+
+		keys, err = q.GetEntitiesByNumericAnnotation(ctx, postgresgolem.GetEntitiesByNumericAnnotationParams{
+			AnnotationKey: "numericTest",
+			Value:         1234567890,
+		})
+		if err != nil {
+			return fmt.Errorf("failed to get entities by numeric annotation: %w", err)
+		}
+
+		if diff := cmp.Diff([]string{w.CreatedEntityKey.Hex()}, keys); diff != "" {
+			return fmt.Errorf("unexpected entities for the numeric annotation: %s", diff)
+		}
+
+		return nil
+	})
+}
+
+func theEntityShouldBeFoundByARangeOfItsNumericAnnotationInThePostgreSQLDatabase(ctx context.Context) error {
+	w := etlworld.GetWorld(ctx)
+
+	return eventually(ctx, func(q *postgresgolem.Queries) error {
+		keys, err := q.GetEntitiesByNumericAnnotationRange(ctx, postgresgolem.GetEntitiesByNumericAnnotationRangeParams{
+			AnnotationKey: "numericTest",
+			MinValue:      1234567000,
+			MaxValue:      1234568000,
+		})
+		if err != nil {
+			return fmt.Errorf("failed to get entities by numeric annotation range: %w", err)
+		}
+
+		if diff := cmp.Diff([]string{w.CreatedEntityKey.Hex()}, keys); diff != "" {
+			return fmt.Errorf("unexpected entities for the numeric annotation range: %s", diff)
+		}
+
+		keys, err = q.GetEntitiesByNumericAnnotationRange(ctx, postgresgolem.GetEntitiesByNumericAnnotationRangeParams{
+			AnnotationKey: "numericTest",
+			MinValue:      1234567891,
+			MaxValue:      1234568000,
+		})
+		if err != nil {
+			return fmt.Errorf("failed to get entities by numeric annotation range: %w", err)
+		}
+
+		if len(keys) != 0 {
+			return fmt.Errorf("expected no entities above the value of the annotation, but got %v", keys)
+		}
+
+		return nil
+	})
+}
+
+func thePayloadOfTheEntityShouldBeStoredAsJSONInThePostgreSQLDatabase(ctx context.Context) error {
+	w := etlworld.GetWorld(ctx)
+
+	return eventually(ctx, func(q *postgresgolem.Queries) error {
+		e, err := q.GetEntity(ctx, w.CreatedEntityKey.Hex())
+		if err != nil {
+			return fmt.Errorf("failed to get entity: %w", err)
+		}
+
+		if e.PayloadJson == nil {
+			return fmt.Errorf("expected the payload to be stored as JSON")
+		}
+
+		var actual, expected any
+		err = json.Unmarshal(e.PayloadJson, &actual)
+		if err != nil {
+			return fmt.Errorf("failed to decode the JSON payload: %w", err)
+		}
+
+		err = json.Unmarshal(e.Payload, &expected)
+		if err != nil {
+			return fmt.Errorf("failed to decode the payload: %w", err)
+		}
+
+		if diff := cmp.Diff(expected, actual); diff != "" {
+			return fmt.Errorf("JSON payload is not equal: %s", diff)
+		}
+
+		return nil
+	})
+}
+
+func thePayloadOfTheEntityShouldNotBeStoredAsJSONInThePostgreSQLDatabase(ctx context.Context) error {
+	w := etlworld.GetWorld(ctx)
+
+	return eventually(ctx, func(q *postgresgolem.Queries) error {
+		e, err := q.GetEntity(ctx, w.CreatedEntityKey.Hex())
+		if err != nil {
+			return fmt.Errorf("failed to get entity: %w", err)
+		}
+
+		if e.PayloadJson != nil {
+			return fmt.Errorf("expected no JSON payload, but got %s", e.PayloadJson)
+		}
+
+		return nil
+	})
+}
+
+func theEntityShouldBeUpdatedInThePostgreSQLDatabase(ctx context.Context) error {
+	w := etlworld.GetWorld(ctx)
+
+	return eventually(ctx, func(q *postgresgolem.Queries) error {
+		e, err := q.GetEntity(ctx, w.CreatedEntityKey.Hex())
+		if err != nil {
+			return fmt.Errorf("failed to get entity: %w", err)
+		}
+
+		if string(e.Payload) != string(etltest.UpdatedPayload) {
+			return fmt.Errorf("expected payload to be %q, but got %q", etltest.UpdatedPayload, e.Payload)
+		}
+
+		// the owner is preserved by the update
+		if e.OwnerAddress != w.FundedAccount.Address.Hex() {
+			return fmt.Errorf("expected owner address to be %s, but got %s", w.FundedAccount.Address.Hex(), e.OwnerAddress)
+		}
+
+		stringAnnotations, numericAnnotations, err := annotations(ctx, q, w.CreatedEntityKey.Hex(), e)
+		if err != nil {
+			return err
+		}
+
+		if diff := cmp.Diff(map[string]string{"stringTest2": "stringTest2"}, stringAnnotations); diff != "" {
+			return fmt.Errorf("string annotations are not equal: %s", diff)
+		}
+
+		if diff := cmp.Diff(map[string]int64{"numericTest2": 12345678901}, numericAnnotations); diff != "" {
+			return fmt.Errorf("numeric annotations are not equal: %s", diff)
+		}
+
+		return nil
+	})
+}
+
+func theEntityShouldBeDeletedInThePostgreSQLDatabase(ctx context.Context) error {
+	w := etlworld.GetWorld(ctx)
+
+	return eventually(ctx, func(q *postgresgolem.Queries) error {
+		exists, err := q.EntityExists(ctx, w.CreatedEntityKey.Hex())
+		if err != nil {
+			return fmt.Errorf("failed to check entity: %w", err)
+		}
+
+		if exists {
+			return fmt.Errorf("entity still exists")
+		}
+
+		return nil
+	})
+}
+
+func theNewOwnerAddressShouldBeStoredInThePostgreSQLDatabase(ctx context.Context) error {
+	w := etlworld.GetWorld(ctx)
+
+	return eventually(ctx, func(q *postgresgolem.Queries) error {
+		e, err := q.GetEntity(ctx, w.CreatedEntityKey.Hex())
+		if err != nil {
+			return fmt.Errorf("failed to get entity: %w", err)
+		}
+
+		if e.OwnerAddress != etltest.NewOwnerAddress.Hex() {
+			return fmt.Errorf("expected owner address to be %s, but got %s", etltest.NewOwnerAddress.Hex(), e.OwnerAddress)
+		}
+
+		return nil
+	})
+}
+
+func thePatchShouldBeAppliedInThePostgreSQLDatabase(ctx context.Context) error {
+	w := etlworld.GetWorld(ctx)
+
+	return eventually(ctx, func(q *postgresgolem.Queries) error {
+		e, err := q.GetEntity(ctx, w.CreatedEntityKey.Hex())
+		if err != nil {
+			return fmt.Errorf("failed to get entity: %w", err)
+		}
+
+		if string(e.Payload) != string(etltest.PatchedPayload) {
+			return fmt.Errorf("expected payload to be patched, but got %q", string(e.Payload))
+		}
+
+		if string(e.PayloadJson) != `{"patched": true}` {
+			return fmt.Errorf("expected JSON payload to be patched, but got %s", e.PayloadJson)
+		}
+
+		stringAnnotations, numericAnnotations, err := annotations(ctx, q, w.CreatedEntityKey.Hex(), e)
+		if err != nil {
+			return err
+		}
+
+		if diff := cmp.Diff(map[string]string{"stringTest": "patched"}, stringAnnotations); diff != "" {
+			return fmt.Errorf("string annotations are not equal: %s", diff)
+		}
+
+		if diff := cmp.Diff(map[string]int64{"numericTest2": 42}, numericAnnotations); diff != "" {
+			return fmt.Errorf("numeric annotations are not equal: %s", diff)
+		}
+
+		return nil
+	})
+}
+
+func theEntityOfTheSnapshotShouldBeInThePostgreSQLDatabase(ctx context.Context) error {
+	expected := etltest.SnapshotEntity
+
+	return eventually(ctx, func(q *postgresgolem.Queries) error {
+		e, err := q.GetEntity(ctx, expected.EntityKey.Hex())
+		if err != nil {
+			return fmt.Errorf("failed to get entity: %w", err)
+		}
+
+		if string(e.Payload) != string(expected.Payload) {
+			return fmt.Errorf("unexpected payload %q", e.Payload)
+		}
+
+		if e.OwnerAddress != expected.Owner.Hex() {
+			return fmt.Errorf("unexpected owner %s", e.OwnerAddress)
+		}
+
+		stringAnnotations, numericAnnotations, err := annotations(ctx, q, expected.EntityKey.Hex(), e)
+		if err != nil {
+			return err
+		}
+
+		if diff := cmp.Diff(map[string]string{"source": "snapshot"}, stringAnnotations); diff != "" {
+			return fmt.Errorf("string annotations are not equal: %s", diff)
+		}
+
+		if diff := cmp.Diff(map[string]int64{"version": 1}, numericAnnotations); diff != "" {
+			return fmt.Errorf("numeric annotations are not equal: %s", diff)
+		}
+
+		return nil
+	})
+}
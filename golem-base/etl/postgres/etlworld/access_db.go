@@ -0,0 +1,11 @@
+package etlworld
+
+import (
+	"context"
+
+	"github.com/ethereum/go-ethereum/golem-base/etl/postgres/postgresgolem"
+)
+
+func (e *ETLWorld) WithDB(ctx context.Context, dbfunc func(q *postgresgolem.Queries) error) error {
+	return dbfunc(postgresgolem.New(e.pool))
+}
@@ -0,0 +1,86 @@
+package etlworld
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os/exec"
+)
+
+type etlProcess struct {
+	*exec.Cmd
+	output  *bytes.Buffer
+	cleanup func()
+}
+
+// ConsumerName is the name the ETL acknowledges the processed blocks with.
+const ConsumerName = "postgres-etl"
+
+func startETLProcess(
+	ctx context.Context,
+	postgresETLBinaryPath string,
+	walDir string,
+	rpcEndpoint string,
+	postgresURL string,
+	snapshotPath string,
+) (_ *etlProcess, err error) {
+	args := []string{
+		"--postgres-url",
+		postgresURL,
+		"--rpc-endpoint",
+		rpcEndpoint,
+		"--consumer",
+		ConsumerName,
+	}
+
+	// without a WAL dir, the ETL streams the operations from the RPC endpoint
+	if walDir != "" {
+		args = append(args, "--wal", walDir)
+	}
+
+	if snapshotPath != "" {
+		args = append(args, "--snapshot", snapshotPath)
+	}
+
+	cmd := exec.CommandContext(
+		ctx,
+		postgresETLBinaryPath,
+		args...,
+	)
+
+	output := &bytes.Buffer{}
+
+	cmd.Stdout = output
+	cmd.Stderr = output
+
+	err = cmd.Start()
+	if err != nil {
+		return nil, fmt.Errorf("failed to start postgres-etl: %w", err)
+	}
+
+	defer func() {
+		if err != nil {
+			ek := cmd.Process.Kill()
+			if ek != nil {
+				err = errors.Join(err, ek)
+			}
+			_, we := cmd.Process.Wait()
+			if we != nil {
+				err = errors.Join(err, we)
+			}
+		}
+	}()
+
+	// Return cleanup function
+	cleanup := func() {
+		cmd.Process.Kill()
+		cmd.Process.Wait()
+	}
+
+	return &etlProcess{
+		Cmd:     cmd,
+		output:  output,
+		cleanup: cleanup,
+	}, nil
+}
@@ -0,0 +1,109 @@
+package etlworld
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/ethereum/go-ethereum/golem-base/etl/etltest"
+	"github.com/ethereum/go-ethereum/golem-base/testutil"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// ETLWorld is an environment for integration testing the PostgreSQL ETL.
+type ETLWorld struct {
+	*testutil.World
+	pool            *pgxpool.Pool
+	postgresCleanup func()
+	etlProcess      *etlProcess
+}
+
+// NewETLWorld starts geth, provides an empty database and starts the ETL. If streamOperations is set,
+// the ETL receives the operations over the websocket subscription of geth instead of reading the WAL directory.
+// If loadSnapshot is set, the ETL starts from a snapshot of the head block holding etltest.SnapshotEntity.
+func NewETLWorld(
+	ctx context.Context,
+	gethPath string,
+	postgresETLPath string,
+	streamOperations bool,
+	loadSnapshot bool,
+) (_ *ETLWorld, err error) {
+	world, err := testutil.NewWorld(ctx, gethPath)
+	if err != nil {
+		return nil, err
+	}
+
+	defer func() {
+		if err != nil {
+			world.Shutdown()
+		}
+	}()
+
+	postgresURL, postgresCleanup, err := startPostgres(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	defer func() {
+		if err != nil {
+			postgresCleanup()
+		}
+	}()
+
+	pool, err := pgxpool.New(ctx, postgresURL)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to PostgreSQL: %w", err)
+	}
+
+	snapshotPath := ""
+	if loadSnapshot {
+		snapshotPath, err = etltest.WriteSnapshot(ctx, world)
+		if err != nil {
+			pool.Close()
+			return nil, err
+		}
+	}
+
+	walDir := world.GethInstance.WALDir
+	rpcEndpoint := world.GethInstance.RPCEndpoint
+	if streamOperations {
+		walDir = ""
+		rpcEndpoint = world.GethInstance.WSEndpoint
+	}
+
+	etlProcess, err := startETLProcess(
+		ctx,
+		postgresETLPath,
+		walDir,
+		rpcEndpoint,
+		postgresURL,
+		snapshotPath,
+	)
+	if err != nil {
+		pool.Close()
+		return nil, err
+	}
+
+	return &ETLWorld{
+		World:           world,
+		pool:            pool,
+		postgresCleanup: postgresCleanup,
+		etlProcess:      etlProcess,
+	}, nil
+}
+
+func (w *ETLWorld) AddLogsToTestError(err error) error {
+	if err == nil {
+		return nil
+	}
+
+	err = fmt.Errorf("%w\n\nETL Logs:\n%s", err, w.etlProcess.output.String())
+
+	return w.World.AddLogsToTestError(err)
+}
+
+func (w *ETLWorld) Shutdown() {
+	w.etlProcess.cleanup()
+	w.pool.Close()
+	w.postgresCleanup()
+	w.World.Shutdown()
+}
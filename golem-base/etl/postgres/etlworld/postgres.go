@@ -0,0 +1,86 @@
+package etlworld
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"net/url"
+	"os"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/testcontainers/testcontainers-go/modules/postgres"
+)
+
+// startPostgres provides an empty database for a scenario. If POSTGRES_URL is set, the database
+// is created on that local server, otherwise a PostgreSQL container is started.
+func startPostgres(ctx context.Context) (string, func(), error) {
+	serverURL := os.Getenv("POSTGRES_URL")
+	if serverURL != "" {
+		return createLocalDatabase(ctx, serverURL)
+	}
+
+	container, err := postgres.Run(
+		ctx,
+		"postgres:16-alpine",
+		postgres.WithDatabase("golem_test"),
+		postgres.WithUsername("golem"),
+		postgres.WithPassword("golem"),
+		postgres.BasicWaitStrategies(),
+	)
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to start PostgreSQL container: %w", err)
+	}
+
+	cleanup := func() {
+		container.Terminate(context.Background())
+	}
+
+	connectionString, err := container.ConnectionString(ctx, "sslmode=disable")
+	if err != nil {
+		cleanup()
+		return "", nil, fmt.Errorf("failed to get PostgreSQL connection string: %w", err)
+	}
+
+	return connectionString, cleanup, nil
+}
+
+func createLocalDatabase(ctx context.Context, serverURL string) (string, func(), error) {
+	u, err := url.Parse(serverURL)
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to parse POSTGRES_URL: %w", err)
+	}
+
+	suffix := make([]byte, 8)
+	_, err = rand.Read(suffix)
+	if err != nil {
+		return "", nil, err
+	}
+
+	dbName := "golem_test_" + hex.EncodeToString(suffix)
+
+	conn, err := pgx.Connect(ctx, serverURL)
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to connect to PostgreSQL: %w", err)
+	}
+	defer conn.Close(ctx)
+
+	_, err = conn.Exec(ctx, "CREATE DATABASE "+dbName)
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to create database: %w", err)
+	}
+
+	cleanup := func() {
+		ctx := context.Background()
+		conn, err := pgx.Connect(ctx, serverURL)
+		if err != nil {
+			return
+		}
+		defer conn.Close(ctx)
+		conn.Exec(ctx, "DROP DATABASE "+dbName+" WITH (FORCE)")
+	}
+
+	u.Path = "/" + dbName
+
+	return u.String(), cleanup, nil
+}
@@ -0,0 +1,72 @@
+Feature: ETL to PostgreSQL
+
+  Scenario: ETL Create to PostgreSQL
+    Given A running Golembase node with WAL enabled
+    And A running ETL to PostgreSQL
+    When I create a new entity in Golebase
+    Then the entity should be created in the PostgreSQL database
+    And the annotations of the entity should be existing in the PostgreSQL database
+    And the payload of the entity should not be stored as JSON in the PostgreSQL database
+
+  Scenario: Finding entities by their annotations
+    Given A running Golembase node with WAL enabled
+    And A running ETL to PostgreSQL
+    When I create a new entity in Golebase
+    Then the entity should be found by its annotations in the PostgreSQL database
+    And the entity should be found by a range of its numeric annotation in the PostgreSQL database
+
+  Scenario: ETL JSON payload to PostgreSQL
+    Given A running Golembase node with WAL enabled
+    And A running ETL to PostgreSQL
+    When I create an entity with a JSON payload in Golembase
+    Then the payload of the entity should be stored as JSON in the PostgreSQL database
+
+  Scenario: ETL Update to PostgreSQL
+    Given A running Golembase node with WAL enabled
+    And A running ETL to PostgreSQL
+    And an existing entity in the PostgreSQL database
+    When update the entity in Golembase
+    Then the entity should be updated in the PostgreSQL database
+
+  Scenario: ETL Delete to PostgreSQL
+    Given A running Golembase node with WAL enabled
+    And A running ETL to PostgreSQL
+    And an existing entity in the PostgreSQL database
+    When delete the entity in Golembase
+    Then the entity should be deleted in the PostgreSQL database
+
+  Scenario: Owner Address is Changed on Ownership Transfer
+    Given A running Golembase node with WAL enabled
+    And A running ETL to PostgreSQL
+    And an existing entity in the PostgreSQL database
+    When the ownership of the entity is transferred in Golembase
+    Then the new owner address should be stored in the PostgreSQL database
+
+  Scenario: ETL Patch to PostgreSQL
+    Given A running Golembase node with WAL enabled
+    And A running ETL to PostgreSQL
+    And an existing entity in the PostgreSQL database
+    When the entity is patched in Golembase
+    Then the patch should be applied in the PostgreSQL database
+
+  @stream
+  Scenario: ETL streaming the operations over RPC to PostgreSQL
+    Given A running Golembase node with WAL enabled
+    And A running ETL to PostgreSQL
+    When I create a new entity in Golebase
+    Then the entity should be created in the PostgreSQL database
+    And the annotations of the entity should be existing in the PostgreSQL database
+
+  Scenario: ETL acknowledging the processed blocks
+    Given A running Golembase node with WAL enabled
+    And A running ETL to PostgreSQL
+    When I create a new entity in Golebase
+    Then the ETL should acknowledge the block of the entity
+
+  @snapshot
+  Scenario: ETL starting from a snapshot
+    Given A running Golembase node with WAL enabled
+    And A running ETL to PostgreSQL
+    Then the entity of the snapshot should be in the PostgreSQL database
+    When I create a new entity in Golebase
+    Then the entity should be created in the PostgreSQL database
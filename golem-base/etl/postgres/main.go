@@ -0,0 +1,520 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	_ "embed"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"math/big"
+	"os"
+	"os/signal"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/ethclient"
+	"github.com/ethereum/go-ethereum/golem-base/etl/postgres/postgresgolem"
+	"github.com/ethereum/go-ethereum/golem-base/snapshot"
+	"github.com/ethereum/go-ethereum/golem-base/storageutil/entity"
+	"github.com/ethereum/go-ethereum/golem-base/wal"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/urfave/cli/v2"
+)
+
+//go:embed postgresgolem/schema.sql
+var schema string
+
+func main() {
+	log := slog.New(slog.NewTextHandler(os.Stdout, nil))
+	cfg := struct {
+		postgresURL string
+		walDir      string
+		segmentsDir string
+		rpcEndpoint string
+		consumer    string
+		snapshot    string
+	}{}
+	app := &cli.App{
+		Name: "postgres-etl",
+		Flags: []cli.Flag{
+			&cli.StringFlag{
+				Name:        "postgres-url",
+				Usage:       "PostgreSQL connection URL",
+				EnvVars:     []string{"POSTGRES_URL"},
+				Destination: &cfg.postgresURL,
+				Required:    true,
+			},
+			&cli.PathFlag{
+				Name:        "wal",
+				Usage:       "wal dir, if not set the operations are streamed from the RPC endpoint, which must support subscriptions",
+				EnvVars:     []string{"WAL_DIR"},
+				Destination: &cfg.walDir,
+			},
+			&cli.PathFlag{
+				Name:        "wal-segments",
+				Usage:       "dir of the wal in the binary segment format, written by op-geth with --golembase.writeaheadlog.segments, read instead of the wal dir",
+				EnvVars:     []string{"WAL_SEGMENTS_DIR"},
+				Destination: &cfg.segmentsDir,
+			},
+			&cli.StringFlag{
+				Name:        "rpc-endpoint",
+				Usage:       "RPC Endpoint for op-geth",
+				EnvVars:     []string{"RPC_ENDPOINT"},
+				Required:    true,
+				Destination: &cfg.rpcEndpoint,
+			},
+			&cli.StringFlag{
+				Name:        "consumer",
+				Usage:       "name to acknowledge the processed blocks with, so that the node only prunes them from the wal once they were processed",
+				EnvVars:     []string{"CONSUMER_NAME"},
+				Destination: &cfg.consumer,
+			},
+			&cli.PathFlag{
+				Name:        "snapshot",
+				Usage:       "snapshot file to load when the database is empty, the processing continues with the block after the snapshot",
+				EnvVars:     []string{"SNAPSHOT_FILE"},
+				Destination: &cfg.snapshot,
+			},
+		},
+		Action: func(c *cli.Context) error {
+
+			ctx, cancel := signal.NotifyContext(c.Context, os.Interrupt)
+			defer cancel()
+
+			db, err := pgxpool.New(ctx, cfg.postgresURL)
+			if err != nil {
+				return fmt.Errorf("failed to open database: %w", err)
+			}
+			defer db.Close()
+
+			var hasSchema bool
+			err = db.QueryRow(ctx, `
+				SELECT EXISTS (
+					SELECT FROM information_schema.tables
+					WHERE table_schema = current_schema() AND table_name = 'entities'
+				);
+			`).Scan(&hasSchema)
+			if err != nil {
+				return fmt.Errorf("failed to check the schema: %w", err)
+			}
+
+			if !hasSchema {
+				log.Info("could not find 'entities' table, applying schema")
+				_, err := db.Exec(ctx, schema)
+				if err != nil {
+					return fmt.Errorf("failed to apply schema table: %w", err)
+				}
+			}
+
+			autocommit := postgresgolem.New(db)
+
+			ec, err := ethclient.Dial(cfg.rpcEndpoint)
+			if err != nil {
+				return fmt.Errorf("failed to dial rpc endpoint: %w", err)
+			}
+
+			networkID, err := ec.NetworkID(ctx)
+			if err != nil {
+				return fmt.Errorf("failed to get network id: %w", err)
+			}
+
+			hasProcessingStatus, err := autocommit.HasProcessingStatus(ctx, networkID.String())
+			if err != nil {
+				return fmt.Errorf("failed to check if processing status exists: %w", err)
+			}
+
+			switch {
+			case hasProcessingStatus && cfg.snapshot != "":
+				log.Info("processing status found, ignoring the snapshot", "snapshot", cfg.snapshot)
+			case cfg.snapshot != "":
+				log.Info("no processing status found, loading snapshot", "snapshot", cfg.snapshot)
+
+				// the snapshot records the chain id, which can differ from the network id
+				chainID, err := ec.ChainID(ctx)
+				if err != nil {
+					return fmt.Errorf("failed to get chain id: %w", err)
+				}
+
+				err = loadSnapshot(ctx, db, cfg.snapshot, chainID, networkID)
+				if err != nil {
+					return fmt.Errorf("failed to load snapshot: %w", err)
+				}
+			case !hasProcessingStatus:
+				log.Info("no processing status found, inserting genesis block")
+
+				genesisHeader, err := ec.HeaderByNumber(ctx, big.NewInt(0))
+				if err != nil {
+					return fmt.Errorf("failed to get genesis header: %w", err)
+				}
+
+				err = autocommit.InsertProcessingStatus(ctx, postgresgolem.InsertProcessingStatusParams{
+					Network:                  networkID.String(),
+					LastProcessedBlockNumber: 0,
+					LastProcessedBlockHash:   genesisHeader.Hash().String(),
+				})
+				if err != nil {
+					return fmt.Errorf("failed to insert processing status: %w", err)
+				}
+			}
+
+			processingStatus, err := autocommit.GetProcessingStatus(ctx, networkID.String())
+			if err != nil {
+				return fmt.Errorf("failed to get processing status: %w", err)
+			}
+
+			blockNumber := processingStatus.LastProcessedBlockNumber
+			blockHash := processingStatus.LastProcessedBlockHash
+
+			blocks := wal.NewIterator(ctx, cfg.walDir, uint64(blockNumber)+1, common.HexToHash(blockHash), true)
+			switch {
+			case cfg.segmentsDir != "":
+				log.Info("reading the wal segments", "dir", cfg.segmentsDir)
+				blocks = wal.NewSegmentIterator(ctx, cfg.segmentsDir, uint64(blockNumber)+1, common.HexToHash(blockHash), true)
+			case cfg.walDir == "":
+				log.Info("no wal dir, streaming operations from the rpc endpoint")
+				blocks = wal.NewRPCIterator(ctx, ec.Client(), uint64(blockNumber)+1, common.HexToHash(blockHash))
+			}
+
+			for blockWal, err := range blocks {
+				if err != nil {
+					return fmt.Errorf("failed to iterate over wal: %w", err)
+				}
+
+				err = func() (err error) {
+					log.Info("processing block", "block", blockWal.BlockInfo.Number, "revert", blockWal.Revert)
+					tx, err := db.Begin(ctx)
+					if err != nil {
+						return fmt.Errorf("failed to begin transaction: %w", err)
+					}
+
+					defer func() {
+						if err != nil {
+							err = errors.Join(err, tx.Rollback(ctx))
+						}
+					}()
+
+					txDB := postgresgolem.New(tx)
+
+					for op, err := range blockWal.OperationsIterator {
+						if err != nil {
+							return fmt.Errorf("failed to iterate over operations: %w", err)
+						}
+
+						switch {
+						case op.Create != nil:
+							log.Info("create", "entity", op.Create.EntityKey.Hex())
+							err = insertEntity(ctx, txDB, *op.Create)
+							if err != nil {
+								return err
+							}
+						case op.Update != nil:
+							stringAnnotations, err := stringAnnotationsJSON(op.Update.StringAnnotations)
+							if err != nil {
+								return err
+							}
+
+							payload := op.Update.Payload
+							if payload == nil {
+								payload = []byte{}
+							}
+
+							err = txDB.ReplaceEntity(ctx, postgresgolem.ReplaceEntityParams{
+								Key:               op.Update.EntityKey.Hex(),
+								ExpiresAt:         int64(op.Update.ExpiresAtBlock),
+								Payload:           payload,
+								PayloadJson:       payloadJSON(payload),
+								StringAnnotations: stringAnnotations,
+							})
+							if err != nil {
+								return fmt.Errorf("failed to update entity: %w", err)
+							}
+
+							err = txDB.DeleteNumericAnnotations(ctx, op.Update.EntityKey.Hex())
+							if err != nil {
+								return fmt.Errorf("failed to delete numeric annotations: %w", err)
+							}
+
+							err = setNumericAnnotations(ctx, txDB, op.Update.EntityKey, op.Update.NumericAnnotations)
+							if err != nil {
+								return err
+							}
+						case op.Delete != nil:
+							err = txDB.DeleteEntity(ctx, op.Delete.Hex())
+							if err != nil {
+								return fmt.Errorf("failed to delete entity: %w", err)
+							}
+						case op.Expire != nil:
+							err = txDB.DeleteEntity(ctx, op.Expire.Hex())
+							if err != nil {
+								return fmt.Errorf("failed to delete expired entity: %w", err)
+							}
+						case op.TransferOwnership != nil:
+							err = txDB.UpdateEntityOwner(ctx, postgresgolem.UpdateEntityOwnerParams{
+								Key:          op.TransferOwnership.EntityKey.Hex(),
+								OwnerAddress: op.TransferOwnership.NewOwner.Hex(),
+							})
+							if err != nil {
+								return fmt.Errorf("failed to update entity owner: %w", err)
+							}
+						case op.Extend != nil:
+							err = txDB.UpdateEntityExpiresAt(ctx, postgresgolem.UpdateEntityExpiresAtParams{
+								Key:       op.Extend.EntityKey.Hex(),
+								ExpiresAt: int64(op.Extend.ExpiresAtBlock),
+							})
+							if err != nil {
+								return fmt.Errorf("failed to update entity expiration: %w", err)
+							}
+						case op.Patch != nil:
+							err = applyPatch(ctx, txDB, *op.Patch)
+							if err != nil {
+								return err
+							}
+						}
+
+						log.Info("operation", "operation", op)
+					}
+
+					// a reverted block leaves its parent as the last processed block
+					processedNumber, processedHash := blockWal.ProcessedBlock()
+					err = txDB.UpdateProcessingStatus(ctx, postgresgolem.UpdateProcessingStatusParams{
+						Network:                  networkID.String(),
+						LastProcessedBlockNumber: int64(processedNumber),
+						LastProcessedBlockHash:   processedHash.String(),
+					})
+					if err != nil {
+						return fmt.Errorf("failed to update processing status: %w", err)
+					}
+
+					return tx.Commit(ctx)
+
+				}()
+
+				if err != nil {
+					return fmt.Errorf("failed to process block: %w", err)
+				}
+
+				if cfg.consumer != "" {
+					processedNumber, processedHash := blockWal.ProcessedBlock()
+					checkpoint := wal.Checkpoint{
+						BlockNumber: processedNumber,
+						BlockHash:   processedHash,
+					}
+
+					if cfg.walDir != "" {
+						err = wal.WriteCheckpoint(cfg.walDir, cfg.consumer, checkpoint)
+					} else {
+						err = wal.AcknowledgeOperations(ctx, ec.Client(), cfg.consumer, checkpoint)
+					}
+					if err != nil {
+						return fmt.Errorf("failed to acknowledge block: %w", err)
+					}
+				}
+
+			}
+
+			return nil
+		},
+	}
+
+	err := app.Run(os.Args)
+	if err != nil {
+		log.Error("failed to run app", "error", err)
+		os.Exit(1)
+	}
+}
+
+// payloadJSON returns the payload if it is a JSON document that can be stored as JSONB, otherwise nil.
+// The payload is normalized by decoding it, which replaces invalid unicode escapes that JSONB rejects.
+func payloadJSON(payload []byte) []byte {
+	dec := json.NewDecoder(bytes.NewReader(payload))
+	dec.UseNumber()
+
+	var v any
+	if dec.Decode(&v) != nil || dec.More() {
+		return nil
+	}
+
+	normalized, err := json.Marshal(v)
+	if err != nil {
+		return nil
+	}
+
+	// JSONB can not hold NUL characters
+	if bytes.Contains(normalized, []byte(`\u0000`)) {
+		return nil
+	}
+
+	return normalized
+}
+
+// stringAnnotationsJSON returns the string annotations as a JSON object mapping the annotation keys to their values.
+func stringAnnotationsJSON(stringAnnotations []entity.StringAnnotation) ([]byte, error) {
+	stringMap := make(map[string]string, len(stringAnnotations))
+	for _, annotation := range stringAnnotations {
+		stringMap[annotation.Key] = annotation.Value
+	}
+
+	stringJSON, err := json.Marshal(stringMap)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode string annotations: %w", err)
+	}
+
+	return stringJSON, nil
+}
+
+// setNumericAnnotations inserts the numeric annotations of the entity, replacing the values of existing ones.
+func setNumericAnnotations(ctx context.Context, txDB *postgresgolem.Queries, key common.Hash, numericAnnotations []entity.NumericAnnotation) error {
+	for _, annotation := range numericAnnotations {
+		err := txDB.SetNumericAnnotation(ctx, postgresgolem.SetNumericAnnotationParams{
+			EntityKey:     key.Hex(),
+			AnnotationKey: annotation.Key,
+			Value:         int64(annotation.Value),
+		})
+		if err != nil {
+			return fmt.Errorf("failed to set numeric annotation: %w", err)
+		}
+	}
+
+	return nil
+}
+
+func insertEntity(ctx context.Context, txDB *postgresgolem.Queries, create wal.Create) error {
+	stringAnnotations, err := stringAnnotationsJSON(create.StringAnnotations)
+	if err != nil {
+		return err
+	}
+
+	payload := create.Payload
+	if payload == nil {
+		payload = []byte{}
+	}
+
+	err = txDB.InsertEntity(ctx, postgresgolem.InsertEntityParams{
+		Key:               create.EntityKey.Hex(),
+		ExpiresAt:         int64(create.ExpiresAtBlock),
+		Payload:           payload,
+		PayloadJson:       payloadJSON(payload),
+		OwnerAddress:      create.Owner.Hex(),
+		StringAnnotations: stringAnnotations,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to insert entity: %w", err)
+	}
+
+	return setNumericAnnotations(ctx, txDB, create.EntityKey, create.NumericAnnotations)
+}
+
+func applyPatch(ctx context.Context, txDB *postgresgolem.Queries, patch wal.Patch) error {
+	key := patch.EntityKey.Hex()
+
+	err := txDB.UpdateEntityExpiresAt(ctx, postgresgolem.UpdateEntityExpiresAtParams{
+		Key:       key,
+		ExpiresAt: int64(patch.ExpiresAtBlock),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to update entity expiration: %w", err)
+	}
+
+	if patch.ReplacePayload {
+		payload := patch.Payload
+		if payload == nil {
+			payload = []byte{}
+		}
+
+		err = txDB.UpdateEntityPayload(ctx, postgresgolem.UpdateEntityPayloadParams{
+			Key:         key,
+			Payload:     payload,
+			PayloadJson: payloadJSON(payload),
+		})
+		if err != nil {
+			return fmt.Errorf("failed to update entity payload: %w", err)
+		}
+	}
+
+	if len(patch.RemoveStringAnnotations) > 0 {
+		err = txDB.RemoveStringAnnotations(ctx, postgresgolem.RemoveStringAnnotationsParams{
+			Key:            key,
+			AnnotationKeys: patch.RemoveStringAnnotations,
+		})
+		if err != nil {
+			return fmt.Errorf("failed to delete string annotations: %w", err)
+		}
+	}
+
+	if len(patch.RemoveNumericAnnotations) > 0 {
+		err = txDB.RemoveNumericAnnotations(ctx, postgresgolem.RemoveNumericAnnotationsParams{
+			Key:            key,
+			AnnotationKeys: patch.RemoveNumericAnnotations,
+		})
+		if err != nil {
+			return fmt.Errorf("failed to delete numeric annotations: %w", err)
+		}
+	}
+
+	stringAnnotations, err := stringAnnotationsJSON(patch.SetStringAnnotations)
+	if err != nil {
+		return err
+	}
+
+	if len(patch.SetStringAnnotations) > 0 {
+		err = txDB.SetStringAnnotations(ctx, postgresgolem.SetStringAnnotationsParams{
+			Key:         key,
+			Annotations: stringAnnotations,
+		})
+		if err != nil {
+			return fmt.Errorf("failed to set string annotations: %w", err)
+		}
+	}
+
+	return setNumericAnnotations(ctx, txDB, patch.EntityKey, patch.SetNumericAnnotations)
+}
+
+// loadSnapshot inserts the entities of the snapshot and records its block as the last processed block,
+// all in one transaction, so that an interrupted load leaves the database empty.
+func loadSnapshot(ctx context.Context, db *pgxpool.Pool, path string, chainID, networkID *big.Int) (err error) {
+	r, err := snapshot.Open(path)
+	if err != nil {
+		return err
+	}
+	defer r.Close()
+
+	if r.Header.ChainID != nil && r.Header.ChainID.Cmp(chainID) != 0 {
+		return fmt.Errorf("snapshot of chain %s, expected %s", r.Header.ChainID, chainID)
+	}
+
+	tx, err := db.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+
+	defer func() {
+		if err != nil {
+			err = errors.Join(err, tx.Rollback(ctx))
+		}
+	}()
+
+	txDB := postgresgolem.New(tx)
+
+	for e, err := range r.Entities() {
+		if err != nil {
+			return err
+		}
+
+		err = insertEntity(ctx, txDB, e)
+		if err != nil {
+			return err
+		}
+	}
+
+	err = txDB.InsertProcessingStatus(ctx, postgresgolem.InsertProcessingStatusParams{
+		Network:                  networkID.String(),
+		LastProcessedBlockNumber: int64(r.Header.BlockNumber),
+		LastProcessedBlockHash:   r.Header.BlockHash.String(),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to insert processing status: %w", err)
+	}
+
+	return tx.Commit(ctx)
+}
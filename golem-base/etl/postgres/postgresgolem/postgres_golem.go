@@ -0,0 +1,3 @@
+package postgresgolem
+
+//go:generate sqlc generate
@@ -0,0 +1,78 @@
+-- name: InsertEntity :exec
+INSERT INTO entities (key, expires_at, payload, payload_json, owner_address, string_annotations)
+VALUES ($1, $2, $3, $4, $5, $6);
+
+-- name: GetEntity :one
+SELECT expires_at, payload, payload_json, owner_address, string_annotations FROM entities WHERE key = $1;
+
+-- name: GetNumericAnnotations :many
+SELECT annotation_key, value FROM numeric_annotations WHERE entity_key = $1 ORDER BY annotation_key;
+
+-- name: GetEntitiesByOwner :many
+SELECT key, expires_at, payload FROM entities WHERE owner_address = $1;
+
+-- name: GetEntitiesByStringAnnotation :many
+SELECT key FROM entities
+WHERE string_annotations @> jsonb_build_object(sqlc.arg(annotation_key)::text, sqlc.arg(value)::text)
+ORDER BY key;
+
+-- name: GetEntitiesByNumericAnnotation :many
+SELECT entity_key FROM numeric_annotations
+WHERE annotation_key = $1 AND value = $2
+ORDER BY entity_key;
+
+-- name: GetEntitiesByNumericAnnotationRange :many
+SELECT entity_key FROM numeric_annotations
+WHERE annotation_key = sqlc.arg(annotation_key) AND value >= sqlc.arg(min_value) AND value <= sqlc.arg(max_value)
+ORDER BY value, entity_key;
+
+-- name: ReplaceEntity :exec
+UPDATE entities
+SET expires_at = $2, payload = $3, payload_json = $4, string_annotations = $5
+WHERE key = $1;
+
+-- name: UpdateEntityOwner :exec
+UPDATE entities SET owner_address = $2 WHERE key = $1;
+
+-- name: UpdateEntityExpiresAt :exec
+UPDATE entities SET expires_at = $2 WHERE key = $1;
+
+-- name: UpdateEntityPayload :exec
+UPDATE entities SET payload = $2, payload_json = $3 WHERE key = $1;
+
+-- name: SetStringAnnotations :exec
+UPDATE entities SET string_annotations = string_annotations || sqlc.arg(annotations)::jsonb WHERE key = sqlc.arg(key);
+
+-- name: SetNumericAnnotation :exec
+INSERT INTO numeric_annotations (entity_key, annotation_key, value) VALUES ($1, $2, $3)
+ON CONFLICT (entity_key, annotation_key) DO UPDATE SET value = EXCLUDED.value;
+
+-- name: RemoveStringAnnotations :exec
+UPDATE entities SET string_annotations = string_annotations - sqlc.arg(annotation_keys)::text[] WHERE key = sqlc.arg(key);
+
+-- name: RemoveNumericAnnotations :exec
+DELETE FROM numeric_annotations WHERE entity_key = sqlc.arg(key) AND annotation_key = ANY(sqlc.arg(annotation_keys)::text[]);
+
+-- name: DeleteNumericAnnotations :exec
+DELETE FROM numeric_annotations WHERE entity_key = $1;
+
+-- name: DeleteEntity :exec
+DELETE FROM entities WHERE key = $1;
+
+-- name: EntityExists :one
+SELECT COUNT(*) > 0 FROM entities WHERE key = $1;
+
+-- name: GetProcessingStatus :one
+SELECT last_processed_block_number, last_processed_block_hash FROM processing_status WHERE network = $1;
+
+-- name: UpdateProcessingStatus :exec
+UPDATE processing_status SET last_processed_block_number = $2, last_processed_block_hash = $3 WHERE network = $1;
+
+-- name: InsertProcessingStatus :exec
+INSERT INTO processing_status (network, last_processed_block_number, last_processed_block_hash) VALUES ($1, $2, $3);
+
+-- name: HasProcessingStatus :one
+SELECT COUNT(*) > 0 FROM processing_status WHERE network = $1;
+
+-- name: DeleteProcessingStatus :exec
+DELETE FROM processing_status WHERE network = $1;
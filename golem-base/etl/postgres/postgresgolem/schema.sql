@@ -0,0 +1,31 @@
+CREATE TABLE processing_status (
+  network TEXT NOT NULL PRIMARY KEY,
+  last_processed_block_number BIGINT NOT NULL,
+  last_processed_block_hash TEXT NOT NULL
+);
+
+-- payload_json holds the payload if it is a JSON document, otherwise it is NULL.
+-- The string annotations are stored as a JSON object mapping the annotation keys to their values.
+CREATE TABLE entities (
+  key TEXT NOT NULL PRIMARY KEY,
+  expires_at BIGINT NOT NULL,
+  payload BYTEA NOT NULL,
+  payload_json JSONB,
+  owner_address TEXT NOT NULL,
+  string_annotations JSONB NOT NULL DEFAULT '{}'
+);
+
+-- The numeric annotations have their own table, the GIN index of a JSONB column only serves
+-- containment, the btree index on the values also serves range queries.
+CREATE TABLE numeric_annotations (
+  entity_key TEXT NOT NULL REFERENCES entities(key) ON DELETE CASCADE,
+  annotation_key TEXT NOT NULL,
+  value BIGINT NOT NULL,
+  PRIMARY KEY (entity_key, annotation_key)
+);
+
+CREATE INDEX idx_entities_owner_address ON entities(owner_address);
+CREATE INDEX idx_entities_expires_at ON entities(expires_at);
+CREATE INDEX idx_entities_payload_json ON entities USING GIN (payload_json jsonb_path_ops);
+CREATE INDEX idx_entities_string_annotations ON entities USING GIN (string_annotations jsonb_path_ops);
+CREATE INDEX idx_numeric_annotations_value ON numeric_annotations(annotation_key, value);
@@ -0,0 +1,87 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/golem-base/golemtype"
+	"github.com/ethereum/go-ethereum/golem-base/query"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+var errBlockNotAvailable = errors.New("block not available")
+
+// golemBaseAPI serves the read-only golembase_* methods from the database of an ETL.
+type golemBaseAPI struct {
+	backend      backend
+	queryResults *query.ResultCache
+}
+
+// checkBlock fails unless the requested block is the last block processed by the ETL,
+// which is the only state the database holds.
+func checkBlock(blockNrOrHash *rpc.BlockNumberOrHash, blockNumber uint64, blockHash common.Hash) error {
+	if blockNrOrHash == nil {
+		return nil
+	}
+
+	if hash, ok := blockNrOrHash.Hash(); ok && hash != blockHash {
+		return fmt.Errorf("%w: %s, the last processed block is %d (%s)", errBlockNotAvailable, hash.Hex(), blockNumber, blockHash.Hex())
+	}
+
+	if number, ok := blockNrOrHash.Number(); ok && number >= 0 && uint64(number) != blockNumber {
+		return fmt.Errorf("%w: %d, the last processed block is %d", errBlockNotAvailable, number, blockNumber)
+	}
+
+	return nil
+}
+
+// QueryEntities returns all the entities matching the query, like golembase_queryEntities of the node.
+func (api *golemBaseAPI) QueryEntities(ctx context.Context, req string, blockNrOrHash *rpc.BlockNumberOrHash) ([]golemtype.SearchResult, error) {
+	result, err := api.QueryEntitiesPage(ctx, req, blockNrOrHash, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	searchResults := make([]golemtype.SearchResult, 0, len(result.Results))
+	for _, r := range result.Results {
+		searchResults = append(searchResults, r.SearchResult)
+	}
+
+	return searchResults, nil
+}
+
+// QueryEntitiesPage returns a page of the entities matching the query, like golembase_queryEntitiesPage of the node.
+// The results are read from the last block processed by the ETL, the block parameter can only select it.
+// A cursor can only be used until the ETL processes the next block.
+func (api *golemBaseAPI) QueryEntitiesPage(ctx context.Context, req string, blockNrOrHash *rpc.BlockNumberOrHash, options *golemtype.QueryOptions) (*golemtype.QueryResult, error) {
+	q, err := query.NewEntitiesQuery(req, options)
+	if err != nil {
+		return nil, err
+	}
+
+	var result *golemtype.QueryResult
+
+	err = api.backend.withEntities(ctx, func(store query.EntityStore, blockNumber uint64, blockHash common.Hash) error {
+		// all the pages are read from the same block
+		if cursorBlockHash, ok := q.CursorBlockHash(); ok {
+			if cursorBlockHash != blockHash {
+				return errors.New("the block of the cursor is no longer available, the query has to be started again")
+			}
+		} else {
+			err := checkBlock(blockNrOrHash, blockNumber, blockHash)
+			if err != nil {
+				return err
+			}
+		}
+
+		result, err = q.Run(store, blockHash, blockNumber, api.queryResults)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
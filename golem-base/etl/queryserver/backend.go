@@ -0,0 +1,81 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/golem-base/etl/mongodb/mongogolem"
+	"github.com/ethereum/go-ethereum/golem-base/etl/sqlite/sqlitegolem"
+	"github.com/ethereum/go-ethereum/golem-base/query"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// entitiesFunc is called with the entities of the last block processed by the ETL.
+type entitiesFunc func(store query.EntityStore, blockNumber uint64, blockHash common.Hash) error
+
+// backend reads the entities from the database of an ETL.
+type backend interface {
+	// withEntities calls fn with a consistent view of the entities, which the ETL
+	// does not change while fn runs.
+	withEntities(ctx context.Context, fn entitiesFunc) error
+}
+
+// sqliteBackend reads the database of the SQLite ETL.
+type sqliteBackend struct {
+	db      *sql.DB
+	network string
+}
+
+func (b *sqliteBackend) withEntities(ctx context.Context, fn entitiesFunc) (err error) {
+	// a read transaction sees the database as it was when it started
+	tx, err := b.db.BeginTx(ctx, &sql.TxOptions{ReadOnly: true})
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	q := sqlitegolem.New(tx)
+
+	status, err := q.GetProcessingStatus(ctx, b.network)
+	if err != nil {
+		return fmt.Errorf("failed to get processing status: %w", err)
+	}
+
+	return fn(
+		sqlitegolem.NewDataSource(ctx, q),
+		uint64(status.LastProcessedBlockNumber),
+		common.HexToHash(status.LastProcessedBlockHash),
+	)
+}
+
+// mongoBackend reads the database of the MongoDB ETL.
+type mongoBackend struct {
+	client  *mongo.Client
+	db      *mongogolem.MongoGolem
+	network string
+}
+
+func (b *mongoBackend) withEntities(ctx context.Context, fn entitiesFunc) error {
+	// a snapshot session sees the database as it was at a single point in time
+	session, err := b.client.StartSession(options.Session().SetSnapshot(true))
+	if err != nil {
+		return fmt.Errorf("failed to start session: %w", err)
+	}
+	defer session.EndSession(ctx)
+
+	return mongo.WithSession(ctx, session, func(sc mongo.SessionContext) error {
+		status, err := b.db.GetProcessingStatus(sc, b.network)
+		if err != nil {
+			return err
+		}
+
+		return fn(
+			mongogolem.NewDataSource(sc, b.db),
+			uint64(status.LastProcessedBlockNumber),
+			common.HexToHash(status.LastProcessedBlockHash),
+		)
+	})
+}
@@ -0,0 +1,151 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"os"
+	"os/signal"
+	"time"
+
+	"github.com/ethereum/go-ethereum/golem-base/etl/mongodb/mongogolem"
+	"github.com/ethereum/go-ethereum/golem-base/query"
+	"github.com/ethereum/go-ethereum/rpc"
+	_ "github.com/mattn/go-sqlite3"
+	"github.com/urfave/cli/v2"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+func main() {
+	log := slog.New(slog.NewTextHandler(os.Stdout, nil))
+	cfg := struct {
+		listen    string
+		networkID string
+		dbFile    string
+		mongoURI  string
+		dbName    string
+	}{}
+	app := &cli.App{
+		Name:  "query-server",
+		Usage: "serves golembase_queryEntities from the database of the SQLite or MongoDB ETL",
+		Flags: []cli.Flag{
+			&cli.StringFlag{
+				Name:        "listen",
+				Usage:       "address to serve the JSON-RPC API on",
+				EnvVars:     []string{"LISTEN_ADDR"},
+				Value:       "localhost:8547",
+				Destination: &cfg.listen,
+			},
+			&cli.StringFlag{
+				Name:        "network-id",
+				Usage:       "network id of the chain the ETL processes",
+				EnvVars:     []string{"NETWORK_ID"},
+				Required:    true,
+				Destination: &cfg.networkID,
+			},
+			&cli.StringFlag{
+				Name:        "db",
+				Usage:       "database file of the SQLite ETL",
+				EnvVars:     []string{"DB_FILE"},
+				Destination: &cfg.dbFile,
+			},
+			&cli.StringFlag{
+				Name:        "mongo-uri",
+				Usage:       "MongoDB connection URI of the MongoDB ETL",
+				EnvVars:     []string{"MONGO_URI"},
+				Destination: &cfg.mongoURI,
+			},
+			&cli.StringFlag{
+				Name:        "db-name",
+				Usage:       "MongoDB database name of the MongoDB ETL",
+				EnvVars:     []string{"DB_NAME"},
+				Value:       "golembase",
+				Destination: &cfg.dbName,
+			},
+		},
+		Action: func(c *cli.Context) error {
+
+			ctx, cancel := signal.NotifyContext(c.Context, os.Interrupt)
+			defer cancel()
+
+			var b backend
+
+			switch {
+			case cfg.dbFile != "" && cfg.mongoURI != "":
+				return errors.New("only one of --db and --mongo-uri can be set")
+			case cfg.dbFile != "":
+				db, err := sql.Open("sqlite3", fmt.Sprintf("file:%s?mode=ro&_journal_mode=WAL", cfg.dbFile))
+				if err != nil {
+					return fmt.Errorf("failed to open database: %w", err)
+				}
+				defer db.Close()
+
+				err = db.PingContext(ctx)
+				if err != nil {
+					return fmt.Errorf("failed to open database: %w", err)
+				}
+
+				b = &sqliteBackend{db: db, network: cfg.networkID}
+			case cfg.mongoURI != "":
+				client, err := mongo.Connect(ctx, options.Client().ApplyURI(cfg.mongoURI))
+				if err != nil {
+					return fmt.Errorf("failed to connect to MongoDB: %w", err)
+				}
+				defer func() {
+					if err := client.Disconnect(context.Background()); err != nil {
+						log.Error("failed to disconnect from MongoDB", "error", err)
+					}
+				}()
+
+				err = client.Ping(ctx, nil)
+				if err != nil {
+					return fmt.Errorf("failed to ping MongoDB: %w", err)
+				}
+
+				b = &mongoBackend{client: client, db: mongogolem.New(client.Database(cfg.dbName)), network: cfg.networkID}
+			default:
+				return errors.New("one of --db and --mongo-uri has to be set")
+			}
+
+			rpcServer := rpc.NewServer()
+			defer rpcServer.Stop()
+
+			err := rpcServer.RegisterName("golembase", &golemBaseAPI{backend: b, queryResults: query.NewResultCache(64)})
+			if err != nil {
+				return fmt.Errorf("failed to register API: %w", err)
+			}
+
+			server := &http.Server{
+				Addr:              cfg.listen,
+				Handler:           rpcServer,
+				ReadHeaderTimeout: 10 * time.Second,
+			}
+
+			go func() {
+				<-ctx.Done()
+				shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+				defer cancel()
+				server.Shutdown(shutdownCtx)
+			}()
+
+			log.Info("serving golembase API", "address", cfg.listen)
+
+			err = server.ListenAndServe()
+			if err != nil && !errors.Is(err, http.ErrServerClosed) {
+				return fmt.Errorf("failed to serve: %w", err)
+			}
+
+			return nil
+		},
+	}
+
+	err := app.Run(os.Args)
+	if err != nil {
+		log.Error("failed to run app", "error", err)
+		os.Exit(1)
+	}
+}
@@ -1,23 +1,28 @@
 package main_test
 
 import (
-	"bytes"
 	"context"
 	"database/sql"
 	"fmt"
 	"log"
+	"math"
+	"math/big"
 	"os"
-	"os/exec"
-	"path/filepath"
+	"slices"
+	"strings"
 	"testing"
 	"time"
 
 	"github.com/cenkalti/backoff/v4"
 	"github.com/cucumber/godog"
 	"github.com/cucumber/godog/colors"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/golem-base/etl/etltest"
 	"github.com/ethereum/go-ethereum/golem-base/etl/sqlite/etlworld"
 	"github.com/ethereum/go-ethereum/golem-base/etl/sqlite/sqlitegolem"
+	"github.com/ethereum/go-ethereum/golem-base/golemtype"
 	"github.com/ethereum/go-ethereum/golem-base/storageutil/entity"
+	"github.com/ethereum/go-ethereum/golem-base/testutil"
 	"github.com/google/go-cmp/cmp"
 	"github.com/spf13/pflag" // godog v0.11.0 and later
 )
@@ -41,71 +46,51 @@ func init() {
 	}
 }
 
-func compileGeth() (string, func(), error) {
-	td, err := os.MkdirTemp("", "golem-base")
-	if err != nil {
-		panic(fmt.Errorf("failed to create temp dir: %w", err))
-	}
-
-	gethBinaryPath := filepath.Join(td, "geth")
-
-	cmd := exec.Command("go", "build", "-o", gethBinaryPath, "../../../cmd/geth")
-	out := &bytes.Buffer{}
-	cmd.Stdout = out
-	cmd.Stderr = out
-	err = cmd.Run()
-	if err != nil {
-		return "", func() {}, fmt.Errorf("failed to compile geth: %w\n%s", err, out.String())
-	}
-
-	return gethBinaryPath, func() {
-		os.RemoveAll(td)
-	}, nil
-}
-
-func compileSqliteETL() (string, func(), error) {
-	td, err := os.MkdirTemp("", "sqlite-etl")
-	if err != nil {
-		panic(fmt.Errorf("failed to create temp dir: %w", err))
-	}
-
-	sqliteETLBinaryPath := filepath.Join(td, "sqlite-etl")
-
-	cmd := exec.Command("go", "build", "-o", sqliteETLBinaryPath, ".")
-	out := &bytes.Buffer{}
-	cmd.Stdout = out
-	cmd.Stderr = out
-	err = cmd.Run()
-	if err != nil {
-		return "", func() {}, fmt.Errorf("failed to compile sqlite-etl: %w\n%s", err, out.String())
-	}
-
-	return sqliteETLBinaryPath, func() {
-		os.RemoveAll(td)
-	}, nil
-}
+// queryServerPath is the path of the compiled query server
+var queryServerPath string
 
 func TestMain(m *testing.M) {
 	pflag.Parse()
 	opts.Paths = pflag.Args()
 
-	gethPath, cleanupCompiled, err := compileGeth()
+	gethPath, cleanupCompiled, err := etltest.CompileGeth()
 	if err != nil {
 		log.Fatal(fmt.Errorf("failed to compile geth: %w", err))
 	}
 
-	sqliteETLPath, cleanupCompiledSQLiteETL, err := compileSqliteETL()
+	sqliteETLPath, cleanupCompiledSQLiteETL, err := etltest.Compile("sqlite-etl", ".")
 	if err != nil {
 		log.Fatal(fmt.Errorf("failed to compile geth: %w", err))
 	}
 
+	var cleanupCompiledQueryServer func()
+	queryServerPath, cleanupCompiledQueryServer, err = etltest.Compile("query-server", "../queryserver")
+	if err != nil {
+		log.Fatal(err)
+	}
+
 	suite := godog.TestSuite{
 		Name: "cucumber",
 		ScenarioInitializer: func(sctx *godog.ScenarioContext) {
 			InitializeScenario(sctx)
 			sctx.Before(func(ctx context.Context, sc *godog.Scenario) (context.Context, error) {
 
-				world, err := etlworld.NewETLWorld(ctx, gethPath, sqliteETLPath)
+				source := etlworld.FromWALDir
+				start := etlworld.FromGenesis
+				for _, tag := range sc.Tags {
+					switch tag.Name {
+					case "@stream":
+						source = etlworld.FromSubscription
+					case "@segments":
+						source = etlworld.FromSegments
+					case "@snapshot":
+						start = etlworld.FromSnapshot
+					case "@legacy":
+						start = etlworld.FromLegacyDatabase
+					}
+				}
+
+				world, err := etlworld.NewETLWorld(ctx, gethPath, sqliteETLPath, source, start)
 				if err != nil {
 					return ctx, fmt.Errorf("failed to start geth instance: %w", err)
 				}
@@ -136,25 +121,42 @@ func TestMain(m *testing.M) {
 
 	cleanupCompiled()
 	cleanupCompiledSQLiteETL()
+	cleanupCompiledQueryServer()
 
 	os.Exit(status)
 }
 
 func InitializeScenario(ctx *godog.ScenarioContext) {
+	etltest.Steps{
+		World:        func(ctx context.Context) *testutil.World { return etlworld.GetWorld(ctx).World },
+		ConsumerName: etlworld.ConsumerName,
+	}.Register(ctx)
+
 	ctx.Step(`^A running ETL to SQLite$`, aRunningETLToSQLite)
-	ctx.Step(`^A running Golembase node with WAL enabled$`, aRunningGolembaseNodeWithWALEnabled)
 	ctx.Step(`^I create a new entity in Golebase$`, iCreateANewEntityInGolebase)
 	ctx.Step(`^the entity should be created in the SQLite database$`, theEntityShouldBeCreatedInTheSQLiteDatabase)
 	ctx.Step(`^the annotations of the entity should be existing in the SQLite database$`, theAnnotationsOfTheEntityShouldBeExistingInTheSQLiteDatabase)
-	ctx.Step(`^an existing entity in the SQLite database$`, anExistingEntityInTheSQLiteDatabase)
+	ctx.Step(`^an existing entity in the SQLite database$`, iCreateANewEntityInGolebase)
 	ctx.Step(`^the annotations of the entity should be updated in the SQLite database$`, theAnnotationsOfTheEntityShouldBeUpdatedInTheSQLiteDatabase)
 	ctx.Step(`^the entity should be updated in the SQLite database$`, theEntityShouldBeUpdatedInTheSQLiteDatabase)
-	ctx.Step(`^update the entity in Golembase$`, updateTheEntityInGolembase)
-	ctx.Step(`^delete the entity in Golembase$`, deleteTheEntityInGolembase)
 	ctx.Step(`^the annotations of the entity should be deleted in the SQLite database$`, theAnnotationsOfTheEntityShouldBeDeletedInTheSQLiteDatabase)
 	ctx.Step(`^the entity should be deleted in the SQLite database$`, theEntityShouldBeDeletedInTheSQLiteDatabase)
 	ctx.Step(`^the owner address should be stored in the SQLite database$`, theOwnerAddressShouldBeStoredInTheSQLiteDatabase)
 	ctx.Step(`^the owner address should be preserved in the SQLite database$`, theOwnerAddressShouldBePreservedInTheSQLiteDatabase)
+	ctx.Step(`^the new owner address should be stored in the SQLite database$`, theNewOwnerAddressShouldBeStoredInTheSQLiteDatabase)
+	ctx.Step(`^the patch should be applied in the SQLite database$`, thePatchShouldBeAppliedInTheSQLiteDatabase)
+	ctx.Step(`^the entity of the snapshot should be in the SQLite database$`, theEntityOfTheSnapshotShouldBeInTheSQLiteDatabase)
+	ctx.Step(`^the history of the entity should have the events "([^"]*)"$`, theHistoryOfTheEntityShouldHaveTheEvents)
+	ctx.Step(`^the SQLite database should have the indexes of the annotation values$`, theSQLiteDatabaseShouldHaveTheIndexesOfTheAnnotationValues)
+	ctx.Step(`^the entity should have its created payload at the block of its creation$`, theEntityShouldHaveItsCreatedPayloadAtTheBlockOfItsCreation)
+	ctx.Step(`^the entity should have its updated payload at the latest block$`, theEntityShouldHaveItsUpdatedPayloadAtTheLatestBlock)
+	ctx.Step(`^the entity should not be live at the latest block$`, theEntityShouldNotBeLiveAtTheLatestBlock)
+	ctx.Step(`^I create an entity that expires in the next block in Golembase$`, iCreateAnEntityThatExpiresInTheNextBlockInGolembase)
+	ctx.Step(`^there is a new block in Golembase$`, thereIsANewBlockInGolembase)
+	ctx.Step(`^I create entities with different annotations in Golembase$`, iCreateEntitiesWithDifferentAnnotationsInGolembase)
+	ctx.Step(`^a query server on the SQLite database$`, aQueryServerOnTheSQLiteDatabase)
+	ctx.Step(`^the query server should return the same results as the node for '([^']*)'$`, theQueryServerShouldReturnTheSameResultsAsTheNodeFor)
+	ctx.Step(`^the query server should return the same pages as the node for '([^']*)' ordered by "([^"]*)"$`, theQueryServerShouldReturnTheSamePagesAsTheNodeForOrderedBy)
 }
 
 func aRunningETLToSQLite() error {
@@ -162,33 +164,15 @@ func aRunningETLToSQLite() error {
 	return nil
 }
 
-func aRunningGolembaseNodeWithWALEnabled() error {
-	// this is a default when starting testutil.World, so we don't need to do anything here
-	return nil
-}
-
 func iCreateANewEntityInGolebase(ctx context.Context) error {
 	w := etlworld.GetWorld(ctx)
-	_, err := w.CreateEntity(ctx,
-		1000,
-		[]byte("test"),
-		[]entity.StringAnnotation{
-			{
-				Key:   "stringTest",
-				Value: "stringTest",
-			},
-		},
-		[]entity.NumericAnnotation{
-			{
-				Key:   "numericTest",
-				Value: 1234567890,
-			},
-		},
-	)
+	receipt, err := etltest.CreateEntity(ctx, w.World)
 	if err != nil {
-		return fmt.Errorf("failed to create entity: %w", err)
+		return err
 	}
 
+	w.EntityCreatedAtBlock = receipt.BlockNumber.Uint64()
+
 	return nil
 }
 
@@ -277,31 +261,6 @@ func theAnnotationsOfTheEntityShouldBeExistingInTheSQLiteDatabase(ctx context.Co
 	return nil
 }
 
-func anExistingEntityInTheSQLiteDatabase(ctx context.Context) error {
-	w := etlworld.GetWorld(ctx)
-	_, err := w.CreateEntity(ctx,
-		1000,
-		[]byte("test"),
-		[]entity.StringAnnotation{
-			{
-				Key:   "stringTest",
-				Value: "stringTest",
-			},
-		},
-		[]entity.NumericAnnotation{
-			{
-				Key:   "numericTest",
-				Value: 1234567890,
-			},
-		},
-	)
-	if err != nil {
-		return fmt.Errorf("failed to create entity: %w", err)
-	}
-
-	return nil
-}
-
 func theAnnotationsOfTheEntityShouldBeUpdatedInTheSQLiteDatabase(ctx context.Context) error {
 	w := etlworld.GetWorld(ctx)
 
@@ -392,40 +351,6 @@ func theEntityShouldBeUpdatedInTheSQLiteDatabase(ctx context.Context) error {
 
 }
 
-func updateTheEntityInGolembase(ctx context.Context) error {
-	w := etlworld.GetWorld(ctx)
-	_, err := w.UpdateEntity(ctx,
-		w.CreatedEntityKey,
-		999,
-		[]byte("test2"),
-		[]entity.StringAnnotation{
-			{
-				Key:   "stringTest2",
-				Value: "stringTest2",
-			},
-		},
-		[]entity.NumericAnnotation{
-			{
-				Key:   "numericTest2",
-				Value: 12345678901,
-			},
-		},
-	)
-	if err != nil {
-		return fmt.Errorf("failed to create entity: %w", err)
-	}
-	return nil
-}
-
-func deleteTheEntityInGolembase(ctx context.Context) error {
-	w := etlworld.GetWorld(ctx)
-	_, err := w.DeleteEntity(ctx, w.CreatedEntityKey)
-	if err != nil {
-		return fmt.Errorf("failed to delete entity: %w", err)
-	}
-	return nil
-}
-
 func theAnnotationsOfTheEntityShouldBeDeletedInTheSQLiteDatabase(ctx context.Context) error {
 	w := etlworld.GetWorld(ctx)
 
@@ -519,17 +444,511 @@ func theOwnerAddressShouldBePreservedInTheSQLiteDatabase(ctx context.Context) er
 	w := etlworld.GetWorld(ctx)
 	entityKey := w.CreatedEntityKey
 
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	bo := backoff.WithContext(backoff.NewConstantBackOff(100*time.Millisecond), ctx)
+
+	return backoff.Retry(func() error {
+		return w.WithDB(ctx, func(db *sql.DB) error {
+			gl := sqlitegolem.New(db)
+			entity, err := gl.GetEntity(ctx, entityKey.Hex())
+			if err != nil {
+				return fmt.Errorf("failed to get entity: %w", err)
+			}
+
+			if entity.OwnerAddress == "" {
+				return fmt.Errorf("expected owner address to be preserved, but it was empty")
+			}
+
+			return nil
+		})
+	}, bo)
+}
+
+func theNewOwnerAddressShouldBeStoredInTheSQLiteDatabase(ctx context.Context) error {
+	w := etlworld.GetWorld(ctx)
+
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	bo := backoff.WithContext(backoff.NewConstantBackOff(100*time.Millisecond), ctx)
+
+	return backoff.Retry(func() error {
+		return w.WithDB(ctx, func(db *sql.DB) error {
+			gl := sqlitegolem.New(db)
+			entity, err := gl.GetEntity(ctx, w.CreatedEntityKey.Hex())
+			if err != nil {
+				return fmt.Errorf("failed to get entity: %w", err)
+			}
+
+			if entity.OwnerAddress != etltest.NewOwnerAddress.Hex() {
+				return fmt.Errorf("expected owner address to be %s, but got %s", etltest.NewOwnerAddress.Hex(), entity.OwnerAddress)
+			}
+
+			return nil
+		})
+	}, bo)
+}
+
+func thePatchShouldBeAppliedInTheSQLiteDatabase(ctx context.Context) error {
+	w := etlworld.GetWorld(ctx)
+
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	bo := backoff.WithContext(backoff.NewConstantBackOff(100*time.Millisecond), ctx)
+
+	return backoff.Retry(func() error {
+		return w.WithDB(ctx, func(db *sql.DB) error {
+			gl := sqlitegolem.New(db)
+			entity, err := gl.GetEntity(ctx, w.CreatedEntityKey.Hex())
+			if err != nil {
+				return fmt.Errorf("failed to get entity: %w", err)
+			}
+
+			if string(entity.Payload) != string(etltest.PatchedPayload) {
+				return fmt.Errorf("expected payload to be patched, but got %q", string(entity.Payload))
+			}
+
+			stringAnnotations, err := gl.GetStringAnnotations(ctx, w.CreatedEntityKey.Hex())
+			if err != nil {
+				return fmt.Errorf("failed to get string annotations: %w", err)
+			}
+
+			expectedStringAnnotations := []sqlitegolem.GetStringAnnotationsRow{
+				{
+					AnnotationKey: "stringTest",
+					Value:         "patched",
+				},
+			}
+
+			if diff := cmp.Diff(stringAnnotations, expectedStringAnnotations); diff != "" {
+				return fmt.Errorf("string annotations are not equal: %s", diff)
+			}
+
+			numericAnnotations, err := gl.GetNumericAnnotations(ctx, w.CreatedEntityKey.Hex())
+			if err != nil {
+				return fmt.Errorf("failed to get numeric annotations: %w", err)
+			}
+
+			expectedNumericAnnotations := []sqlitegolem.GetNumericAnnotationsRow{
+				{
+					AnnotationKey: "numericTest2",
+					Value:         42,
+				},
+			}
+
+			if diff := cmp.Diff(numericAnnotations, expectedNumericAnnotations); diff != "" {
+				return fmt.Errorf("numeric annotations are not equal: %s", diff)
+			}
+
+			return nil
+		})
+	}, bo)
+}
+
+func theEntityOfTheSnapshotShouldBeInTheSQLiteDatabase(ctx context.Context) error {
+	w := etlworld.GetWorld(ctx)
+	key := etltest.SnapshotEntity.EntityKey.Hex()
+
+	ctx, cancel := context.WithTimeout(ctx, 2*time.Second)
+	defer cancel()
+
+	bo := backoff.WithContext(backoff.NewConstantBackOff(200*time.Millisecond), ctx)
+
+	return backoff.Retry(func() error {
+		return checkSnapshotEntity(ctx, w, key)
+	}, bo)
+}
+
+func checkSnapshotEntity(ctx context.Context, w *etlworld.ETLWorld, key string) error {
 	return w.WithDB(ctx, func(db *sql.DB) error {
 		gl := sqlitegolem.New(db)
-		entity, err := gl.GetEntity(ctx, entityKey.Hex())
+
+		entity, err := gl.GetEntity(ctx, key)
 		if err != nil {
 			return fmt.Errorf("failed to get entity: %w", err)
 		}
 
-		if entity.OwnerAddress == "" {
-			return fmt.Errorf("expected owner address to be preserved, but it was empty")
+		if string(entity.Payload) != string(etltest.SnapshotEntity.Payload) {
+			return fmt.Errorf("unexpected payload %q", entity.Payload)
+		}
+
+		if entity.OwnerAddress != etltest.SnapshotEntity.Owner.Hex() {
+			return fmt.Errorf("unexpected owner %s", entity.OwnerAddress)
+		}
+
+		stringAnnotations, err := gl.GetStringAnnotations(ctx, key)
+		if err != nil {
+			return fmt.Errorf("failed to get string annotations: %w", err)
+		}
+
+		if len(stringAnnotations) != 1 || stringAnnotations[0].Value != "snapshot" {
+			return fmt.Errorf("unexpected string annotations %v", stringAnnotations)
+		}
+
+		numericAnnotations, err := gl.GetNumericAnnotations(ctx, key)
+		if err != nil {
+			return fmt.Errorf("failed to get numeric annotations: %w", err)
+		}
+
+		if len(numericAnnotations) != 1 || numericAnnotations[0].Value != 1 {
+			return fmt.Errorf("unexpected numeric annotations %v", numericAnnotations)
 		}
 
 		return nil
 	})
 }
+
+func theHistoryOfTheEntityShouldHaveTheEvents(ctx context.Context, events string) error {
+	w := etlworld.GetWorld(ctx)
+	expected := strings.Split(events, ", ")
+
+	ctx, cancel := context.WithTimeout(ctx, 2*time.Second)
+	defer cancel()
+
+	bo := backoff.WithContext(backoff.NewConstantBackOff(200*time.Millisecond), ctx)
+
+	return backoff.Retry(func() error {
+		return w.WithDB(ctx, func(db *sql.DB) error {
+			gl := sqlitegolem.New(db)
+
+			history, err := gl.GetEntityHistory(ctx, w.CreatedEntityKey.Hex())
+			if err != nil {
+				return fmt.Errorf("failed to get entity history: %w", err)
+			}
+
+			actual := []string{}
+			for _, h := range history {
+				actual = append(actual, h.Event)
+			}
+
+			if diff := cmp.Diff(expected, actual); diff != "" {
+				return fmt.Errorf("unexpected events in the entity history: %s", diff)
+			}
+
+			if history[0].BlockNumber != int64(w.EntityCreatedAtBlock) {
+				return fmt.Errorf("expected the entity to be created at block %d, but got %d", w.EntityCreatedAtBlock, history[0].BlockNumber)
+			}
+
+			return nil
+		})
+	}, bo)
+}
+
+func theSQLiteDatabaseShouldHaveTheIndexesOfTheAnnotationValues(ctx context.Context) error {
+	w := etlworld.GetWorld(ctx)
+
+	return w.WithDB(ctx, func(db *sql.DB) error {
+		for _, index := range []string{"idx_string_annotations_value", "idx_numeric_annotations_value"} {
+			var name string
+			err := db.QueryRowContext(ctx, `SELECT name FROM sqlite_master WHERE type = 'index' AND name = ?`, index).Scan(&name)
+			if err != nil {
+				return fmt.Errorf("failed to find the index %s: %w", index, err)
+			}
+		}
+
+		return nil
+	})
+}
+
+// entitiesAtBlock returns the payloads of the entities live at the block, once the ETL has processed the last block.
+func entitiesAtBlock(ctx context.Context, w *etlworld.ETLWorld, blockNumber uint64) (map[string]string, error) {
+	payloads := map[string]string{}
+
+	err := w.WithDB(ctx, func(db *sql.DB) error {
+		gl := sqlitegolem.New(db)
+
+		networkID, err := w.GethInstance.ETHClient.NetworkID(ctx)
+		if err != nil {
+			return fmt.Errorf("failed to get network id: %w", err)
+		}
+
+		status, err := gl.GetProcessingStatus(ctx, networkID.String())
+		if err != nil {
+			return fmt.Errorf("failed to get processing status: %w", err)
+		}
+
+		if status.LastProcessedBlockNumber < w.LastReceipt.BlockNumber.Int64() {
+			return fmt.Errorf("block %d is not processed yet", w.LastReceipt.BlockNumber)
+		}
+
+		entities, err := gl.GetEntitiesAtBlock(ctx, int64(blockNumber))
+		if err != nil {
+			return fmt.Errorf("failed to get entities at block: %w", err)
+		}
+
+		for _, e := range entities {
+			payloads[e.EntityKey] = string(e.Payload)
+		}
+
+		return nil
+	})
+
+	return payloads, err
+}
+
+func theEntityShouldHaveItsCreatedPayloadAtTheBlockOfItsCreation(ctx context.Context) error {
+	w := etlworld.GetWorld(ctx)
+
+	ctx, cancel := context.WithTimeout(ctx, 2*time.Second)
+	defer cancel()
+
+	bo := backoff.WithContext(backoff.NewConstantBackOff(200*time.Millisecond), ctx)
+
+	return backoff.Retry(func() error {
+		payloads, err := entitiesAtBlock(ctx, w, w.EntityCreatedAtBlock)
+		if err != nil {
+			return err
+		}
+
+		if diff := cmp.Diff(map[string]string{w.CreatedEntityKey.Hex(): "test"}, payloads); diff != "" {
+			return fmt.Errorf("unexpected entities at block %d: %s", w.EntityCreatedAtBlock, diff)
+		}
+
+		payloads, err = entitiesAtBlock(ctx, w, w.EntityCreatedAtBlock-1)
+		if err != nil {
+			return err
+		}
+
+		if len(payloads) != 0 {
+			return fmt.Errorf("expected no entities before block %d, but got %v", w.EntityCreatedAtBlock, payloads)
+		}
+
+		return nil
+	}, bo)
+}
+
+func theEntityShouldHaveItsUpdatedPayloadAtTheLatestBlock(ctx context.Context) error {
+	w := etlworld.GetWorld(ctx)
+
+	ctx, cancel := context.WithTimeout(ctx, 2*time.Second)
+	defer cancel()
+
+	bo := backoff.WithContext(backoff.NewConstantBackOff(200*time.Millisecond), ctx)
+
+	return backoff.Retry(func() error {
+		payloads, err := entitiesAtBlock(ctx, w, w.LastReceipt.BlockNumber.Uint64())
+		if err != nil {
+			return err
+		}
+
+		if diff := cmp.Diff(map[string]string{w.CreatedEntityKey.Hex(): "test2"}, payloads); diff != "" {
+			return fmt.Errorf("unexpected entities at the latest block: %s", diff)
+		}
+
+		return nil
+	}, bo)
+}
+
+func theEntityShouldNotBeLiveAtTheLatestBlock(ctx context.Context) error {
+	w := etlworld.GetWorld(ctx)
+
+	ctx, cancel := context.WithTimeout(ctx, 2*time.Second)
+	defer cancel()
+
+	bo := backoff.WithContext(backoff.NewConstantBackOff(200*time.Millisecond), ctx)
+
+	return backoff.Retry(func() error {
+		payloads, err := entitiesAtBlock(ctx, w, w.LastReceipt.BlockNumber.Uint64())
+		if err != nil {
+			return err
+		}
+
+		if len(payloads) != 0 {
+			return fmt.Errorf("expected no entities at the latest block, but got %v", payloads)
+		}
+
+		return nil
+	}, bo)
+}
+
+func iCreateAnEntityThatExpiresInTheNextBlockInGolembase(ctx context.Context) error {
+	w := etlworld.GetWorld(ctx)
+	receipt, err := w.CreateEntity(ctx,
+		1,
+		[]byte("test"),
+		[]entity.StringAnnotation{},
+		[]entity.NumericAnnotation{},
+	)
+	if err != nil {
+		return fmt.Errorf("failed to create entity: %w", err)
+	}
+
+	w.EntityCreatedAtBlock = receipt.BlockNumber.Uint64()
+
+	return nil
+}
+
+func thereIsANewBlockInGolembase(ctx context.Context) error {
+	w := etlworld.GetWorld(ctx)
+	_, err := w.Transfer(ctx, big.NewInt(1), common.HexToAddress("0x0000000000000000000000000000000000000001"))
+	if err != nil {
+		return fmt.Errorf("failed to create a new block: %w", err)
+	}
+	return nil
+}
+
+func iCreateEntitiesWithDifferentAnnotationsInGolembase(ctx context.Context) error {
+	w := etlworld.GetWorld(ctx)
+
+	entities := []struct {
+		name string
+		kind string
+		size uint64
+	}{
+		{name: "invoice-1", kind: "document", size: 10},
+		{name: "invoice-2", kind: "document", size: math.MaxUint64},
+		{name: "receipt-1", kind: "document", size: 3},
+		{name: "photo-1", kind: "image", size: 1 << 63},
+	}
+
+	for _, e := range entities {
+		_, err := w.CreateEntity(ctx,
+			1000,
+			[]byte(e.name),
+			[]entity.StringAnnotation{
+				{Key: "name", Value: e.name},
+				{Key: "type", Value: e.kind},
+			},
+			[]entity.NumericAnnotation{
+				{Key: "size", Value: e.size},
+			},
+		)
+		if err != nil {
+			return fmt.Errorf("failed to create entity: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// waitForETL waits until the ETL has processed the block of the last receipt.
+func waitForETL(ctx context.Context, w *etlworld.ETLWorld) error {
+	networkID, err := w.GethInstance.ETHClient.NetworkID(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to get network id: %w", err)
+	}
+
+	bo := backoff.WithContext(backoff.NewConstantBackOff(200*time.Millisecond), ctx)
+
+	return backoff.Retry(func() error {
+		return w.WithDB(ctx, func(db *sql.DB) error {
+			status, err := sqlitegolem.New(db).GetProcessingStatus(ctx, networkID.String())
+			if err != nil {
+				return fmt.Errorf("failed to get processing status: %w", err)
+			}
+
+			if status.LastProcessedBlockNumber < w.LastReceipt.BlockNumber.Int64() {
+				return fmt.Errorf("block %d is not processed yet", w.LastReceipt.BlockNumber)
+			}
+
+			return nil
+		})
+	}, bo)
+}
+
+func aQueryServerOnTheSQLiteDatabase(ctx context.Context) error {
+	w := etlworld.GetWorld(ctx)
+
+	err := waitForETL(ctx, w)
+	if err != nil {
+		return err
+	}
+
+	return w.StartQueryServer(ctx, queryServerPath)
+}
+
+// sortAnnotations orders the annotations of the results by key, the ETL does not keep their order
+func sortAnnotations(res *golemtype.QueryResult) {
+	for _, r := range res.Results {
+		if r.EntityMetaData == nil {
+			continue
+		}
+		slices.SortFunc(r.StringAnnotations, func(a, b entity.StringAnnotation) int {
+			return strings.Compare(a.Key, b.Key)
+		})
+		slices.SortFunc(r.NumericAnnotations, func(a, b entity.NumericAnnotation) int {
+			return strings.Compare(a.Key, b.Key)
+		})
+	}
+}
+
+// queryBoth runs the query on the node and on the query server
+func queryBoth(ctx context.Context, w *etlworld.ETLWorld, q string, options golemtype.QueryOptions) (*golemtype.QueryResult, *golemtype.QueryResult, error) {
+	fromNode := &golemtype.QueryResult{}
+	err := w.GethInstance.RPCClient.CallContext(ctx, fromNode, "golembase_queryEntitiesPage", q, nil, options)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to query the node: %w", err)
+	}
+
+	fromServer := &golemtype.QueryResult{}
+	err = w.QueryServer().CallContext(ctx, fromServer, "golembase_queryEntitiesPage", q, nil, options)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to query the query server: %w", err)
+	}
+
+	sortAnnotations(fromNode)
+	sortAnnotations(fromServer)
+
+	return fromNode, fromServer, nil
+}
+
+func theQueryServerShouldReturnTheSameResultsAsTheNodeFor(ctx context.Context, q string) error {
+	w := etlworld.GetWorld(ctx)
+
+	fromNode, fromServer, err := queryBoth(ctx, w, q, golemtype.QueryOptions{
+		Projection: golemtype.ProjectionMetaData,
+	})
+	if err != nil {
+		return err
+	}
+
+	if len(fromNode.Results) == 0 {
+		return fmt.Errorf("expected the query %q to have results", q)
+	}
+
+	if diff := cmp.Diff(fromNode, fromServer); diff != "" {
+		return fmt.Errorf("results of the query server differ from the node: %s", diff)
+	}
+
+	return nil
+}
+
+func theQueryServerShouldReturnTheSamePagesAsTheNodeForOrderedBy(ctx context.Context, q string, annotation string) error {
+	w := etlworld.GetWorld(ctx)
+
+	options := golemtype.QueryOptions{
+		Limit:             1,
+		OrderBy:           golemtype.OrderByNumericAnnotation,
+		OrderByAnnotation: annotation,
+	}
+
+	pages := 0
+
+	for {
+		fromNode, fromServer, err := queryBoth(ctx, w, q, options)
+		if err != nil {
+			return err
+		}
+
+		if diff := cmp.Diff(fromNode, fromServer); diff != "" {
+			return fmt.Errorf("page %d of the query server differs from the node: %s", pages, diff)
+		}
+
+		pages++
+
+		if fromNode.Cursor == "" {
+			break
+		}
+
+		options.Cursor = fromNode.Cursor
+	}
+
+	if pages < 2 {
+		return fmt.Errorf("expected several pages, got %d", pages)
+	}
+
+	return nil
+}
@@ -18,11 +18,17 @@ type etlProcess struct {
 	cleanup func()
 }
 
+// ConsumerName is the name the ETL acknowledges the processed blocks with.
+const ConsumerName = "sqlite-etl"
+
 func startETLProcess(
 	ctx context.Context,
 	slqliteETHBinaryPath string,
 	walDir string,
+	segmentsDir string,
 	rpcEndpoint string,
+	snapshotPath string,
+	initDB func(db *sql.DB) error,
 ) (_ *etlProcess, err error) {
 	// Start geth in dev mode
 
@@ -38,20 +44,43 @@ func startETLProcess(
 	if err != nil {
 		return nil, fmt.Errorf("failed to open database: %w", err)
 	}
+	if initDB != nil {
+		err = initDB(db)
+		if err != nil {
+			return nil, errors.Join(fmt.Errorf("failed to initialize database: %w", err), db.Close())
+		}
+	}
 	err = db.Close()
 	if err != nil {
 		return nil, fmt.Errorf("failed to close database: %w", err)
 	}
 
-	cmd := exec.CommandContext(
-		ctx,
-		slqliteETHBinaryPath,
+	args := []string{
 		"--db",
 		dbPath,
-		"--wal",
-		walDir,
 		"--rpc-endpoint",
 		rpcEndpoint,
+		"--consumer",
+		ConsumerName,
+	}
+
+	// without a WAL dir, the ETL streams the operations from the RPC endpoint
+	if walDir != "" {
+		args = append(args, "--wal", walDir)
+	}
+
+	if segmentsDir != "" {
+		args = append(args, "--wal-segments", segmentsDir)
+	}
+
+	if snapshotPath != "" {
+		args = append(args, "--snapshot", snapshotPath)
+	}
+
+	cmd := exec.CommandContext(
+		ctx,
+		slqliteETHBinaryPath,
+		args...,
 	)
 
 	output := &bytes.Buffer{}
@@ -2,8 +2,11 @@ package etlworld
 
 import (
 	"context"
+	"database/sql"
 	"fmt"
 
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/golem-base/etl/etltest"
 	"github.com/ethereum/go-ethereum/golem-base/testutil"
 )
 
@@ -12,25 +15,90 @@ type ETLWorld struct {
 	*testutil.World
 	sqlliteETLBinaryPath string
 	etlProcess           *etlProcess
+	queryServer          *queryServerProcess
+
+	// EntityCreatedAtBlock is the block in which the created entity was created
+	EntityCreatedAtBlock uint64
 }
 
+// OperationsSource is where the ETL reads the operations from.
+type OperationsSource int
+
+const (
+	// FromWALDir reads the JSON files of the WAL directory of geth.
+	FromWALDir OperationsSource = iota
+	// FromSubscription receives the operations over the websocket subscription of geth.
+	FromSubscription
+	// FromSegments reads the segments geth writes with --golembase.writeaheadlog.segments.
+	FromSegments
+)
+
+// Start is what the database of the ETL holds when the ETL starts.
+type Start int
+
+const (
+	// FromGenesis starts with an empty database.
+	FromGenesis Start = iota
+	// FromSnapshot starts with an empty database and a snapshot of the head block holding etltest.SnapshotEntity.
+	FromSnapshot
+	// FromLegacyDatabase starts with a database without the entity history, holding LegacyEntity
+	// and the head block as the last processed block.
+	FromLegacyDatabase
+)
+
+// NewETLWorld starts geth and the ETL, reading the operations from the given source.
 func NewETLWorld(
 	ctx context.Context,
 	gethPath string,
 	sqlliteETLPath string,
+	source OperationsSource,
+	start Start,
 ) (*ETLWorld, error) {
 	world, err := testutil.NewWorld(ctx, gethPath)
 	if err != nil {
 		return nil, err
 	}
 
+	snapshotPath := ""
+	var initDB func(db *sql.DB) error
+	var legacyBlock uint64
+
+	switch start {
+	case FromSnapshot:
+		snapshotPath, err = etltest.WriteSnapshot(ctx, world)
+		if err != nil {
+			world.Shutdown()
+			return nil, err
+		}
+	case FromLegacyDatabase:
+		initDB = func(db *sql.DB) error {
+			legacyBlock, err = writeLegacyDatabase(ctx, world, db)
+			return err
+		}
+	}
+
+	walDir := world.GethInstance.WALDir
+	segmentsDir := ""
+	rpcEndpoint := world.GethInstance.RPCEndpoint
+	switch source {
+	case FromSubscription:
+		walDir = ""
+		rpcEndpoint = world.GethInstance.WSEndpoint
+	case FromSegments:
+		segmentsDir = world.GethInstance.SegmentsDir
+	}
+
 	etlProcess, err := startETLProcess(
 		ctx,
 		sqlliteETLPath,
-		world.GethInstance.WALDir,
-		world.GethInstance.RPCEndpoint,
+		walDir,
+		segmentsDir,
+		rpcEndpoint,
+		snapshotPath,
+		initDB,
 	)
 	if err != nil {
+		world.Shutdown()
 		return nil, err
 	}
 
@@ -40,6 +108,11 @@ func NewETLWorld(
 		etlProcess:           etlProcess,
 	}
 
+	if start == FromLegacyDatabase {
+		e.CreatedEntityKey = common.HexToHash(LegacyEntity.Key)
+		e.EntityCreatedAtBlock = legacyBlock
+	}
+
 	return e, nil
 }
 
@@ -50,10 +123,19 @@ func (w *ETLWorld) AddLogsToTestError(err error) error {
 
 	err = fmt.Errorf("%w\n\nETL Logs:\n%s", err, w.etlProcess.output.String())
 
+	if w.queryServer != nil {
+		err = fmt.Errorf("%w\n\nQuery Server Logs:\n%s", err, w.queryServer.output.String())
+	}
+
 	return w.World.AddLogsToTestError(err)
 }
 
 func (w *ETLWorld) Shutdown() {
+	if w.queryServer != nil {
+		w.queryServer.client.Close()
+		w.queryServer.Process.Kill()
+		w.queryServer.Wait()
+	}
 	w.etlProcess.cleanup()
 	w.World.Shutdown()
 }
@@ -0,0 +1,58 @@
+package etlworld
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/golem-base/etl/sqlite/sqlitegolem"
+	"github.com/ethereum/go-ethereum/golem-base/testutil"
+)
+
+// LegacyEntity is the entity of the database written by an ETL without the entity history.
+var LegacyEntity = sqlitegolem.InsertEntityParams{
+	Key:          common.HexToHash("0x1e").Hex(),
+	ExpiresAt:    1000,
+	Payload:      []byte("legacy payload"),
+	OwnerAddress: common.HexToAddress("0x1e1e").Hex(),
+}
+
+// writeLegacyDatabase creates the tables of an ETL without the entity history, holding LegacyEntity
+// and the head block of geth as the last processed block, and returns the number of the head block.
+func writeLegacyDatabase(ctx context.Context, world *testutil.World, db *sql.DB) (uint64, error) {
+	ec := world.GethInstance.ETHClient
+
+	networkID, err := ec.NetworkID(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get network id: %w", err)
+	}
+
+	head, err := ec.HeaderByNumber(ctx, nil)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get head header: %w", err)
+	}
+
+	_, err = db.ExecContext(ctx, sqlitegolem.Schema)
+	if err != nil {
+		return 0, fmt.Errorf("failed to apply schema: %w", err)
+	}
+
+	q := sqlitegolem.New(db)
+
+	err = q.InsertEntity(ctx, LegacyEntity)
+	if err != nil {
+		return 0, fmt.Errorf("failed to insert entity: %w", err)
+	}
+
+	err = q.InsertProcessingStatus(ctx, sqlitegolem.InsertProcessingStatusParams{
+		Network:                  networkID.String(),
+		LastProcessedBlockNumber: head.Number.Int64(),
+		LastProcessedBlockHash:   head.Hash().Hex(),
+	})
+	if err != nil {
+		return 0, fmt.Errorf("failed to insert processing status: %w", err)
+	}
+
+	return head.Number.Uint64(), nil
+}
@@ -0,0 +1,82 @@
+package etlworld
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net"
+	"os/exec"
+	"time"
+
+	"github.com/cenkalti/backoff/v4"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+type queryServerProcess struct {
+	*exec.Cmd
+	output *bytes.Buffer
+	client *rpc.Client
+}
+
+// StartQueryServer starts the query server on the database of the ETL and connects QueryServer to it.
+func (w *ETLWorld) StartQueryServer(ctx context.Context, queryServerBinaryPath string) error {
+	networkID, err := w.GethInstance.ETHClient.NetworkID(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to get network id: %w", err)
+	}
+
+	// find a free port for the server
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return fmt.Errorf("failed to find a free port: %w", err)
+	}
+	address := l.Addr().String()
+	l.Close()
+
+	cmd := exec.Command(
+		queryServerBinaryPath,
+		"--db", w.etlProcess.dbPath,
+		"--network-id", networkID.String(),
+		"--listen", address,
+	)
+
+	output := &bytes.Buffer{}
+	cmd.Stdout = output
+	cmd.Stderr = output
+
+	err = cmd.Start()
+	if err != nil {
+		return fmt.Errorf("failed to start query server: %w", err)
+	}
+
+	w.queryServer = &queryServerProcess{
+		Cmd:    cmd,
+		output: output,
+	}
+
+	client, err := rpc.DialContext(ctx, "http://"+address)
+	if err != nil {
+		return fmt.Errorf("failed to dial query server: %w", err)
+	}
+	w.queryServer.client = client
+
+	// wait until the server accepts connections
+	bo := backoff.WithContext(backoff.NewConstantBackOff(100*time.Millisecond), ctx)
+	err = backoff.Retry(func() error {
+		conn, err := net.Dial("tcp", address)
+		if err != nil {
+			return err
+		}
+		return conn.Close()
+	}, bo)
+	if err != nil {
+		return fmt.Errorf("query server did not start: %w", err)
+	}
+
+	return nil
+}
+
+// QueryServer returns the client of the query server started with StartQueryServer.
+func (w *ETLWorld) QueryServer() *rpc.Client {
+	return w.queryServer.client
+}
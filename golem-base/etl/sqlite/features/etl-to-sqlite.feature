@@ -36,3 +36,110 @@ Feature: ETL to SQLite
     And an existing entity in the SQLite database
     When update the entity in Golembase
     Then the owner address should be preserved in the SQLite database
+
+  Scenario: Owner Address is Changed on Ownership Transfer
+    Given A running Golembase node with WAL enabled
+    And A running ETL to SQLite
+    And an existing entity in the SQLite database
+    When the ownership of the entity is transferred in Golembase
+    Then the new owner address should be stored in the SQLite database
+
+  Scenario: ETL Patch to SQLite
+    Given A running Golembase node with WAL enabled
+    And A running ETL to SQLite
+    And an existing entity in the SQLite database
+    When the entity is patched in Golembase
+    Then the patch should be applied in the SQLite database
+
+  @stream
+  Scenario: ETL streaming the operations over RPC to SQLite
+    Given A running Golembase node with WAL enabled
+    And A running ETL to SQLite
+    When I create a new entity in Golebase
+    Then the entity should be created in the SQLite database
+    And the annotations of the entity should be existing in the SQLite database
+
+  @segments
+  Scenario: ETL reading the segments of the WAL to SQLite
+    Given A running Golembase node with WAL enabled
+    And A running ETL to SQLite
+    When I create a new entity in Golebase
+    Then the entity should be created in the SQLite database
+    And the annotations of the entity should be existing in the SQLite database
+
+  @segments
+  Scenario: ETL Update from the segments of the WAL to SQLite
+    Given A running Golembase node with WAL enabled
+    And A running ETL to SQLite
+    And an existing entity in the SQLite database
+    When update the entity in Golembase
+    Then the entity should be updated in the SQLite database
+
+  Scenario: ETL acknowledging the processed blocks
+    Given A running Golembase node with WAL enabled
+    And A running ETL to SQLite
+    When I create a new entity in Golebase
+    Then the ETL should acknowledge the block of the entity
+
+  @stream
+  Scenario: ETL acknowledging the streamed blocks
+    Given A running Golembase node with WAL enabled
+    And A running ETL to SQLite
+    When I create a new entity in Golebase
+    Then the ETL should acknowledge the block of the entity
+
+  @snapshot
+  Scenario: ETL starting from a snapshot
+    Given A running Golembase node with WAL enabled
+    And A running ETL to SQLite
+    Then the entity of the snapshot should be in the SQLite database
+    When I create a new entity in Golebase
+    Then the entity should be created in the SQLite database
+
+  @legacy
+  Scenario: ETL adding the entity history to a database without it
+    Given A running Golembase node with WAL enabled
+    And A running ETL to SQLite
+    Then the history of the entity should have the events "snapshot"
+    And the SQLite database should have the indexes of the annotation values
+    When I create a new entity in Golebase
+    Then the entity should be created in the SQLite database
+    And the history of the entity should have the events "create"
+
+  Scenario: Entity history in SQLite
+    Given A running Golembase node with WAL enabled
+    And A running ETL to SQLite
+    And an existing entity in the SQLite database
+    When the entity is patched in Golembase
+    And update the entity in Golembase
+    And delete the entity in Golembase
+    Then the entity should be deleted in the SQLite database
+    And the history of the entity should have the events "create, patch, update, delete"
+
+  Scenario: Entities live at a past block in SQLite
+    Given A running Golembase node with WAL enabled
+    And A running ETL to SQLite
+    And an existing entity in the SQLite database
+    When update the entity in Golembase
+    Then the entity should have its created payload at the block of its creation
+    And the entity should have its updated payload at the latest block
+
+  Scenario: Expired entities in SQLite
+    Given A running Golembase node with WAL enabled
+    And A running ETL to SQLite
+    When I create an entity that expires in the next block in Golembase
+    And there is a new block in Golembase
+    Then the entity should be deleted in the SQLite database
+    And the history of the entity should have the events "create, expire"
+    And the entity should not be live at the latest block
+
+  Scenario: Querying the SQLite database with the query server
+    Given A running Golembase node with WAL enabled
+    And A running ETL to SQLite
+    When I create entities with different annotations in Golembase
+    And a query server on the SQLite database
+    Then the query server should return the same results as the node for 'type = "document"'
+    And the query server should return the same results as the node for 'name ~ "invoice-*" && size > 5'
+    And the query server should return the same results as the node for 'size >= 9223372036854775808 || size = 3'
+    And the query server should return the same results as the node for '!(type = "image") && name != "receipt-1"'
+    And the query server should return the same pages as the node for 'size > 0' ordered by "size"
@@ -1,8 +1,9 @@
 package main
 
 import (
+	"context"
 	"database/sql"
-	_ "embed"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"log/slog"
@@ -13,20 +14,21 @@ import (
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/ethclient"
 	"github.com/ethereum/go-ethereum/golem-base/etl/sqlite/sqlitegolem"
+	"github.com/ethereum/go-ethereum/golem-base/snapshot"
 	"github.com/ethereum/go-ethereum/golem-base/wal"
 	_ "github.com/mattn/go-sqlite3"
 	"github.com/urfave/cli/v2"
 )
 
-//go:embed sqlitegolem/schema.sql
-var schema string
-
 func main() {
 	log := slog.New(slog.NewTextHandler(os.Stdout, nil))
 	cfg := struct {
 		dbFile      string
 		walDir      string
+		segmentsDir string
 		rpcEndpoint string
+		consumer    string
+		snapshot    string
 	}{}
 	app := &cli.App{
 		Name: "sqlite-etl",
@@ -40,11 +42,16 @@ func main() {
 			},
 			&cli.PathFlag{
 				Name:        "wal",
-				Usage:       "wal dir",
+				Usage:       "wal dir, if not set the operations are streamed from the RPC endpoint, which must support subscriptions",
 				EnvVars:     []string{"WAL_DIR"},
-				Required:    true,
 				Destination: &cfg.walDir,
 			},
+			&cli.PathFlag{
+				Name:        "wal-segments",
+				Usage:       "dir of the wal in the binary segment format, written by op-geth with --golembase.writeaheadlog.segments, read instead of the wal dir",
+				EnvVars:     []string{"WAL_SEGMENTS_DIR"},
+				Destination: &cfg.segmentsDir,
+			},
 			&cli.StringFlag{
 				Name:        "rpc-endpoint",
 				Usage:       "RPC Endpoint for op-geth",
@@ -52,6 +59,18 @@ func main() {
 				Required:    true,
 				Destination: &cfg.rpcEndpoint,
 			},
+			&cli.StringFlag{
+				Name:        "consumer",
+				Usage:       "name to acknowledge the processed blocks with, so that the node only prunes them from the wal once they were processed",
+				EnvVars:     []string{"CONSUMER_NAME"},
+				Destination: &cfg.consumer,
+			},
+			&cli.PathFlag{
+				Name:        "snapshot",
+				Usage:       "snapshot file to load when the database is empty, the processing continues with the block after the snapshot",
+				EnvVars:     []string{"SNAPSHOT_FILE"},
+				Destination: &cfg.snapshot,
+			},
 		},
 		Action: func(c *cli.Context) error {
 
@@ -64,18 +83,35 @@ func main() {
 			}
 			defer db.Close()
 
+			migrateHistory := false
+
 			var tableName string
 			err = db.QueryRowContext(ctx, `
 				SELECT name FROM sqlite_master 
 				WHERE type='table' AND name='entities';
 			`).Scan(&tableName)
 
-			if err == sql.ErrNoRows {
+			switch {
+			case err == sql.ErrNoRows:
 				log.Info("could not find 'entities' table, applying schema")
-				_, err := db.ExecContext(ctx, schema)
+				_, err := db.ExecContext(ctx, sqlitegolem.Schema+sqlitegolem.HistorySchema)
 				if err != nil {
 					return fmt.Errorf("failed to apply schema table: %w", err)
 				}
+			case err != nil:
+				return fmt.Errorf("failed to check for the 'entities' table: %w", err)
+			default:
+				err = db.QueryRowContext(ctx, `
+					SELECT name FROM sqlite_master
+					WHERE type='table' AND name='entity_history';
+				`).Scan(&tableName)
+				if err == sql.ErrNoRows {
+					migrateHistory = true
+					err = nil
+				}
+				if err != nil {
+					return fmt.Errorf("failed to check for the 'entity_history' table: %w", err)
+				}
 			}
 
 			autocommit := sqlitegolem.New(db)
@@ -90,12 +126,36 @@ func main() {
 				return fmt.Errorf("failed to get network id: %w", err)
 			}
 
+			if migrateHistory {
+				log.Info("could not find 'entity_history' table, adding the entity history")
+				err = migrateEntityHistory(ctx, db, networkID.String())
+				if err != nil {
+					return fmt.Errorf("failed to add the entity history: %w", err)
+				}
+			}
+
 			hasProcessingStatus, err := autocommit.HasProcessingStatus(ctx, networkID.String())
 			if err != nil {
 				return fmt.Errorf("failed to check if processing status exists: %w", err)
 			}
 
-			if !hasProcessingStatus {
+			switch {
+			case hasProcessingStatus && cfg.snapshot != "":
+				log.Info("processing status found, ignoring the snapshot", "snapshot", cfg.snapshot)
+			case cfg.snapshot != "":
+				log.Info("no processing status found, loading snapshot", "snapshot", cfg.snapshot)
+
+				// the snapshot records the chain id, which can differ from the network id
+				chainID, err := ec.ChainID(ctx)
+				if err != nil {
+					return fmt.Errorf("failed to get chain id: %w", err)
+				}
+
+				err = loadSnapshot(ctx, db, cfg.snapshot, chainID, networkID)
+				if err != nil {
+					return fmt.Errorf("failed to load snapshot: %w", err)
+				}
+			case !hasProcessingStatus:
 				log.Info("no processing status found, inserting genesis block")
 
 				genesisHeade, err := ec.HeaderByNumber(ctx, big.NewInt(0))
@@ -121,13 +181,23 @@ func main() {
 			blockNumber := processingStatus.LastProcessedBlockNumber
 			blockHash := processingStatus.LastProcessedBlockHash
 
-			for blockWal, err := range wal.NewIterator(ctx, cfg.walDir, uint64(blockNumber)+1, common.HexToHash(blockHash), true) {
+			blocks := wal.NewIterator(ctx, cfg.walDir, uint64(blockNumber)+1, common.HexToHash(blockHash), true)
+			switch {
+			case cfg.segmentsDir != "":
+				log.Info("reading the wal segments", "dir", cfg.segmentsDir)
+				blocks = wal.NewSegmentIterator(ctx, cfg.segmentsDir, uint64(blockNumber)+1, common.HexToHash(blockHash), true)
+			case cfg.walDir == "":
+				log.Info("no wal dir, streaming operations from the rpc endpoint")
+				blocks = wal.NewRPCIterator(ctx, ec.Client(), uint64(blockNumber)+1, common.HexToHash(blockHash))
+			}
+
+			for blockWal, err := range blocks {
 				if err != nil {
 					return fmt.Errorf("failed to iterate over wal: %w", err)
 				}
 
 				err = func() (err error) {
-					log.Info("processing block", "block", blockWal.BlockInfo.Number)
+					log.Info("processing block", "block", blockWal.BlockInfo.Number, "revert", blockWal.Revert)
 					tx, err := db.BeginTx(ctx, nil)
 					if err != nil {
 						return fmt.Errorf("failed to begin transaction: %w", err)
@@ -141,6 +211,28 @@ func main() {
 
 					txDB := sqlitegolem.New(tx)
 
+					blockNumber := blockWal.BlockInfo.Number
+
+					// the history of a reverted block is dropped, its operations restore the state of its parent,
+					// except for the snapshot events the history starts with, see recordSnapshot
+					if blockWal.Revert {
+						err = txDB.DeleteEntityHistoryOfBlock(ctx, int64(blockNumber))
+						if err != nil {
+							return fmt.Errorf("failed to delete entity history of reverted block: %w", err)
+						}
+					}
+
+					operationIndex := 0
+
+					// record records the state of the entity after the operation in the entity history
+					record := func(event string, key common.Hash) error {
+						defer func() { operationIndex++ }()
+						if blockWal.Revert {
+							return nil
+						}
+						return recordHistory(ctx, txDB, event, blockNumber, operationIndex, key)
+					}
+
 					for op, err := range blockWal.OperationsIterator {
 						if err != nil {
 							return fmt.Errorf("failed to iterate over operations: %w", err)
@@ -149,95 +241,190 @@ func main() {
 						switch {
 						case op.Create != nil:
 							log.Info("create", "entity", op.Create.EntityKey.Hex())
-							err = txDB.InsertEntity(ctx, sqlitegolem.InsertEntityParams{
-								Key:          op.Create.EntityKey.Hex(),
-								ExpiresAt:    int64(op.Create.ExpiresAtBlock),
-								Payload:      op.Create.Payload,
-								OwnerAddress: op.Create.Owner.Hex(),
+							err = insertEntity(ctx, txDB, *op.Create)
+							if err != nil {
+								return err
+							}
+
+							err = record(eventCreate, op.Create.EntityKey)
+							if err != nil {
+								return err
+							}
+						case op.Update != nil:
+							existingEntity, err := txDB.GetEntity(ctx, op.Update.EntityKey.Hex())
+							if err != nil {
+								return fmt.Errorf("failed to get existing entity: %w", err)
+							}
+
+							err = deleteEntity(ctx, txDB, op.Update.EntityKey.Hex())
+							if err != nil {
+								return err
+							}
+
+							// updates keep the owner of the entity
+							err = insertEntity(ctx, txDB, wal.Create{
+								EntityKey:          op.Update.EntityKey,
+								ExpiresAtBlock:     op.Update.ExpiresAtBlock,
+								Payload:            op.Update.Payload,
+								StringAnnotations:  op.Update.StringAnnotations,
+								NumericAnnotations: op.Update.NumericAnnotations,
+								Owner:              common.HexToAddress(existingEntity.OwnerAddress),
 							})
 							if err != nil {
-								return fmt.Errorf("failed to insert entity: %w", err)
+								return err
 							}
 
-							for _, annotation := range op.Create.NumericAnnotations {
-								err = txDB.InsertNumericAnnotation(ctx, sqlitegolem.InsertNumericAnnotationParams{
-									EntityKey:     op.Create.EntityKey.Hex(),
-									AnnotationKey: annotation.Key,
-									Value:         int64(annotation.Value),
+							err = record(eventUpdate, op.Update.EntityKey)
+							if err != nil {
+								return err
+							}
+						case op.Delete != nil:
+							existingEntity, err := txDB.GetEntity(ctx, op.Delete.Hex())
+							if err != nil {
+								return fmt.Errorf("failed to get existing entity: %w", err)
+							}
+
+							// write-ahead logs written before the expire operation have deletes for the
+							// entities the housekeeping removed because they expired at the block
+							event := eventDelete
+							if !blockWal.Revert && uint64(existingEntity.ExpiresAt) <= blockNumber {
+								event = eventExpire
+							}
+
+							// the last state of the entity is recorded before it is deleted
+							err = record(event, *op.Delete)
+							if err != nil {
+								return err
+							}
+
+							err = deleteEntity(ctx, txDB, op.Delete.Hex())
+							if err != nil {
+								return err
+							}
+						case op.Expire != nil:
+							err = record(eventExpire, *op.Expire)
+							if err != nil {
+								return err
+							}
+
+							err = deleteEntity(ctx, txDB, op.Expire.Hex())
+							if err != nil {
+								return err
+							}
+						case op.TransferOwnership != nil:
+							err = txDB.UpdateEntityOwner(ctx, sqlitegolem.UpdateEntityOwnerParams{
+								Key:          op.TransferOwnership.EntityKey.Hex(),
+								OwnerAddress: op.TransferOwnership.NewOwner.Hex(),
+							})
+							if err != nil {
+								return fmt.Errorf("failed to update entity owner: %w", err)
+							}
+
+							err = record(eventTransfer, op.TransferOwnership.EntityKey)
+							if err != nil {
+								return err
+							}
+						case op.Extend != nil:
+							err = txDB.UpdateEntityExpiresAt(ctx, sqlitegolem.UpdateEntityExpiresAtParams{
+								Key:       op.Extend.EntityKey.Hex(),
+								ExpiresAt: int64(op.Extend.ExpiresAtBlock),
+							})
+							if err != nil {
+								return fmt.Errorf("failed to update entity expiration: %w", err)
+							}
+
+							err = record(eventExtend, op.Extend.EntityKey)
+							if err != nil {
+								return err
+							}
+						case op.Patch != nil:
+							key := op.Patch.EntityKey.Hex()
+
+							err = txDB.UpdateEntityExpiresAt(ctx, sqlitegolem.UpdateEntityExpiresAtParams{
+								Key:       key,
+								ExpiresAt: int64(op.Patch.ExpiresAtBlock),
+							})
+							if err != nil {
+								return fmt.Errorf("failed to update entity expiration: %w", err)
+							}
+
+							if op.Patch.ReplacePayload {
+								payload := op.Patch.Payload
+								if payload == nil {
+									payload = []byte{}
+								}
+
+								err = txDB.UpdateEntityPayload(ctx, sqlitegolem.UpdateEntityPayloadParams{
+									Key:     key,
+									Payload: payload,
 								})
 								if err != nil {
-									return fmt.Errorf("failed to insert numeric annotation: %w", err)
+									return fmt.Errorf("failed to update entity payload: %w", err)
 								}
 							}
 
-							for _, annotation := range op.Create.StringAnnotations {
-								err = txDB.InsertStringAnnotation(ctx, sqlitegolem.InsertStringAnnotationParams{
-									EntityKey:     op.Create.EntityKey.Hex(),
-									AnnotationKey: annotation.Key,
-									Value:         annotation.Value,
+							for _, annotationKey := range op.Patch.RemoveStringAnnotations {
+								err = txDB.DeleteStringAnnotation(ctx, sqlitegolem.DeleteStringAnnotationParams{
+									EntityKey:     key,
+									AnnotationKey: annotationKey,
 								})
 								if err != nil {
-									return fmt.Errorf("failed to insert string annotation: %w", err)
+									return fmt.Errorf("failed to delete string annotation: %w", err)
 								}
 							}
-						case op.Update != nil:
-							existingEntity, err := txDB.GetEntity(ctx, op.Update.EntityKey.Hex())
-							if err != nil {
-								return fmt.Errorf("failed to get existing entity: %w", err)
-							}
-
-							txDB.DeleteEntity(ctx, op.Update.EntityKey.Hex())
-							txDB.DeleteNumericAnnotations(ctx, op.Update.EntityKey.Hex())
-							txDB.DeleteStringAnnotations(ctx, op.Update.EntityKey.Hex())
 
-							txDB.InsertEntity(ctx, sqlitegolem.InsertEntityParams{
-								Key:          op.Update.EntityKey.Hex(),
-								ExpiresAt:    int64(op.Update.ExpiresAtBlock),
-								Payload:      op.Update.Payload,
-								OwnerAddress: existingEntity.OwnerAddress,
-							})
-
-							for _, annotation := range op.Update.NumericAnnotations {
-								err = txDB.InsertNumericAnnotation(ctx, sqlitegolem.InsertNumericAnnotationParams{
-									EntityKey:     op.Update.EntityKey.Hex(),
-									AnnotationKey: annotation.Key,
-									Value:         int64(annotation.Value),
+							for _, annotationKey := range op.Patch.RemoveNumericAnnotations {
+								err = txDB.DeleteNumericAnnotation(ctx, sqlitegolem.DeleteNumericAnnotationParams{
+									EntityKey:     key,
+									AnnotationKey: annotationKey,
 								})
 								if err != nil {
-									return fmt.Errorf("failed to insert numeric annotation: %w", err)
+									return fmt.Errorf("failed to delete numeric annotation: %w", err)
 								}
 							}
 
-							for _, annotation := range op.Update.StringAnnotations {
-								err = txDB.InsertStringAnnotation(ctx, sqlitegolem.InsertStringAnnotationParams{
-									EntityKey:     op.Update.EntityKey.Hex(),
+							for _, annotation := range op.Patch.SetStringAnnotations {
+								err = txDB.UpsertStringAnnotation(ctx, sqlitegolem.UpsertStringAnnotationParams{
+									EntityKey:     key,
 									AnnotationKey: annotation.Key,
 									Value:         annotation.Value,
 								})
 								if err != nil {
-									return fmt.Errorf("failed to insert string annotation: %w", err)
+									return fmt.Errorf("failed to set string annotation: %w", err)
 								}
 							}
-						case op.Delete != nil:
-							err = txDB.DeleteEntity(ctx, op.Delete.Hex())
-							if err != nil {
-								return fmt.Errorf("failed to delete entity: %w", err)
-							}
 
-							err = txDB.DeleteNumericAnnotations(ctx, op.Delete.Hex())
-							if err != nil {
-								return fmt.Errorf("failed to delete numeric annotations: %w", err)
+							for _, annotation := range op.Patch.SetNumericAnnotations {
+								err = txDB.UpsertNumericAnnotation(ctx, sqlitegolem.UpsertNumericAnnotationParams{
+									EntityKey:     key,
+									AnnotationKey: annotation.Key,
+									Value:         int64(annotation.Value),
+								})
+								if err != nil {
+									return fmt.Errorf("failed to set numeric annotation: %w", err)
+								}
 							}
 
-							err = txDB.DeleteStringAnnotations(ctx, op.Delete.Hex())
+							err = record(eventPatch, op.Patch.EntityKey)
 							if err != nil {
-								return fmt.Errorf("failed to delete string annotations: %w", err)
+								return err
 							}
 						}
 
 						log.Info("operation", "operation", op)
 					}
 
+					// a reverted block leaves its parent as the last processed block
+					processedNumber, processedHash := blockWal.ProcessedBlock()
+					err = txDB.UpdateProcessingStatus(ctx, sqlitegolem.UpdateProcessingStatusParams{
+						Network:                  networkID.String(),
+						LastProcessedBlockNumber: int64(processedNumber),
+						LastProcessedBlockHash:   processedHash.String(),
+					})
+					if err != nil {
+						return fmt.Errorf("failed to update processing status: %w", err)
+					}
+
 					return tx.Commit()
 
 				}()
@@ -246,6 +433,23 @@ func main() {
 					return fmt.Errorf("failed to process block: %w", err)
 				}
 
+				if cfg.consumer != "" {
+					processedNumber, processedHash := blockWal.ProcessedBlock()
+					checkpoint := wal.Checkpoint{
+						BlockNumber: processedNumber,
+						BlockHash:   processedHash,
+					}
+
+					if cfg.walDir != "" {
+						err = wal.WriteCheckpoint(cfg.walDir, cfg.consumer, checkpoint)
+					} else {
+						err = wal.AcknowledgeOperations(ctx, ec.Client(), cfg.consumer, checkpoint)
+					}
+					if err != nil {
+						return fmt.Errorf("failed to acknowledge block: %w", err)
+					}
+				}
+
 			}
 
 			return nil
@@ -258,3 +462,243 @@ func main() {
 		os.Exit(1)
 	}
 }
+
+// The events of the entity history.
+const (
+	eventCreate   = "create"
+	eventUpdate   = "update"
+	eventDelete   = "delete"
+	eventExpire   = "expire"
+	eventTransfer = "transfer"
+	eventExtend   = "extend"
+	eventPatch    = "patch"
+	eventSnapshot = "snapshot"
+)
+
+func insertEntity(ctx context.Context, txDB *sqlitegolem.Queries, create wal.Create) error {
+	payload := create.Payload
+	if payload == nil {
+		payload = []byte{}
+	}
+
+	err := txDB.InsertEntity(ctx, sqlitegolem.InsertEntityParams{
+		Key:          create.EntityKey.Hex(),
+		ExpiresAt:    int64(create.ExpiresAtBlock),
+		Payload:      payload,
+		OwnerAddress: create.Owner.Hex(),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to insert entity: %w", err)
+	}
+
+	for _, annotation := range create.NumericAnnotations {
+		err = txDB.InsertNumericAnnotation(ctx, sqlitegolem.InsertNumericAnnotationParams{
+			EntityKey:     create.EntityKey.Hex(),
+			AnnotationKey: annotation.Key,
+			Value:         int64(annotation.Value),
+		})
+		if err != nil {
+			return fmt.Errorf("failed to insert numeric annotation: %w", err)
+		}
+	}
+
+	for _, annotation := range create.StringAnnotations {
+		err = txDB.InsertStringAnnotation(ctx, sqlitegolem.InsertStringAnnotationParams{
+			EntityKey:     create.EntityKey.Hex(),
+			AnnotationKey: annotation.Key,
+			Value:         annotation.Value,
+		})
+		if err != nil {
+			return fmt.Errorf("failed to insert string annotation: %w", err)
+		}
+	}
+
+	return nil
+}
+
+func deleteEntity(ctx context.Context, txDB *sqlitegolem.Queries, key string) error {
+	err := txDB.DeleteEntity(ctx, key)
+	if err != nil {
+		return fmt.Errorf("failed to delete entity: %w", err)
+	}
+
+	err = txDB.DeleteNumericAnnotations(ctx, key)
+	if err != nil {
+		return fmt.Errorf("failed to delete numeric annotations: %w", err)
+	}
+
+	err = txDB.DeleteStringAnnotations(ctx, key)
+	if err != nil {
+		return fmt.Errorf("failed to delete string annotations: %w", err)
+	}
+
+	return nil
+}
+
+// recordHistory records the current state of the entity in the entity history.
+func recordHistory(ctx context.Context, txDB *sqlitegolem.Queries, event string, blockNumber uint64, operationIndex int, key common.Hash) error {
+	e, err := txDB.GetEntity(ctx, key.Hex())
+	if err != nil {
+		return fmt.Errorf("failed to get entity %s: %w", key.Hex(), err)
+	}
+
+	stringAnnotations, err := txDB.GetStringAnnotations(ctx, key.Hex())
+	if err != nil {
+		return fmt.Errorf("failed to get string annotations: %w", err)
+	}
+
+	stringAnnotationsMap := map[string]string{}
+	for _, a := range stringAnnotations {
+		stringAnnotationsMap[a.AnnotationKey] = a.Value
+	}
+
+	numericAnnotations, err := txDB.GetNumericAnnotations(ctx, key.Hex())
+	if err != nil {
+		return fmt.Errorf("failed to get numeric annotations: %w", err)
+	}
+
+	numericAnnotationsMap := map[string]int64{}
+	for _, a := range numericAnnotations {
+		numericAnnotationsMap[a.AnnotationKey] = a.Value
+	}
+
+	stringAnnotationsJSON, err := json.Marshal(stringAnnotationsMap)
+	if err != nil {
+		return fmt.Errorf("failed to marshal string annotations: %w", err)
+	}
+
+	numericAnnotationsJSON, err := json.Marshal(numericAnnotationsMap)
+	if err != nil {
+		return fmt.Errorf("failed to marshal numeric annotations: %w", err)
+	}
+
+	err = txDB.InsertEntityHistory(ctx, sqlitegolem.InsertEntityHistoryParams{
+		BlockNumber:        int64(blockNumber),
+		OperationIndex:     int64(operationIndex),
+		EntityKey:          key.Hex(),
+		Event:              event,
+		ExpiresAt:          e.ExpiresAt,
+		Payload:            e.Payload,
+		OwnerAddress:       e.OwnerAddress,
+		StringAnnotations:  string(stringAnnotationsJSON),
+		NumericAnnotations: string(numericAnnotationsJSON),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to insert entity history: %w", err)
+	}
+
+	return nil
+}
+
+// recordSnapshot records the current state of the i-th entity of a snapshot of the block as a
+// snapshot event. Snapshot events get negative operation indexes, so that they come before the
+// operations of the block and are not replaced when the block is reverted and processed again:
+// the history of a reverted block is deleted, but the history before the snapshot is unknown.
+func recordSnapshot(ctx context.Context, txDB *sqlitegolem.Queries, blockNumber uint64, i int, key common.Hash) error {
+	return recordHistory(ctx, txDB, eventSnapshot, blockNumber, -1-i, key)
+}
+
+// migrateEntityHistory adds the entity history to a database created before it existed.
+// The history of the existing entities starts with their state at the last processed block,
+// recorded as snapshot events, like the history of a database loaded from a snapshot.
+func migrateEntityHistory(ctx context.Context, db *sql.DB, networkID string) (err error) {
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+
+	defer func() {
+		if err != nil {
+			err = errors.Join(err, tx.Rollback())
+		}
+	}()
+
+	_, err = tx.ExecContext(ctx, sqlitegolem.HistorySchema)
+	if err != nil {
+		return fmt.Errorf("failed to apply the entity history schema: %w", err)
+	}
+
+	txDB := sqlitegolem.New(tx)
+
+	status, err := txDB.GetProcessingStatus(ctx, networkID)
+	if errors.Is(err, sql.ErrNoRows) {
+		// no block was processed yet, the history starts empty
+		return tx.Commit()
+	}
+	if err != nil {
+		return fmt.Errorf("failed to get processing status: %w", err)
+	}
+
+	keys, err := txDB.GetAllEntityKeys(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to get entity keys: %w", err)
+	}
+
+	for i, key := range keys {
+		err = recordSnapshot(ctx, txDB, uint64(status.LastProcessedBlockNumber), i, common.HexToHash(key))
+		if err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+// loadSnapshot inserts the entities of the snapshot and records its block as the last processed block,
+// all in one transaction, so that an interrupted load leaves the database empty.
+func loadSnapshot(ctx context.Context, db *sql.DB, path string, chainID, networkID *big.Int) (err error) {
+	r, err := snapshot.Open(path)
+	if err != nil {
+		return err
+	}
+	defer r.Close()
+
+	if r.Header.ChainID != nil && r.Header.ChainID.Cmp(chainID) != 0 {
+		return fmt.Errorf("snapshot of chain %s, expected %s", r.Header.ChainID, chainID)
+	}
+
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+
+	defer func() {
+		if err != nil {
+			err = errors.Join(err, tx.Rollback())
+		}
+	}()
+
+	txDB := sqlitegolem.New(tx)
+
+	i := 0
+
+	for e, err := range r.Entities() {
+		if err != nil {
+			return err
+		}
+
+		err = insertEntity(ctx, txDB, e)
+		if err != nil {
+			return err
+		}
+
+		// the history starts with the state of the snapshot
+		err = recordSnapshot(ctx, txDB, r.Header.BlockNumber, i, e.EntityKey)
+		if err != nil {
+			return err
+		}
+
+		i++
+	}
+
+	err = txDB.InsertProcessingStatus(ctx, sqlitegolem.InsertProcessingStatusParams{
+		Network:                  networkID.String(),
+		LastProcessedBlockNumber: int64(r.Header.BlockNumber),
+		LastProcessedBlockHash:   r.Header.BlockHash.String(),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to insert processing status: %w", err)
+	}
+
+	return tx.Commit()
+}
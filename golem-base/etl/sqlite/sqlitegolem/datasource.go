@@ -0,0 +1,142 @@
+package sqlitegolem
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/golem-base/query"
+	"github.com/ethereum/go-ethereum/golem-base/storageutil/entity"
+)
+
+var _ query.EntityStore = &DataSource{}
+
+// DataSource evaluates queries against the entities stored by the SQLite ETL.
+// Queries run in a transaction see the entities of a single block.
+type DataSource struct {
+	ctx context.Context
+	q   *Queries
+}
+
+func NewDataSource(ctx context.Context, q *Queries) *DataSource {
+	return &DataSource{
+		ctx: ctx,
+		q:   q,
+	}
+}
+
+func toHashes(keys []string) []common.Hash {
+	hashes := make([]common.Hash, 0, len(keys))
+	for _, key := range keys {
+		hashes = append(hashes, common.HexToHash(key))
+	}
+	return hashes
+}
+
+func (ds *DataSource) GetKeysForStringAnnotation(annotation string, value string) ([]common.Hash, error) {
+	keys, err := ds.q.GetEntityKeysByStringAnnotation(ds.ctx, GetEntityKeysByStringAnnotationParams{
+		AnnotationKey: annotation,
+		Value:         value,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get entities by string annotation: %w", err)
+	}
+	return toHashes(keys), nil
+}
+
+func (ds *DataSource) GetKeysForNumericAnnotation(annotation string, value uint64) ([]common.Hash, error) {
+	keys, err := ds.q.GetEntityKeysByNumericAnnotation(ds.ctx, GetEntityKeysByNumericAnnotationParams{
+		AnnotationKey: annotation,
+		Value:         int64(value),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get entities by numeric annotation: %w", err)
+	}
+	return toHashes(keys), nil
+}
+
+func (ds *DataSource) GetKeysForNumericAnnotationRange(annotation string, from, to uint64) ([]common.Hash, error) {
+	hashes := []common.Hash{}
+
+	// the numeric annotations are stored as int64
+	for _, r := range query.Int64Ranges(from, to) {
+		keys, err := ds.q.GetEntityKeysByNumericAnnotationRange(ds.ctx, GetEntityKeysByNumericAnnotationRangeParams{
+			AnnotationKey: annotation,
+			FromValue:     r.From,
+			ToValue:       r.To,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to get entities by numeric annotation range: %w", err)
+		}
+		hashes = append(hashes, toHashes(keys)...)
+	}
+
+	return hashes, nil
+}
+
+func (ds *DataSource) GetStringAnnotationValuesWithPrefix(annotation string, prefix string) ([]string, error) {
+	values, err := ds.q.GetStringAnnotationValuesWithPrefix(ds.ctx, GetStringAnnotationValuesWithPrefixParams{
+		AnnotationKey: annotation,
+		Prefix:        prefix,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get string annotation values: %w", err)
+	}
+	return values, nil
+}
+
+func (ds *DataSource) GetAllKeys() ([]common.Hash, error) {
+	keys, err := ds.q.GetAllEntityKeys(ds.ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get all entities: %w", err)
+	}
+	return toHashes(keys), nil
+}
+
+func (ds *DataSource) GetPayload(key common.Hash) ([]byte, error) {
+	e, err := ds.q.GetEntity(ds.ctx, key.Hex())
+	if err != nil {
+		return nil, fmt.Errorf("failed to get entity %s: %w", key.Hex(), err)
+	}
+	return e.Payload, nil
+}
+
+func (ds *DataSource) GetEntityMetaData(key common.Hash) (*entity.EntityMetaData, error) {
+	e, err := ds.q.GetEntity(ds.ctx, key.Hex())
+	if err != nil {
+		return nil, fmt.Errorf("failed to get entity %s: %w", key.Hex(), err)
+	}
+
+	stringAnnotations, err := ds.q.GetStringAnnotations(ds.ctx, key.Hex())
+	if err != nil {
+		return nil, fmt.Errorf("failed to get string annotations: %w", err)
+	}
+
+	numericAnnotations, err := ds.q.GetNumericAnnotations(ds.ctx, key.Hex())
+	if err != nil {
+		return nil, fmt.Errorf("failed to get numeric annotations: %w", err)
+	}
+
+	md := &entity.EntityMetaData{
+		ExpiresAtBlock:     uint64(e.ExpiresAt),
+		StringAnnotations:  []entity.StringAnnotation{},
+		NumericAnnotations: []entity.NumericAnnotation{},
+		Owner:              common.HexToAddress(e.OwnerAddress),
+	}
+
+	for _, a := range stringAnnotations {
+		md.StringAnnotations = append(md.StringAnnotations, entity.StringAnnotation{
+			Key:   a.AnnotationKey,
+			Value: a.Value,
+		})
+	}
+
+	for _, a := range numericAnnotations {
+		md.NumericAnnotations = append(md.NumericAnnotations, entity.NumericAnnotation{
+			Key:   a.AnnotationKey,
+			Value: uint64(a.Value),
+		})
+	}
+
+	return md, nil
+}
@@ -0,0 +1,51 @@
+-- The entity history was added after the tables above, databases created without it are migrated
+-- by applying this file.
+
+-- The indexes of the annotation values were added together with the entity history.
+CREATE INDEX IF NOT EXISTS idx_string_annotations_value ON string_annotations(annotation_key, value);
+CREATE INDEX IF NOT EXISTS idx_numeric_annotations_value ON numeric_annotations(annotation_key, value);
+
+-- entity_history holds the state of an entity after every change, keyed by the block and the
+-- index of the operation in the block. Deleted and expired entities are recorded with their last state.
+-- The history of a database loaded from a snapshot or migrated starts with snapshot events at negative
+-- operation indexes, before the operations of their block, which are kept when the block is reverted.
+-- The annotations are stored as JSON objects mapping the annotation keys to their values.
+CREATE TABLE entity_history (
+  block_number INTEGER NOT NULL,
+  operation_index INTEGER NOT NULL,
+  entity_key TEXT NOT NULL,
+  event TEXT NOT NULL CHECK (event IN ('create', 'update', 'delete', 'expire', 'transfer', 'extend', 'patch', 'snapshot')),
+  expires_at INTEGER NOT NULL,
+  payload BLOB NOT NULL,
+  owner_address TEXT NOT NULL,
+  string_annotations TEXT NOT NULL,
+  numeric_annotations TEXT NOT NULL,
+  PRIMARY KEY (block_number, operation_index)
+);
+
+CREATE INDEX idx_entity_history_entity_key ON entity_history(entity_key, block_number, operation_index);
+
+-- live_entity_versions holds every state an entity had at the end of a block while it existed,
+-- with the first block at which it was the state and the first block at which it no longer was.
+-- The entities live at block N are the versions with live_from_block <= N and
+-- live_until_block either NULL or > N.
+CREATE VIEW live_entity_versions AS
+SELECT
+  h.entity_key,
+  h.event,
+  h.expires_at,
+  h.payload,
+  h.owner_address,
+  h.string_annotations,
+  h.numeric_annotations,
+  h.block_number AS live_from_block,
+  (
+    SELECT MIN(n.block_number) FROM entity_history n
+    WHERE n.entity_key = h.entity_key AND n.block_number > h.block_number
+  ) AS live_until_block
+FROM entity_history h
+WHERE h.event NOT IN ('delete', 'expire')
+  AND NOT EXISTS (
+    SELECT 1 FROM entity_history s
+    WHERE s.entity_key = h.entity_key AND s.block_number = h.block_number AND s.operation_index > h.operation_index
+  );
@@ -11,6 +11,30 @@ type Entity struct {
 	OwnerAddress string
 }
 
+type EntityHistory struct {
+	BlockNumber        int64
+	OperationIndex     int64
+	EntityKey          string
+	Event              string
+	ExpiresAt          int64
+	Payload            []byte
+	OwnerAddress       string
+	StringAnnotations  string
+	NumericAnnotations string
+}
+
+type LiveEntityVersion struct {
+	EntityKey          string
+	Event              string
+	ExpiresAt          int64
+	Payload            []byte
+	OwnerAddress       string
+	StringAnnotations  string
+	NumericAnnotations string
+	LiveFromBlock      int64
+	LiveUntilBlock     interface{}
+}
+
 type NumericAnnotation struct {
 	EntityKey     string
 	AnnotationKey string
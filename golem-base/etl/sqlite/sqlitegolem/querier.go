@@ -10,8 +10,10 @@ import (
 
 type Querier interface {
 	DeleteEntity(ctx context.Context, key string) error
+	DeleteNumericAnnotation(ctx context.Context, arg DeleteNumericAnnotationParams) error
 	DeleteNumericAnnotations(ctx context.Context, entityKey string) error
 	DeleteProcessingStatus(ctx context.Context, network string) error
+	DeleteStringAnnotation(ctx context.Context, arg DeleteStringAnnotationParams) error
 	DeleteStringAnnotations(ctx context.Context, entityKey string) error
 	EntityExists(ctx context.Context, key string) (bool, error)
 	GetEntity(ctx context.Context, key string) (GetEntityRow, error)
@@ -25,7 +27,12 @@ type Querier interface {
 	InsertStringAnnotation(ctx context.Context, arg InsertStringAnnotationParams) error
 	NumericAnnotationsForEntityExists(ctx context.Context, entityKey string) (bool, error)
 	StringAnnotationsForEntityExists(ctx context.Context, entityKey string) (bool, error)
+	UpdateEntityExpiresAt(ctx context.Context, arg UpdateEntityExpiresAtParams) error
+	UpdateEntityOwner(ctx context.Context, arg UpdateEntityOwnerParams) error
+	UpdateEntityPayload(ctx context.Context, arg UpdateEntityPayloadParams) error
 	UpdateProcessingStatus(ctx context.Context, arg UpdateProcessingStatusParams) error
+	UpsertNumericAnnotation(ctx context.Context, arg UpsertNumericAnnotationParams) error
+	UpsertStringAnnotation(ctx context.Context, arg UpsertStringAnnotationParams) error
 }
 
 var _ Querier = (*Queries)(nil)
@@ -19,6 +19,40 @@ SELECT annotation_key, value FROM string_annotations WHERE entity_key = ?;
 -- name: GetNumericAnnotations :many
 SELECT annotation_key, value FROM numeric_annotations WHERE entity_key = ?;
 
+-- name: GetAllEntityKeys :many
+SELECT key FROM entities;
+
+-- name: GetEntityKeysByStringAnnotation :many
+SELECT entity_key FROM string_annotations WHERE annotation_key = ? AND value = ?;
+
+-- name: GetEntityKeysByNumericAnnotation :many
+SELECT entity_key FROM numeric_annotations WHERE annotation_key = ? AND value = ?;
+
+-- name: GetEntityKeysByNumericAnnotationRange :many
+SELECT entity_key FROM numeric_annotations
+WHERE annotation_key = sqlc.arg(annotation_key) AND value >= sqlc.arg(from_value) AND value <= sqlc.arg(to_value);
+
+-- name: GetStringAnnotationValuesWithPrefix :many
+SELECT DISTINCT value FROM string_annotations
+WHERE annotation_key = sqlc.arg(annotation_key) AND substr(value, 1, length(CAST(sqlc.arg(prefix) AS TEXT))) = CAST(sqlc.arg(prefix) AS TEXT);
+
+-- name: UpdateEntityOwner :exec
+UPDATE entities SET owner_address = ? WHERE key = ?;
+
+-- name: UpdateEntityExpiresAt :exec
+UPDATE entities SET expires_at = ? WHERE key = ?;
+
+-- name: UpdateEntityPayload :exec
+UPDATE entities SET payload = ? WHERE key = ?;
+
+-- name: UpsertStringAnnotation :exec
+INSERT INTO string_annotations (entity_key, annotation_key, value) VALUES (?, ?, ?)
+ON CONFLICT (entity_key, annotation_key) DO UPDATE SET value = excluded.value;
+
+-- name: UpsertNumericAnnotation :exec
+INSERT INTO numeric_annotations (entity_key, annotation_key, value) VALUES (?, ?, ?)
+ON CONFLICT (entity_key, annotation_key) DO UPDATE SET value = excluded.value;
+
 -- name: DeleteEntity :exec
 DELETE FROM entities WHERE key = ?;
 
@@ -28,6 +62,12 @@ DELETE FROM string_annotations WHERE entity_key = ?;
 -- name: DeleteNumericAnnotations :exec
 DELETE FROM numeric_annotations WHERE entity_key = ?;
 
+-- name: DeleteStringAnnotation :exec
+DELETE FROM string_annotations WHERE entity_key = ? AND annotation_key = ?;
+
+-- name: DeleteNumericAnnotation :exec
+DELETE FROM numeric_annotations WHERE entity_key = ? AND annotation_key = ?;
+
 -- name: GetProcessingStatus :one
 SELECT last_processed_block_number, last_processed_block_hash FROM processing_status WHERE network = ?;
 
@@ -52,3 +92,19 @@ SELECT COUNT(*) > 0 FROM string_annotations WHERE entity_key = ?;
 -- name: NumericAnnotationsForEntityExists :one
 SELECT COUNT(*) > 0 FROM numeric_annotations WHERE entity_key = ?;
 
+
+-- name: InsertEntityHistory :exec
+INSERT INTO entity_history (block_number, operation_index, entity_key, event, expires_at, payload, owner_address, string_annotations, numeric_annotations) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?);
+
+-- name: DeleteEntityHistoryOfBlock :exec
+DELETE FROM entity_history WHERE block_number = ? AND event != 'snapshot';
+
+-- name: GetEntityHistory :many
+SELECT block_number, operation_index, event, expires_at, payload, owner_address, string_annotations, numeric_annotations
+FROM entity_history WHERE entity_key = ? ORDER BY block_number, operation_index;
+
+-- name: GetEntitiesAtBlock :many
+SELECT entity_key, expires_at, payload, owner_address, string_annotations, numeric_annotations
+FROM live_entity_versions
+WHERE live_from_block <= sqlc.arg(block_number) AND (live_until_block IS NULL OR live_until_block > sqlc.arg(block_number))
+ORDER BY entity_key;
@@ -18,6 +18,29 @@ func (q *Queries) DeleteEntity(ctx context.Context, key string) error {
 	return err
 }
 
+const deleteEntityHistoryOfBlock = `-- name: DeleteEntityHistoryOfBlock :exec
+DELETE FROM entity_history WHERE block_number = ? AND event != 'snapshot'
+`
+
+func (q *Queries) DeleteEntityHistoryOfBlock(ctx context.Context, blockNumber int64) error {
+	_, err := q.db.ExecContext(ctx, deleteEntityHistoryOfBlock, blockNumber)
+	return err
+}
+
+const deleteNumericAnnotation = `-- name: DeleteNumericAnnotation :exec
+DELETE FROM numeric_annotations WHERE entity_key = ? AND annotation_key = ?
+`
+
+type DeleteNumericAnnotationParams struct {
+	EntityKey     string
+	AnnotationKey string
+}
+
+func (q *Queries) DeleteNumericAnnotation(ctx context.Context, arg DeleteNumericAnnotationParams) error {
+	_, err := q.db.ExecContext(ctx, deleteNumericAnnotation, arg.EntityKey, arg.AnnotationKey)
+	return err
+}
+
 const deleteNumericAnnotations = `-- name: DeleteNumericAnnotations :exec
 DELETE FROM numeric_annotations WHERE entity_key = ?
 `
@@ -36,6 +59,20 @@ func (q *Queries) DeleteProcessingStatus(ctx context.Context, network string) er
 	return err
 }
 
+const deleteStringAnnotation = `-- name: DeleteStringAnnotation :exec
+DELETE FROM string_annotations WHERE entity_key = ? AND annotation_key = ?
+`
+
+type DeleteStringAnnotationParams struct {
+	EntityKey     string
+	AnnotationKey string
+}
+
+func (q *Queries) DeleteStringAnnotation(ctx context.Context, arg DeleteStringAnnotationParams) error {
+	_, err := q.db.ExecContext(ctx, deleteStringAnnotation, arg.EntityKey, arg.AnnotationKey)
+	return err
+}
+
 const deleteStringAnnotations = `-- name: DeleteStringAnnotations :exec
 DELETE FROM string_annotations WHERE entity_key = ?
 `
@@ -56,6 +93,79 @@ func (q *Queries) EntityExists(ctx context.Context, key string) (bool, error) {
 	return column_1, err
 }
 
+const getAllEntityKeys = `-- name: GetAllEntityKeys :many
+SELECT key FROM entities
+`
+
+func (q *Queries) GetAllEntityKeys(ctx context.Context) ([]string, error) {
+	rows, err := q.db.QueryContext(ctx, getAllEntityKeys)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []string
+	for rows.Next() {
+		var key string
+		if err := rows.Scan(&key); err != nil {
+			return nil, err
+		}
+		items = append(items, key)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getEntitiesAtBlock = `-- name: GetEntitiesAtBlock :many
+SELECT entity_key, expires_at, payload, owner_address, string_annotations, numeric_annotations
+FROM live_entity_versions
+WHERE live_from_block <= ?1 AND (live_until_block IS NULL OR live_until_block > ?1)
+ORDER BY entity_key
+`
+
+type GetEntitiesAtBlockRow struct {
+	EntityKey          string
+	ExpiresAt          int64
+	Payload            []byte
+	OwnerAddress       string
+	StringAnnotations  string
+	NumericAnnotations string
+}
+
+func (q *Queries) GetEntitiesAtBlock(ctx context.Context, blockNumber int64) ([]GetEntitiesAtBlockRow, error) {
+	rows, err := q.db.QueryContext(ctx, getEntitiesAtBlock, blockNumber)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetEntitiesAtBlockRow
+	for rows.Next() {
+		var i GetEntitiesAtBlockRow
+		if err := rows.Scan(
+			&i.EntityKey,
+			&i.ExpiresAt,
+			&i.Payload,
+			&i.OwnerAddress,
+			&i.StringAnnotations,
+			&i.NumericAnnotations,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const getEntitiesByOwner = `-- name: GetEntitiesByOwner :many
 SELECT key, expires_at, payload FROM entities WHERE owner_address = ?
 `
@@ -106,6 +216,152 @@ func (q *Queries) GetEntity(ctx context.Context, key string) (GetEntityRow, erro
 	return i, err
 }
 
+const getEntityHistory = `-- name: GetEntityHistory :many
+SELECT block_number, operation_index, event, expires_at, payload, owner_address, string_annotations, numeric_annotations
+FROM entity_history WHERE entity_key = ? ORDER BY block_number, operation_index
+`
+
+type GetEntityHistoryRow struct {
+	BlockNumber        int64
+	OperationIndex     int64
+	Event              string
+	ExpiresAt          int64
+	Payload            []byte
+	OwnerAddress       string
+	StringAnnotations  string
+	NumericAnnotations string
+}
+
+func (q *Queries) GetEntityHistory(ctx context.Context, entityKey string) ([]GetEntityHistoryRow, error) {
+	rows, err := q.db.QueryContext(ctx, getEntityHistory, entityKey)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetEntityHistoryRow
+	for rows.Next() {
+		var i GetEntityHistoryRow
+		if err := rows.Scan(
+			&i.BlockNumber,
+			&i.OperationIndex,
+			&i.Event,
+			&i.ExpiresAt,
+			&i.Payload,
+			&i.OwnerAddress,
+			&i.StringAnnotations,
+			&i.NumericAnnotations,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getEntityKeysByNumericAnnotation = `-- name: GetEntityKeysByNumericAnnotation :many
+SELECT entity_key FROM numeric_annotations WHERE annotation_key = ? AND value = ?
+`
+
+type GetEntityKeysByNumericAnnotationParams struct {
+	AnnotationKey string
+	Value         int64
+}
+
+func (q *Queries) GetEntityKeysByNumericAnnotation(ctx context.Context, arg GetEntityKeysByNumericAnnotationParams) ([]string, error) {
+	rows, err := q.db.QueryContext(ctx, getEntityKeysByNumericAnnotation, arg.AnnotationKey, arg.Value)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []string
+	for rows.Next() {
+		var entity_key string
+		if err := rows.Scan(&entity_key); err != nil {
+			return nil, err
+		}
+		items = append(items, entity_key)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getEntityKeysByNumericAnnotationRange = `-- name: GetEntityKeysByNumericAnnotationRange :many
+SELECT entity_key FROM numeric_annotations
+WHERE annotation_key = ?1 AND value >= ?2 AND value <= ?3
+`
+
+type GetEntityKeysByNumericAnnotationRangeParams struct {
+	AnnotationKey string
+	FromValue     int64
+	ToValue       int64
+}
+
+func (q *Queries) GetEntityKeysByNumericAnnotationRange(ctx context.Context, arg GetEntityKeysByNumericAnnotationRangeParams) ([]string, error) {
+	rows, err := q.db.QueryContext(ctx, getEntityKeysByNumericAnnotationRange, arg.AnnotationKey, arg.FromValue, arg.ToValue)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []string
+	for rows.Next() {
+		var entity_key string
+		if err := rows.Scan(&entity_key); err != nil {
+			return nil, err
+		}
+		items = append(items, entity_key)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getEntityKeysByStringAnnotation = `-- name: GetEntityKeysByStringAnnotation :many
+SELECT entity_key FROM string_annotations WHERE annotation_key = ? AND value = ?
+`
+
+type GetEntityKeysByStringAnnotationParams struct {
+	AnnotationKey string
+	Value         string
+}
+
+func (q *Queries) GetEntityKeysByStringAnnotation(ctx context.Context, arg GetEntityKeysByStringAnnotationParams) ([]string, error) {
+	rows, err := q.db.QueryContext(ctx, getEntityKeysByStringAnnotation, arg.AnnotationKey, arg.Value)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []string
+	for rows.Next() {
+		var entity_key string
+		if err := rows.Scan(&entity_key); err != nil {
+			return nil, err
+		}
+		items = append(items, entity_key)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const getNumericAnnotations = `-- name: GetNumericAnnotations :many
 SELECT annotation_key, value FROM numeric_annotations WHERE entity_key = ?
 `
@@ -154,6 +410,39 @@ func (q *Queries) GetProcessingStatus(ctx context.Context, network string) (GetP
 	return i, err
 }
 
+const getStringAnnotationValuesWithPrefix = `-- name: GetStringAnnotationValuesWithPrefix :many
+SELECT DISTINCT value FROM string_annotations
+WHERE annotation_key = ?1 AND substr(value, 1, length(CAST(?2 AS TEXT))) = CAST(?2 AS TEXT)
+`
+
+type GetStringAnnotationValuesWithPrefixParams struct {
+	AnnotationKey string
+	Prefix        string
+}
+
+func (q *Queries) GetStringAnnotationValuesWithPrefix(ctx context.Context, arg GetStringAnnotationValuesWithPrefixParams) ([]string, error) {
+	rows, err := q.db.QueryContext(ctx, getStringAnnotationValuesWithPrefix, arg.AnnotationKey, arg.Prefix)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []string
+	for rows.Next() {
+		var value string
+		if err := rows.Scan(&value); err != nil {
+			return nil, err
+		}
+		items = append(items, value)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const getStringAnnotations = `-- name: GetStringAnnotations :many
 SELECT annotation_key, value FROM string_annotations WHERE entity_key = ?
 `
@@ -218,6 +507,37 @@ func (q *Queries) InsertEntity(ctx context.Context, arg InsertEntityParams) erro
 	return err
 }
 
+const insertEntityHistory = `-- name: InsertEntityHistory :exec
+INSERT INTO entity_history (block_number, operation_index, entity_key, event, expires_at, payload, owner_address, string_annotations, numeric_annotations) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)
+`
+
+type InsertEntityHistoryParams struct {
+	BlockNumber        int64
+	OperationIndex     int64
+	EntityKey          string
+	Event              string
+	ExpiresAt          int64
+	Payload            []byte
+	OwnerAddress       string
+	StringAnnotations  string
+	NumericAnnotations string
+}
+
+func (q *Queries) InsertEntityHistory(ctx context.Context, arg InsertEntityHistoryParams) error {
+	_, err := q.db.ExecContext(ctx, insertEntityHistory,
+		arg.BlockNumber,
+		arg.OperationIndex,
+		arg.EntityKey,
+		arg.Event,
+		arg.ExpiresAt,
+		arg.Payload,
+		arg.OwnerAddress,
+		arg.StringAnnotations,
+		arg.NumericAnnotations,
+	)
+	return err
+}
+
 const insertNumericAnnotation = `-- name: InsertNumericAnnotation :exec
 INSERT INTO numeric_annotations (entity_key, annotation_key, value) VALUES (?, ?, ?)
 `
@@ -285,6 +605,48 @@ func (q *Queries) StringAnnotationsForEntityExists(ctx context.Context, entityKe
 	return column_1, err
 }
 
+const updateEntityExpiresAt = `-- name: UpdateEntityExpiresAt :exec
+UPDATE entities SET expires_at = ? WHERE key = ?
+`
+
+type UpdateEntityExpiresAtParams struct {
+	ExpiresAt int64
+	Key       string
+}
+
+func (q *Queries) UpdateEntityExpiresAt(ctx context.Context, arg UpdateEntityExpiresAtParams) error {
+	_, err := q.db.ExecContext(ctx, updateEntityExpiresAt, arg.ExpiresAt, arg.Key)
+	return err
+}
+
+const updateEntityOwner = `-- name: UpdateEntityOwner :exec
+UPDATE entities SET owner_address = ? WHERE key = ?
+`
+
+type UpdateEntityOwnerParams struct {
+	OwnerAddress string
+	Key          string
+}
+
+func (q *Queries) UpdateEntityOwner(ctx context.Context, arg UpdateEntityOwnerParams) error {
+	_, err := q.db.ExecContext(ctx, updateEntityOwner, arg.OwnerAddress, arg.Key)
+	return err
+}
+
+const updateEntityPayload = `-- name: UpdateEntityPayload :exec
+UPDATE entities SET payload = ? WHERE key = ?
+`
+
+type UpdateEntityPayloadParams struct {
+	Payload []byte
+	Key     string
+}
+
+func (q *Queries) UpdateEntityPayload(ctx context.Context, arg UpdateEntityPayloadParams) error {
+	_, err := q.db.ExecContext(ctx, updateEntityPayload, arg.Payload, arg.Key)
+	return err
+}
+
 const updateProcessingStatus = `-- name: UpdateProcessingStatus :exec
 UPDATE processing_status SET last_processed_block_number = ?, last_processed_block_hash = ? WHERE network = ?
 `
@@ -299,3 +661,35 @@ func (q *Queries) UpdateProcessingStatus(ctx context.Context, arg UpdateProcessi
 	_, err := q.db.ExecContext(ctx, updateProcessingStatus, arg.LastProcessedBlockNumber, arg.LastProcessedBlockHash, arg.Network)
 	return err
 }
+
+const upsertNumericAnnotation = `-- name: UpsertNumericAnnotation :exec
+INSERT INTO numeric_annotations (entity_key, annotation_key, value) VALUES (?, ?, ?)
+ON CONFLICT (entity_key, annotation_key) DO UPDATE SET value = excluded.value
+`
+
+type UpsertNumericAnnotationParams struct {
+	EntityKey     string
+	AnnotationKey string
+	Value         int64
+}
+
+func (q *Queries) UpsertNumericAnnotation(ctx context.Context, arg UpsertNumericAnnotationParams) error {
+	_, err := q.db.ExecContext(ctx, upsertNumericAnnotation, arg.EntityKey, arg.AnnotationKey, arg.Value)
+	return err
+}
+
+const upsertStringAnnotation = `-- name: UpsertStringAnnotation :exec
+INSERT INTO string_annotations (entity_key, annotation_key, value) VALUES (?, ?, ?)
+ON CONFLICT (entity_key, annotation_key) DO UPDATE SET value = excluded.value
+`
+
+type UpsertStringAnnotationParams struct {
+	EntityKey     string
+	AnnotationKey string
+	Value         string
+}
+
+func (q *Queries) UpsertStringAnnotation(ctx context.Context, arg UpsertStringAnnotationParams) error {
+	_, err := q.db.ExecContext(ctx, upsertStringAnnotation, arg.EntityKey, arg.AnnotationKey, arg.Value)
+	return err
+}
@@ -0,0 +1,14 @@
+package sqlitegolem
+
+import _ "embed"
+
+// Schema creates the tables of the entities and the processing status.
+//
+//go:embed schema.sql
+var Schema string
+
+// HistorySchema creates the entity history. It is applied after Schema, and on its own to migrate
+// databases created before the entity history existed.
+//
+//go:embed history_schema.sql
+var HistorySchema string
@@ -26,4 +26,3 @@ CREATE TABLE numeric_annotations (
   value INTEGER NOT NULL,
   PRIMARY KEY (entity_key, annotation_key)
 );
-
@@ -6,6 +6,7 @@ Feature: deleting entities
     Then the entity should be deleted
     And the number of entities should be 0
     And the list of all entities should be empty
+    And the meta data of the entity should not be found
 
   Scenario: deleting entity after it has been updated
     Given I have created an entity
@@ -0,0 +1,20 @@
+Feature: subscribing to entity events
+
+  Scenario: receiving the events of the entities matching the query
+    Given I have subscribed to the entity events with the query 'test_key = "test_value" && test_number >= 40'
+    When I have created an entity
+    And I submit a transaction to delete the entity
+    Then I should receive the created event of the entity
+    And I should receive the deleted event of the entity
+
+  Scenario: not receiving the events of the entities not matching the query
+    Given I have subscribed to the entity events with the query 'test_key = "other_value"'
+    When I have created an entity
+    Then I should not receive an event of the entity
+
+  Scenario: receiving the expiration of the entities of an owner
+    Given I have subscribed to the entity events of my account
+    And there is an entity that will expire in the next block
+    When there is a new block
+    Then I should receive the created event of the entity
+    And I should receive the expired event of the entity
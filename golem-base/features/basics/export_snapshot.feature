@@ -0,0 +1,13 @@
+Feature: exporting a snapshot of all entities
+
+  Scenario: exporting a snapshot of a stopped node
+    Given I have created an entity
+    When I stop the node and export a snapshot
+    Then the snapshot should be of the last block of the write-ahead log
+    And the snapshot should contain the entity
+
+  Scenario: exporting a snapshot of an earlier block
+    Given I have created an entity
+    When I stop the node and export a snapshot of the block before the entity
+    Then the snapshot should be of the block before the entity
+    And the snapshot should not contain the entity
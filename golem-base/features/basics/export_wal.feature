@@ -0,0 +1,12 @@
+Feature: exporting the write-ahead log from the chain history
+
+  Scenario: exporting the write-ahead log of a stopped node
+    Given I have created an entity
+    When I stop the node and export the write-ahead log
+    Then the exported write-ahead log should contain the operations of the write-ahead log of the node
+
+  Scenario: resuming the export of the write-ahead log
+    Given I have created an entity
+    When I stop the node and export the write-ahead log up to the block before the entity
+    And I export the write-ahead log again
+    Then the exported write-ahead log should contain the operations of the write-ahead log of the node
@@ -0,0 +1,26 @@
+Feature: extending the TTL of an entity
+
+  Scenario: extending the TTL of an entity
+    Given I have created an entity
+    When I submit a transaction to extend the TTL of the entity by 100 blocks
+    Then the entity should expire 100 blocks later
+    And the entity should be in the list of entities to expire at the new expiration block
+    And the payload of the entity should not be changed
+
+  Scenario: extending the TTL of an entity owned by another account
+    Given I have created an entity
+    And there is another account
+    When the other account submits a transaction to extend the TTL of the entity by 100 blocks
+    Then the transaction should fail
+
+  Scenario: write-ahead log of the extension
+    Given I have created an entity
+    When I submit a transaction to extend the TTL of the entity by 100 blocks
+    Then the write-ahead log for the extension should be created
+
+  Scenario: extending a deleted entity fails
+    Given I have created an entity
+    And I submit a transaction to delete the entity
+    When I try to extend the TTL of the entity by 100 blocks
+    Then the transaction should fail
+    And the number of entities should be 0
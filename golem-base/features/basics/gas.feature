@@ -0,0 +1,10 @@
+Feature: Gas metering
+
+  Scenario: storage operations are charged for
+    When I create an entity with a payload of 1K and a TTL of 1000 blocks
+    Then the gas used by the transaction should cover the storage operations
+
+  Scenario: running out of gas reverts the storage transaction
+    When I create an entity without enough gas for the storage operations
+    Then I should see an error containing "transaction failed"
+    And the number of entities should be 0
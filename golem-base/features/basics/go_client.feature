@@ -0,0 +1,24 @@
+Feature: the Go client
+
+  Scenario: creating entities in a batch with the Go client
+    When I create 3 entities in a batch with the Go client
+    Then the receipt should contain the keys of the 3 created entities
+    And the Go client should return the payloads and annotations of the created entities
+    And the Go client should find the created entities with the query 'client = "batch"'
+
+  Scenario: changing entities in a batch with the Go client
+    Given I have created 2 entities with the Go client
+    When I update the first entity and extend the second entity by 10 blocks in a batch with the Go client
+    Then the receipt should contain the update of the first entity and the extension of the second entity
+    When I delete the entities with the Go client
+    Then the receipt should contain the deletion of the entities
+    And the Go client should not find any entities of the account
+
+  Scenario: packing many operations into transactions with the Go client
+    When I create 40 entities in transactions of at most 3000000 gas with the Go client
+    Then the entities should have been created in several transactions of at most 3000000 gas
+    And the Go client should find the created entities with the query 'client = "packed"'
+
+  Scenario: failing transactions with the Go client
+    When I delete an entity that does not exist with the Go client
+    Then the Go client should report a failed transaction
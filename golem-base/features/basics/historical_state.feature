@@ -0,0 +1,16 @@
+Feature: querying historical state
+
+  Scenario: reading the payload of an entity at an earlier block
+    Given I have created an entity
+    When I submit a transaction to update the entity, changing the paylod
+    Then the payload of the entity at the creation block should be the original payload
+    And the payload of the entity at the latest block should be changed
+
+  Scenario: querying entities at an earlier block
+    Given I have created an entity
+    When I submit a transaction to delete the entity
+    Then the query at the creation block should find the entity
+    And the number of entities at the creation block should be 1
+    And the entity should be in the list of all entities at the creation block
+    And the entity should be in the list of entities of the owner at the creation block
+    And the number of entities should be 0
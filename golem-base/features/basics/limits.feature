@@ -0,0 +1,16 @@
+Feature: Storage limits
+
+  Scenario: creating an entity with a TTL of zero
+    When I try to create an entity with a TTL of 0 blocks
+    Then I should see an error containing "TTL must be greater than zero"
+    And the number of entities should be 0
+
+  Scenario: creating an entity with a TTL above the maximum
+    When I try to create an entity with a TTL of 1296001 blocks
+    Then I should see an error containing "TTL exceeds the maximum"
+    And the number of entities should be 0
+
+  Scenario: creating an entity with too many annotations
+    When I try to create an entity with 33 string annotations
+    Then I should see an error containing "number of annotations exceeds the maximum"
+    And the number of entities should be 0
@@ -0,0 +1,11 @@
+Feature: subscribing to the operations of the write-ahead log
+
+  Scenario: receiving the operations of new blocks
+    Given I have subscribed to the operations of new blocks
+    When I have created an entity
+    Then I should receive the create operation of the entity
+
+  Scenario: receiving the operations of past blocks
+    Given I have created an entity
+    When I subscribe to the operations from block 0
+    Then I should receive the create operation of the entity
@@ -0,0 +1,43 @@
+Feature: entity ownership
+
+  Scenario: owner is kept when the entity is updated
+    Given I have created an entity
+    When I submit a transaction to update the entity, changing the paylod
+    Then the sender should be the owner of the entity
+    And the entity should be in the list of entities of the owner
+
+  Scenario: updating an entity owned by another account
+    Given I have created an entity
+    And there is another account
+    When the other account submits a transaction to update the entity
+    Then the transaction should fail
+    And the payload of the entity should not be changed
+
+  Scenario: deleting an entity owned by another account
+    Given I have created an entity
+    And there is another account
+    When the other account submits a transaction to delete the entity
+    Then the transaction should fail
+    And the number of entities should be 1
+
+  Scenario: transferring ownership of an entity
+    Given I have created an entity
+    And there is another account
+    When I submit a transaction to transfer the ownership of the entity to the other account
+    Then the other account should be the owner of the entity
+    And the entity should be in the list of entities of the other account
+
+  Scenario: new owner can delete the transferred entity
+    Given I have created an entity
+    And there is another account
+    And I have transferred the ownership of the entity to the other account
+    When the other account submits a transaction to delete the entity
+    Then the number of entities should be 0
+
+  Scenario: previous owner cannot delete the transferred entity
+    Given I have created an entity
+    And there is another account
+    And I have transferred the ownership of the entity to the other account
+    When I try to delete the entity
+    Then the transaction should fail
+    And the number of entities should be 1
@@ -0,0 +1,43 @@
+Feature: patching entities
+
+  Scenario: setting a string annotation
+    Given I have created an entity
+    When I submit a transaction to patch the entity, setting the string annotation "test_key" to "patched"
+    Then the entity should have the string annotation "test_key" with the value "patched"
+    And the payload of the entity should not be changed
+    When I search for entities with the string annotation "test_key" equal to "patched"
+    Then I should find 1 entity
+    When I search for entities with the string annotation "test_key" equal to "test_value"
+    Then I should find 0 entities
+
+  Scenario: removing a numeric annotation
+    Given I have created an entity
+    When I submit a transaction to patch the entity, removing the numeric annotation "test_number"
+    Then the entity should not have the numeric annotation "test_number"
+    When I search for entities with the numeric annotation "test_number" equal to "42"
+    Then I should find 0 entities
+
+  Scenario: removing an annotation the entity does not have
+    Given I have created an entity
+    When I try to patch the entity, removing the numeric annotation "missing"
+    Then the transaction should fail
+
+  Scenario: replacing the payload
+    Given I have created an entity
+    When I submit a transaction to patch the entity, replacing the payload with "patched payload"
+    Then the payload of the entity should be "patched payload"
+    And the entity should have the string annotation "test_key" with the value "test_value"
+
+  Scenario: write-ahead log of the patch
+    Given I have created an entity
+    When I submit a transaction to patch the entity, setting the string annotation "test_key" to "patched"
+    Then the write-ahead log for the patch should be created
+
+  Scenario: patching a deleted entity fails
+    Given I have created an entity
+    And I submit a transaction to delete the entity
+    When I try to patch the entity, setting the string annotation "test_key" to "patched"
+    Then the transaction should fail
+    And the number of entities should be 0
+    When I search for entities with the string annotation "test_key" equal to "patched"
+    Then I should find 0 entities
@@ -0,0 +1,75 @@
+Feature: Query options
+
+  Scenario: paging through results ordered by a numeric annotation
+    Given I have an entity "e1" with numeric annotations:
+      | rank | 3 |
+    And I have an entity "e2" with numeric annotations:
+      | rank | 1 |
+    And I have an entity "e3" with numeric annotations:
+      | rank | 2 |
+    When I search for entities with the query "rank > 0" and the options:
+      """
+      {"limit": 2, "orderBy": "numericAnnotation", "orderByAnnotation": "rank"}
+      """
+    Then I should find 2 entities
+    And the results should be ordered by the numeric annotation "rank"
+    When I fetch the next page of the results
+    Then I should find 1 entity
+    And there should be no more results
+
+  Scenario: pages are read from the block of the first page
+    Given I have an entity "e1" with numeric annotations:
+      | rank | 1 |
+    And I have an entity "e2" with numeric annotations:
+      | rank | 2 |
+    When I search for entities with the query "rank > 0" and the options:
+      """
+      {"limit": 1}
+      """
+    And I have an entity "e3" with numeric annotations:
+      | rank | 3 |
+    And I fetch the next page of the results
+    Then I should find 1 entity
+    And there should be no more results
+
+  Scenario: leaving out the payloads
+    Given I have an entity "e1" with numeric annotations:
+      | rank | 1 |
+    When I search for entities with the query "rank = 1" and the options:
+      """
+      {"omitPayload": true}
+      """
+    Then I should find 1 entity
+    And the results should not contain payloads
+
+  Scenario: using a cursor with a different query
+    Given I have an entity "e1" with numeric annotations:
+      | rank | 1 |
+    And I have an entity "e2" with numeric annotations:
+      | rank | 2 |
+    When I search for entities with the query "rank > 0" and the options:
+      """
+      {"limit": 1}
+      """
+    And I fetch the next page of the results for the query "rank > 1"
+    Then I should see an error containing "cursor does not belong to this query"
+
+  Scenario: including the meta data in the results
+    Given I have an entity "e1" with numeric annotations:
+      | rank | 1 |
+    When I search for entities with the query "rank = 1" and the options:
+      """
+      {"projection": "metaData"}
+      """
+    Then I should find 1 entity
+    And the results should contain the meta data of the entities
+
+  Scenario: leaving out the meta data by default
+    Given I have an entity "e1" with numeric annotations:
+      | rank | 1 |
+    When I search for entities with the query "rank = 1" and the options:
+      """
+      {}
+      """
+    Then I should find 1 entity
+    And the results should not contain the meta data of the entities
@@ -39,3 +39,70 @@ Feature: Search
       key = 8e
       """
     Then I should see an error containing "unexpected token"
+
+  Scenario: finding entities by a numeric range
+    Given I have an entity "e1" with numeric annotations:
+      | age | 17 |
+    And I have an entity "e2" with numeric annotations:
+      | age | 18 |
+    And I have an entity "e3" with numeric annotations:
+      | age | 65 |
+    And I have an entity "e4" with numeric annotations:
+      | age | 300 |
+    When I search for entities with the query
+      """
+      age >= 18 && age < 300
+      """
+    Then I should find 2 entities
+
+  Scenario: finding entities with a numeric annotation not equal to a value
+    Given I have an entity "e1" with numeric annotations:
+      | age | 17 |
+    And I have an entity "e2" with numeric annotations:
+      | age | 18 |
+    And I have an entity "e3" with string annotations:
+      | foo | bar |
+    When I search for entities with the query
+      """
+      age != 18
+      """
+    Then I should find 1 entity
+
+  Scenario: finding entities by a glob on a string annotation
+    Given I have an entity "e1" with string annotations:
+      | name | invoice-1 |
+    And I have an entity "e2" with string annotations:
+      | name | invoice-2 |
+    And I have an entity "e3" with string annotations:
+      | name | receipt-1 |
+    When I search for entities with the query
+      """
+      name ~ "invoice-*"
+      """
+    Then I should find 2 entities
+
+  Scenario: finding entities with a string annotation not equal to a value
+    Given I have an entity "e1" with string annotations:
+      | name | invoice-1 |
+    And I have an entity "e2" with string annotations:
+      | name | invoice-2 |
+    And I have an entity "e3" with numeric annotations:
+      | age | 18 |
+    When I search for entities with the query
+      """
+      name != "invoice-1"
+      """
+    Then I should find 1 entity
+
+  Scenario: finding entities with a negated query
+    Given I have an entity "e1" with string annotations:
+      | name | invoice-1 |
+    And I have an entity "e2" with string annotations:
+      | name | invoice-2 |
+    And I have an entity "e3" with numeric annotations:
+      | age | 18 |
+    When I search for entities with the query
+      """
+      NOT name ~ "invoice-*"
+      """
+    Then I should find 1 entity
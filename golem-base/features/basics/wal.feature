@@ -23,4 +23,10 @@ Feature: Write-ahead log
     And there is an entity that will expire in the next block
     When there is a new block
     Then the expired entity should be deleted
-    And the write-ahead log for the delete should be created
+    And the write-ahead log for the expiration should be created
+
+  Scenario: transferring ownership of an entity
+    Given I have created an entity
+    And there is another account
+    When I submit a transaction to transfer the ownership of the entity to the other account
+    Then the write-ahead log for the ownership transfer should be created
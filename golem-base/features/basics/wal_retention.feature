@@ -0,0 +1,16 @@
+Feature: acknowledging the operations of the write-ahead log
+
+  Scenario: acknowledging the operations registers the consumer
+    Given I have created an entity
+    When I acknowledge the operations of the last block as consumer "etl"
+    Then the write-ahead log should have a checkpoint of consumer "etl" for the last block
+
+  Scenario: removing a consumer
+    Given I have created an entity
+    And I acknowledge the operations of the last block as consumer "etl"
+    When I remove the consumer "etl"
+    Then the write-ahead log should not have a checkpoint of consumer "etl"
+
+  Scenario: acknowledging with an invalid consumer name
+    When I acknowledge the operations of the last block as consumer "../etl"
+    Then I should see an error containing "invalid consumer name"
@@ -0,0 +1,37 @@
+package golemtype
+
+import (
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/golem-base/storageutil/entity"
+)
+
+// EntityFilter selects the events of the golembase_subscribe("entities") subscription.
+// Both the query and the owner are optional, an empty filter selects all events.
+type EntityFilter struct {
+	// Query is an expression of the query language the annotations of the entity have to match.
+	Query string `json:"query,omitempty"`
+	// Owner is the owner the entity has to have.
+	Owner *common.Address `json:"owner,omitempty"`
+}
+
+type EntityEventType string
+
+const (
+	EntityCreated EntityEventType = "created"
+	// EntityUpdated is a change of an existing entity: an update, a patch, an extension or a transfer of its ownership.
+	EntityUpdated EntityEventType = "updated"
+	EntityDeleted EntityEventType = "deleted"
+	EntityExpired EntityEventType = "expired"
+)
+
+// EntityEvent is a change of an entity in a block.
+type EntityEvent struct {
+	Type        EntityEventType `json:"type"`
+	EntityKey   common.Hash     `json:"entityKey"`
+	BlockNumber uint64          `json:"blockNumber"`
+	BlockHash   common.Hash     `json:"blockHash"`
+	// MetaData is the meta data of the entity after the change, or before it was deleted or expired.
+	MetaData entity.EntityMetaData `json:"metaData"`
+	// PreviousMetaData is the meta data of the entity before it was updated.
+	PreviousMetaData *entity.EntityMetaData `json:"previousMetaData,omitempty"`
+}
@@ -0,0 +1,48 @@
+package golemtype
+
+import (
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// The orderings supported by QueryOptions.OrderBy.
+const (
+	OrderByKey               = "key"
+	OrderByExpiresAtBlock    = "expiresAtBlock"
+	OrderByNumericAnnotation = "numericAnnotation"
+)
+
+// The projections supported by QueryOptions.Projection.
+const (
+	ProjectionPayload  = "payload"
+	ProjectionMetaData = "metaData"
+)
+
+// QueryOptions controls which page of the results of a query is returned,
+// in which order and whether the payloads are included.
+type QueryOptions struct {
+	// Limit is the maximum number of results in a page, 0 means no limit.
+	Limit uint64 `json:"limit,omitempty"`
+	// Cursor is the opaque cursor returned with the previous page.
+	// The next page is read from the same block as the previous one.
+	Cursor string `json:"cursor,omitempty"`
+	// OrderBy is one of OrderByKey (the default), OrderByExpiresAtBlock or OrderByNumericAnnotation.
+	OrderBy string `json:"orderBy,omitempty"`
+	// OrderByAnnotation is the numeric annotation to order by when OrderBy is OrderByNumericAnnotation.
+	OrderByAnnotation string `json:"orderByAnnotation,omitempty"`
+	// Descending reverses the order of the results.
+	Descending bool `json:"descending,omitempty"`
+	// OmitPayload leaves the payloads out of the results.
+	OmitPayload bool `json:"omitPayload,omitempty"`
+	// Projection is either ProjectionPayload (the default), returning the key and the payload
+	// of the entities, or ProjectionMetaData, returning their meta data as well.
+	Projection string `json:"projection,omitempty"`
+}
+
+// QueryResult is a page of the results of a query.
+type QueryResult struct {
+	BlockHash   common.Hash                `json:"blockHash"`
+	BlockNumber uint64                     `json:"blockNumber"`
+	Results     []SearchResultWithMetaData `json:"results"`
+	// Cursor is the cursor of the next page, empty if there are no more results.
+	Cursor string `json:"cursor,omitempty"`
+}
@@ -2,9 +2,17 @@ package golemtype
 
 import (
 	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/golem-base/storageutil/entity"
 )
 
 type SearchResult struct {
 	Key   common.Hash `json:"key"`
 	Value []byte      `json:"value"`
 }
+
+// SearchResultWithMetaData is a SearchResult extended with the meta data of the entity.
+// The meta data is only present when the query is made with ProjectionMetaData.
+type SearchResultWithMetaData struct {
+	SearchResult
+	*entity.EntityMetaData
+}
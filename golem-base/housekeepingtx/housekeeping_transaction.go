@@ -13,7 +13,11 @@ import (
 	"github.com/ethereum/go-ethereum/golem-base/storageutil/entity/entityexpiration"
 )
 
-func ExecuteTransaction(blockNumber uint64, txHash common.Hash, db vm.StateDB) ([]*types.Log, error) {
+// ExecuteTransaction removes the entities expiring at the block. Before the Golem Base
+// storage rules are activated, storageRules is false and the entities are removed the way
+// they were before the switch, with a deleted log. In the first block with the storage
+// rules, the string prefix and numeric range indexes of the existing entities are built.
+func ExecuteTransaction(blockNumber uint64, txHash common.Hash, storageRules bool, db vm.StateDB) ([]*types.Log, error) {
 
 	// create the golem base storage processor address if it doesn't exist
 	// this is needed to be able to use the state access interface
@@ -23,19 +27,31 @@ func ExecuteTransaction(blockNumber uint64, txHash common.Hash, db vm.StateDB) (
 		db.SetNonce(address.GolemBaseStorageProcessorAddress, 1, tracing.NonceChangeNewContract)
 	}
 
+	if storageRules {
+		err := entity.BuildValueIndexes(db)
+		if err != nil {
+			return nil, fmt.Errorf("failed to build value indexes: %w", err)
+		}
+	}
+
 	logs := []*types.Log{}
 
-	deleteEntity := func(toDelete common.Hash) error {
+	expireEntity := func(toExpire common.Hash) error {
+
+		deleteEntity, topic := entity.Delete, storagetx.GolemBaseStorageEntityExpired
+		if !storageRules {
+			deleteEntity, topic = entity.LegacyDelete, storagetx.GolemBaseStorageEntityDeleted
+		}
 
-		err := entity.Delete(db, toDelete)
+		err := deleteEntity(db, toExpire)
 		if err != nil {
 			return fmt.Errorf("failed to delete entity: %w", err)
 		}
 
-		// create the log for the created entity
+		// create the log for the expired entity
 		log := &types.Log{
 			Address:     address.GolemBaseStorageProcessorAddress, // Set the appropriate address if needed
-			Topics:      []common.Hash{storagetx.GolemBaseStorageEntityDeleted, toDelete},
+			Topics:      []common.Hash{topic, toExpire},
 			Data:        []byte{},
 			BlockNumber: blockNumber,
 		}
@@ -46,9 +62,9 @@ func ExecuteTransaction(blockNumber uint64, txHash common.Hash, db vm.StateDB) (
 	}
 
 	for key := range entityexpiration.IteratorOfEntitiesToExpireAtBlock(db, blockNumber) {
-		err := deleteEntity(key)
+		err := expireEntity(key)
 		if err != nil {
-			return nil, fmt.Errorf("failed to delete entity %s: %w", key.Hex(), err)
+			return nil, fmt.Errorf("failed to expire entity %s: %w", key.Hex(), err)
 		}
 	}
 
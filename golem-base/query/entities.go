@@ -0,0 +1,484 @@
+package query
+
+import (
+	"cmp"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"iter"
+	gomath "math"
+	"slices"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/lru"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/golem-base/golemtype"
+	"github.com/ethereum/go-ethereum/golem-base/storageutil/entity"
+	"github.com/ethereum/go-ethereum/rlp"
+)
+
+// EntityStore is what a query for entities is run against: the annotation indexes
+// and the payloads and meta data of the entities, all of the same block.
+type EntityStore interface {
+	DataSource
+	GetPayload(key common.Hash) ([]byte, error)
+	GetEntityMetaData(key common.Hash) (*entity.EntityMetaData, error)
+}
+
+// EntitiesQuery is a query for entities with the options of golembase_queryEntities.
+type EntitiesQuery struct {
+	expr   *Expression
+	opts   golemtype.QueryOptions
+	hash   common.Hash
+	cursor *queryCursor
+}
+
+// NewEntitiesQuery parses the query and checks the options. The options may be nil.
+func NewEntitiesQuery(req string, options *golemtype.QueryOptions) (*EntitiesQuery, error) {
+	expr, err := Parse(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse query: %w", err)
+	}
+
+	opts := golemtype.QueryOptions{}
+	if options != nil {
+		opts = *options
+	}
+
+	if opts.OrderBy == "" {
+		opts.OrderBy = golemtype.OrderByKey
+	}
+
+	switch opts.OrderBy {
+	case golemtype.OrderByKey, golemtype.OrderByExpiresAtBlock:
+	case golemtype.OrderByNumericAnnotation:
+		if opts.OrderByAnnotation == "" {
+			return nil, errors.New("orderByAnnotation is required when ordering by numeric annotation")
+		}
+	default:
+		return nil, fmt.Errorf("unsupported ordering %q", opts.OrderBy)
+	}
+
+	switch opts.Projection {
+	case "", golemtype.ProjectionPayload, golemtype.ProjectionMetaData:
+	default:
+		return nil, fmt.Errorf("unsupported projection %q", opts.Projection)
+	}
+
+	q := &EntitiesQuery{
+		expr: expr,
+		opts: opts,
+		hash: queryCursorHash(req, opts),
+	}
+
+	if opts.Cursor != "" {
+		cursor, err := decodeQueryCursor(opts.Cursor)
+		if err != nil {
+			return nil, err
+		}
+
+		if cursor.QueryHash != q.hash {
+			return nil, errors.New("cursor does not belong to this query and ordering")
+		}
+
+		q.cursor = &cursor
+	}
+
+	return q, nil
+}
+
+// CursorBlockHash returns the block of the cursor of the options, if there is one.
+// All the pages of a query are read from the same block, so the query has to be run against it.
+func (q *EntitiesQuery) CursorBlockHash() (common.Hash, bool) {
+	if q.cursor == nil {
+		return common.Hash{}, false
+	}
+	return q.cursor.BlockHash, true
+}
+
+// NumericAnnotationIndex is implemented by stores that can list the values of a numeric
+// annotation in order. The values are those >= from in ascending order, or <= from in
+// descending order. A query ordered by a numeric annotation is then paged by walking the
+// values from the cursor on, instead of evaluating and ordering all of its results.
+type NumericAnnotationIndex interface {
+	NumericAnnotationValues(annotation string, from uint64, descending bool) iter.Seq[uint64]
+}
+
+// ResultCache keeps the ordered results of recently paged queries by block and query,
+// so that the following pages are read without evaluating the query again.
+// It is safe for concurrent use.
+type ResultCache struct {
+	results *lru.Cache[common.Hash, []queryPosition]
+}
+
+// NewResultCache returns a cache of the results of up to the given number of queries.
+func NewResultCache(queries int) *ResultCache {
+	return &ResultCache{
+		results: lru.NewCache[common.Hash, []queryPosition](queries),
+	}
+}
+
+// Run returns the page of the results selected by the options, read from the store
+// holding the state of the given block. The cache may be nil.
+func (q *EntitiesQuery) Run(store EntityStore, blockHash common.Hash, blockNumber uint64, cache *ResultCache) (*golemtype.QueryResult, error) {
+	opts := q.opts
+
+	var positions []queryPosition
+	var err error
+
+	index, ok := store.(NumericAnnotationIndex)
+	if ok && opts.OrderBy == golemtype.OrderByNumericAnnotation && opts.Limit > 0 {
+		positions, err = q.pageOverIndex(store, index)
+	} else {
+		positions, err = q.orderedResults(store, blockHash, cache)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	result := &golemtype.QueryResult{
+		BlockHash:   blockHash,
+		BlockNumber: blockNumber,
+		Results:     []golemtype.SearchResultWithMetaData{},
+	}
+
+	if opts.Limit > 0 && uint64(len(positions)) > opts.Limit {
+		positions = positions[:opts.Limit]
+
+		result.Cursor, err = encodeQueryCursor(queryCursor{
+			BlockHash: result.BlockHash,
+			QueryHash: q.hash,
+			Position:  positions[len(positions)-1],
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	for _, pos := range positions {
+		sr := golemtype.SearchResultWithMetaData{
+			SearchResult: golemtype.SearchResult{
+				Key: pos.Key,
+			},
+		}
+		if !opts.OmitPayload {
+			sr.Value, err = store.GetPayload(pos.Key)
+			if err != nil {
+				return nil, fmt.Errorf("failed to get entity payload: %w", err)
+			}
+		}
+		if opts.Projection == golemtype.ProjectionMetaData {
+			sr.EntityMetaData, err = store.GetEntityMetaData(pos.Key)
+			if err != nil {
+				return nil, fmt.Errorf("failed to get entity meta data: %w", err)
+			}
+		}
+		result.Results = append(result.Results, sr)
+	}
+
+	return result, nil
+}
+
+func (q *EntitiesQuery) compare(a, b queryPosition) int {
+	if q.opts.Descending {
+		return b.compare(a)
+	}
+	return a.compare(b)
+}
+
+// afterCursor reports whether the position comes after the cursor, if there is one.
+func (q *EntitiesQuery) afterCursor(pos queryPosition) bool {
+	return q.cursor == nil || q.compare(pos, q.cursor.Position) > 0
+}
+
+// orderedResults evaluates the query and returns all its results after the cursor, in order.
+// When paging, the ordered results are cached, so only the first page evaluates the query.
+func (q *EntitiesQuery) orderedResults(store EntityStore, blockHash common.Hash, cache *ResultCache) ([]queryPosition, error) {
+	cacheKey := crypto.Keccak256Hash(blockHash.Bytes(), q.hash.Bytes())
+
+	positions, cached := []queryPosition(nil), false
+	if cache != nil && q.opts.Limit > 0 {
+		positions, cached = cache.results.Get(cacheKey)
+	}
+
+	if !cached {
+		entites, err := q.expr.Evaluate(store)
+		if err != nil {
+			return nil, fmt.Errorf("failed to evaluate query: %w", err)
+		}
+
+		positions = make([]queryPosition, 0, len(entites))
+		for _, key := range entites {
+			pos, err := positionOf(store, key, q.opts)
+			if err != nil {
+				return nil, err
+			}
+			positions = append(positions, pos)
+		}
+
+		slices.SortFunc(positions, q.compare)
+
+		if cache != nil && q.opts.Limit > 0 {
+			cache.results.Add(cacheKey, positions)
+		}
+	}
+
+	if q.cursor != nil {
+		start, found := slices.BinarySearchFunc(positions, q.cursor.Position, q.compare)
+		if found {
+			start++
+		}
+		positions = positions[start:]
+	}
+
+	return positions, nil
+}
+
+// pageOverIndex returns the results after the cursor of a query ordered by a numeric annotation,
+// up to one more than the limit. The values of the annotation are walked from the cursor on
+// and only the entities holding them are matched against the query, followed or, in descending
+// order, preceded by the results without the annotation.
+func (q *EntitiesQuery) pageOverIndex(store EntityStore, index NumericAnnotationIndex) ([]queryPosition, error) {
+	annotation := q.opts.OrderByAnnotation
+	want := int(q.opts.Limit) + 1
+	page := []queryPosition{}
+
+	add := func(pos queryPosition) bool {
+		if q.afterCursor(pos) {
+			page = append(page, pos)
+		}
+		return len(page) == want
+	}
+
+	withAnnotation := func() (bool, error) {
+		if q.cursor != nil && q.cursor.Position.Missing && !q.opts.Descending {
+			// the results with the annotation come first and have all been returned
+			return false, nil
+		}
+
+		from := uint64(0)
+		if q.opts.Descending {
+			from = gomath.MaxUint64
+		}
+		if q.cursor != nil && !q.cursor.Position.Missing {
+			from = q.cursor.Position.Value
+		}
+
+		for value := range index.NumericAnnotationValues(annotation, from, q.opts.Descending) {
+			keys, err := store.GetKeysForNumericAnnotation(annotation, value)
+			if err != nil {
+				return false, fmt.Errorf("failed to get entities for numeric annotation: %w", err)
+			}
+
+			positions := make([]queryPosition, 0, len(keys))
+			for _, key := range keys {
+				positions = append(positions, queryPosition{Value: value, Key: key})
+			}
+			slices.SortFunc(positions, q.compare)
+
+			for _, pos := range positions {
+				if !q.afterCursor(pos) {
+					continue
+				}
+
+				md, err := store.GetEntityMetaData(pos.Key)
+				if err != nil {
+					return false, fmt.Errorf("failed to get entity meta data: %w", err)
+				}
+
+				// an entity with several values of the annotation is ordered by the lowest one
+				lowest, found := lowestNumericAnnotation(md, annotation)
+				if !found || lowest != value {
+					continue
+				}
+
+				match, err := MatchEntity(q.expr, pos.Key, *md)
+				if err != nil {
+					return false, err
+				}
+
+				if match && add(pos) {
+					return true, nil
+				}
+			}
+		}
+
+		return false, nil
+	}
+
+	withoutAnnotation := func() (bool, error) {
+		positions, err := q.resultsWithoutAnnotation(store)
+		if err != nil {
+			return false, err
+		}
+
+		for _, pos := range positions {
+			if add(pos) {
+				return true, nil
+			}
+		}
+
+		return false, nil
+	}
+
+	parts := []func() (bool, error){withAnnotation, withoutAnnotation}
+	if q.opts.Descending {
+		slices.Reverse(parts)
+	}
+
+	for _, part := range parts {
+		full, err := part()
+		if err != nil {
+			return nil, err
+		}
+		if full {
+			break
+		}
+	}
+
+	return page, nil
+}
+
+// resultsWithoutAnnotation returns the results of the query that do not have the annotation
+// they are ordered by, in order.
+func (q *EntitiesQuery) resultsWithoutAnnotation(store EntityStore) ([]queryPosition, error) {
+	if q.cursor != nil && !q.cursor.Position.Missing && q.opts.Descending {
+		// the results without the annotation come first and have all been returned
+		return nil, nil
+	}
+
+	entities, err := q.expr.Evaluate(store)
+	if err != nil {
+		return nil, fmt.Errorf("failed to evaluate query: %w", err)
+	}
+
+	withAnnotation, err := store.GetKeysForNumericAnnotationRange(q.opts.OrderByAnnotation, 0, gomath.MaxUint64)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get entities for numeric annotation: %w", err)
+	}
+
+	skip := make(map[common.Hash]struct{}, len(withAnnotation))
+	for _, key := range withAnnotation {
+		skip[key] = struct{}{}
+	}
+
+	positions := []queryPosition{}
+	for _, key := range entities {
+		if _, found := skip[key]; !found {
+			positions = append(positions, queryPosition{Missing: true, Key: key})
+		}
+	}
+	slices.SortFunc(positions, q.compare)
+
+	return positions, nil
+}
+
+// queryPosition is the position of an entity in the ordered results of a query.
+type queryPosition struct {
+	// Missing is set when the entity does not have the annotation the results are ordered by,
+	// such entities come after all the others.
+	Missing bool
+	Value   uint64
+	Key     common.Hash
+}
+
+func (p queryPosition) compare(o queryPosition) int {
+	if p.Missing != o.Missing {
+		if p.Missing {
+			return 1
+		}
+		return -1
+	}
+
+	if c := cmp.Compare(p.Value, o.Value); c != 0 {
+		return c
+	}
+
+	return p.Key.Cmp(o.Key)
+}
+
+func positionOf(store EntityStore, key common.Hash, opts golemtype.QueryOptions) (queryPosition, error) {
+	pos := queryPosition{Key: key}
+
+	if opts.OrderBy == golemtype.OrderByKey {
+		return pos, nil
+	}
+
+	md, err := store.GetEntityMetaData(key)
+	if err != nil {
+		return pos, fmt.Errorf("failed to get entity meta data: %w", err)
+	}
+
+	if opts.OrderBy == golemtype.OrderByExpiresAtBlock {
+		pos.Value = md.ExpiresAtBlock
+		return pos, nil
+	}
+
+	value, found := lowestNumericAnnotation(md, opts.OrderByAnnotation)
+	pos.Missing = !found
+	pos.Value = value
+
+	return pos, nil
+}
+
+// lowestNumericAnnotation returns the lowest value of the annotation, an entity can have
+// several values of the same annotation.
+func lowestNumericAnnotation(md *entity.EntityMetaData, annotation string) (uint64, bool) {
+	var lowest uint64
+	found := false
+	for _, a := range md.NumericAnnotations {
+		if a.Key == annotation && (!found || a.Value < lowest) {
+			found = true
+			lowest = a.Value
+		}
+	}
+	return lowest, found
+}
+
+// queryCursor is the content of the opaque cursor returned with a page of query results.
+type queryCursor struct {
+	BlockHash common.Hash
+	QueryHash common.Hash
+	Position  queryPosition
+}
+
+// queryCursorHash identifies the query and ordering a cursor can be used with.
+func queryCursorHash(req string, opts golemtype.QueryOptions) common.Hash {
+	descending := []byte{0}
+	if opts.Descending {
+		descending = []byte{1}
+	}
+
+	return crypto.Keccak256Hash(
+		[]byte(req),
+		[]byte{0},
+		[]byte(opts.OrderBy),
+		[]byte{0},
+		[]byte(opts.OrderByAnnotation),
+		descending,
+	)
+}
+
+func encodeQueryCursor(c queryCursor) (string, error) {
+	data, err := rlp.EncodeToBytes(c)
+	if err != nil {
+		return "", fmt.Errorf("failed to encode cursor: %w", err)
+	}
+	return base64.RawURLEncoding.EncodeToString(data), nil
+}
+
+func decodeQueryCursor(s string) (queryCursor, error) {
+	c := queryCursor{}
+
+	data, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return c, fmt.Errorf("invalid cursor: %w", err)
+	}
+
+	err = rlp.DecodeBytes(data, &c)
+	if err != nil {
+		return c, fmt.Errorf("invalid cursor: %w", err)
+	}
+
+	return c, nil
+}
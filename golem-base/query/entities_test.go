@@ -0,0 +1,285 @@
+package query_test
+
+import (
+	"iter"
+	"math/big"
+	"slices"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/golem-base/golemtype"
+	"github.com/ethereum/go-ethereum/golem-base/query"
+	"github.com/ethereum/go-ethereum/golem-base/storageutil/entity"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeEntityStore struct {
+	fakeDataSource
+	metaData map[common.Hash]*entity.EntityMetaData
+}
+
+func (f *fakeEntityStore) GetPayload(key common.Hash) ([]byte, error) {
+	return key.Bytes()[31:], nil
+}
+
+func (f *fakeEntityStore) GetEntityMetaData(key common.Hash) (*entity.EntityMetaData, error) {
+	return f.metaData[key], nil
+}
+
+// indexedEntityStore is a fakeEntityStore that lists the values of its numeric annotations
+// and counts the meta data reads.
+type indexedEntityStore struct {
+	fakeEntityStore
+	metaDataReads int
+}
+
+func (f *indexedEntityStore) GetEntityMetaData(key common.Hash) (*entity.EntityMetaData, error) {
+	f.metaDataReads++
+	return f.fakeEntityStore.GetEntityMetaData(key)
+}
+
+func (f *indexedEntityStore) NumericAnnotationValues(annotation string, from uint64, descending bool) iter.Seq[uint64] {
+	values := []uint64{}
+	for value := range f.numericAnnotations[annotation] {
+		if (!descending && value >= from) || (descending && value <= from) {
+			values = append(values, value)
+		}
+	}
+	slices.Sort(values)
+	if descending {
+		slices.Reverse(values)
+	}
+	return slices.Values(values)
+}
+
+func keysOf(res *golemtype.QueryResult) []common.Hash {
+	keys := []common.Hash{}
+	for _, r := range res.Results {
+		keys = append(keys, r.Key)
+	}
+	return keys
+}
+
+func TestEntitiesQuery(t *testing.T) {
+	key1 := common.HexToHash("0x1")
+	key2 := common.HexToHash("0x2")
+	key3 := common.HexToHash("0x3")
+
+	store := &fakeEntityStore{
+		fakeDataSource: fakeDataSource{
+			stringAnnotations: map[string]map[string][]common.Hash{
+				"type": {"note": {key3, key1, key2}},
+			},
+		},
+		metaData: map[common.Hash]*entity.EntityMetaData{
+			key1: {ExpiresAtBlock: 30},
+			key2: {ExpiresAtBlock: 10},
+			key3: {ExpiresAtBlock: 20},
+		},
+	}
+
+	blockHash := common.HexToHash("0xb")
+
+	t.Run("orders the results", func(t *testing.T) {
+		q, err := query.NewEntitiesQuery(`type = "note"`, &golemtype.QueryOptions{
+			OrderBy:    golemtype.OrderByExpiresAtBlock,
+			Descending: true,
+		})
+		require.NoError(t, err)
+
+		res, err := q.Run(store, blockHash, 5, nil)
+		require.NoError(t, err)
+		require.Equal(t, []common.Hash{key1, key3, key2}, keysOf(res))
+		require.Equal(t, blockHash, res.BlockHash)
+		require.Equal(t, uint64(5), res.BlockNumber)
+		require.Empty(t, res.Cursor)
+	})
+
+	t.Run("pages through the results with the cursor", func(t *testing.T) {
+		options := &golemtype.QueryOptions{Limit: 2}
+
+		q, err := query.NewEntitiesQuery(`type = "note"`, options)
+		require.NoError(t, err)
+
+		_, hasCursor := q.CursorBlockHash()
+		require.False(t, hasCursor)
+
+		res, err := q.Run(store, blockHash, 5, nil)
+		require.NoError(t, err)
+		require.Equal(t, []common.Hash{key1, key2}, keysOf(res))
+		require.NotEmpty(t, res.Cursor)
+
+		options.Cursor = res.Cursor
+		q, err = query.NewEntitiesQuery(`type = "note"`, options)
+		require.NoError(t, err)
+
+		cursorBlockHash, hasCursor := q.CursorBlockHash()
+		require.True(t, hasCursor)
+		require.Equal(t, blockHash, cursorBlockHash)
+
+		res, err = q.Run(store, blockHash, 5, nil)
+		require.NoError(t, err)
+		require.Equal(t, []common.Hash{key3}, keysOf(res))
+		require.Empty(t, res.Cursor)
+	})
+
+	t.Run("rejects the cursor of another query", func(t *testing.T) {
+		q, err := query.NewEntitiesQuery(`type = "note"`, &golemtype.QueryOptions{Limit: 1})
+		require.NoError(t, err)
+
+		res, err := q.Run(store, blockHash, 5, nil)
+		require.NoError(t, err)
+
+		_, err = query.NewEntitiesQuery(`type = "other"`, &golemtype.QueryOptions{Limit: 1, Cursor: res.Cursor})
+		require.ErrorContains(t, err, "cursor does not belong to this query")
+	})
+
+	t.Run("includes the meta data with the meta data projection", func(t *testing.T) {
+		q, err := query.NewEntitiesQuery(`type = "note"`, &golemtype.QueryOptions{
+			Projection:  golemtype.ProjectionMetaData,
+			OmitPayload: true,
+		})
+		require.NoError(t, err)
+
+		res, err := q.Run(store, blockHash, 5, nil)
+		require.NoError(t, err)
+		require.Len(t, res.Results, 3)
+		require.Nil(t, res.Results[0].Value)
+		require.Equal(t, uint64(30), res.Results[0].ExpiresAtBlock)
+	})
+
+	t.Run("rejects unsupported options", func(t *testing.T) {
+		_, err := query.NewEntitiesQuery(`type = "note"`, &golemtype.QueryOptions{OrderBy: "payload"})
+		require.ErrorContains(t, err, "unsupported ordering")
+
+		_, err = query.NewEntitiesQuery(`type = "note"`, &golemtype.QueryOptions{OrderBy: golemtype.OrderByNumericAnnotation})
+		require.ErrorContains(t, err, "orderByAnnotation is required")
+	})
+
+	t.Run("caches the ordered results for the following pages", func(t *testing.T) {
+		cache := query.NewResultCache(10)
+		counting := &indexedEntityStore{fakeEntityStore: *store}
+
+		options := &golemtype.QueryOptions{OrderBy: golemtype.OrderByExpiresAtBlock, Limit: 1}
+		for {
+			q, err := query.NewEntitiesQuery(`type = "note"`, options)
+			require.NoError(t, err)
+
+			res, err := q.Run(counting, blockHash, 5, cache)
+			require.NoError(t, err)
+
+			if res.Cursor == "" {
+				break
+			}
+			options.Cursor = res.Cursor
+		}
+
+		require.Equal(t, 3, counting.metaDataReads)
+	})
+}
+
+func TestEntitiesQueryOverNumericAnnotationIndex(t *testing.T) {
+	key := func(i int) common.Hash {
+		return common.BigToHash(big.NewInt(int64(i)))
+	}
+
+	// entities 1 to 9 have priority i%3, entities 10 and 11 have none,
+	// entity 12 has the priorities 0 and 2 and is ordered by 0,
+	// only the notes match the query
+	store := &indexedEntityStore{
+		fakeEntityStore: fakeEntityStore{
+			fakeDataSource: fakeDataSource{
+				stringAnnotations:  map[string]map[string][]common.Hash{"type": {}},
+				numericAnnotations: map[string]map[uint64][]common.Hash{"priority": {}},
+			},
+			metaData: map[common.Hash]*entity.EntityMetaData{},
+		},
+	}
+
+	for i := 1; i <= 12; i++ {
+		md := &entity.EntityMetaData{}
+
+		kind := "note"
+		if i == 3 {
+			kind = "task"
+		}
+		md.StringAnnotations = []entity.StringAnnotation{{Key: "type", Value: kind}}
+		store.stringAnnotations["type"][kind] = append(store.stringAnnotations["type"][kind], key(i))
+
+		priorities := []uint64{}
+		switch {
+		case i <= 9:
+			priorities = append(priorities, uint64(i%3))
+		case i == 12:
+			priorities = append(priorities, 2, 0)
+		}
+		for _, p := range priorities {
+			md.NumericAnnotations = append(md.NumericAnnotations, entity.NumericAnnotation{Key: "priority", Value: p})
+			store.numericAnnotations["priority"][p] = append(store.numericAnnotations["priority"][p], key(i))
+		}
+
+		store.metaData[key(i)] = md
+	}
+
+	pages := func(t *testing.T, descending bool, limit uint64) [][]common.Hash {
+		t.Helper()
+
+		options := &golemtype.QueryOptions{
+			OrderBy:           golemtype.OrderByNumericAnnotation,
+			OrderByAnnotation: "priority",
+			Descending:        descending,
+			Limit:             limit,
+			OmitPayload:       true,
+		}
+
+		res := [][]common.Hash{}
+		for {
+			q, err := query.NewEntitiesQuery(`type = "note"`, options)
+			require.NoError(t, err)
+
+			page, err := q.Run(store, common.HexToHash("0xb"), 5, nil)
+			require.NoError(t, err)
+			res = append(res, keysOf(page))
+
+			if page.Cursor == "" {
+				return res
+			}
+			options.Cursor = page.Cursor
+		}
+	}
+
+	t.Run("pages in ascending order", func(t *testing.T) {
+		require.Equal(t, [][]common.Hash{
+			{key(6), key(9), key(12), key(1)},
+			{key(4), key(7), key(2), key(5)},
+			{key(8), key(10), key(11)},
+		}, pages(t, false, 4))
+	})
+
+	t.Run("pages in descending order", func(t *testing.T) {
+		require.Equal(t, [][]common.Hash{
+			{key(11), key(10), key(8)},
+			{key(5), key(2), key(7)},
+			{key(4), key(1), key(12)},
+			{key(9), key(6)},
+		}, pages(t, true, 3))
+	})
+
+	t.Run("reads only the meta data of the page", func(t *testing.T) {
+		store.metaDataReads = 0
+
+		q, err := query.NewEntitiesQuery(`type = "note"`, &golemtype.QueryOptions{
+			OrderBy:           golemtype.OrderByNumericAnnotation,
+			OrderByAnnotation: "priority",
+			Limit:             2,
+			OmitPayload:       true,
+		})
+		require.NoError(t, err)
+
+		res, err := q.Run(store, common.HexToHash("0xb"), 5, nil)
+		require.NoError(t, err)
+		require.Equal(t, []common.Hash{key(6), key(9)}, keysOf(res))
+		// the task 3, the notes 6 and 9 and the note 12 telling there is a next page
+		require.Equal(t, 4, store.metaDataReads)
+	})
+}
@@ -5,6 +5,14 @@ import "github.com/ethereum/go-ethereum/common"
 type DataSource interface {
 	GetKeysForStringAnnotation(annotation string, value string) ([]common.Hash, error)
 	GetKeysForNumericAnnotation(annotation string, value uint64) ([]common.Hash, error)
+	// GetKeysForNumericAnnotationRange returns the keys of the entities whose numeric
+	// annotation lies within the inclusive range [from, to].
+	GetKeysForNumericAnnotationRange(annotation string, from, to uint64) ([]common.Hash, error)
+	// GetStringAnnotationValuesWithPrefix returns the distinct values of the string
+	// annotation that start with the prefix.
+	GetStringAnnotationValuesWithPrefix(annotation string, prefix string) ([]string, error)
+	// GetAllKeys returns the keys of all the entities, used to evaluate negations.
+	GetAllKeys() ([]common.Hash, error)
 }
 
 type Evaluator interface {
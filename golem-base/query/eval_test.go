@@ -1,6 +1,8 @@
 package query_test
 
 import (
+	"math"
+	"strings"
 	"testing"
 
 	"github.com/ethereum/go-ethereum/common"
@@ -23,6 +25,41 @@ func (f *fakeDataSource) GetKeysForNumericAnnotation(key string, value uint64) (
 	return f.numericAnnotations[key][value], nil
 }
 
+func (f *fakeDataSource) GetKeysForNumericAnnotationRange(key string, from, to uint64) ([]common.Hash, error) {
+	res := []common.Hash{}
+	for value, keys := range f.numericAnnotations[key] {
+		if value >= from && value <= to {
+			res = append(res, keys...)
+		}
+	}
+	return res, nil
+}
+
+func (f *fakeDataSource) GetStringAnnotationValuesWithPrefix(key, prefix string) ([]string, error) {
+	res := []string{}
+	for value := range f.stringAnnotations[key] {
+		if strings.HasPrefix(value, prefix) {
+			res = append(res, value)
+		}
+	}
+	return res, nil
+}
+
+func (f *fakeDataSource) GetAllKeys() ([]common.Hash, error) {
+	res := []common.Hash{}
+	for _, values := range f.stringAnnotations {
+		for _, keys := range values {
+			res = append(res, keys...)
+		}
+	}
+	for _, values := range f.numericAnnotations {
+		for _, keys := range values {
+			res = append(res, keys...)
+		}
+	}
+	return res, nil
+}
+
 func TestEqualExpr(t *testing.T) {
 	ds := &fakeDataSource{
 		stringAnnotations: map[string]map[string][]common.Hash{
@@ -140,3 +177,95 @@ func TestParenthesesExpr(t *testing.T) {
 		common.HexToHash("0x5"),
 	}, res)
 }
+
+func TestComparisonExpr(t *testing.T) {
+	ds := &fakeDataSource{
+		stringAnnotations: map[string]map[string][]common.Hash{},
+		numericAnnotations: map[string]map[uint64][]common.Hash{
+			"age": {
+				0:              []common.Hash{common.HexToHash("0x1")},
+				18:             []common.Hash{common.HexToHash("0x2")},
+				30:             []common.Hash{common.HexToHash("0x3"), common.HexToHash("0x4")},
+				math.MaxUint64: []common.Hash{common.HexToHash("0x5")},
+			},
+		},
+	}
+
+	for _, tc := range []struct {
+		query    string
+		expected []common.Hash
+	}{
+		{"age < 18", []common.Hash{common.HexToHash("0x1")}},
+		{"age <= 18", []common.Hash{common.HexToHash("0x1"), common.HexToHash("0x2")}},
+		{"age > 18", []common.Hash{common.HexToHash("0x3"), common.HexToHash("0x4"), common.HexToHash("0x5")}},
+		{"age >= 30", []common.Hash{common.HexToHash("0x3"), common.HexToHash("0x4"), common.HexToHash("0x5")}},
+		{"age != 30", []common.Hash{common.HexToHash("0x1"), common.HexToHash("0x2"), common.HexToHash("0x5")}},
+		{"age != 0", []common.Hash{common.HexToHash("0x2"), common.HexToHash("0x3"), common.HexToHash("0x4"), common.HexToHash("0x5")}},
+		{"age < 0", []common.Hash{}},
+		{"age > 18446744073709551615", []common.Hash{}},
+		{"age > 0 && age < 30", []common.Hash{common.HexToHash("0x2")}},
+	} {
+		t.Run(tc.query, func(t *testing.T) {
+			expr, err := query.Parse(tc.query)
+			require.NoError(t, err)
+
+			res, err := expr.Evaluate(ds)
+			require.NoError(t, err)
+			require.ElementsMatch(t, tc.expected, res)
+		})
+	}
+}
+
+func TestStringExpr(t *testing.T) {
+	ds := &fakeDataSource{
+		stringAnnotations: map[string]map[string][]common.Hash{
+			"name": {
+				"invoice-1":  []common.Hash{common.HexToHash("0x1")},
+				"invoice-22": []common.Hash{common.HexToHash("0x2")},
+				"invoice":    []common.Hash{common.HexToHash("0x3")},
+				"receipt-1":  []common.Hash{common.HexToHash("0x4")},
+				"a*b":        []common.Hash{common.HexToHash("0x5")},
+			},
+		},
+		numericAnnotations: map[string]map[uint64][]common.Hash{
+			"age": {
+				18: []common.Hash{common.HexToHash("0x1"), common.HexToHash("0x6")},
+			},
+		},
+	}
+
+	for _, tc := range []struct {
+		query    string
+		expected []common.Hash
+	}{
+		{`name ~ "invoice-*"`, []common.Hash{common.HexToHash("0x1"), common.HexToHash("0x2")}},
+		{`name ~ "invoice*"`, []common.Hash{common.HexToHash("0x1"), common.HexToHash("0x2"), common.HexToHash("0x3")}},
+		{`name ~ "invoice-?"`, []common.Hash{common.HexToHash("0x1")}},
+		{`name ~ "*-1"`, []common.Hash{common.HexToHash("0x1"), common.HexToHash("0x4")}},
+		{`name ~ "*ice"`, []common.Hash{common.HexToHash("0x3")}},
+		{`name ~ "invoice"`, []common.Hash{common.HexToHash("0x3")}},
+		{`name ~ "a\\*b"`, []common.Hash{common.HexToHash("0x5")}},
+		{`name ~ "x*"`, []common.Hash{}},
+		{`name != "invoice"`, []common.Hash{common.HexToHash("0x1"), common.HexToHash("0x2"), common.HexToHash("0x4"), common.HexToHash("0x5")}},
+		{`!(name ~ "invoice*")`, []common.Hash{common.HexToHash("0x4"), common.HexToHash("0x5"), common.HexToHash("0x6")}},
+		{`NOT name ~ "invoice*" && age = 18`, []common.Hash{common.HexToHash("0x6")}},
+		{`!age = 18`, []common.Hash{common.HexToHash("0x2"), common.HexToHash("0x3"), common.HexToHash("0x4"), common.HexToHash("0x5")}},
+	} {
+		t.Run(tc.query, func(t *testing.T) {
+			expr, err := query.Parse(tc.query)
+			require.NoError(t, err)
+
+			res, err := expr.Evaluate(ds)
+			require.NoError(t, err)
+			require.ElementsMatch(t, tc.expected, res)
+		})
+	}
+}
+
+func TestNumericGlobIsNotSupported(t *testing.T) {
+	expr, err := query.Parse(`age ~ 18`)
+	require.NoError(t, err)
+
+	_, err = expr.Evaluate(&fakeDataSource{})
+	require.Error(t, err)
+}
@@ -0,0 +1,71 @@
+package query
+
+import "strings"
+
+// globLiteralPrefix returns the part of the glob pattern before the first wildcard,
+// with escapes resolved. All the values matching the pattern start with it.
+func globLiteralPrefix(pattern string) string {
+	var prefix strings.Builder
+	for i := 0; i < len(pattern); i++ {
+		switch pattern[i] {
+		case '*', '?':
+			return prefix.String()
+		case '\\':
+			if i+1 < len(pattern) {
+				i++
+			}
+		}
+		prefix.WriteByte(pattern[i])
+	}
+	return prefix.String()
+}
+
+// matchGlob reports whether the value matches the glob pattern.
+// `*` matches any sequence of bytes, `?` matches a single byte and
+// `\` escapes the following character.
+func matchGlob(pattern, value string) bool {
+	// position in the pattern and the value to restart from when a `*` has to match more bytes
+	starPattern, starValue := -1, -1
+
+	p, v := 0, 0
+	for v < len(value) {
+		if p < len(pattern) {
+			switch pattern[p] {
+			case '*':
+				starPattern, starValue = p, v
+				p++
+				continue
+			case '?':
+				p++
+				v++
+				continue
+			case '\\':
+				if p+1 < len(pattern) && pattern[p+1] == value[v] {
+					p += 2
+					v++
+					continue
+				}
+			default:
+				if pattern[p] == value[v] {
+					p++
+					v++
+					continue
+				}
+			}
+		}
+
+		if starPattern < 0 {
+			return false
+		}
+
+		// let the last `*` match one more byte
+		starValue++
+		p, v = starPattern+1, starValue
+	}
+
+	for p < len(pattern) && pattern[p] == '*' {
+		p++
+	}
+
+	return p == len(pattern)
+}
@@ -0,0 +1,30 @@
+package query
+
+import "math"
+
+// Int64Range is an inclusive range of int64 values.
+type Int64Range struct {
+	From, To int64
+}
+
+// Int64Ranges returns the ranges of int64 values holding the uint64 values of the inclusive range [from, to]
+// when they are stored as int64, like the ETL databases without unsigned 64 bit integers do.
+// Values above math.MaxInt64 wrap around to negative values, so a range crossing math.MaxInt64
+// becomes two ranges.
+func Int64Ranges(from, to uint64) []Int64Range {
+	if from > to {
+		return nil
+	}
+
+	ranges := []Int64Range{}
+
+	if from <= math.MaxInt64 {
+		ranges = append(ranges, Int64Range{From: int64(from), To: int64(min(to, math.MaxInt64))})
+	}
+
+	if to > math.MaxInt64 {
+		ranges = append(ranges, Int64Range{From: int64(max(from, math.MaxInt64+1)), To: int64(to)})
+	}
+
+	return ranges
+}
@@ -0,0 +1,30 @@
+package query_test
+
+import (
+	"math"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/golem-base/query"
+	"github.com/stretchr/testify/require"
+)
+
+func TestInt64Ranges(t *testing.T) {
+	t.Run("range below MaxInt64", func(t *testing.T) {
+		require.Equal(t, []query.Int64Range{{From: 1, To: 10}}, query.Int64Ranges(1, 10))
+	})
+
+	t.Run("range above MaxInt64", func(t *testing.T) {
+		require.Equal(t, []query.Int64Range{{From: math.MinInt64, To: -1}}, query.Int64Ranges(math.MaxInt64+1, math.MaxUint64))
+	})
+
+	t.Run("range crossing MaxInt64", func(t *testing.T) {
+		require.Equal(t, []query.Int64Range{
+			{From: 0, To: math.MaxInt64},
+			{From: math.MinInt64, To: -1},
+		}, query.Int64Ranges(0, math.MaxUint64))
+	})
+
+	t.Run("empty range", func(t *testing.T) {
+		require.Empty(t, query.Int64Ranges(10, 1))
+	})
+}
@@ -2,6 +2,8 @@ package query
 
 import (
 	"errors"
+	"fmt"
+	"math"
 
 	"github.com/alecthomas/participle/v2"
 	"github.com/alecthomas/participle/v2/lexer"
@@ -15,6 +17,13 @@ var lex = lexer.MustSimple([]lexer.SimpleRule{
 	{Name: "RParen", Pattern: `\)`},
 	{Name: "And", Pattern: `&&`},
 	{Name: "Or", Pattern: `\|\|`},
+	{Name: "Neq", Pattern: `!=`},
+	{Name: "Not", Pattern: `!`},
+	{Name: "Glob", Pattern: `~`},
+	{Name: "Lte", Pattern: `<=`},
+	{Name: "Gte", Pattern: `>=`},
+	{Name: "Lt", Pattern: `<`},
+	{Name: "Gt", Pattern: `>`},
 	{Name: "Eq", Pattern: `=`},
 	{Name: "String", Pattern: `"(?:[^"\\]|\\.)*"`},
 	{Name: "Number", Pattern: `[0-9]+`},
@@ -140,10 +149,29 @@ func (e *AndRHS) Evaluate(ds DataSource) ([]common.Hash, error) {
 	return e.Expr.Evaluate(ds)
 }
 
-// EqualExpr can be either an equality or a parenthesized expression.
+// EqualExpr can be either an equality, a numeric comparison, a negation or a parenthesized expression.
 type EqualExpr struct {
-	Paren  *Expression `parser:"  \"(\" @@ \")\""`
-	Assign *Equality   `parser:"| @@"`
+	Paren   *Expression `parser:"  \"(\" @@ \")\""`
+	Not     *EqualExpr  `parser:"| ( \"!\" | \"NOT\" ) @@"`
+	Compare *Comparison `parser:"| @@"`
+	Assign  *Equality   `parser:"| @@"`
+}
+
+func difference(a, b []common.Hash) []common.Hash {
+	result := make([]common.Hash, 0)
+	excluded := make(map[common.Hash]bool)
+
+	for _, hash := range b {
+		excluded[hash] = true
+	}
+
+	for _, hash := range a {
+		if !excluded[hash] {
+			result = append(result, hash)
+		}
+	}
+
+	return result
 }
 
 func (e *EqualExpr) Evaluate(ds DataSource) ([]common.Hash, error) {
@@ -151,17 +179,105 @@ func (e *EqualExpr) Evaluate(ds DataSource) ([]common.Hash, error) {
 		return e.Paren.Evaluate(ds)
 	}
 
+	if e.Not != nil {
+		all, err := ds.GetAllKeys()
+		if err != nil {
+			return nil, err
+		}
+
+		excluded, err := e.Not.Evaluate(ds)
+		if err != nil {
+			return nil, err
+		}
+
+		return difference(all, excluded), nil
+	}
+
+	if e.Compare != nil {
+		return e.Compare.Evaluate(ds)
+	}
+
 	return e.Assign.Evaluate(ds)
 }
 
-// Equality represents a simple equality (e.g. name = 123).
+// Equality represents a simple equality (e.g. name = 123), an inequality (e.g. name != 123)
+// or a glob match of a string annotation (e.g. name ~ "invoice-*").
 type Equality struct {
-	Var   string `parser:"@Ident \"=\""`
+	Var   string `parser:"@Ident"`
+	Neq   bool   `parser:"( \"=\" | @\"!=\""`
+	Glob  bool   `parser:"| @\"~\" )"`
 	Value *Value `parser:"@@"`
 }
 
+// keysForStringValues returns the keys of the entities carrying the string annotation
+// with a value that starts with the prefix and is accepted by the filter.
+func keysForStringValues(ds DataSource, annotation, prefix string, accept func(value string) bool) ([]common.Hash, error) {
+	values, err := ds.GetStringAnnotationValuesWithPrefix(annotation, prefix)
+	if err != nil {
+		return nil, err
+	}
+
+	res := []common.Hash{}
+	for _, value := range values {
+		if !accept(value) {
+			continue
+		}
+
+		keys, err := ds.GetKeysForStringAnnotation(annotation, value)
+		if err != nil {
+			return nil, err
+		}
+		res = union(res, keys)
+	}
+
+	return res, nil
+}
+
 func (e *Equality) Evaluate(ds DataSource) ([]common.Hash, error) {
 
+	if e.Glob {
+		if e.Value.String == nil {
+			return nil, errors.New("~ is only supported for string annotations")
+		}
+
+		pattern := *e.Value.String
+		return keysForStringValues(ds, e.Var, globLiteralPrefix(pattern), func(value string) bool {
+			return matchGlob(pattern, value)
+		})
+	}
+
+	if e.Neq && e.Value.String != nil {
+		// entities carrying the annotation with any value other than the given one
+		excluded := *e.Value.String
+		return keysForStringValues(ds, e.Var, "", func(value string) bool {
+			return value != excluded
+		})
+	}
+
+	if e.Neq && e.Value.Number != nil {
+		// entities carrying the annotation with any value other than the given one
+		v := *e.Value.Number
+		var res []common.Hash
+
+		if v > 0 {
+			below, err := ds.GetKeysForNumericAnnotationRange(e.Var, 0, v-1)
+			if err != nil {
+				return nil, err
+			}
+			res = below
+		}
+
+		if v < math.MaxUint64 {
+			above, err := ds.GetKeysForNumericAnnotationRange(e.Var, v+1, math.MaxUint64)
+			if err != nil {
+				return nil, err
+			}
+			res = union(res, above)
+		}
+
+		return union(res, nil), nil
+	}
+
 	if e.Value.String != nil {
 		return ds.GetKeysForStringAnnotation(e.Var, *e.Value.String)
 	}
@@ -173,6 +289,44 @@ func (e *Equality) Evaluate(ds DataSource) ([]common.Hash, error) {
 	return nil, errors.New("unsupported value type")
 }
 
+// Comparison represents a comparison of a numeric annotation (e.g. age >= 18).
+type Comparison struct {
+	Var   string `parser:"@Ident"`
+	Op    string `parser:"@( \"<=\" | \">=\" | \"<\" | \">\" )"`
+	Value uint64 `parser:"@Number"`
+}
+
+func (e *Comparison) Evaluate(ds DataSource) ([]common.Hash, error) {
+	from, to := uint64(0), uint64(math.MaxUint64)
+
+	switch e.Op {
+	case "<":
+		if e.Value == 0 {
+			return []common.Hash{}, nil
+		}
+		to = e.Value - 1
+	case "<=":
+		to = e.Value
+	case ">":
+		if e.Value == math.MaxUint64 {
+			return []common.Hash{}, nil
+		}
+		from = e.Value + 1
+	case ">=":
+		from = e.Value
+	default:
+		return nil, fmt.Errorf("unsupported comparison operator %q", e.Op)
+	}
+
+	keys, err := ds.GetKeysForNumericAnnotationRange(e.Var, from, to)
+	if err != nil {
+		return nil, err
+	}
+
+	// an entity can carry several values of the same annotation within the range
+	return union(keys, nil), nil
+}
+
 // Value is a literal value (a number or a string).
 type Value struct {
 	String *string `parser:"  @String"`
@@ -183,6 +337,7 @@ var Parser = participle.MustBuild[Expression](
 	participle.Lexer(lex),
 	participle.Elide("Whitespace"),
 	participle.Unquote("String"),
+	participle.UseLookahead(2),
 )
 
 func Parse(s string) (*Expression, error) {
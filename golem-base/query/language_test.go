@@ -213,6 +213,128 @@ func TestParse(t *testing.T) {
 		)
 	})
 
+	t.Run("comparison", func(t *testing.T) {
+		v, err := query.Parse(`age >= 18 && age < 65`)
+		require.NoError(t, err)
+
+		require.Equal(t,
+			&query.Expression{
+				Or: &query.OrExpression{
+					Left: &query.AndExpression{
+						Left: &query.EqualExpr{
+							Compare: &query.Comparison{
+								Var:   "age",
+								Op:    ">=",
+								Value: 18,
+							},
+						},
+						Right: []*query.AndRHS{
+							{
+								Op: "&&",
+								Expr: &query.EqualExpr{
+									Compare: &query.Comparison{
+										Var:   "age",
+										Op:    "<",
+										Value: 65,
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+			v,
+		)
+	})
+
+	t.Run("not equal", func(t *testing.T) {
+		v, err := query.Parse(`age != 18`)
+		require.NoError(t, err)
+
+		require.Equal(t,
+			&query.Expression{
+				Or: &query.OrExpression{
+					Left: &query.AndExpression{
+						Left: &query.EqualExpr{
+							Assign: &query.Equality{
+								Var: "age",
+								Neq: true,
+								Value: &query.Value{
+									Number: pointerOf(uint64(18)),
+								},
+							},
+						},
+					},
+				},
+			},
+			v,
+		)
+	})
+
+	t.Run("negated glob", func(t *testing.T) {
+		v, err := query.Parse(`!name ~ "invoice-*"`)
+		require.NoError(t, err)
+
+		require.Equal(t,
+			&query.Expression{
+				Or: &query.OrExpression{
+					Left: &query.AndExpression{
+						Left: &query.EqualExpr{
+							Not: &query.EqualExpr{
+								Assign: &query.Equality{
+									Var:  "name",
+									Glob: true,
+									Value: &query.Value{
+										String: pointerOf("invoice-*"),
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+			v,
+		)
+	})
+
+	t.Run("NOT keyword", func(t *testing.T) {
+		v, err := query.Parse(`NOT (name = "abc")`)
+		require.NoError(t, err)
+
+		require.Equal(t,
+			&query.Expression{
+				Or: &query.OrExpression{
+					Left: &query.AndExpression{
+						Left: &query.EqualExpr{
+							Not: &query.EqualExpr{
+								Paren: &query.Expression{
+									Or: &query.OrExpression{
+										Left: &query.AndExpression{
+											Left: &query.EqualExpr{
+												Assign: &query.Equality{
+													Var: "name",
+													Value: &query.Value{
+														String: pointerOf("abc"),
+													},
+												},
+											},
+										},
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+			v,
+		)
+	})
+
+	t.Run("comparison with string", func(t *testing.T) {
+		_, err := query.Parse(`name < "abc"`)
+		require.Error(t, err)
+	})
+
 	t.Run("invalid expression", func(t *testing.T) {
 		_, err := query.Parse(`key = 8e`)
 		require.Error(t, err, `1:8: unexpected token "e"`)
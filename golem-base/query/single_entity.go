@@ -0,0 +1,64 @@
+package query
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/golem-base/storageutil/entity"
+)
+
+// MatchEntity reports whether the entity with the given key and meta data matches the query,
+// without reading any index.
+func MatchEntity(expr *Expression, key common.Hash, md entity.EntityMetaData) (bool, error) {
+	keys, err := expr.Evaluate(singleEntity{key: key, md: md})
+	if err != nil {
+		return false, fmt.Errorf("failed to evaluate query: %w", err)
+	}
+
+	return len(keys) > 0, nil
+}
+
+// singleEntity is a DataSource containing a single entity.
+type singleEntity struct {
+	key common.Hash
+	md  entity.EntityMetaData
+}
+
+func (s singleEntity) keysIf(match bool) ([]common.Hash, error) {
+	if match {
+		return []common.Hash{s.key}, nil
+	}
+	return []common.Hash{}, nil
+}
+
+func (s singleEntity) GetKeysForStringAnnotation(annotation string, value string) ([]common.Hash, error) {
+	return s.keysIf(slices.ContainsFunc(s.md.StringAnnotations, func(a entity.StringAnnotation) bool {
+		return a.Key == annotation && a.Value == value
+	}))
+}
+
+func (s singleEntity) GetKeysForNumericAnnotation(annotation string, value uint64) ([]common.Hash, error) {
+	return s.GetKeysForNumericAnnotationRange(annotation, value, value)
+}
+
+func (s singleEntity) GetKeysForNumericAnnotationRange(annotation string, from, to uint64) ([]common.Hash, error) {
+	return s.keysIf(slices.ContainsFunc(s.md.NumericAnnotations, func(a entity.NumericAnnotation) bool {
+		return a.Key == annotation && a.Value >= from && a.Value <= to
+	}))
+}
+
+func (s singleEntity) GetStringAnnotationValuesWithPrefix(annotation string, prefix string) ([]string, error) {
+	values := []string{}
+	for _, a := range s.md.StringAnnotations {
+		if a.Key == annotation && strings.HasPrefix(a.Value, prefix) && !slices.Contains(values, a.Value) {
+			values = append(values, a.Value)
+		}
+	}
+	return values, nil
+}
+
+func (s singleEntity) GetAllKeys() ([]common.Hash, error) {
+	return []common.Hash{s.key}, nil
+}
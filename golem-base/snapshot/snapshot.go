@@ -0,0 +1,265 @@
+// Package snapshot reads and writes snapshots of the full Golem Base entity set at a block.
+//
+// A snapshot is a file of JSON lines, it is compressed with gzip if its name ends with ".gz".
+// The first line is the Header, identifying the block of the snapshot. Every following line
+// is an entity, written as the create operation of the write-ahead log that creates it, so that
+// consumers of the write-ahead log can load a snapshot and continue with the block after it.
+package snapshot
+
+import (
+	"bufio"
+	"compress/gzip"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/golem-base/storageutil"
+	"github.com/ethereum/go-ethereum/golem-base/storageutil/entity"
+	"github.com/ethereum/go-ethereum/golem-base/storageutil/entity/allentities"
+	"github.com/ethereum/go-ethereum/golem-base/storageutil/keyset"
+	"github.com/ethereum/go-ethereum/golem-base/wal"
+)
+
+// Version is the version of the snapshot format.
+const Version = 1
+
+var ErrUnsupportedVersion = errors.New("unsupported snapshot version")
+
+// Header identifies the block whose state the snapshot holds.
+type Header struct {
+	Version     int         `json:"version"`
+	ChainID     *big.Int    `json:"chainId"`
+	BlockNumber uint64      `json:"blockNumber,string"`
+	BlockHash   common.Hash `json:"blockHash"`
+	ParentHash  common.Hash `json:"parentHash"`
+	Entities    uint64      `json:"entities,string"`
+}
+
+// BlockInfo returns the block of the snapshot, the write-ahead log continues with its child.
+func (h Header) BlockInfo() wal.BlockInfo {
+	return wal.BlockInfo{
+		Number:     h.BlockNumber,
+		Hash:       h.BlockHash,
+		ParentHash: h.ParentHash,
+	}
+}
+
+// EntitiesIterator yields the entities of a snapshot.
+type EntitiesIterator func(yield func(e wal.Create, err error) bool)
+
+// Count returns the number of entities in the state.
+func Count(access storageutil.StateAccess) uint64 {
+	return keyset.Size(access, allentities.AllEntitiesKey).Uint64()
+}
+
+// FromState returns the entities of the state with their metadata and payloads.
+func FromState(access storageutil.StateAccess) EntitiesIterator {
+	return func(yield func(e wal.Create, err error) bool) {
+		for key := range allentities.Iterate(access) {
+			emd, err := entity.GetEntityMetaData(access, key)
+			if err != nil {
+				yield(wal.Create{}, fmt.Errorf("failed to get metadata of entity %s: %w", key.Hex(), err))
+				return
+			}
+
+			e := wal.Create{
+				EntityKey:          key,
+				ExpiresAtBlock:     emd.ExpiresAtBlock,
+				Payload:            entity.GetPayload(access, key),
+				StringAnnotations:  emd.StringAnnotations,
+				NumericAnnotations: emd.NumericAnnotations,
+				Owner:              emd.Owner,
+			}
+
+			if !yield(e, nil) {
+				return
+			}
+		}
+	}
+}
+
+func isCompressed(path string) bool {
+	return strings.HasSuffix(path, ".gz")
+}
+
+// Write writes the header and the entities. The number of entities must match the header.
+func Write(w io.Writer, header Header, entities EntitiesIterator) error {
+	header.Version = Version
+
+	enc := json.NewEncoder(w)
+
+	err := enc.Encode(header)
+	if err != nil {
+		return fmt.Errorf("failed to write header: %w", err)
+	}
+
+	written := uint64(0)
+
+	for e, err := range entities {
+		if err != nil {
+			return err
+		}
+
+		err = enc.Encode(e)
+		if err != nil {
+			return fmt.Errorf("failed to write entity %s: %w", e.EntityKey.Hex(), err)
+		}
+
+		written++
+	}
+
+	if written != header.Entities {
+		return fmt.Errorf("wrote %d entities, expected %d", written, header.Entities)
+	}
+
+	return nil
+}
+
+// WriteFile writes the snapshot to the file at path. The file only appears once it is complete.
+func WriteFile(path string, header Header, entities EntitiesIterator) (err error) {
+	err = os.MkdirAll(filepath.Dir(path), 0755)
+	if err != nil {
+		return fmt.Errorf("failed to create snapshot dir: %w", err)
+	}
+
+	tempPath := path + ".temp"
+
+	f, err := os.Create(tempPath)
+	if err != nil {
+		return fmt.Errorf("failed to create snapshot file: %w", err)
+	}
+
+	defer func() {
+		if err != nil {
+			f.Close()
+			os.Remove(tempPath)
+		}
+	}()
+
+	bw := bufio.NewWriter(f)
+
+	var w io.Writer = bw
+	var gw *gzip.Writer
+	if isCompressed(path) {
+		gw = gzip.NewWriter(bw)
+		w = gw
+	}
+
+	err = Write(w, header, entities)
+	if err != nil {
+		return err
+	}
+
+	if gw != nil {
+		err = gw.Close()
+		if err != nil {
+			return fmt.Errorf("failed to compress snapshot: %w", err)
+		}
+	}
+
+	err = bw.Flush()
+	if err != nil {
+		return fmt.Errorf("failed to write snapshot file: %w", err)
+	}
+
+	err = f.Close()
+	if err != nil {
+		return fmt.Errorf("failed to close snapshot file: %w", err)
+	}
+
+	err = os.Rename(tempPath, path)
+	if err != nil {
+		return fmt.Errorf("failed to rename snapshot file: %w", err)
+	}
+
+	return nil
+}
+
+// Reader reads a snapshot file.
+type Reader struct {
+	Header Header
+
+	f   *os.File
+	gr  *gzip.Reader
+	dec *json.Decoder
+}
+
+// Open opens the snapshot file at path and reads its header.
+func Open(path string) (*Reader, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open snapshot: %w", err)
+	}
+
+	r := &Reader{f: f}
+
+	var rd io.Reader = bufio.NewReader(f)
+	if isCompressed(path) {
+		r.gr, err = gzip.NewReader(rd)
+		if err != nil {
+			f.Close()
+			return nil, fmt.Errorf("failed to decompress snapshot: %w", err)
+		}
+		rd = r.gr
+	}
+
+	r.dec = json.NewDecoder(rd)
+
+	err = r.dec.Decode(&r.Header)
+	if err != nil {
+		r.Close()
+		return nil, fmt.Errorf("failed to read snapshot header: %w", err)
+	}
+
+	if r.Header.Version != Version {
+		r.Close()
+		return nil, fmt.Errorf("%w: %d", ErrUnsupportedVersion, r.Header.Version)
+	}
+
+	return r, nil
+}
+
+// Entities yields the entities of the snapshot. It fails if the snapshot holds
+// a different number of entities than its header, which happens if the file was truncated.
+// The entities can only be iterated once.
+func (r *Reader) Entities() EntitiesIterator {
+	return func(yield func(e wal.Create, err error) bool) {
+		read := uint64(0)
+
+		for {
+			e := wal.Create{}
+			err := r.dec.Decode(&e)
+			if err == io.EOF {
+				break
+			}
+			if err != nil {
+				yield(wal.Create{}, fmt.Errorf("failed to read entity %d of the snapshot: %w", read, err))
+				return
+			}
+
+			read++
+
+			if !yield(e, nil) {
+				return
+			}
+		}
+
+		if read != r.Header.Entities {
+			yield(wal.Create{}, fmt.Errorf("snapshot holds %d entities, expected %d", read, r.Header.Entities))
+		}
+	}
+}
+
+// Close closes the snapshot file.
+func (r *Reader) Close() error {
+	if r.gr != nil {
+		r.gr.Close()
+	}
+	return r.f.Close()
+}
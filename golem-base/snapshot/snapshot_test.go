@@ -0,0 +1,145 @@
+package snapshot_test
+
+import (
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/golem-base/snapshot"
+	"github.com/ethereum/go-ethereum/golem-base/storageutil/entity"
+	"github.com/ethereum/go-ethereum/golem-base/wal"
+	"github.com/stretchr/testify/require"
+)
+
+type mockStateAccess map[common.Address]map[common.Hash]common.Hash
+
+func (m mockStateAccess) GetState(addr common.Address, key common.Hash) common.Hash {
+	return m[addr][key]
+}
+
+func (m mockStateAccess) SetState(addr common.Address, key common.Hash, value common.Hash) common.Hash {
+	if _, ok := m[addr]; !ok {
+		m[addr] = map[common.Hash]common.Hash{}
+	}
+	m[addr][key] = value
+	return value
+}
+
+func testState(t *testing.T) mockStateAccess {
+	state := mockStateAccess{}
+
+	owner := common.HexToAddress("0x1234")
+
+	require.NoError(t, entity.Store(state, common.HexToHash("0x10"), owner, entity.EntityMetaData{
+		ExpiresAtBlock:     100,
+		StringAnnotations:  []entity.StringAnnotation{{Key: "type", Value: "test"}},
+		NumericAnnotations: []entity.NumericAnnotation{{Key: "size", Value: 3}},
+		Owner:              owner,
+	}, []byte("first")))
+
+	require.NoError(t, entity.Store(state, common.HexToHash("0x11"), owner, entity.EntityMetaData{
+		ExpiresAtBlock:     200,
+		StringAnnotations:  []entity.StringAnnotation{},
+		NumericAnnotations: []entity.NumericAnnotation{},
+		Owner:              owner,
+	}, []byte("second")))
+
+	return state
+}
+
+func readEntities(t *testing.T, path string) (snapshot.Header, map[common.Hash]wal.Create) {
+	r, err := snapshot.Open(path)
+	require.NoError(t, err)
+	defer r.Close()
+
+	entities := map[common.Hash]wal.Create{}
+	for e, err := range r.Entities() {
+		require.NoError(t, err)
+		entities[e.EntityKey] = e
+	}
+
+	return r.Header, entities
+}
+
+func TestSnapshot(t *testing.T) {
+
+	state := testState(t)
+
+	header := snapshot.Header{
+		ChainID:     big.NewInt(1337),
+		BlockNumber: 5,
+		BlockHash:   common.HexToHash("0x5"),
+		ParentHash:  common.HexToHash("0x4"),
+		Entities:    snapshot.Count(state),
+	}
+
+	for _, name := range []string{"snapshot.jsonl", "snapshot.jsonl.gz"} {
+		t.Run("should read the written entities from "+name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), name)
+
+			require.NoError(t, snapshot.WriteFile(path, header, snapshot.FromState(state)))
+
+			readHeader, entities := readEntities(t, path)
+
+			header.Version = snapshot.Version
+			require.Equal(t, header, readHeader)
+
+			require.Len(t, entities, 2)
+			require.Equal(t, wal.Create{
+				EntityKey:          common.HexToHash("0x10"),
+				ExpiresAtBlock:     100,
+				Payload:            []byte("first"),
+				StringAnnotations:  []entity.StringAnnotation{{Key: "type", Value: "test"}},
+				NumericAnnotations: []entity.NumericAnnotation{{Key: "size", Value: 3}},
+				Owner:              common.HexToAddress("0x1234"),
+			}, entities[common.HexToHash("0x10")])
+			require.Equal(t, []byte("second"), entities[common.HexToHash("0x11")].Payload)
+		})
+	}
+
+	t.Run("should detect a truncated snapshot", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "snapshot.jsonl")
+
+		require.NoError(t, snapshot.WriteFile(path, header, snapshot.FromState(state)))
+
+		data, err := os.ReadFile(path)
+		require.NoError(t, err)
+
+		// remove the last entity
+		lines := 0
+		for i, b := range data {
+			if b == '\n' {
+				lines++
+				if lines == 2 {
+					data = data[:i+1]
+					break
+				}
+			}
+		}
+		require.NoError(t, os.WriteFile(path, data, 0644))
+
+		r, err := snapshot.Open(path)
+		require.NoError(t, err)
+		defer r.Close()
+
+		var iterErr error
+		for _, err := range r.Entities() {
+			iterErr = err
+		}
+		require.ErrorContains(t, iterErr, "snapshot holds 1 entities, expected 2")
+	})
+
+	t.Run("should not write an incomplete snapshot", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "snapshot.jsonl")
+
+		wrongHeader := header
+		wrongHeader.Entities = 3
+
+		require.Error(t, snapshot.WriteFile(path, wrongHeader, snapshot.FromState(state)))
+
+		_, err := os.Stat(path)
+		require.ErrorIs(t, err, os.ErrNotExist)
+	})
+}
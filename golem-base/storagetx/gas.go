@@ -0,0 +1,185 @@
+package storagetx
+
+import (
+	"fmt"
+	gomath "math"
+
+	"github.com/ethereum/go-ethereum/common/math"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/golem-base/storageutil/entity"
+	"github.com/ethereum/go-ethereum/golem-base/storageutil/entity/numericrangeindex"
+	"github.com/ethereum/go-ethereum/golem-base/storageutil/entity/stringprefixindex"
+	"github.com/ethereum/go-ethereum/golem-base/storageutil/keyset"
+	"github.com/ethereum/go-ethereum/golem-base/storageutil/stateblob"
+	"github.com/ethereum/go-ethereum/params"
+	"github.com/ethereum/go-ethereum/rlp"
+)
+
+// Gas costs of the storage operations, charged on top of the intrinsic gas of the transaction.
+// Storage transactions do not run EVM code, so the costs are derived from the operations
+// and the entities they change: every storage slot an operation may write is charged,
+// whether or not it is already set. The slots of the payloads and meta data already
+// stored are charged while the transaction is run, see gasMeter.
+const (
+	// SlotGas is charged for every storage slot written, the cost of an SSTORE setting a new slot.
+	SlotGas uint64 = params.SstoreSetGasEIP2200
+	// ClearSlotGas is charged for every slot of meta data cleared, the cost of an SSTORE resetting a slot.
+	ClearSlotGas uint64 = params.SstoreResetGasEIP2200
+	// PayloadByteGas is charged for every byte of payload instead of SlotGas for the slots
+	// holding it, so that payloads up to the maximum payload size fit into a block.
+	// Clearing the slots of a payload is charged at the same rate.
+	PayloadByteGas uint64 = 8
+	// TTLBlockGas is charged for every block an entity is stored for.
+	TTLBlockGas uint64 = 10
+	// DeleteGas is charged for every deleted entity, including the previous version of an updated
+	// entity, covering the removal from the lists of entities and the annotation indexes.
+	DeleteGas uint64 = 5000
+	// RemoveAnnotationGas is charged for every annotation removed by a patch.
+	RemoveAnnotationGas uint64 = 5000
+)
+
+// gasCounter sums gas costs, remembering if the sum has overflowed.
+type gasCounter struct {
+	gas      uint64
+	overflow bool
+}
+
+func (c *gasCounter) add(gas uint64) {
+	var overflow bool
+	c.gas, overflow = math.SafeAdd(c.gas, gas)
+	c.overflow = c.overflow || overflow
+}
+
+func (c *gasCounter) addMul(count, gas uint64) {
+	product, overflow := math.SafeMul(count, gas)
+	c.overflow = c.overflow || overflow
+	c.add(product)
+}
+
+func (c *gasCounter) addSlots(slots uint64) {
+	c.addMul(slots, SlotGas)
+}
+
+// addMetaData charges the slots of meta data holding the annotations.
+// The expiration block is assumed to take its maximum size.
+func (c *gasCounter) addMetaData(stringAnnotations []entity.StringAnnotation, numericAnnotations []entity.NumericAnnotation) {
+	encoded, err := rlp.EncodeToBytes(&entity.EntityMetaData{
+		ExpiresAtBlock:     gomath.MaxUint64,
+		StringAnnotations:  stringAnnotations,
+		NumericAnnotations: numericAnnotations,
+	})
+	if err != nil {
+		c.overflow = true
+		return
+	}
+	c.addSlots(stateblob.Slots(uint64(len(encoded))))
+}
+
+func (c *gasCounter) addPayload(payload []byte) {
+	c.addMul(uint64(len(payload)), PayloadByteGas)
+}
+
+// addEntityWrite charges storing an entity: its entries in the lists of all entities, entities
+// of the owner and entities to expire, its meta data, its payload and its annotations.
+func (c *gasCounter) addEntityWrite(ttl uint64, payload []byte, stringAnnotations []entity.StringAnnotation, numericAnnotations []entity.NumericAnnotation) {
+	c.addSlots(3 * keyset.AddValueSlots)
+	c.addMetaData(stringAnnotations, numericAnnotations)
+	c.addPayload(payload)
+	c.addMul(ttl, TTLBlockGas)
+
+	c.addAnnotations(stringAnnotations, numericAnnotations)
+}
+
+// addAnnotations charges the index entries of the annotations: the set of entities carrying
+// the annotation and the nodes of the string prefix or numeric range index of its value.
+func (c *gasCounter) addAnnotations(stringAnnotations []entity.StringAnnotation, numericAnnotations []entity.NumericAnnotation) {
+	for _, a := range stringAnnotations {
+		c.addSlots(keyset.AddValueSlots)
+		c.addSlots(stringprefixindex.MaxSlotsWritten(a.Value))
+	}
+
+	for range numericAnnotations {
+		c.addSlots(keyset.AddValueSlots)
+		c.addSlots(numericrangeindex.MaxSlotsWritten)
+	}
+}
+
+// Gas returns the gas charged for the storage operations of the transaction that does not
+// depend on the state: the meta data rewritten by transfers, extensions and patches and the
+// slots cleared by deletes, updates and patches are charged on top while it is run.
+// The second return value is false if the cost overflows uint64,
+// in which case the transaction cannot be paid for.
+func (tx *StorageTransaction) Gas() (uint64, bool) {
+	c := &gasCounter{}
+
+	for _, create := range tx.Create {
+		c.addEntityWrite(create.TTL, create.Payload, create.StringAnnotations, create.NumericAnnotations)
+	}
+
+	for _, update := range tx.Update {
+		c.add(DeleteGas)
+		c.addEntityWrite(update.TTL, update.Payload, update.StringAnnotations, update.NumericAnnotations)
+	}
+
+	c.addMul(uint64(len(tx.Delete)), DeleteGas)
+
+	for range tx.TransferOwnership {
+		// the entry in the list of entities of the new owner
+		c.addSlots(keyset.AddValueSlots)
+	}
+
+	for _, extend := range tx.Extend {
+		// the entry in the list of entities to expire at the new expiration block
+		c.addSlots(keyset.AddValueSlots)
+		c.addMul(extend.NumberOfBlocks, TTLBlockGas)
+	}
+
+	for _, patch := range tx.Patch {
+		if patch.TTL != 0 {
+			c.addSlots(keyset.AddValueSlots)
+			c.addMul(patch.TTL, TTLBlockGas)
+		}
+		if patch.ReplacePayload {
+			c.addPayload(patch.Payload)
+		}
+		c.addAnnotations(patch.SetStringAnnotations, patch.SetNumericAnnotations)
+		c.addMul(uint64(len(patch.RemoveStringAnnotations)+len(patch.RemoveNumericAnnotations)), RemoveAnnotationGas)
+	}
+
+	return c.gas, !c.overflow
+}
+
+// gasMeter charges the gas of the storage operations that depends on the entities they change,
+// while the transaction is run: the slots of the rewritten meta data and the cleared slots
+// of the previous payloads and meta data. It fails with vm.ErrOutOfGas once limit is exceeded.
+type gasMeter struct {
+	used  uint64
+	limit uint64
+}
+
+func (m *gasMeter) charge(count, gas uint64) error {
+	cost, overflow := math.SafeMul(count, gas)
+	if overflow || cost > m.limit-m.used {
+		return vm.ErrOutOfGas
+	}
+	m.used += cost
+	return nil
+}
+
+// chargeClear charges clearing the slots of a payload and of meta data.
+func (m *gasMeter) chargeClear(payloadSlots, metaDataSlots uint64) error {
+	err := m.charge(payloadSlots, 32*PayloadByteGas)
+	if err != nil {
+		return err
+	}
+	return m.charge(metaDataSlots, ClearSlotGas)
+}
+
+// chargeMetaData charges writing the slots of the encoded meta data.
+func (m *gasMeter) chargeMetaData(md entity.EntityMetaData) error {
+	encoded, err := rlp.EncodeToBytes(&md)
+	if err != nil {
+		return fmt.Errorf("failed to encode entity meta data: %w", err)
+	}
+	return m.charge(stateblob.Slots(uint64(len(encoded))), SlotGas)
+}
@@ -0,0 +1,232 @@
+package storagetx_test
+
+import (
+	"fmt"
+	"math"
+	"strings"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/state"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/golem-base/storagetx"
+	"github.com/ethereum/go-ethereum/golem-base/storageutil/entity"
+	"github.com/ethereum/go-ethereum/golem-base/storageutil/keyset"
+	"github.com/ethereum/go-ethereum/golem-base/storageutil/stateblob"
+	"github.com/ethereum/go-ethereum/rlp"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStorageTransactionGas(t *testing.T) {
+	t.Run("EmptyTransaction", func(t *testing.T) {
+		gas, ok := (&storagetx.StorageTransaction{}).Gas()
+		require.True(t, ok)
+		require.Zero(t, gas)
+	})
+
+	t.Run("AllOperations", func(t *testing.T) {
+		tx := &storagetx.StorageTransaction{
+			Create: []storagetx.Create{
+				{
+					TTL:     100,
+					Payload: []byte("payload"),
+					StringAnnotations: []entity.StringAnnotation{
+						{Key: "type", Value: "test"},
+					},
+					NumericAnnotations: []entity.NumericAnnotation{
+						{Key: "size", Value: 1024},
+					},
+				},
+			},
+			Update: []storagetx.Update{
+				{
+					EntityKey: common.HexToHash("0x1234"),
+					TTL:       10,
+					Payload:   []byte("new"),
+				},
+			},
+			Delete: []common.Hash{common.HexToHash("0xdead")},
+			TransferOwnership: []storagetx.TransferOwnership{
+				{EntityKey: common.HexToHash("0xbeef"), NewOwner: common.HexToAddress("0x1")},
+			},
+			Extend: []storagetx.ExtendTTL{
+				{EntityKey: common.HexToHash("0xcafe"), NumberOfBlocks: 1000},
+			},
+			Patch: []storagetx.Patch{
+				{
+					EntityKey:                common.HexToHash("0xf00d"),
+					TTL:                      50,
+					ReplacePayload:           true,
+					Payload:                  []byte("patch"),
+					SetStringAnnotations:     []entity.StringAnnotation{{Key: "type", Value: "new"}},
+					RemoveNumericAnnotations: []string{"size"},
+				},
+			},
+		}
+
+		slots := func(n uint64) uint64 { return n * storagetx.SlotGas }
+		keySet := keyset.AddValueSlots
+
+		expected :=
+			// create: 3 lists, meta data, payload, TTL, string and numeric annotation
+			slots(3*keySet+metaDataSlots(t, tx.Create[0].StringAnnotations, tx.Create[0].NumericAnnotations)) + 7*storagetx.PayloadByteGas + 100*storagetx.TTLBlockGas +
+				slots(keySet+1+4) +
+				slots(keySet+8) +
+				// update: removing the previous version, 3 lists, meta data, payload and TTL
+				storagetx.DeleteGas + slots(3*keySet+metaDataSlots(t, nil, nil)) + 3*storagetx.PayloadByteGas + 10*storagetx.TTLBlockGas +
+				storagetx.DeleteGas +
+				// transfer: the list of entities of the new owner
+				slots(keySet) +
+				// extend: the list of entities to expire
+				slots(keySet) + 1000*storagetx.TTLBlockGas +
+				// patch: the list of entities to expire, payload, set and removed annotation
+				slots(keySet) + 50*storagetx.TTLBlockGas + 5*storagetx.PayloadByteGas +
+				slots(keySet+1+3) +
+				storagetx.RemoveAnnotationGas
+
+		gas, ok := tx.Gas()
+		require.True(t, ok)
+		require.Equal(t, expected, gas)
+	})
+
+	t.Run("LongStringAnnotationValue", func(t *testing.T) {
+		short := &storagetx.StorageTransaction{Patch: []storagetx.Patch{{
+			SetStringAnnotations: []entity.StringAnnotation{{Key: "k", Value: "v"}},
+		}}}
+		long := &storagetx.StorageTransaction{Patch: []storagetx.Patch{{
+			SetStringAnnotations: []entity.StringAnnotation{{Key: "k", Value: "v" + string(make([]byte, 99))}},
+		}}}
+
+		shortGas, _ := short.Gas()
+		longGas, _ := long.Gas()
+
+		// every byte of the value is a node of the prefix index
+		require.GreaterOrEqual(t, longGas-shortGas, 99*storagetx.SlotGas)
+	})
+
+	t.Run("Overflow", func(t *testing.T) {
+		tx := &storagetx.StorageTransaction{
+			Create: []storagetx.Create{
+				{TTL: math.MaxUint64},
+			},
+		}
+
+		_, ok := tx.Gas()
+		require.False(t, ok)
+	})
+}
+
+func TestStorageTransactionGasOfLargeEntity(t *testing.T) {
+	db, err := state.New(types.EmptyRootHash, state.NewDatabaseForTesting())
+	require.NoError(t, err)
+
+	owner := common.HexToAddress("0x1")
+	other := common.HexToAddress("0x2")
+
+	execute := func(sender common.Address, gasRemaining uint64, tx *storagetx.StorageTransaction) ([]*types.Log, uint64, error) {
+		t.Helper()
+		d, err := rlp.EncodeToBytes(tx)
+		require.NoError(t, err)
+		return storagetx.ExecuteTransaction(d, gasRemaining, 10, common.BigToHash(common.Big1), sender, nil, db)
+	}
+
+	staticGas := func(tx *storagetx.StorageTransaction) uint64 {
+		t.Helper()
+		gas, ok := tx.Gas()
+		require.True(t, ok)
+		return gas
+	}
+
+	annotations := []entity.StringAnnotation{}
+	for i := range 100 {
+		annotations = append(annotations, entity.StringAnnotation{Key: fmt.Sprintf("key%d", i), Value: strings.Repeat("v", 100)})
+	}
+
+	payload := make([]byte, 64*1024)
+	logs, _, err := execute(owner, math.MaxUint64, &storagetx.StorageTransaction{
+		Create: []storagetx.Create{{TTL: 100, Payload: payload, StringAnnotations: annotations}},
+	})
+	require.NoError(t, err)
+	key := logs[0].Topics[1]
+
+	// metaDataGas is the gas of rewriting the meta data of the entity, changed by modify
+	metaDataGas := func(modify func(md *entity.EntityMetaData)) uint64 {
+		t.Helper()
+		md, err := entity.GetEntityMetaData(db, key)
+		require.NoError(t, err)
+		modify(md)
+		encoded, err := rlp.EncodeToBytes(md)
+		require.NoError(t, err)
+		return stateblob.Slots(uint64(len(encoded))) * storagetx.SlotGas
+	}
+
+	payloadSlots, storedMetaDataSlots := entity.StorageSlots(db, key)
+	require.Equal(t, stateblob.Slots(uint64(len(payload))), payloadSlots)
+	require.Greater(t, storedMetaDataSlots, uint64(300))
+
+	t.Run("Extend", func(t *testing.T) {
+		tx := &storagetx.StorageTransaction{Extend: []storagetx.ExtendTTL{{EntityKey: key, NumberOfBlocks: 10}}}
+		expected := staticGas(tx) + metaDataGas(func(md *entity.EntityMetaData) { md.ExpiresAtBlock += 10 })
+
+		_, gas, err := execute(owner, math.MaxUint64, tx)
+		require.NoError(t, err)
+		require.Equal(t, expected, gas)
+	})
+
+	t.Run("Patch", func(t *testing.T) {
+		tx := &storagetx.StorageTransaction{Patch: []storagetx.Patch{{
+			EntityKey:            key,
+			ReplacePayload:       true,
+			Payload:              []byte("small"),
+			SetStringAnnotations: []entity.StringAnnotation{{Key: "key0", Value: "patched"}},
+		}}}
+		expected := staticGas(tx) + metaDataGas(func(md *entity.EntityMetaData) { md.StringAnnotations[0].Value = "patched" }) +
+			// the cleared slots of the previous payload
+			payloadSlots*32*storagetx.PayloadByteGas
+
+		_, gas, err := execute(owner, math.MaxUint64, tx)
+		require.NoError(t, err)
+		require.Equal(t, expected, gas)
+	})
+
+	transfer := &storagetx.StorageTransaction{TransferOwnership: []storagetx.TransferOwnership{{EntityKey: key, NewOwner: other}}}
+
+	t.Run("OutOfGasWhileRunning", func(t *testing.T) {
+		gasRemaining := staticGas(transfer) + storagetx.SlotGas
+
+		_, gas, err := execute(owner, gasRemaining, transfer)
+		require.ErrorIs(t, err, vm.ErrOutOfGas)
+		require.Equal(t, gasRemaining, gas)
+	})
+
+	t.Run("TransferOwnership", func(t *testing.T) {
+		expected := staticGas(transfer) + metaDataGas(func(md *entity.EntityMetaData) { md.Owner = other })
+
+		_, gas, err := execute(owner, math.MaxUint64, transfer)
+		require.NoError(t, err)
+		require.Equal(t, expected, gas)
+	})
+
+	t.Run("Delete", func(t *testing.T) {
+		payloadSlots, metaDataSlots := entity.StorageSlots(db, key)
+		tx := &storagetx.StorageTransaction{Delete: []common.Hash{key}}
+		expected := staticGas(tx) + payloadSlots*32*storagetx.PayloadByteGas + metaDataSlots*storagetx.ClearSlotGas
+
+		_, gas, err := execute(other, math.MaxUint64, tx)
+		require.NoError(t, err)
+		require.Equal(t, expected, gas)
+		require.Greater(t, gas, metaDataSlots*storagetx.ClearSlotGas)
+	})
+}
+
+func metaDataSlots(t *testing.T, stringAnnotations []entity.StringAnnotation, numericAnnotations []entity.NumericAnnotation) uint64 {
+	t.Helper()
+	encoded, err := rlp.EncodeToBytes(&entity.EntityMetaData{
+		ExpiresAtBlock:     math.MaxUint64,
+		StringAnnotations:  stringAnnotations,
+		NumericAnnotations: numericAnnotations,
+	})
+	require.NoError(t, err)
+	return stateblob.Slots(uint64(len(encoded)))
+}
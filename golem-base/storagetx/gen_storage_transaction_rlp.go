@@ -62,6 +62,67 @@ func (obj *StorageTransaction) EncodeRLP(_w io.Writer) error {
 		w.WriteBytes(_tmp20[:])
 	}
 	w.ListEnd(_tmp19)
+	_tmp21 := len(obj.TransferOwnership) > 0
+	_tmp22 := len(obj.Extend) > 0
+	_tmp23 := len(obj.Patch) > 0
+	if _tmp21 || _tmp22 || _tmp23 {
+		_tmp24 := w.List()
+		for _, _tmp25 := range obj.TransferOwnership {
+			_tmp26 := w.List()
+			w.WriteBytes(_tmp25.EntityKey[:])
+			w.WriteBytes(_tmp25.NewOwner[:])
+			w.ListEnd(_tmp26)
+		}
+		w.ListEnd(_tmp24)
+	}
+	if _tmp22 || _tmp23 {
+		_tmp27 := w.List()
+		for _, _tmp28 := range obj.Extend {
+			_tmp29 := w.List()
+			w.WriteBytes(_tmp28.EntityKey[:])
+			w.WriteUint64(_tmp28.NumberOfBlocks)
+			w.ListEnd(_tmp29)
+		}
+		w.ListEnd(_tmp27)
+	}
+	if _tmp23 {
+		_tmp30 := w.List()
+		for _, _tmp31 := range obj.Patch {
+			_tmp32 := w.List()
+			w.WriteBytes(_tmp31.EntityKey[:])
+			w.WriteUint64(_tmp31.TTL)
+			w.WriteBool(_tmp31.ReplacePayload)
+			w.WriteBytes(_tmp31.Payload)
+			_tmp33 := w.List()
+			for _, _tmp34 := range _tmp31.SetStringAnnotations {
+				_tmp35 := w.List()
+				w.WriteString(_tmp34.Key)
+				w.WriteString(_tmp34.Value)
+				w.ListEnd(_tmp35)
+			}
+			w.ListEnd(_tmp33)
+			_tmp36 := w.List()
+			for _, _tmp37 := range _tmp31.SetNumericAnnotations {
+				_tmp38 := w.List()
+				w.WriteString(_tmp37.Key)
+				w.WriteUint64(_tmp37.Value)
+				w.ListEnd(_tmp38)
+			}
+			w.ListEnd(_tmp36)
+			_tmp39 := w.List()
+			for _, _tmp40 := range _tmp31.RemoveStringAnnotations {
+				w.WriteString(_tmp40)
+			}
+			w.ListEnd(_tmp39)
+			_tmp41 := w.List()
+			for _, _tmp42 := range _tmp31.RemoveNumericAnnotations {
+				w.WriteString(_tmp42)
+			}
+			w.ListEnd(_tmp41)
+			w.ListEnd(_tmp32)
+		}
+		w.ListEnd(_tmp30)
+	}
 	w.ListEnd(_tmp0)
 	return w.Flush()
 }
@@ -0,0 +1,124 @@
+package storagetx
+
+import (
+	"fmt"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/golem-base/address"
+	"github.com/ethereum/go-ethereum/golem-base/storageutil"
+	"github.com/ethereum/go-ethereum/golem-base/storageutil/entity"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/rlp"
+	"github.com/holiman/uint256"
+)
+
+// legacyStorageTransaction is the storage transaction accepted before the Golem Base storage
+// rules are activated. It only has the Create, Update and Delete operations.
+type legacyStorageTransaction struct {
+	Create []Create
+	Update []Update
+	Delete []common.Hash
+}
+
+// run applies the operations the way they were applied before the Golem Base storage rules:
+// there are no limits and no ownership checks, and the changes made before a failing
+// operation are kept.
+func (tx *legacyStorageTransaction) run(blockNumber uint64, txHash common.Hash, sender common.Address, access storageutil.StateAccess) (_ []*types.Log, err error) {
+
+	defer func() {
+		if err != nil {
+			log.Error("failed to run legacy storage transaction", "error", err)
+		}
+	}()
+
+	logs := []*types.Log{}
+
+	for i, create := range tx.Create {
+		// Convert i to a big integer and pad to 32 bytes
+		bigI := big.NewInt(int64(i))
+		paddedI := common.LeftPadBytes(bigI.Bytes(), 32)
+
+		key := crypto.Keccak256Hash(txHash.Bytes(), create.Payload, paddedI)
+
+		ap := entity.EntityMetaData{
+			Owner:              sender,
+			ExpiresAtBlock:     blockNumber + create.TTL,
+			StringAnnotations:  create.StringAnnotations,
+			NumericAnnotations: create.NumericAnnotations,
+		}
+
+		err := entity.LegacyStore(access, key, sender, ap, create.Payload)
+		if err != nil {
+			return nil, fmt.Errorf("failed to store entity: %w", err)
+		}
+
+		logs = append(logs, &types.Log{
+			Address:     address.GolemBaseStorageProcessorAddress,
+			Topics:      []common.Hash{GolemBaseStorageEntityCreated, key},
+			Data:        uint256.NewInt(ap.ExpiresAtBlock).Bytes(),
+			BlockNumber: blockNumber,
+		})
+	}
+
+	for _, toDelete := range tx.Delete {
+		err := entity.LegacyDelete(access, toDelete)
+		if err != nil {
+			return nil, fmt.Errorf("failed to delete entity: %w", err)
+		}
+
+		logs = append(logs, &types.Log{
+			Address:     address.GolemBaseStorageProcessorAddress,
+			Topics:      []common.Hash{GolemBaseStorageEntityDeleted, toDelete},
+			Data:        []byte{},
+			BlockNumber: blockNumber,
+		})
+	}
+
+	for _, update := range tx.Update {
+		err := entity.LegacyDelete(access, update.EntityKey)
+		if err != nil {
+			return nil, fmt.Errorf("failed to delete entity: %w", err)
+		}
+
+		// the owner is not kept in the meta data of updated entities
+		ap := entity.EntityMetaData{
+			ExpiresAtBlock:     blockNumber + update.TTL,
+			StringAnnotations:  update.StringAnnotations,
+			NumericAnnotations: update.NumericAnnotations,
+		}
+
+		err = entity.LegacyStore(access, update.EntityKey, sender, ap, update.Payload)
+		if err != nil {
+			return nil, fmt.Errorf("failed to store entity: %w", err)
+		}
+
+		logs = append(logs, &types.Log{
+			Address:     address.GolemBaseStorageProcessorAddress,
+			Topics:      []common.Hash{GolemBaseStorageEntityUpdated, update.EntityKey},
+			Data:        common.BigToHash(big.NewInt(int64(ap.ExpiresAtBlock))).Bytes(),
+			BlockNumber: blockNumber,
+		})
+	}
+
+	return logs, nil
+}
+
+// ExecuteLegacyTransaction decodes and runs a storage transaction included before the Golem
+// Base storage rules are activated. No gas is charged for its operations and the
+// transaction is not atomic, see legacyStorageTransaction.run.
+func ExecuteLegacyTransaction(d []byte, blockNumber uint64, txHash common.Hash, sender common.Address, access storageutil.StateAccess) ([]*types.Log, error) {
+	tx := &legacyStorageTransaction{}
+	err := rlp.DecodeBytes(d, tx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode storage transaction: %w", err)
+	}
+	logs, err := tx.run(blockNumber, txHash, sender, access)
+	if err != nil {
+		log.Error("Failed to run storage transaction", "error", err)
+		return nil, fmt.Errorf("failed to run storage transaction: %w", err)
+	}
+	return logs, nil
+}
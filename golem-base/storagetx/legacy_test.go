@@ -0,0 +1,90 @@
+package storagetx_test
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/state"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/golem-base/housekeepingtx"
+	"github.com/ethereum/go-ethereum/golem-base/storagetx"
+	"github.com/ethereum/go-ethereum/golem-base/storageutil"
+	"github.com/ethereum/go-ethereum/golem-base/storageutil/entity"
+	"github.com/ethereum/go-ethereum/golem-base/storageutil/entity/stringprefixindex"
+	"github.com/ethereum/go-ethereum/rlp"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExecuteLegacyTransaction(t *testing.T) {
+	db, err := state.New(types.EmptyRootHash, state.NewDatabaseForTesting())
+	require.NoError(t, err)
+
+	owner := common.HexToAddress("0x1")
+	other := common.HexToAddress("0x2")
+
+	execute := func(sender common.Address, tx *storagetx.StorageTransaction) ([]*types.Log, error) {
+		t.Helper()
+		d, err := rlp.EncodeToBytes(tx)
+		require.NoError(t, err)
+		return storagetx.ExecuteLegacyTransaction(d, 10, common.BigToHash(common.Big1), sender, db)
+	}
+
+	logs, err := execute(owner, &storagetx.StorageTransaction{
+		Create: []storagetx.Create{{
+			TTL:               100,
+			Payload:           []byte("a"),
+			StringAnnotations: []entity.StringAnnotation{{Key: "type", Value: "a"}},
+		}},
+	})
+	require.NoError(t, err)
+	require.Len(t, logs, 1)
+	key := logs[0].Topics[1]
+
+	t.Run("value indexes are not maintained", func(t *testing.T) {
+		require.Empty(t, slices.Collect(stringprefixindex.IterateWithPrefix(db, "type", "")))
+	})
+
+	t.Run("operations of the storage rules are rejected", func(t *testing.T) {
+		_, err := execute(owner, &storagetx.StorageTransaction{
+			TransferOwnership: []storagetx.TransferOwnership{{EntityKey: key, NewOwner: other}},
+		})
+		require.ErrorContains(t, err, "failed to decode storage transaction")
+	})
+
+	t.Run("value indexes are built once", func(t *testing.T) {
+		require.NoError(t, entity.BuildValueIndexes(db))
+		require.Equal(t, []string{"a"}, slices.Collect(stringprefixindex.IterateWithPrefix(db, "type", "")))
+
+		stringprefixindex.RemoveValue(db, "type", "a")
+		require.NoError(t, entity.BuildValueIndexes(db))
+		require.Empty(t, slices.Collect(stringprefixindex.IterateWithPrefix(db, "type", "")))
+	})
+
+	t.Run("any sender can delete", func(t *testing.T) {
+		logs, err := execute(other, &storagetx.StorageTransaction{Delete: []common.Hash{key}})
+		require.NoError(t, err)
+		require.Len(t, logs, 1)
+		require.Equal(t, []common.Hash{storagetx.GolemBaseStorageEntityDeleted, key}, logs[0].Topics)
+	})
+}
+
+func TestLegacyHousekeepingTransaction(t *testing.T) {
+	db, err := state.New(types.EmptyRootHash, state.NewDatabaseForTesting())
+	require.NoError(t, err)
+
+	d, err := rlp.EncodeToBytes(&storagetx.StorageTransaction{
+		Create: []storagetx.Create{{TTL: 5, Payload: []byte("a")}},
+	})
+	require.NoError(t, err)
+
+	_, err = storagetx.ExecuteLegacyTransaction(d, 10, common.BigToHash(common.Big1), common.HexToAddress("0x1"), db)
+	require.NoError(t, err)
+
+	logs, err := housekeepingtx.ExecuteTransaction(15, common.Hash{}, false, db)
+	require.NoError(t, err)
+	require.Len(t, logs, 1)
+	require.Equal(t, storagetx.GolemBaseStorageEntityDeleted, logs[0].Topics[0])
+
+	require.Equal(t, common.Hash{}, db.GetState(storageutil.GolemDBAddress, entity.ValueIndexesBuiltKey))
+}
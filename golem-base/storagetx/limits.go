@@ -0,0 +1,166 @@
+package storagetx
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/ethereum/go-ethereum/common/math"
+	"github.com/ethereum/go-ethereum/golem-base/storageutil/entity"
+	"github.com/ethereum/go-ethereum/params"
+	"github.com/ethereum/go-ethereum/rlp"
+)
+
+// Errors returned when a storage transaction violates the Golem Base limits of the chain.
+var (
+	ErrZeroTTL                = errors.New("TTL must be greater than zero")
+	ErrTTLTooLarge            = errors.New("TTL exceeds the maximum")
+	ErrPayloadTooLarge        = errors.New("payload exceeds the maximum size")
+	ErrTooManyAnnotations     = errors.New("number of annotations exceeds the maximum")
+	ErrAnnotationKeyTooLong   = errors.New("annotation key exceeds the maximum length")
+	ErrAnnotationValueTooLong = errors.New("annotation value exceeds the maximum length")
+)
+
+// exceeds reports whether value is above the limit, a limit of zero meaning no limit.
+func exceeds(value, limit uint64) bool {
+	return limit != 0 && value > limit
+}
+
+// validateTTL checks that an entity stored for ttl blocks from blockNumber on
+// has a non-zero TTL within the limit, with an expiration block that fits into uint64.
+func validateTTL(blockNumber, ttl uint64, limits *params.GolemBaseConfig) error {
+	if ttl == 0 {
+		return ErrZeroTTL
+	}
+
+	if _, overflow := math.SafeAdd(blockNumber, ttl); overflow {
+		return fmt.Errorf("%w: TTL %d overflows the expiration block", ErrTTLTooLarge, ttl)
+	}
+
+	if limits != nil && exceeds(ttl, limits.MaxTTL) {
+		return fmt.Errorf("%w: TTL %d, maximum %d", ErrTTLTooLarge, ttl, limits.MaxTTL)
+	}
+
+	return nil
+}
+
+func validatePayload(payload []byte, limits *params.GolemBaseConfig) error {
+	if limits != nil && exceeds(uint64(len(payload)), limits.MaxPayloadSize) {
+		return fmt.Errorf("%w: payload size %d, maximum %d", ErrPayloadTooLarge, len(payload), limits.MaxPayloadSize)
+	}
+
+	return nil
+}
+
+func validateAnnotations(stringAnnotations []entity.StringAnnotation, numericAnnotations []entity.NumericAnnotation, limits *params.GolemBaseConfig) error {
+	if limits == nil {
+		return nil
+	}
+
+	annotations := uint64(len(stringAnnotations) + len(numericAnnotations))
+	if exceeds(annotations, limits.MaxAnnotations) {
+		return fmt.Errorf("%w: %d annotations, maximum %d", ErrTooManyAnnotations, annotations, limits.MaxAnnotations)
+	}
+
+	validateKey := func(key string) error {
+		if exceeds(uint64(len(key)), limits.MaxAnnotationKeyLength) {
+			return fmt.Errorf("%w: key length %d, maximum %d", ErrAnnotationKeyTooLong, len(key), limits.MaxAnnotationKeyLength)
+		}
+		return nil
+	}
+
+	for _, a := range stringAnnotations {
+		err := validateKey(a.Key)
+		if err != nil {
+			return err
+		}
+
+		if exceeds(uint64(len(a.Value)), limits.MaxAnnotationValueLength) {
+			return fmt.Errorf("%w: value length of %q is %d, maximum %d", ErrAnnotationValueTooLong, a.Key, len(a.Value), limits.MaxAnnotationValueLength)
+		}
+	}
+
+	for _, a := range numericAnnotations {
+		err := validateKey(a.Key)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func validateEntity(blockNumber, ttl uint64, payload []byte, stringAnnotations []entity.StringAnnotation, numericAnnotations []entity.NumericAnnotation, limits *params.GolemBaseConfig) error {
+	err := validateTTL(blockNumber, ttl, limits)
+	if err != nil {
+		return err
+	}
+
+	err = validatePayload(payload, limits)
+	if err != nil {
+		return err
+	}
+
+	return validateAnnotations(stringAnnotations, numericAnnotations, limits)
+}
+
+// Validate checks the operations of the transaction against the limits, for a
+// transaction included in the block with the given number. limits can be nil,
+// in which case only the TTLs are checked to be non-zero and not to overflow.
+// The resulting expiration of Extend operations and the resulting number of
+// annotations of Patch operations depend on the state and are checked when the transaction is run.
+func (tx *StorageTransaction) Validate(blockNumber uint64, limits *params.GolemBaseConfig) error {
+	for i, create := range tx.Create {
+		err := validateEntity(blockNumber, create.TTL, create.Payload, create.StringAnnotations, create.NumericAnnotations, limits)
+		if err != nil {
+			return fmt.Errorf("invalid create operation %d: %w", i, err)
+		}
+	}
+
+	for _, update := range tx.Update {
+		err := validateEntity(blockNumber, update.TTL, update.Payload, update.StringAnnotations, update.NumericAnnotations, limits)
+		if err != nil {
+			return fmt.Errorf("invalid update of entity %s: %w", update.EntityKey.Hex(), err)
+		}
+	}
+
+	for _, patch := range tx.Patch {
+		err := validatePatch(blockNumber, patch, limits)
+		if err != nil {
+			return fmt.Errorf("invalid patch of entity %s: %w", patch.EntityKey.Hex(), err)
+		}
+	}
+
+	return nil
+}
+
+// validatePatch checks the parts of the entity set by the patch. The number of
+// annotations of the patched entity depends on the state and is checked when the transaction is run.
+func validatePatch(blockNumber uint64, patch Patch, limits *params.GolemBaseConfig) error {
+	if patch.TTL != 0 {
+		err := validateTTL(blockNumber, patch.TTL, limits)
+		if err != nil {
+			return err
+		}
+	}
+
+	if patch.ReplacePayload {
+		err := validatePayload(patch.Payload, limits)
+		if err != nil {
+			return err
+		}
+	}
+
+	return validateAnnotations(patch.SetStringAnnotations, patch.SetNumericAnnotations, limits)
+}
+
+// ValidateTransaction decodes the storage transaction and checks it against the limits,
+// without running it. It is used to reject invalid transactions before they are included in a block.
+func ValidateTransaction(d []byte, blockNumber uint64, limits *params.GolemBaseConfig) error {
+	tx := &StorageTransaction{}
+	err := rlp.DecodeBytes(d, tx)
+	if err != nil {
+		return fmt.Errorf("failed to decode storage transaction: %w", err)
+	}
+
+	return tx.Validate(blockNumber, limits)
+}
@@ -0,0 +1,113 @@
+package storagetx_test
+
+import (
+	"math"
+	"strings"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/golem-base/storagetx"
+	"github.com/ethereum/go-ethereum/golem-base/storageutil/entity"
+	"github.com/ethereum/go-ethereum/params"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStorageTransactionValidate(t *testing.T) {
+	limits := &params.GolemBaseConfig{
+		MaxTTL:                   1000,
+		MaxPayloadSize:           16,
+		MaxAnnotations:           2,
+		MaxAnnotationKeyLength:   8,
+		MaxAnnotationValueLength: 8,
+	}
+
+	create := func(modify func(c *storagetx.Create)) *storagetx.StorageTransaction {
+		c := storagetx.Create{
+			TTL:     100,
+			Payload: []byte("payload"),
+			StringAnnotations: []entity.StringAnnotation{
+				{Key: "type", Value: "test"},
+			},
+			NumericAnnotations: []entity.NumericAnnotation{
+				{Key: "size", Value: 1024},
+			},
+		}
+		modify(&c)
+		return &storagetx.StorageTransaction{Create: []storagetx.Create{c}}
+	}
+
+	t.Run("WithinLimits", func(t *testing.T) {
+		require.NoError(t, create(func(c *storagetx.Create) {}).Validate(10, limits))
+	})
+
+	t.Run("ZeroTTL", func(t *testing.T) {
+		tx := create(func(c *storagetx.Create) { c.TTL = 0 })
+		require.ErrorIs(t, tx.Validate(10, limits), storagetx.ErrZeroTTL)
+		require.ErrorIs(t, tx.Validate(10, nil), storagetx.ErrZeroTTL)
+	})
+
+	t.Run("TTLTooLarge", func(t *testing.T) {
+		tx := create(func(c *storagetx.Create) { c.TTL = 1001 })
+		require.ErrorIs(t, tx.Validate(10, limits), storagetx.ErrTTLTooLarge)
+		require.NoError(t, tx.Validate(10, nil))
+	})
+
+	t.Run("TTLOverflow", func(t *testing.T) {
+		tx := create(func(c *storagetx.Create) { c.TTL = math.MaxUint64 })
+		require.ErrorIs(t, tx.Validate(10, nil), storagetx.ErrTTLTooLarge)
+	})
+
+	t.Run("PayloadTooLarge", func(t *testing.T) {
+		tx := create(func(c *storagetx.Create) { c.Payload = make([]byte, 17) })
+		require.ErrorIs(t, tx.Validate(10, limits), storagetx.ErrPayloadTooLarge)
+	})
+
+	t.Run("TooManyAnnotations", func(t *testing.T) {
+		tx := create(func(c *storagetx.Create) {
+			c.StringAnnotations = append(c.StringAnnotations, entity.StringAnnotation{Key: "other", Value: "value"})
+		})
+		require.ErrorIs(t, tx.Validate(10, limits), storagetx.ErrTooManyAnnotations)
+	})
+
+	t.Run("AnnotationKeyTooLong", func(t *testing.T) {
+		tx := create(func(c *storagetx.Create) { c.NumericAnnotations[0].Key = strings.Repeat("k", 9) })
+		require.ErrorIs(t, tx.Validate(10, limits), storagetx.ErrAnnotationKeyTooLong)
+	})
+
+	t.Run("AnnotationValueTooLong", func(t *testing.T) {
+		tx := create(func(c *storagetx.Create) { c.StringAnnotations[0].Value = strings.Repeat("v", 9) })
+		require.ErrorIs(t, tx.Validate(10, limits), storagetx.ErrAnnotationValueTooLong)
+	})
+
+	t.Run("UpdateIsValidated", func(t *testing.T) {
+		tx := &storagetx.StorageTransaction{
+			Update: []storagetx.Update{
+				{
+					EntityKey: common.HexToHash("0x1234"),
+					TTL:       0,
+				},
+			},
+		}
+		require.ErrorIs(t, tx.Validate(10, limits), storagetx.ErrZeroTTL)
+	})
+
+	t.Run("PatchIsValidated", func(t *testing.T) {
+		patch := func(p storagetx.Patch) *storagetx.StorageTransaction {
+			p.EntityKey = common.HexToHash("0x1234")
+			return &storagetx.StorageTransaction{Patch: []storagetx.Patch{p}}
+		}
+
+		// a zero TTL keeps the expiration of the entity
+		require.NoError(t, patch(storagetx.Patch{}).Validate(10, limits))
+		require.ErrorIs(t, patch(storagetx.Patch{TTL: 1001}).Validate(10, limits), storagetx.ErrTTLTooLarge)
+		require.ErrorIs(t, patch(storagetx.Patch{ReplacePayload: true, Payload: make([]byte, 17)}).Validate(10, limits), storagetx.ErrPayloadTooLarge)
+		require.ErrorIs(t, patch(storagetx.Patch{
+			SetStringAnnotations: []entity.StringAnnotation{{Key: "type", Value: strings.Repeat("v", 9)}},
+		}).Validate(10, limits), storagetx.ErrAnnotationValueTooLong)
+	})
+
+	t.Run("ZeroLimitsAreNotEnforced", func(t *testing.T) {
+		tx := create(func(c *storagetx.Create) { c.Payload = make([]byte, 1024) })
+		require.NoError(t, tx.Validate(10, &params.GolemBaseConfig{}))
+	})
+}
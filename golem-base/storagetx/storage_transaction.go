@@ -1,16 +1,23 @@
 package storagetx
 
 import (
+	"errors"
 	"fmt"
+	gomath "math"
 	"math/big"
+	"slices"
 
 	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/math"
 	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/core/vm"
 	"github.com/ethereum/go-ethereum/crypto"
 	"github.com/ethereum/go-ethereum/golem-base/address"
 	"github.com/ethereum/go-ethereum/golem-base/storageutil"
 	"github.com/ethereum/go-ethereum/golem-base/storageutil/entity"
+	"github.com/ethereum/go-ethereum/golem-base/storageutil/entity/allentities"
 	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/params"
 	"github.com/ethereum/go-ethereum/rlp"
 	"github.com/holiman/uint256"
 )
@@ -23,16 +30,46 @@ var GolemBaseStorageEntityCreated = crypto.Keccak256Hash([]byte("GolemBaseStorag
 // GolemBaseStorageEntityDeleted is the event signature for entity deletion logs.
 var GolemBaseStorageEntityDeleted = crypto.Keccak256Hash([]byte("GolemBaseStorageEntityDeleted(uint256)"))
 
+// GolemBaseStorageEntityExpired is the event signature for the logs of entities removed by the housekeeping transaction when their TTL ran out.
+var GolemBaseStorageEntityExpired = crypto.Keccak256Hash([]byte("GolemBaseStorageEntityExpired(uint256)"))
+
 // GolemBaseStorageEntityUpdated is the event signature for entity update logs.
 var GolemBaseStorageEntityUpdated = crypto.Keccak256Hash([]byte("GolemBaseStorageEntityUpdated(uint256,uint256)"))
 
+// GolemBaseStorageEntityOwnershipTransferred is the event signature for entity ownership transfer logs.
+var GolemBaseStorageEntityOwnershipTransferred = crypto.Keccak256Hash([]byte("GolemBaseStorageEntityOwnershipTransferred(uint256,address)"))
+
+// GolemBaseStorageEntityTTLExtended is the event signature for entity TTL extension logs.
+var GolemBaseStorageEntityTTLExtended = crypto.Keccak256Hash([]byte("GolemBaseStorageEntityTTLExtended(uint256,uint256,uint256)"))
+
+// GolemBaseStorageEntityPatched is the event signature for entity patch logs.
+var GolemBaseStorageEntityPatched = crypto.Keccak256Hash([]byte("GolemBaseStorageEntityPatched(uint256,uint256)"))
+
+// ErrAnnotationNotFound is returned when a Patch operation removes an annotation the entity does not have.
+var ErrAnnotationNotFound = errors.New("annotation not found")
+
+// ErrEntityNotFound is returned when an operation of a storage transaction modifies an entity that does not exist,
+// including deleted and expired entities.
+var ErrEntityNotFound = errors.New("entity not found")
+
+// ErrNotEntityOwner is returned when the sender of a storage transaction tries to modify an entity it does not own.
+var ErrNotEntityOwner = errors.New("sender is not the owner of the entity")
+
 // StorageTransaction represents a transaction that can be applied to the storage layer.
-// It contains a list of Create operations, a list of Update operations and a list of Delete operations.
+// It contains a list of Create operations, a list of Update operations, a list of Delete operations,
+// a list of TransferOwnership operations, a list of Extend operations and a list of Patch operations.
 //
 // Semantics of the transaction operations are as follows:
 //   - Create: adds new entities to the storage layer. Each entity has a TTL (number of blocks), a payload and a list of annotations. The Key of the entity is derived from the payload content, the transaction hash where the entity was created and the index of the create operation in the transaction.
-//   - Update: updates existing entities. Each entity has a key, a TTL (number of blocks), a payload and a list of annotations. If the entity does not exist, the operation fails, failing the whole transaction.
+//   - Update: updates existing entities. Each entity has a key, a TTL (number of blocks), a payload and a list of annotations. If the entity does not exist, the operation fails, failing the whole transaction. The owner of the entity is kept.
 //   - Delete: removes entities from the storage layer. If the entity does not exist, the operation fails, failing back the whole transaction.
+//   - TransferOwnership: changes the owner of existing entities. If the entity does not exist, the operation fails, failing the whole transaction.
+//   - Extend: extends the TTL of existing entities by a number of blocks, without changing their payload or annotations. If the entity does not exist, the operation fails, failing the whole transaction.
+//   - Patch: changes parts of existing entities without rewriting them: sets or removes individual annotations, replaces the payload or sets a new TTL. Setting an annotation replaces the value of an existing annotation of the same type and key. If the entity or a removed annotation does not exist, the operation fails, failing the whole transaction.
+//
+// Only the owner of an entity can update, delete, transfer the ownership of, extend or patch it.
+// If the sender is not the owner, the whole transaction fails with ErrNotEntityOwner, and if the
+// entity does not exist, with ErrEntityNotFound.
 //
 // The transaction is atomic, meaning that all operations are applied or none are.
 //
@@ -43,6 +80,10 @@ type StorageTransaction struct {
 	Create []Create      `json:"create"`
 	Update []Update      `json:"update"`
 	Delete []common.Hash `json:"delete"`
+
+	TransferOwnership []TransferOwnership `json:"transferOwnership" rlp:"optional"`
+	Extend            []ExtendTTL         `json:"extend" rlp:"optional"`
+	Patch             []Patch             `json:"patch" rlp:"optional"`
 }
 
 type Create struct {
@@ -60,7 +101,83 @@ type Update struct {
 	NumericAnnotations []entity.NumericAnnotation `json:"numericAnnotations"`
 }
 
-func (tx *StorageTransaction) Run(blockNumber uint64, txHash common.Hash, sender common.Address, access storageutil.StateAccess) (_ []*types.Log, err error) {
+type TransferOwnership struct {
+	EntityKey common.Hash    `json:"entityKey"`
+	NewOwner  common.Address `json:"newOwner"`
+}
+
+type ExtendTTL struct {
+	EntityKey      common.Hash `json:"entityKey"`
+	NumberOfBlocks uint64      `json:"numberOfBlocks"`
+}
+
+// Patch changes parts of an existing entity, leaving the rest of it untouched.
+// Annotations are removed before they are set.
+type Patch struct {
+	EntityKey common.Hash `json:"entityKey"`
+	// TTL, if not zero, sets the expiration of the entity to TTL blocks after the current block.
+	TTL uint64 `json:"ttl"`
+	// ReplacePayload replaces the payload of the entity with Payload, which can be empty.
+	ReplacePayload           bool                       `json:"replacePayload"`
+	Payload                  []byte                     `json:"payload"`
+	SetStringAnnotations     []entity.StringAnnotation  `json:"setStringAnnotations"`
+	SetNumericAnnotations    []entity.NumericAnnotation `json:"setNumericAnnotations"`
+	RemoveStringAnnotations  []string                   `json:"removeStringAnnotations"`
+	RemoveNumericAnnotations []string                   `json:"removeNumericAnnotations"`
+}
+
+// apply returns the meta data of the entity with the annotations and the TTL of the patch applied.
+func (p *Patch) apply(md entity.EntityMetaData, blockNumber uint64) (entity.EntityMetaData, error) {
+	stringAnnotations := slices.Clone(md.StringAnnotations)
+	for _, key := range p.RemoveStringAnnotations {
+		n := len(stringAnnotations)
+		stringAnnotations = slices.DeleteFunc(stringAnnotations, func(a entity.StringAnnotation) bool { return a.Key == key })
+		if len(stringAnnotations) == n {
+			return md, fmt.Errorf("%w: string annotation %q of entity %s", ErrAnnotationNotFound, key, p.EntityKey.Hex())
+		}
+	}
+
+	numericAnnotations := slices.Clone(md.NumericAnnotations)
+	for _, key := range p.RemoveNumericAnnotations {
+		n := len(numericAnnotations)
+		numericAnnotations = slices.DeleteFunc(numericAnnotations, func(a entity.NumericAnnotation) bool { return a.Key == key })
+		if len(numericAnnotations) == n {
+			return md, fmt.Errorf("%w: numeric annotation %q of entity %s", ErrAnnotationNotFound, key, p.EntityKey.Hex())
+		}
+	}
+
+	for _, set := range p.SetStringAnnotations {
+		stringAnnotations = slices.DeleteFunc(stringAnnotations, func(a entity.StringAnnotation) bool { return a.Key == set.Key })
+		stringAnnotations = append(stringAnnotations, set)
+	}
+
+	for _, set := range p.SetNumericAnnotations {
+		numericAnnotations = slices.DeleteFunc(numericAnnotations, func(a entity.NumericAnnotation) bool { return a.Key == set.Key })
+		numericAnnotations = append(numericAnnotations, set)
+	}
+
+	patched := entity.EntityMetaData{
+		ExpiresAtBlock:     md.ExpiresAtBlock,
+		StringAnnotations:  stringAnnotations,
+		NumericAnnotations: numericAnnotations,
+		Owner:              md.Owner,
+	}
+
+	if p.TTL != 0 {
+		patched.ExpiresAtBlock = blockNumber + p.TTL
+	}
+
+	return patched, nil
+}
+
+// Run applies the operations of the transaction to the state, after checking them against the limits.
+// limits can be nil, see Validate.
+func (tx *StorageTransaction) Run(blockNumber uint64, txHash common.Hash, sender common.Address, limits *params.GolemBaseConfig, access storageutil.StateAccess) ([]*types.Log, error) {
+	return tx.run(blockNumber, txHash, sender, limits, access, &gasMeter{limit: gomath.MaxUint64})
+}
+
+// run is Run, charging the gas that depends on the state to meter.
+func (tx *StorageTransaction) run(blockNumber uint64, txHash common.Hash, sender common.Address, limits *params.GolemBaseConfig, access storageutil.StateAccess, meter *gasMeter) (_ []*types.Log, err error) {
 
 	defer func() {
 		if err != nil {
@@ -68,11 +185,16 @@ func (tx *StorageTransaction) Run(blockNumber uint64, txHash common.Hash, sender
 		}
 	}()
 
+	err = tx.Validate(blockNumber, limits)
+	if err != nil {
+		return nil, err
+	}
+
 	logs := []*types.Log{}
 
 	storeEntity := func(key common.Hash, ap *entity.EntityMetaData, payload []byte, emitLogs bool) error {
 
-		err := entity.Store(access, key, sender, *ap, payload)
+		err := entity.Store(access, key, ap.Owner, *ap, payload)
 		if err != nil {
 			return fmt.Errorf("failed to store entity: %w", err)
 		}
@@ -117,9 +239,32 @@ func (tx *StorageTransaction) Run(blockNumber uint64, txHash common.Hash, sender
 
 	}
 
+	// checkOwner returns the meta data of the entity if it exists and the sender is its owner.
+	checkOwner := func(key common.Hash) (*entity.EntityMetaData, error) {
+		if !allentities.Contains(access, key) {
+			return nil, fmt.Errorf("%w: %s", ErrEntityNotFound, key.Hex())
+		}
+
+		md, err := entity.GetEntityMetaData(access, key)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get entity meta data of %s: %w", key.Hex(), err)
+		}
+
+		if md.Owner != sender {
+			return nil, fmt.Errorf("%w: entity %s is owned by %s, sender is %s", ErrNotEntityOwner, key.Hex(), md.Owner.Hex(), sender.Hex())
+		}
+
+		return md, nil
+	}
+
 	deleteEntity := func(toDelete common.Hash, emitLogs bool) error {
 
-		err := entity.Delete(access, toDelete)
+		err := meter.chargeClear(entity.StorageSlots(access, toDelete))
+		if err != nil {
+			return err
+		}
+
+		err = entity.Delete(access, toDelete)
 		if err != nil {
 			return fmt.Errorf("failed to delete entity: %w", err)
 		}
@@ -142,19 +287,30 @@ func (tx *StorageTransaction) Run(blockNumber uint64, txHash common.Hash, sender
 	}
 
 	for _, toDelete := range tx.Delete {
-		err := deleteEntity(toDelete, true)
+		_, err := checkOwner(toDelete)
+		if err != nil {
+			return nil, err
+		}
+
+		err = deleteEntity(toDelete, true)
 		if err != nil {
 			return nil, err
 		}
 	}
 
 	for _, update := range tx.Update {
-		err := deleteEntity(update.EntityKey, false)
+		md, err := checkOwner(update.EntityKey)
+		if err != nil {
+			return nil, err
+		}
+
+		err = deleteEntity(update.EntityKey, false)
 		if err != nil {
 			return nil, err
 		}
 
 		ap := &entity.EntityMetaData{
+			Owner:              md.Owner,
 			ExpiresAtBlock:     blockNumber + update.TTL,
 			StringAnnotations:  update.StringAnnotations,
 			NumericAnnotations: update.NumericAnnotations,
@@ -175,21 +331,146 @@ func (tx *StorageTransaction) Run(blockNumber uint64, txHash common.Hash, sender
 
 	}
 
-	// TODO: implement update
+	for _, transfer := range tx.TransferOwnership {
+		md, err := checkOwner(transfer.EntityKey)
+		if err != nil {
+			return nil, err
+		}
+
+		transferred := *md
+		transferred.Owner = transfer.NewOwner
+		err = meter.chargeMetaData(transferred)
+		if err != nil {
+			return nil, err
+		}
+
+		err = entity.TransferOwnership(access, transfer.EntityKey, *md, transfer.NewOwner)
+		if err != nil {
+			return nil, fmt.Errorf("failed to transfer ownership of entity %s: %w", transfer.EntityKey.Hex(), err)
+		}
+
+		logs = append(logs, &types.Log{
+			Address:     address.GolemBaseStorageProcessorAddress,
+			Topics:      []common.Hash{GolemBaseStorageEntityOwnershipTransferred, transfer.EntityKey},
+			Data:        common.LeftPadBytes(transfer.NewOwner.Bytes(), 32),
+			BlockNumber: blockNumber,
+		})
+	}
+
+	for _, extend := range tx.Extend {
+		md, err := checkOwner(extend.EntityKey)
+		if err != nil {
+			return nil, err
+		}
+
+		oldExpiresAtBlock := md.ExpiresAtBlock
+		newExpiresAtBlock, overflow := math.SafeAdd(oldExpiresAtBlock, extend.NumberOfBlocks)
+		if overflow {
+			return nil, fmt.Errorf("%w: extending the TTL of entity %s by %d blocks overflows", ErrTTLTooLarge, extend.EntityKey.Hex(), extend.NumberOfBlocks)
+		}
+
+		if limits != nil && newExpiresAtBlock > blockNumber && exceeds(newExpiresAtBlock-blockNumber, limits.MaxTTL) {
+			return nil, fmt.Errorf("%w: extending the TTL of entity %s to block %d, maximum TTL %d", ErrTTLTooLarge, extend.EntityKey.Hex(), newExpiresAtBlock, limits.MaxTTL)
+		}
+
+		extended := *md
+		extended.ExpiresAtBlock = newExpiresAtBlock
+		err = meter.chargeMetaData(extended)
+		if err != nil {
+			return nil, err
+		}
+
+		err = entity.ExtendTTL(access, extend.EntityKey, *md, newExpiresAtBlock)
+		if err != nil {
+			return nil, fmt.Errorf("failed to extend TTL of entity %s: %w", extend.EntityKey.Hex(), err)
+		}
+
+		logs = append(logs, &types.Log{
+			Address: address.GolemBaseStorageProcessorAddress,
+			Topics:  []common.Hash{GolemBaseStorageEntityTTLExtended, extend.EntityKey},
+			Data: append(
+				common.BigToHash(new(big.Int).SetUint64(oldExpiresAtBlock)).Bytes(),
+				common.BigToHash(new(big.Int).SetUint64(newExpiresAtBlock)).Bytes()...,
+			),
+			BlockNumber: blockNumber,
+		})
+	}
+
+	for _, patch := range tx.Patch {
+		md, err := checkOwner(patch.EntityKey)
+		if err != nil {
+			return nil, err
+		}
+
+		patched, err := patch.apply(*md, blockNumber)
+		if err != nil {
+			return nil, err
+		}
+
+		err = validateAnnotations(patched.StringAnnotations, patched.NumericAnnotations, limits)
+		if err != nil {
+			return nil, fmt.Errorf("invalid patch of entity %s: %w", patch.EntityKey.Hex(), err)
+		}
+
+		err = meter.chargeMetaData(patched)
+		if err != nil {
+			return nil, err
+		}
+
+		if patch.ReplacePayload {
+			payloadSlots, _ := entity.StorageSlots(access, patch.EntityKey)
+			err = meter.chargeClear(payloadSlots, 0)
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		err = entity.Patch(access, patch.EntityKey, *md, patched)
+		if err != nil {
+			return nil, fmt.Errorf("failed to patch entity %s: %w", patch.EntityKey.Hex(), err)
+		}
+
+		if patch.ReplacePayload {
+			entity.StorePayload(access, patch.EntityKey, patch.Payload)
+		}
+
+		logs = append(logs, &types.Log{
+			Address:     address.GolemBaseStorageProcessorAddress,
+			Topics:      []common.Hash{GolemBaseStorageEntityPatched, patch.EntityKey},
+			Data:        common.BigToHash(new(big.Int).SetUint64(patched.ExpiresAtBlock)).Bytes(),
+			BlockNumber: blockNumber,
+		})
+	}
 
 	return logs, nil
 }
 
-func ExecuteTransaction(d []byte, blockNumber uint64, txHash common.Hash, sender common.Address, access storageutil.StateAccess) ([]*types.Log, error) {
+// ExecuteTransaction decodes and runs the storage transaction, charging the gas of its
+// operations against gasRemaining. It returns the logs and the gas used.
+// If the gas of the operations exceeds gasRemaining, before or while the transaction is
+// run, all of the remaining gas is used and vm.ErrOutOfGas is returned.
+// The gas of the operations is also charged if running the transaction fails,
+// including when the transaction violates the limits.
+func ExecuteTransaction(d []byte, gasRemaining uint64, blockNumber uint64, txHash common.Hash, sender common.Address, limits *params.GolemBaseConfig, access storageutil.StateAccess) ([]*types.Log, uint64, error) {
 	tx := &StorageTransaction{}
 	err := rlp.DecodeBytes(d, tx)
 	if err != nil {
-		return nil, fmt.Errorf("failed to decode storage transaction: %w", err)
+		return nil, 0, fmt.Errorf("failed to decode storage transaction: %w", err)
+	}
+
+	gas, ok := tx.Gas()
+	if !ok || gas > gasRemaining {
+		return nil, gasRemaining, vm.ErrOutOfGas
+	}
+
+	meter := &gasMeter{limit: gasRemaining - gas}
+	logs, err := tx.run(blockNumber, txHash, sender, limits, access, meter)
+	if errors.Is(err, vm.ErrOutOfGas) {
+		return nil, gasRemaining, err
 	}
-	logs, err := tx.Run(blockNumber, txHash, sender, access)
 	if err != nil {
 		log.Error("Failed to run storage transaction", "error", err)
-		return nil, fmt.Errorf("failed to run storage transaction: %w", err)
+		return nil, gas + meter.used, fmt.Errorf("failed to run storage transaction: %w", err)
 	}
-	return logs, nil
+	return logs, gas + meter.used, nil
 }
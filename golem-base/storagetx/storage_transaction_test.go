@@ -4,6 +4,9 @@ import (
 	"testing"
 
 	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/state"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/golem-base/housekeepingtx"
 	"github.com/ethereum/go-ethereum/golem-base/storagetx"
 	"github.com/ethereum/go-ethereum/golem-base/storageutil/entity"
 	"github.com/ethereum/go-ethereum/rlp"
@@ -46,6 +49,34 @@ func TestStorageTransactionMarshalling(t *testing.T) {
 				common.HexToHash("0xdeadbeef"),
 				common.HexToHash("0xbeefdead"),
 			},
+			TransferOwnership: []storagetx.TransferOwnership{
+				{
+					EntityKey: common.HexToHash("0xcafebabe"),
+					NewOwner:  common.HexToAddress("0x1234"),
+				},
+			},
+			Extend: []storagetx.ExtendTTL{
+				{
+					EntityKey:      common.HexToHash("0xfeedface"),
+					NumberOfBlocks: 43200,
+				},
+			},
+			Patch: []storagetx.Patch{
+				{
+					EntityKey:      common.HexToHash("0xdecade"),
+					TTL:            500,
+					ReplacePayload: true,
+					Payload:        []byte("patched payload"),
+					SetStringAnnotations: []entity.StringAnnotation{
+						{Key: "status", Value: "patched"},
+					},
+					SetNumericAnnotations: []entity.NumericAnnotation{
+						{Key: "version", Value: 2},
+					},
+					RemoveStringAnnotations:  []string{"name"},
+					RemoveNumericAnnotations: []string{"size"},
+				},
+			},
 		}
 
 		// Test marshalling
@@ -71,6 +102,28 @@ func TestStorageTransactionMarshalling(t *testing.T) {
 		assert.Equal(t, tx.Update[0].NumericAnnotations, decoded.Update[0].NumericAnnotations)
 
 		assert.Equal(t, tx.Delete, decoded.Delete)
+		assert.Equal(t, tx.TransferOwnership, decoded.TransferOwnership)
+		assert.Equal(t, tx.Extend, decoded.Extend)
+		assert.Equal(t, tx.Patch, decoded.Patch)
+	})
+
+	t.Run("TransactionWithoutOptionalFields", func(t *testing.T) {
+		// Transactions encoded before TransferOwnership was introduced must still decode
+		encoded, err := rlp.EncodeToBytes([]interface{}{
+			[]storagetx.Create{},
+			[]storagetx.Update{},
+			[]common.Hash{common.HexToHash("0xdeadbeef")},
+		})
+		require.NoError(t, err)
+
+		var decoded storagetx.StorageTransaction
+		err = rlp.DecodeBytes(encoded, &decoded)
+		require.NoError(t, err)
+
+		assert.Equal(t, []common.Hash{common.HexToHash("0xdeadbeef")}, decoded.Delete)
+		assert.Empty(t, decoded.TransferOwnership)
+		assert.Empty(t, decoded.Extend)
+		assert.Empty(t, decoded.Patch)
 	})
 
 	t.Run("EmptyTransaction", func(t *testing.T) {
@@ -86,5 +139,90 @@ func TestStorageTransactionMarshalling(t *testing.T) {
 		assert.Empty(t, decodedEmpty.Create)
 		assert.Empty(t, decodedEmpty.Update)
 		assert.Empty(t, decodedEmpty.Delete)
+		assert.Empty(t, decodedEmpty.TransferOwnership)
+		assert.Empty(t, decodedEmpty.Extend)
+	})
+}
+
+func TestRunOnDeletedEntity(t *testing.T) {
+	owner := common.HexToAddress("0x1")
+
+	// newDeletedEntity creates an entity and removes it again, by deleting it or by letting it expire
+	newDeletedEntity := func(t *testing.T, expire bool) (*state.StateDB, common.Hash) {
+		t.Helper()
+
+		db, err := state.New(types.EmptyRootHash, state.NewDatabaseForTesting())
+		require.NoError(t, err)
+
+		create := &storagetx.StorageTransaction{
+			Create: []storagetx.Create{{
+				TTL:                5,
+				Payload:            []byte("payload"),
+				StringAnnotations:  []entity.StringAnnotation{{Key: "type", Value: "a"}},
+				NumericAnnotations: []entity.NumericAnnotation{{Key: "size", Value: 1}},
+			}},
+		}
+		logs, err := create.Run(10, common.BigToHash(common.Big1), owner, nil, db)
+		require.NoError(t, err)
+		key := logs[0].Topics[1]
+
+		if expire {
+			_, err = housekeepingtx.ExecuteTransaction(15, common.Hash{}, true, db)
+		} else {
+			_, err = (&storagetx.StorageTransaction{Delete: []common.Hash{key}}).Run(11, common.BigToHash(common.Big2), owner, nil, db)
+		}
+		require.NoError(t, err)
+
+		_, err = entity.GetEntityMetaData(db, key)
+		require.Error(t, err, "the meta data of a removed entity is deleted")
+
+		return db, key
+	}
+
+	operations := map[string]func(key common.Hash) *storagetx.StorageTransaction{
+		"Delete": func(key common.Hash) *storagetx.StorageTransaction {
+			return &storagetx.StorageTransaction{Delete: []common.Hash{key}}
+		},
+		"Update": func(key common.Hash) *storagetx.StorageTransaction {
+			return &storagetx.StorageTransaction{Update: []storagetx.Update{{EntityKey: key, TTL: 100, Payload: []byte("updated")}}}
+		},
+		"TransferOwnership": func(key common.Hash) *storagetx.StorageTransaction {
+			return &storagetx.StorageTransaction{TransferOwnership: []storagetx.TransferOwnership{{EntityKey: key, NewOwner: common.HexToAddress("0x2")}}}
+		},
+		"Extend": func(key common.Hash) *storagetx.StorageTransaction {
+			return &storagetx.StorageTransaction{Extend: []storagetx.ExtendTTL{{EntityKey: key, NumberOfBlocks: 100}}}
+		},
+		"Patch": func(key common.Hash) *storagetx.StorageTransaction {
+			return &storagetx.StorageTransaction{Patch: []storagetx.Patch{{
+				EntityKey:            key,
+				ReplacePayload:       true,
+				Payload:              []byte("patched"),
+				SetStringAnnotations: []entity.StringAnnotation{{Key: "status", Value: "patched"}},
+			}}}
+		},
+	}
+
+	for name, operation := range operations {
+		for _, expire := range []bool{false, true} {
+			removal := "Deleted"
+			if expire {
+				removal = "Expired"
+			}
+
+			t.Run(name+removal, func(t *testing.T) {
+				db, key := newDeletedEntity(t, expire)
+
+				_, err := operation(key).Run(20, common.BigToHash(common.Big3), owner, nil, db)
+				require.ErrorIs(t, err, storagetx.ErrEntityNotFound)
+			})
+		}
+	}
+
+	t.Run("MissingEntity", func(t *testing.T) {
+		db, err := state.New(types.EmptyRootHash, state.NewDatabaseForTesting())
+		require.NoError(t, err)
+
+		_, err = operations["Extend"](common.HexToHash("0x1234")).Run(20, common.BigToHash(common.Big3), owner, nil, db)
+		require.ErrorIs(t, err, storagetx.ErrEntityNotFound)
 	})
 }
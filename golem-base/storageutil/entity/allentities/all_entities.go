@@ -33,6 +33,11 @@ func RemoveEntity(db StateAccess, hash common.Hash) error {
 	return keyset.RemoveValue(db, AllEntitiesKey, hash)
 }
 
+// Contains reports whether the entity hash is in the global registry.
+func Contains(db StateAccess, hash common.Hash) bool {
+	return keyset.ContainsValue(db, AllEntitiesKey, hash)
+}
+
 // Iterate provides a function that can be used to iterate over all entity hashes in the registry.
 func Iterate(db StateAccess) func(yield func(hash common.Hash) bool) {
 	return keyset.Iterate(db, AllEntitiesKey)
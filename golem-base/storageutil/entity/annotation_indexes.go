@@ -0,0 +1,86 @@
+package entity
+
+import (
+	"fmt"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/golem-base/storageutil/entity/annotationindex"
+	"github.com/ethereum/go-ethereum/golem-base/storageutil/entity/numericrangeindex"
+	"github.com/ethereum/go-ethereum/golem-base/storageutil/entity/stringprefixindex"
+	"github.com/ethereum/go-ethereum/golem-base/storageutil/keyset"
+)
+
+// The annotation helpers always maintain the key sets of the entities carrying an
+// annotation. valueIndexes selects whether the string prefix and numeric range indexes
+// of the annotation values are maintained as well, which is only the case once the
+// Golem Base storage rules are active.
+
+func addStringAnnotationToIndexes(access StateAccess, key common.Hash, stringAnnotation StringAnnotation, valueIndexes bool) error {
+	err := keyset.AddValue(
+		access,
+		annotationindex.StringAnnotationIndexKey(stringAnnotation.Key, stringAnnotation.Value),
+		key,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to append to key list: %w", err)
+	}
+
+	if valueIndexes {
+		stringprefixindex.AddValue(access, stringAnnotation.Key, stringAnnotation.Value)
+	}
+
+	return nil
+}
+
+func addNumericAnnotationToIndexes(access StateAccess, key common.Hash, numericAnnotation NumericAnnotation, valueIndexes bool) error {
+	err := keyset.AddValue(
+		access,
+		annotationindex.NumericAnnotationIndexKey(numericAnnotation.Key, numericAnnotation.Value),
+		key,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to append to key list: %w", err)
+	}
+
+	if valueIndexes {
+		numericrangeindex.AddValue(access, numericAnnotation.Key, numericAnnotation.Value)
+	}
+
+	return nil
+}
+
+func removeStringAnnotationFromIndexes(access StateAccess, key common.Hash, stringAnnotation StringAnnotation, valueIndexes bool) error {
+	setKey := annotationindex.StringAnnotationIndexKey(stringAnnotation.Key, stringAnnotation.Value)
+	err := keyset.RemoveValue(
+		access,
+		setKey,
+		key,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to remove key %s from the string annotation list: %w", key, err)
+	}
+
+	if valueIndexes && keyset.Size(access, setKey).IsZero() {
+		stringprefixindex.RemoveValue(access, stringAnnotation.Key, stringAnnotation.Value)
+	}
+
+	return nil
+}
+
+func removeNumericAnnotationFromIndexes(access StateAccess, key common.Hash, numericAnnotation NumericAnnotation, valueIndexes bool) error {
+	setKey := annotationindex.NumericAnnotationIndexKey(numericAnnotation.Key, numericAnnotation.Value)
+	err := keyset.RemoveValue(
+		access,
+		setKey,
+		key,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to remove key %s from the numeric annotation list: %w", key, err)
+	}
+
+	if valueIndexes && keyset.Size(access, setKey).IsZero() {
+		numericrangeindex.RemoveValue(access, numericAnnotation.Key, numericAnnotation.Value)
+	}
+
+	return nil
+}
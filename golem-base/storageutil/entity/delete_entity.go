@@ -5,13 +5,23 @@ import (
 
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/golem-base/storageutil/entity/allentities"
-	"github.com/ethereum/go-ethereum/golem-base/storageutil/entity/annotationindex"
 	"github.com/ethereum/go-ethereum/golem-base/storageutil/entity/entitiesofowner"
 	"github.com/ethereum/go-ethereum/golem-base/storageutil/entity/entityexpiration"
-	"github.com/ethereum/go-ethereum/golem-base/storageutil/keyset"
 )
 
+// Delete removes the entity from all indexes and deletes its payload and meta data.
 func Delete(access StateAccess, toDelete common.Hash) error {
+	err := deleteEntity(access, toDelete, true)
+	if err != nil {
+		return err
+	}
+
+	DeleteEntityMetaData(access, toDelete)
+
+	return nil
+}
+
+func deleteEntity(access StateAccess, toDelete common.Hash, valueIndexes bool) error {
 	err := allentities.RemoveEntity(access, toDelete)
 	if err != nil {
 		return fmt.Errorf("failed to remove entity from all entities: %w", err)
@@ -23,27 +33,16 @@ func Delete(access StateAccess, toDelete common.Hash) error {
 	}
 
 	for _, stringAnnotation := range md.StringAnnotations {
-		setKey := annotationindex.StringAnnotationIndexKey(stringAnnotation.Key, stringAnnotation.Value)
-		err := keyset.RemoveValue(
-			access,
-			setKey,
-			toDelete,
-		)
+		err := removeStringAnnotationFromIndexes(access, toDelete, stringAnnotation, valueIndexes)
 		if err != nil {
-			return fmt.Errorf("failed to remove key %s from the string annotation list: %w", toDelete, err)
+			return err
 		}
-
 	}
 
 	for _, numericAnnotation := range md.NumericAnnotations {
-		setKeys := annotationindex.NumericAnnotationIndexKey(numericAnnotation.Key, numericAnnotation.Value)
-		err := keyset.RemoveValue(
-			access,
-			setKeys,
-			toDelete,
-		)
+		err := removeNumericAnnotationFromIndexes(access, toDelete, numericAnnotation, valueIndexes)
 		if err != nil {
-			return fmt.Errorf("failed to remove key %s from the numeric annotation list: %w", toDelete, err)
+			return err
 		}
 	}
 
@@ -0,0 +1,12 @@
+package entity
+
+import (
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/golem-base/storageutil/stateblob"
+)
+
+func DeleteEntityMetaData(access StateAccess, key common.Hash) {
+	hash := crypto.Keccak256Hash(EntityMetaDataSalt, key[:])
+	stateblob.DeleteBlob(access, hash)
+}
@@ -0,0 +1,32 @@
+package entity
+
+import (
+	"fmt"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/golem-base/storageutil/entity/entityexpiration"
+)
+
+// ExtendTTL moves the entity from the list of entities to expire at its current expiration block
+// to the list of entities to expire at newExpiresAtBlock and stores the updated meta data.
+// The payload and the annotation indexes are left untouched.
+func ExtendTTL(access StateAccess, key common.Hash, emd EntityMetaData, newExpiresAtBlock uint64) error {
+	err := entityexpiration.RemoveFromEntitiesToExpire(access, emd.ExpiresAtBlock, key)
+	if err != nil {
+		return fmt.Errorf("failed to remove entity from entities to expire: %w", err)
+	}
+
+	err = entityexpiration.AddToEntitiesToExpireAtBlock(access, newExpiresAtBlock, key)
+	if err != nil {
+		return fmt.Errorf("failed to add entity to entities to expire: %w", err)
+	}
+
+	emd.ExpiresAtBlock = newExpiresAtBlock
+
+	err = StoreEntityMetaData(access, key, emd)
+	if err != nil {
+		return fmt.Errorf("failed to store entity meta data: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,64 @@
+package entity
+
+import (
+	"fmt"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/golem-base/storageutil"
+	"github.com/ethereum/go-ethereum/golem-base/storageutil/entity/allentities"
+	"github.com/ethereum/go-ethereum/golem-base/storageutil/entity/numericrangeindex"
+	"github.com/ethereum/go-ethereum/golem-base/storageutil/entity/stringprefixindex"
+)
+
+// ValueIndexesBuiltKey is the storage key marking that the string prefix and numeric range
+// indexes have been built for the entities stored before the Golem Base storage rules were activated.
+var ValueIndexesBuiltKey = crypto.Keccak256Hash([]byte("golemBase.valueIndexesBuilt"))
+
+var valueIndexesBuilt = common.BytesToHash([]byte{1})
+
+// LegacyStore stores an entity the way it was stored before the Golem Base storage rules
+// were activated: its annotations are only added to the annotation key sets.
+func LegacyStore(
+	access StateAccess,
+	key common.Hash,
+	sender common.Address,
+	emd EntityMetaData,
+	payload []byte,
+) error {
+	return store(access, key, sender, emd, payload, false)
+}
+
+// LegacyDelete deletes an entity the way it was deleted before the Golem Base storage rules
+// were activated: its annotations are only removed from the annotation key sets.
+func LegacyDelete(access StateAccess, toDelete common.Hash) error {
+	return deleteEntity(access, toDelete, false)
+}
+
+// BuildValueIndexes adds the annotation values of all entities to the string prefix and
+// numeric range indexes, which are not maintained before the Golem Base storage rules are
+// activated. The indexes are only built once, later calls do nothing.
+func BuildValueIndexes(access StateAccess) error {
+	if access.GetState(storageutil.GolemDBAddress, ValueIndexesBuiltKey) == valueIndexesBuilt {
+		return nil
+	}
+
+	for key := range allentities.Iterate(access) {
+		md, err := GetEntityMetaData(access, key)
+		if err != nil {
+			return fmt.Errorf("failed to get entity meta data of %s: %w", key.Hex(), err)
+		}
+
+		for _, stringAnnotation := range md.StringAnnotations {
+			stringprefixindex.AddValue(access, stringAnnotation.Key, stringAnnotation.Value)
+		}
+
+		for _, numericAnnotation := range md.NumericAnnotations {
+			numericrangeindex.AddValue(access, numericAnnotation.Key, numericAnnotation.Value)
+		}
+	}
+
+	access.SetState(storageutil.GolemDBAddress, ValueIndexesBuiltKey, valueIndexesBuilt)
+
+	return nil
+}
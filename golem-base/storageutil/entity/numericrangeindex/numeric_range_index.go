@@ -0,0 +1,181 @@
+// Package numericrangeindex keeps, for every numeric annotation key, the sorted set of
+// distinct values that are currently in use. It complements the hash-based
+// annotationindex.NumericAnnotationIndexKey sets, which map a single (key, value) pair
+// to the entities carrying it, by allowing the values within a range to be enumerated
+// in ascending order without scanning all entities.
+//
+// The values are stored in a 256-ary trie with one level per byte of the big-endian
+// uint64 value. Every node is a single storage slot holding a 256-bit bitmap of the
+// children that are present, so adding or removing a value touches at most eight slots.
+package numericrangeindex
+
+import (
+	"encoding/binary"
+	"math/bits"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/golem-base/storageutil"
+	"github.com/ethereum/go-ethereum/golem-base/storageutil/entity/annotationindex"
+	"github.com/holiman/uint256"
+)
+
+type StateAccess = storageutil.StateAccess
+
+var NumericRangeIndexSalt = []byte("golemBaseNumericRangeIndex")
+
+// levels is the number of trie levels, one per byte of a uint64 value.
+const levels = 8
+
+// MaxSlotsWritten is the maximum number of storage slots AddValue writes.
+const MaxSlotsWritten uint64 = levels
+
+func nodeKey(key string, level int, prefix uint64) common.Hash {
+	return crypto.Keccak256Hash(
+		NumericRangeIndexSalt,
+		[]byte(key),
+		annotationindex.AnnotationSeparator,
+		[]byte{byte(level)},
+		binary.BigEndian.AppendUint64(nil, prefix),
+	)
+}
+
+// prefixAt returns the bytes of value above the given level, which identify the node of that level.
+func prefixAt(value uint64, level int) uint64 {
+	if level == 0 {
+		return 0
+	}
+	return value >> (8 * (levels - level))
+}
+
+// childAt returns the byte of value that selects the child within the node of the given level.
+func childAt(value uint64, level int) uint {
+	return uint((value >> (8 * (levels - 1 - level))) & 0xff)
+}
+
+func getBitmap(db StateAccess, key common.Hash) *uint256.Int {
+	v := db.GetState(storageutil.GolemDBAddress, key)
+	return new(uint256.Int).SetBytes32(v[:])
+}
+
+func hasBit(bitmap *uint256.Int, bit uint) bool {
+	return bitmap[bit/64]&(1<<(bit%64)) != 0
+}
+
+// AddValue adds the value to the sorted set of values of the numeric annotation key.
+// Adding a value that is already present does nothing.
+func AddValue(db StateAccess, key string, value uint64) {
+	for level := levels - 1; level >= 0; level-- {
+		nk := nodeKey(key, level, prefixAt(value, level))
+		bitmap := getBitmap(db, nk)
+		child := childAt(value, level)
+
+		if hasBit(bitmap, child) {
+			// the node already had this child, so all of its ancestors are already present
+			return
+		}
+
+		bitmap[child/64] |= 1 << (child % 64)
+		db.SetState(storageutil.GolemDBAddress, nk, bitmap.Bytes32())
+	}
+}
+
+// RemoveValue removes the value from the sorted set of values of the numeric annotation key.
+// It should be called once no entity carries the (key, value) annotation anymore.
+func RemoveValue(db StateAccess, key string, value uint64) {
+	for level := levels - 1; level >= 0; level-- {
+		nk := nodeKey(key, level, prefixAt(value, level))
+		bitmap := getBitmap(db, nk)
+		child := childAt(value, level)
+
+		bitmap[child/64] &^= 1 << (child % 64)
+		db.SetState(storageutil.GolemDBAddress, nk, bitmap.Bytes32())
+
+		if !bitmap.IsZero() {
+			// the node still has other children, so its ancestors must stay
+			return
+		}
+	}
+}
+
+// IterateRange yields the values of the numeric annotation key that lie within
+// the inclusive range [from, to], in ascending order.
+func IterateRange(db StateAccess, key string, from, to uint64) func(yield func(value uint64) bool) {
+	return func(yield func(value uint64) bool) {
+		if from > to {
+			return
+		}
+		walk(db, key, 0, 0, from, to, false, yield)
+	}
+}
+
+// IterateRangeDescending is like IterateRange, but yields the values in descending order.
+func IterateRangeDescending(db StateAccess, key string, from, to uint64) func(yield func(value uint64) bool) {
+	return func(yield func(value uint64) bool) {
+		if from > to {
+			return
+		}
+		walk(db, key, 0, 0, from, to, true, yield)
+	}
+}
+
+// walk visits the children of the node identified by level and prefix in ascending order,
+// or in descending order if descending is set.
+// It returns false if the iteration was stopped by yield.
+func walk(db StateAccess, key string, level int, prefix uint64, from, to uint64, descending bool, yield func(value uint64) bool) bool {
+	bitmap := getBitmap(db, nodeKey(key, level, prefix))
+
+	// the number of value bits below the children of this node
+	shift := 8 * (levels - 1 - level)
+
+	for i := 0; i < len(bitmap); i++ {
+		word := i
+		if descending {
+			word = len(bitmap) - 1 - i
+		}
+
+		w := bitmap[word]
+		for w != 0 {
+			var bit uint
+			if descending {
+				high := 63 - bits.LeadingZeros64(w)
+				bit = uint(word*64 + high)
+				w &^= 1 << high
+			} else {
+				bit = uint(word*64 + bits.TrailingZeros64(w))
+				w &= w - 1
+			}
+
+			child := prefix<<8 | uint64(bit)
+			lowest := child << shift
+			highest := lowest | (uint64(1)<<shift - 1)
+
+			// the children before the range are skipped, the ones after it end the walk
+			before, after := highest < from, lowest > to
+			if descending {
+				before, after = after, before
+			}
+
+			if before {
+				continue
+			}
+
+			if after {
+				return true
+			}
+
+			if level == levels-1 {
+				if !yield(child) {
+					return false
+				}
+				continue
+			}
+
+			if !walk(db, key, level+1, child, from, to, descending, yield) {
+				return false
+			}
+		}
+	}
+
+	return true
+}
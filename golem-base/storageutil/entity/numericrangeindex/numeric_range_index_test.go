@@ -0,0 +1,136 @@
+package numericrangeindex_test
+
+import (
+	"math"
+	"slices"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/golem-base/storageutil/entity/numericrangeindex"
+	"github.com/stretchr/testify/require"
+)
+
+// mockStateAccess implements StateAccess interface for testing
+type mockStateAccess struct {
+	storage map[common.Address]map[common.Hash]common.Hash
+}
+
+func newMockStateAccess() *mockStateAccess {
+	return &mockStateAccess{
+		storage: make(map[common.Address]map[common.Hash]common.Hash),
+	}
+}
+
+func (m *mockStateAccess) GetState(addr common.Address, key common.Hash) common.Hash {
+	return m.storage[addr][key]
+}
+
+func (m *mockStateAccess) SetState(addr common.Address, key common.Hash, value common.Hash) common.Hash {
+	if value == (common.Hash{}) {
+		delete(m.storage[addr], key)
+		if len(m.storage[addr]) == 0 {
+			delete(m.storage, addr)
+		}
+		return value
+	}
+
+	if _, exists := m.storage[addr]; !exists {
+		m.storage[addr] = make(map[common.Hash]common.Hash)
+	}
+	m.storage[addr][key] = value
+	return value
+}
+
+func collect(db *mockStateAccess, key string, from, to uint64) []uint64 {
+	return slices.Collect(numericrangeindex.IterateRange(db, key, from, to))
+}
+
+func TestNumericRangeIndex(t *testing.T) {
+	t.Run("values are iterated in ascending order", func(t *testing.T) {
+		db := newMockStateAccess()
+		for _, v := range []uint64{1000, 3, math.MaxUint64, 0, 256, 255, 1 << 40} {
+			numericrangeindex.AddValue(db, "age", v)
+		}
+
+		require.Equal(t,
+			[]uint64{0, 3, 255, 256, 1000, 1 << 40, math.MaxUint64},
+			collect(db, "age", 0, math.MaxUint64),
+		)
+	})
+
+	t.Run("values are iterated in descending order", func(t *testing.T) {
+		db := newMockStateAccess()
+		for _, v := range []uint64{1000, 3, math.MaxUint64, 0, 256, 255, 1 << 40} {
+			numericrangeindex.AddValue(db, "age", v)
+		}
+
+		require.Equal(t,
+			[]uint64{math.MaxUint64, 1 << 40, 1000, 256, 255, 3, 0},
+			slices.Collect(numericrangeindex.IterateRangeDescending(db, "age", 0, math.MaxUint64)),
+		)
+		require.Equal(t,
+			[]uint64{1000, 256},
+			slices.Collect(numericrangeindex.IterateRangeDescending(db, "age", 256, 1000)),
+		)
+	})
+
+	t.Run("range bounds are inclusive", func(t *testing.T) {
+		db := newMockStateAccess()
+		for _, v := range []uint64{1, 5, 10, 15, 20} {
+			numericrangeindex.AddValue(db, "age", v)
+		}
+
+		require.Equal(t, []uint64{5, 10, 15}, collect(db, "age", 5, 15))
+		require.Equal(t, []uint64{10}, collect(db, "age", 6, 14))
+		require.Empty(t, collect(db, "age", 21, math.MaxUint64))
+		require.Empty(t, collect(db, "age", 15, 5))
+	})
+
+	t.Run("adding a value twice does not duplicate it", func(t *testing.T) {
+		db := newMockStateAccess()
+		numericrangeindex.AddValue(db, "age", 42)
+		numericrangeindex.AddValue(db, "age", 42)
+
+		require.Equal(t, []uint64{42}, collect(db, "age", 0, math.MaxUint64))
+	})
+
+	t.Run("annotation keys are independent", func(t *testing.T) {
+		db := newMockStateAccess()
+		numericrangeindex.AddValue(db, "age", 1)
+		numericrangeindex.AddValue(db, "size", 2)
+
+		require.Equal(t, []uint64{1}, collect(db, "age", 0, math.MaxUint64))
+		require.Equal(t, []uint64{2}, collect(db, "size", 0, math.MaxUint64))
+	})
+
+	t.Run("removing values clears the storage", func(t *testing.T) {
+		db := newMockStateAccess()
+		numericrangeindex.AddValue(db, "age", 0x0102)
+		numericrangeindex.AddValue(db, "age", 0x0103)
+		numericrangeindex.AddValue(db, "age", 0x0201)
+
+		numericrangeindex.RemoveValue(db, "age", 0x0102)
+		require.Equal(t, []uint64{0x0103, 0x0201}, collect(db, "age", 0, math.MaxUint64))
+
+		numericrangeindex.RemoveValue(db, "age", 0x0103)
+		numericrangeindex.RemoveValue(db, "age", 0x0201)
+		require.Empty(t, collect(db, "age", 0, math.MaxUint64))
+		require.Empty(t, db.storage)
+	})
+
+	t.Run("iteration can be stopped early", func(t *testing.T) {
+		db := newMockStateAccess()
+		for _, v := range []uint64{1, 2, 3} {
+			numericrangeindex.AddValue(db, "age", v)
+		}
+
+		var seen []uint64
+		for v := range numericrangeindex.IterateRange(db, "age", 0, math.MaxUint64) {
+			seen = append(seen, v)
+			if v == 2 {
+				break
+			}
+		}
+		require.Equal(t, []uint64{1, 2}, seen)
+	})
+}
@@ -0,0 +1,80 @@
+package entity
+
+import (
+	"fmt"
+	"slices"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/golem-base/storageutil/entity/entityexpiration"
+)
+
+// Patch replaces the meta data emd of the entity with patched, updating only the index
+// entries of the annotations that were removed or added and moving the entity to the
+// list of entities to expire at its new expiration block if that has changed.
+// The owner of the entity is kept and the payload is left untouched.
+func Patch(access StateAccess, key common.Hash, emd EntityMetaData, patched EntityMetaData) error {
+	for _, stringAnnotation := range emd.StringAnnotations {
+		if slices.Contains(patched.StringAnnotations, stringAnnotation) {
+			continue
+		}
+
+		err := removeStringAnnotationFromIndexes(access, key, stringAnnotation, true)
+		if err != nil {
+			return err
+		}
+	}
+
+	for _, numericAnnotation := range emd.NumericAnnotations {
+		if slices.Contains(patched.NumericAnnotations, numericAnnotation) {
+			continue
+		}
+
+		err := removeNumericAnnotationFromIndexes(access, key, numericAnnotation, true)
+		if err != nil {
+			return err
+		}
+	}
+
+	for _, stringAnnotation := range patched.StringAnnotations {
+		if slices.Contains(emd.StringAnnotations, stringAnnotation) {
+			continue
+		}
+
+		err := addStringAnnotationToIndexes(access, key, stringAnnotation, true)
+		if err != nil {
+			return err
+		}
+	}
+
+	for _, numericAnnotation := range patched.NumericAnnotations {
+		if slices.Contains(emd.NumericAnnotations, numericAnnotation) {
+			continue
+		}
+
+		err := addNumericAnnotationToIndexes(access, key, numericAnnotation, true)
+		if err != nil {
+			return err
+		}
+	}
+
+	if patched.ExpiresAtBlock != emd.ExpiresAtBlock {
+		err := entityexpiration.RemoveFromEntitiesToExpire(access, emd.ExpiresAtBlock, key)
+		if err != nil {
+			return fmt.Errorf("failed to remove entity from entities to expire: %w", err)
+		}
+
+		err = entityexpiration.AddToEntitiesToExpireAtBlock(access, patched.ExpiresAtBlock, key)
+		if err != nil {
+			return fmt.Errorf("failed to add entity to entities to expire: %w", err)
+		}
+	}
+
+	patched.Owner = emd.Owner
+
+	err := StoreEntityMetaData(access, key, patched)
+	if err != nil {
+		return fmt.Errorf("failed to store entity meta data: %w", err)
+	}
+
+	return nil
+}
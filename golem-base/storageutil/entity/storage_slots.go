@@ -0,0 +1,14 @@
+package entity
+
+import (
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/golem-base/storageutil/stateblob"
+)
+
+// StorageSlots returns the number of storage slots holding the payload and the meta data of the entity.
+func StorageSlots(access StateAccess, key common.Hash) (payloadSlots uint64, metaDataSlots uint64) {
+	payloadSlots = stateblob.StoredSlots(access, crypto.Keccak256Hash(PayloadSalt, key[:]))
+	metaDataSlots = stateblob.StoredSlots(access, crypto.Keccak256Hash(EntityMetaDataSalt, key[:]))
+	return payloadSlots, metaDataSlots
+}
@@ -6,10 +6,8 @@ import (
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/golem-base/storageutil"
 	"github.com/ethereum/go-ethereum/golem-base/storageutil/entity/allentities"
-	"github.com/ethereum/go-ethereum/golem-base/storageutil/entity/annotationindex"
 	"github.com/ethereum/go-ethereum/golem-base/storageutil/entity/entitiesofowner"
 	"github.com/ethereum/go-ethereum/golem-base/storageutil/entity/entityexpiration"
-	"github.com/ethereum/go-ethereum/golem-base/storageutil/keyset"
 )
 
 type StateAccess = storageutil.StateAccess
@@ -21,6 +19,17 @@ func Store(
 	emd EntityMetaData,
 	payload []byte,
 ) error {
+	return store(access, key, sender, emd, payload, true)
+}
+
+func store(
+	access StateAccess,
+	key common.Hash,
+	sender common.Address,
+	emd EntityMetaData,
+	payload []byte,
+	valueIndexes bool,
+) error {
 
 	err := allentities.AddEntity(access, key)
 	if err != nil {
@@ -43,24 +52,16 @@ func Store(
 	}
 
 	for _, stringAnnotation := range emd.StringAnnotations {
-		err = keyset.AddValue(
-			access,
-			annotationindex.StringAnnotationIndexKey(stringAnnotation.Key, stringAnnotation.Value),
-			key,
-		)
+		err = addStringAnnotationToIndexes(access, key, stringAnnotation, valueIndexes)
 		if err != nil {
-			return fmt.Errorf("failed to append to key list: %w", err)
+			return err
 		}
 	}
 
 	for _, numericAnnotation := range emd.NumericAnnotations {
-		err = keyset.AddValue(
-			access,
-			annotationindex.NumericAnnotationIndexKey(numericAnnotation.Key, numericAnnotation.Value),
-			key,
-		)
+		err = addNumericAnnotationToIndexes(access, key, numericAnnotation, valueIndexes)
 		if err != nil {
-			return fmt.Errorf("failed to append to key list: %w", err)
+			return err
 		}
 	}
 
@@ -0,0 +1,139 @@
+// Package stringprefixindex keeps, for every string annotation key, the set of
+// distinct values that are currently in use, organised so that the values starting
+// with a given prefix can be enumerated without scanning all entities. It complements
+// the hash-based annotationindex.StringAnnotationIndexKey sets, which map a single
+// (key, value) pair to the entities carrying it.
+//
+// The values are stored in a 256-ary trie with one level per byte of the value.
+// Every node is a single storage slot holding a 256-bit bitmap of the children that
+// are present, and a separate slot marks the nodes at which a value ends.
+package stringprefixindex
+
+import (
+	"math/bits"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/golem-base/storageutil"
+	"github.com/ethereum/go-ethereum/golem-base/storageutil/entity/annotationindex"
+	"github.com/holiman/uint256"
+)
+
+type StateAccess = storageutil.StateAccess
+
+var StringPrefixIndexNodeSalt = []byte("golemBaseStringPrefixIndexNode")
+var StringPrefixIndexValueSalt = []byte("golemBaseStringPrefixIndexValue")
+
+var present = common.BytesToHash([]byte{1})
+
+func nodeKey(key string, prefix string) common.Hash {
+	return crypto.Keccak256Hash(StringPrefixIndexNodeSalt, []byte(key), annotationindex.AnnotationSeparator, []byte(prefix))
+}
+
+func valueKey(key string, value string) common.Hash {
+	return crypto.Keccak256Hash(StringPrefixIndexValueSalt, []byte(key), annotationindex.AnnotationSeparator, []byte(value))
+}
+
+func getBitmap(db StateAccess, key common.Hash) *uint256.Int {
+	v := db.GetState(storageutil.GolemDBAddress, key)
+	return new(uint256.Int).SetBytes32(v[:])
+}
+
+func hasBit(bitmap *uint256.Int, bit byte) bool {
+	return bitmap[bit/64]&(1<<(bit%64)) != 0
+}
+
+func hasValue(db StateAccess, key string, value string) bool {
+	return db.GetState(storageutil.GolemDBAddress, valueKey(key, value)) != common.Hash{}
+}
+
+// MaxSlotsWritten returns the maximum number of storage slots AddValue writes for the value:
+// the slot marking the value and a node per byte of the value.
+func MaxSlotsWritten(value string) uint64 {
+	return 1 + uint64(len(value))
+}
+
+// AddValue adds the value to the set of values of the string annotation key.
+// Adding a value that is already present does nothing.
+func AddValue(db StateAccess, key string, value string) {
+	if hasValue(db, key, value) {
+		return
+	}
+
+	db.SetState(storageutil.GolemDBAddress, valueKey(key, value), present)
+
+	for i := len(value) - 1; i >= 0; i-- {
+		nk := nodeKey(key, value[:i])
+		bitmap := getBitmap(db, nk)
+		child := value[i]
+
+		if hasBit(bitmap, child) {
+			// the node already had this child, so all of its ancestors are already present
+			return
+		}
+
+		bitmap[child/64] |= 1 << (child % 64)
+		db.SetState(storageutil.GolemDBAddress, nk, bitmap.Bytes32())
+	}
+}
+
+// RemoveValue removes the value from the set of values of the string annotation key.
+// It should be called once no entity carries the (key, value) annotation anymore.
+func RemoveValue(db StateAccess, key string, value string) {
+	if !hasValue(db, key, value) {
+		return
+	}
+
+	db.SetState(storageutil.GolemDBAddress, valueKey(key, value), common.Hash{})
+
+	if !getBitmap(db, nodeKey(key, value)).IsZero() {
+		// longer values starting with this value are still present
+		return
+	}
+
+	for i := len(value) - 1; i >= 0; i-- {
+		nk := nodeKey(key, value[:i])
+		bitmap := getBitmap(db, nk)
+		child := value[i]
+
+		bitmap[child/64] &^= 1 << (child % 64)
+		db.SetState(storageutil.GolemDBAddress, nk, bitmap.Bytes32())
+
+		if !bitmap.IsZero() || hasValue(db, key, value[:i]) {
+			// the node is still needed by other values
+			return
+		}
+	}
+}
+
+// IterateWithPrefix yields the values of the string annotation key that start
+// with the prefix, in ascending byte order.
+func IterateWithPrefix(db StateAccess, key string, prefix string) func(yield func(value string) bool) {
+	return func(yield func(value string) bool) {
+		walk(db, key, prefix, yield)
+	}
+}
+
+// walk visits the value ending at the node identified by prefix and all of its descendants.
+// It returns false if the iteration was stopped by yield.
+func walk(db StateAccess, key string, prefix string, yield func(value string) bool) bool {
+	if hasValue(db, key, prefix) && !yield(prefix) {
+		return false
+	}
+
+	bitmap := getBitmap(db, nodeKey(key, prefix))
+
+	for word := 0; word < len(bitmap); word++ {
+		w := bitmap[word]
+		for w != 0 {
+			child := byte(word*64 + bits.TrailingZeros64(w))
+			w &= w - 1
+
+			if !walk(db, key, prefix+string([]byte{child}), yield) {
+				return false
+			}
+		}
+	}
+
+	return true
+}
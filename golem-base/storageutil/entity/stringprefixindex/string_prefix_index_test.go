@@ -0,0 +1,114 @@
+package stringprefixindex_test
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/golem-base/storageutil/entity/stringprefixindex"
+	"github.com/stretchr/testify/require"
+)
+
+// mockStateAccess implements StateAccess interface for testing
+type mockStateAccess struct {
+	storage map[common.Address]map[common.Hash]common.Hash
+}
+
+func newMockStateAccess() *mockStateAccess {
+	return &mockStateAccess{
+		storage: make(map[common.Address]map[common.Hash]common.Hash),
+	}
+}
+
+func (m *mockStateAccess) GetState(addr common.Address, key common.Hash) common.Hash {
+	return m.storage[addr][key]
+}
+
+func (m *mockStateAccess) SetState(addr common.Address, key common.Hash, value common.Hash) common.Hash {
+	if value == (common.Hash{}) {
+		delete(m.storage[addr], key)
+		if len(m.storage[addr]) == 0 {
+			delete(m.storage, addr)
+		}
+		return value
+	}
+
+	if _, exists := m.storage[addr]; !exists {
+		m.storage[addr] = make(map[common.Hash]common.Hash)
+	}
+	m.storage[addr][key] = value
+	return value
+}
+
+func collect(db *mockStateAccess, key string, prefix string) []string {
+	return slices.Collect(stringprefixindex.IterateWithPrefix(db, key, prefix))
+}
+
+func TestStringPrefixIndex(t *testing.T) {
+	t.Run("values are iterated in ascending order", func(t *testing.T) {
+		db := newMockStateAccess()
+		for _, v := range []string{"invoice-2", "receipt", "", "invoice-10", "invoice", "Invoice"} {
+			stringprefixindex.AddValue(db, "name", v)
+		}
+
+		require.Equal(t,
+			[]string{"", "Invoice", "invoice", "invoice-10", "invoice-2", "receipt"},
+			collect(db, "name", ""),
+		)
+	})
+
+	t.Run("values are filtered by prefix", func(t *testing.T) {
+		db := newMockStateAccess()
+		for _, v := range []string{"invoice-1", "invoice-2", "invoice", "inventory", "receipt"} {
+			stringprefixindex.AddValue(db, "name", v)
+		}
+
+		require.Equal(t, []string{"invoice", "invoice-1", "invoice-2"}, collect(db, "name", "invoice"))
+		require.Equal(t, []string{"invoice-1", "invoice-2"}, collect(db, "name", "invoice-"))
+		require.Equal(t, []string{"inventory", "invoice", "invoice-1", "invoice-2"}, collect(db, "name", "inv"))
+		require.Empty(t, collect(db, "name", "x"))
+	})
+
+	t.Run("adding a value twice does not duplicate it", func(t *testing.T) {
+		db := newMockStateAccess()
+		stringprefixindex.AddValue(db, "name", "abc")
+		stringprefixindex.AddValue(db, "name", "abc")
+
+		require.Equal(t, []string{"abc"}, collect(db, "name", ""))
+	})
+
+	t.Run("annotation keys are independent", func(t *testing.T) {
+		db := newMockStateAccess()
+		stringprefixindex.AddValue(db, "name", "abc")
+		stringprefixindex.AddValue(db, "type", "abd")
+
+		require.Equal(t, []string{"abc"}, collect(db, "name", "ab"))
+		require.Equal(t, []string{"abd"}, collect(db, "type", "ab"))
+	})
+
+	t.Run("removing values clears the storage", func(t *testing.T) {
+		db := newMockStateAccess()
+		for _, v := range []string{"a", "ab", "abc", "b"} {
+			stringprefixindex.AddValue(db, "name", v)
+		}
+
+		stringprefixindex.RemoveValue(db, "name", "ab")
+		require.Equal(t, []string{"a", "abc", "b"}, collect(db, "name", ""))
+
+		stringprefixindex.RemoveValue(db, "name", "abc")
+		require.Equal(t, []string{"a", "b"}, collect(db, "name", ""))
+
+		stringprefixindex.RemoveValue(db, "name", "a")
+		stringprefixindex.RemoveValue(db, "name", "b")
+		require.Empty(t, collect(db, "name", ""))
+		require.Empty(t, db.storage)
+	})
+
+	t.Run("removing a missing value does nothing", func(t *testing.T) {
+		db := newMockStateAccess()
+		stringprefixindex.AddValue(db, "name", "abc")
+		stringprefixindex.RemoveValue(db, "name", "ab")
+
+		require.Equal(t, []string{"abc"}, collect(db, "name", ""))
+	})
+}
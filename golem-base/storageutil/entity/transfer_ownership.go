@@ -0,0 +1,31 @@
+package entity
+
+import (
+	"fmt"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/golem-base/storageutil/entity/entitiesofowner"
+)
+
+// TransferOwnership moves the entity from the list of entities of its current owner
+// to the list of entities of the new owner and stores the updated meta data.
+func TransferOwnership(access StateAccess, key common.Hash, emd EntityMetaData, newOwner common.Address) error {
+	err := entitiesofowner.RemoveEntity(access, emd.Owner, key)
+	if err != nil {
+		return fmt.Errorf("failed to remove entity from owner entities: %w", err)
+	}
+
+	err = entitiesofowner.AddEntity(access, newOwner, key)
+	if err != nil {
+		return fmt.Errorf("failed to add entity to owner entities: %w", err)
+	}
+
+	emd.Owner = newOwner
+
+	err = StoreEntityMetaData(access, key, emd)
+	if err != nil {
+		return fmt.Errorf("failed to store entity meta data: %w", err)
+	}
+
+	return nil
+}
@@ -19,6 +19,10 @@ type StateAccess = storageutil.StateAccess
 var zeroHash = common.HexToHash("0x0000000000000000000000000000000000000000000000000000000000000000")
 var oneUint256 = new(uint256.Int).SetUint64(1)
 
+// AddValueSlots is the maximum number of storage slots AddValue writes:
+// the length of the set, the index of the value and the list entry.
+const AddValueSlots uint64 = 3
+
 // ContainsValue checks if the given value exists in the set identified by setKey.
 // It returns true if the value is present in the set, false otherwise.
 func ContainsValue(db StateAccess, setKey common.Hash, value common.Hash) bool {
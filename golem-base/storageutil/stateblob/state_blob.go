@@ -26,6 +26,30 @@ func SetBlob(db StateAccess, key common.Hash, value []byte) {
 	}
 }
 
+// Slots returns the number of storage slots SetBlob writes for a value of the given size.
+func Slots(size uint64) uint64 {
+	if size <= 31 {
+		return 1
+	}
+	return 1 + (size+31)/32
+}
+
+// StoredSlots returns the number of storage slots of the value stored under the key,
+// the slots DeleteBlob clears. It is 0 if there is no value.
+func StoredSlots(db StateAccess, key common.Hash) uint64 {
+	head := db.GetState(GolemDBAddress, key)
+	if head == emptyHash {
+		return 0
+	}
+
+	if head[31]&0x01 == 0 {
+		return 1
+	}
+
+	length := binary.BigEndian.Uint64(head[24:])
+	return Slots((length - 1) / 2)
+}
+
 func BytesTo32ByteSequence(value []byte) iter.Seq[common.Hash] {
 	return func(yield func(common.Hash) bool) {
 		// For small values that fit in a single hash with length byte
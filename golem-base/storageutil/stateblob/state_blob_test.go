@@ -75,6 +75,24 @@ func (m *mockStateAccess) IsEmpty() bool {
 	return len(m.storage) == 0
 }
 
+func TestStoredSlots(t *testing.T) {
+	for _, size := range []int{0, 1, 31, 32, 33, 64, 1000} {
+		db := newMockStateAccess()
+		key := common.HexToHash("0x1234")
+		value := make([]byte, size)
+		for i := range value {
+			value[i] = 1
+		}
+
+		stateblob.SetBlob(db, key, value)
+
+		require.Equal(t, uint64(len(db.storage[stateblob.GolemDBAddress])), stateblob.StoredSlots(db, key), "size %d", size)
+		if size > 0 {
+			require.Equal(t, stateblob.Slots(uint64(size)), stateblob.StoredSlots(db, key), "size %d", size)
+		}
+	}
+}
+
 func TestGolemDBState(t *testing.T) {
 	t.Run("small payload (≤31 bytes)", func(t *testing.T) {
 		db := newMockStateAccess()
@@ -173,3 +191,10 @@ func TestGolemDBState(t *testing.T) {
 		require.True(t, db.IsEmpty())
 	})
 }
+
+func TestSlots(t *testing.T) {
+	for _, size := range []int{0, 1, 31, 32, 33, 64, 65, 1000} {
+		written := len(slices.Collect(stateblob.BytesTo32ByteSequence(make([]byte, size))))
+		require.Equal(t, uint64(written), stateblob.Slots(uint64(size)), "size %d", size)
+	}
+}
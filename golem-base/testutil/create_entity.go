@@ -20,6 +20,18 @@ func (w *World) CreateEntity(
 	stringAnnotations []entity.StringAnnotation,
 	numericAnnotations []entity.NumericAnnotation,
 ) (*types.Receipt, error) {
+	return w.CreateEntityWithGas(ctx, 5_000_000, ttl, payload, stringAnnotations, numericAnnotations)
+}
+
+// CreateEntityWithGas is like CreateEntity, but with the given gas limit for the transaction.
+func (w *World) CreateEntityWithGas(
+	ctx context.Context,
+	gas uint64,
+	ttl uint64,
+	payload []byte,
+	stringAnnotations []entity.StringAnnotation,
+	numericAnnotations []entity.NumericAnnotation,
+) (*types.Receipt, error) {
 
 	client := w.GethInstance.ETHClient
 
@@ -58,7 +70,7 @@ func (w *World) CreateEntity(
 		Nonce:      nonce,
 		GasTipCap:  big.NewInt(1e9), // 1 Gwei
 		GasFeeCap:  big.NewInt(5e9), // 5 Gwei
-		Gas:        2_800_000,
+		Gas:        gas,
 		To:         &address.GolemBaseStorageProcessorAddress,
 		Value:      big.NewInt(0), // No ETH transfer needed
 		Data:       rlpData,
@@ -95,6 +107,7 @@ func (w *World) CreateEntity(
 	w.LastReceipt = receipt
 
 	w.CreatedEntityKey = receipt.Logs[0].Topics[1]
+	w.CreatedAtBlockHash = receipt.BlockHash
 
 	return receipt, nil
 
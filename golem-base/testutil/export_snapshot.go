@@ -0,0 +1,27 @@
+package testutil
+
+import (
+	"context"
+	"fmt"
+	"os/exec"
+)
+
+// ExportSnapshot runs `geth golembase export-snapshot` on the chain data of the stopped geth instance,
+// writing the snapshot to path.
+func (g *GethInstance) ExportSnapshot(ctx context.Context, path string, args ...string) error {
+	cmd := exec.CommandContext(
+		ctx,
+		g.gethPath,
+		append([]string{
+			"golembase", "export-snapshot",
+			"--datadir", g.DataDir,
+		}, append(args, path)...)...,
+	)
+
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("failed to export the snapshot: %w\n%s", err, out)
+	}
+
+	return nil
+}
@@ -0,0 +1,44 @@
+package testutil
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+)
+
+// Stop stops geth gracefully and waits for it to exit, so that its chain data can be opened by other commands.
+func (g *GethInstance) Stop() error {
+	err := g.Process.Signal(os.Interrupt)
+	if err != nil {
+		return fmt.Errorf("failed to interrupt geth: %w", err)
+	}
+
+	_, err = g.Process.Wait()
+	if err != nil {
+		return fmt.Errorf("failed to wait for geth to stop: %w", err)
+	}
+
+	return nil
+}
+
+// ExportWAL runs `geth golembase export-wal` on the chain data of the stopped geth instance,
+// writing the write-ahead log to walDir.
+func (g *GethInstance) ExportWAL(ctx context.Context, walDir string, args ...string) error {
+	cmd := exec.CommandContext(
+		ctx,
+		g.gethPath,
+		append([]string{
+			"golembase", "export-wal",
+			"--datadir", g.DataDir,
+			"--golembase.writeaheadlog", walDir,
+		}, args...)...,
+	)
+
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("failed to export the write-ahead log: %w\n%s", err, out)
+	}
+
+	return nil
+}
@@ -15,6 +15,7 @@ import (
 	"path/filepath"
 	"regexp"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/ethereum/go-ethereum/common"
@@ -32,7 +33,14 @@ type GethInstance struct {
 	ETHClient   *ethclient.Client
 	RPCClient   *rpc.Client
 	RPCEndpoint string
-	WALDir      string
+	// WSEndpoint is the websocket endpoint, served on the same port as RPCEndpoint
+	WSEndpoint string
+	WALDir     string
+	// SegmentsDir holds the write-ahead log in the binary segment format
+	SegmentsDir string
+	// DataDir holds the chain data of geth
+	DataDir  string
+	gethPath string
 }
 
 type gethProcess struct {
@@ -51,6 +59,8 @@ func startGethInstance(ctx context.Context, gethPath string) (_ *GethInstance, e
 	}
 
 	walDir := filepath.Join(td, "geth-dev-wal")
+	segmentsDir := filepath.Join(td, "geth-dev-wal-segments")
+	dataDir := filepath.Join(td, "datadir")
 
 	geth, err := startGethWithPath(
 		ctx,
@@ -60,9 +70,14 @@ func startGethInstance(ctx context.Context, gethPath string) (_ *GethInstance, e
 		"--http",           // Enable the HTTP-RPC server
 		"--ipcdisable",     // Disable ipc, to avoid concurrency issues (using the same socket path)
 		"--http.port", "0", // Use random port
-		"--http.api", "eth,web3,net,debug,golembase", // Enable necessary APIs
+		"--http.api", "eth,web3,net,debug,golembase,golembaseadmin", // Enable necessary APIs
+		"--ws",           // Enable the WS-RPC server, sharing the port of the HTTP-RPC server
+		"--ws.port", "0", // Use the same random port as the HTTP-RPC server
+		"--ws.api", "eth,net,golembase,golembaseadmin",
 		"--verbosity", "3", // Increase logging to see HTTP endpoint
 		"--golembase.writeaheadlog", walDir,
+		"--golembase.writeaheadlog.segments", segmentsDir,
+		"--datadir", dataDir, // Keep the chain data, so that it can be read after geth was stopped
 	)
 	if err != nil {
 		return nil, fmt.Errorf("failed to start geth: %w", err)
@@ -117,8 +132,12 @@ func startGethInstance(ctx context.Context, gethPath string) (_ *GethInstance, e
 		ETHClient:   client,
 		RPCClient:   rpcClient,
 		RPCEndpoint: endpoint,
+		WSEndpoint:  "ws" + strings.TrimPrefix(endpoint, "http"),
 		shutdown:    cleanup,
 		WALDir:      walDir,
+		SegmentsDir: segmentsDir,
+		DataDir:     dataDir,
+		gethPath:    gethPath,
 	}
 
 	return gi, nil
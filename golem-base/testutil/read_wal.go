@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"math/big"
 
+	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/golem-base/wal"
 )
 
@@ -16,7 +17,13 @@ func (w *World) ReadWAL(ctx context.Context) ([]wal.Operation, error) {
 		return nil, fmt.Errorf("failed to get genesis block header: %w", err)
 	}
 
-	iter := wal.NewIterator(ctx, w.GethInstance.WALDir, 1, genesisHeader.Hash(), false)
+	return ReadWALDir(ctx, w.GethInstance.WALDir, genesisHeader.Hash())
+}
+
+// ReadWALDir reads all operations of the write-ahead log in walDir, starting with block 1.
+func ReadWALDir(ctx context.Context, walDir string, genesisHash common.Hash) ([]wal.Operation, error) {
+
+	iter := wal.NewIterator(ctx, walDir, 1, genesisHash, false)
 
 	ops := []wal.Operation{}
 
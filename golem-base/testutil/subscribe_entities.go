@@ -0,0 +1,36 @@
+package testutil
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/ethereum/go-ethereum/golem-base/golemtype"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+// SubscribeToEntities subscribes to the entity events selected by the filter over the websocket
+// endpoint of the node, the subscription ends with the context.
+func (w *World) SubscribeToEntities(ctx context.Context, filter *golemtype.EntityFilter) error {
+	client, err := rpc.DialContext(ctx, w.GethInstance.WSEndpoint)
+	if err != nil {
+		return fmt.Errorf("failed to dial websocket endpoint: %w", err)
+	}
+
+	received := make(chan golemtype.EntityEvent, 100)
+
+	sub, err := client.Subscribe(ctx, "golembase", received, "entities", filter)
+	if err != nil {
+		client.Close()
+		return fmt.Errorf("failed to subscribe to entities: %w", err)
+	}
+
+	go func() {
+		<-ctx.Done()
+		sub.Unsubscribe()
+		client.Close()
+	}()
+
+	w.ReceivedEntityEvents = received
+
+	return nil
+}
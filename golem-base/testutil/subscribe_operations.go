@@ -0,0 +1,38 @@
+package testutil
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/golem-base/wal"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+// SubscribeToOperations subscribes to the operations of the write-ahead log over the websocket
+// endpoint, starting with the given block. The received blocks are stored in ReceivedOperations
+// until the context is done.
+func (w *World) SubscribeToOperations(ctx context.Context, fromBlock uint64) error {
+	client, err := rpc.DialContext(ctx, w.GethInstance.WSEndpoint)
+	if err != nil {
+		return fmt.Errorf("failed to dial websocket endpoint: %w", err)
+	}
+
+	received := make(chan wal.BlockOperations, 100)
+
+	sub, err := client.Subscribe(ctx, "golembase", received, "operations", hexutil.Uint64(fromBlock))
+	if err != nil {
+		client.Close()
+		return fmt.Errorf("failed to subscribe to operations: %w", err)
+	}
+
+	go func() {
+		<-ctx.Done()
+		sub.Unsubscribe()
+		client.Close()
+	}()
+
+	w.ReceivedOperations = received
+
+	return nil
+}
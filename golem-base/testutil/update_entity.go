@@ -61,7 +61,7 @@ func (w *World) UpdateEntity(
 		Nonce:      nonce,
 		GasTipCap:  big.NewInt(1e9), // 1 Gwei
 		GasFeeCap:  big.NewInt(5e9), // 5 Gwei
-		Gas:        100_000,
+		Gas:        1_000_000,
 		To:         &address.GolemBaseStorageProcessorAddress,
 		Value:      big.NewInt(0), // No ETH transfer needed
 		Data:       rlpData,
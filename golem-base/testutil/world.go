@@ -6,17 +6,47 @@ import (
 
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/golem-base/client"
 	"github.com/ethereum/go-ethereum/golem-base/golemtype"
+	"github.com/ethereum/go-ethereum/golem-base/wal"
 )
 
 // World is the test world - it holds all the state that is shared between steps
 type World struct {
 	GethInstance     *GethInstance
 	FundedAccount    *FundedAccount
+	OtherAccount     *FundedAccount
 	LastReceipt      *types.Receipt
-	SearchResult     []golemtype.SearchResult
+	SearchResult     []golemtype.SearchResultWithMetaData
 	CreatedEntityKey common.Hash
+	// CreatedAtBlockHash is the hash of the block in which CreatedEntityKey was created
+	CreatedAtBlockHash common.Hash
+	// LastQuery, LastQueryOptions and LastQueryCursor allow fetching the next page of the last query
+	LastQuery        string
+	LastQueryOptions golemtype.QueryOptions
+	LastQueryCursor  string
 	LastError        error
+	// ReceivedOperations receives the blocks of the operations subscription
+	ReceivedOperations <-chan wal.BlockOperations
+	// ReceivedEntityEvents receives the events of the entities subscription
+	ReceivedEntityEvents <-chan golemtype.EntityEvent
+	// ExportedWALDir is the directory the write-ahead log was exported to from the chain data
+	ExportedWALDir string
+	// SnapshotFile is the file the snapshot was exported to from the chain data
+	SnapshotFile string
+	// ClientReceipt is the receipt of the last batch submitted with the Go client
+	ClientReceipt *client.Receipt
+	// ClientEntityKeys are the keys of the entities created with the Go client
+	ClientEntityKeys []common.Hash
+	// ClientPackedReceipts are the receipts of the batches packed by the Go client
+	ClientPackedReceipts []*client.Receipt
+}
+
+// Client returns a Go client of the node, signing with the funded account.
+func (w *World) Client() *client.Client {
+	c := client.NewClient(w.GethInstance.RPCClient)
+	c.SetPrivateKey(w.FundedAccount.PrivateKey)
+	return c
 }
 
 func NewWorld(ctx context.Context, gethPath string) (*World, error) {
@@ -37,6 +67,34 @@ func NewWorld(ctx context.Context, gethPath string) (*World, error) {
 
 }
 
+// CreateOtherAccount creates and funds a second account that can be used
+// to submit transactions on behalf of someone else than the FundedAccount.
+func (w *World) CreateOtherAccount(ctx context.Context) error {
+	acc, err := w.GethInstance.createAccountAndTransferFunds(ctx, EthToWei(100))
+	if err != nil {
+		return fmt.Errorf("failed to create account and transfer funds: %w", err)
+	}
+
+	w.OtherAccount = acc
+
+	return nil
+}
+
+// AsOtherAccount runs fn with the OtherAccount used as the sender of transactions.
+func (w *World) AsOtherAccount(fn func() error) error {
+	if w.OtherAccount == nil {
+		return fmt.Errorf("other account has not been created")
+	}
+
+	fundedAccount := w.FundedAccount
+	w.FundedAccount = w.OtherAccount
+	defer func() {
+		w.FundedAccount = fundedAccount
+	}()
+
+	return fn()
+}
+
 func (w *World) Shutdown() {
 	w.GethInstance.shutdown()
 }
@@ -0,0 +1,178 @@
+package wal
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// firstJSONBlock returns the block info of the block file with the lowest number in the directory.
+func firstJSONBlock(jsonDir string) (BlockInfo, bool, error) {
+	entries, err := os.ReadDir(jsonDir)
+	if err != nil {
+		return BlockInfo{}, false, fmt.Errorf("failed to read wal dir: %w", err)
+	}
+
+	// the entries are sorted by filename, and the block numbers are zero padded
+	for _, e := range entries {
+		_, err := PathToBlockNumber(e.Name())
+		if err != nil {
+			continue
+		}
+
+		bi, err := readBlockInfo(filepath.Join(jsonDir, e.Name()))
+		if err != nil {
+			return BlockInfo{}, false, err
+		}
+
+		return bi, true, nil
+	}
+
+	return BlockInfo{}, false, nil
+}
+
+// ConvertJSONToSegments appends the blocks of the JSON WAL in jsonDir to the segments in segmentDir.
+// It continues after the last block of the segments, so it can be run again to convert new blocks,
+// or starts with the first block of the JSON WAL. It returns the number of converted blocks.
+func ConvertJSONToSegments(ctx context.Context, jsonDir, segmentDir string, maxSegmentSize int64) (converted int, err error) {
+
+	m, err := OpenSegmentMirror(jsonDir, segmentDir, maxSegmentSize)
+	if err != nil {
+		return 0, err
+	}
+	defer func() {
+		err = errors.Join(err, m.Close())
+	}()
+
+	return m.CatchUp(ctx)
+}
+
+// SegmentMirror appends the blocks written to a JSON WAL to the segments of another directory,
+// so that the node writing the JSON WAL writes the segments as well.
+type SegmentMirror struct {
+	jsonDir string
+	w       *SegmentWriter
+
+	// started is set once the next block to append is known
+	started         bool
+	nextBlockNumber uint64
+	prevBlockHash   common.Hash
+}
+
+// OpenSegmentMirror opens the segments in segmentDir to append the blocks of the JSON WAL in jsonDir.
+// The blocks are appended after the last block of the segments, or from the first block of the JSON WAL.
+func OpenSegmentMirror(jsonDir, segmentDir string, maxSegmentSize int64) (*SegmentMirror, error) {
+	w, err := OpenSegmentWriter(segmentDir, maxSegmentSize)
+	if err != nil {
+		return nil, err
+	}
+
+	m := &SegmentMirror{
+		jsonDir: jsonDir,
+		w:       w,
+	}
+
+	number, hash, ok, err := SegmentHead(segmentDir)
+	if err != nil {
+		return nil, errors.Join(err, w.Close())
+	}
+
+	if ok {
+		m.started = true
+		m.nextBlockNumber = number + 1
+		m.prevBlockHash = hash
+	}
+
+	return m, nil
+}
+
+// CatchUp appends the blocks and reverts written to the JSON WAL since the last call.
+// It returns the number of appended blocks.
+func (m *SegmentMirror) CatchUp(ctx context.Context) (int, error) {
+	if !m.started {
+		first, ok, err := firstJSONBlock(m.jsonDir)
+		if err != nil || !ok {
+			return 0, err
+		}
+
+		m.started = true
+		m.nextBlockNumber = first.Number
+		m.prevBlockHash = first.ParentHash
+	}
+
+	appended := 0
+
+	for bw, err := range NewIterator(ctx, m.jsonDir, m.nextBlockNumber, m.prevBlockHash, false) {
+		if err != nil {
+			return appended, fmt.Errorf("failed to read wal: %w", err)
+		}
+
+		bo, err := ReadBlockOperations(bw)
+		if err != nil {
+			return appended, err
+		}
+
+		err = m.w.WriteBlock(bo)
+		if err != nil {
+			return appended, err
+		}
+
+		processedNumber, processedHash := bw.ProcessedBlock()
+		m.nextBlockNumber = processedNumber + 1
+		m.prevBlockHash = processedHash
+
+		appended++
+	}
+
+	return appended, ctx.Err()
+}
+
+// Close syncs and closes the segments.
+func (m *SegmentMirror) Close() error {
+	return m.w.Close()
+}
+
+// ConvertSegmentsToJSON writes the blocks of the segments in segmentDir as files of the JSON WAL into jsonDir,
+// in the order they were written. A revert replaces the file of the abandoned block with its revert file,
+// like WriteLogForBlock does. It returns the number of converted blocks.
+func ConvertSegmentsToJSON(ctx context.Context, segmentDir, jsonDir string) (int, error) {
+	err := os.MkdirAll(jsonDir, 0755)
+	if err != nil {
+		return 0, fmt.Errorf("failed to create wal dir: %w", err)
+	}
+
+	converted := 0
+
+	for bo, err := range iterateSegments(ctx, segmentDir) {
+		if err != nil {
+			return converted, err
+		}
+
+		bi := bo.BlockInfo
+
+		if bo.Revert {
+			err = writeBlockFile(jsonDir, RevertFilename(bi.Number, bi.Hash), bi, bo.Operations)
+			if err != nil {
+				return converted, err
+			}
+
+			err = os.Remove(filepath.Join(jsonDir, BlockNumberToFilename(bi.Number)))
+			if err != nil && !errors.Is(err, os.ErrNotExist) {
+				return converted, fmt.Errorf("failed to remove reverted block %d: %w", bi.Number, err)
+			}
+		} else {
+			err = writeBlockFile(jsonDir, BlockNumberToFilename(bi.Number), bi, bo.Operations)
+			if err != nil {
+				return converted, err
+			}
+		}
+
+		converted++
+	}
+
+	return converted, ctx.Err()
+}
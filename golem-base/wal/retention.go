@@ -0,0 +1,378 @@
+package wal
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/log"
+)
+
+// PruneInterval is the number of blocks between two prunings of the WAL directory.
+const PruneInterval = 100
+
+// PrunedFilename is the name of the file recording the highest pruned block of the WAL directory.
+const PrunedFilename = "pruned.json"
+
+// CheckpointsDir is the directory in the WAL directory holding the checkpoints of the registered consumers.
+const CheckpointsDir = "checkpoints"
+
+// MaxConsumers is the maximum number of consumers registered in a WAL directory.
+// Every registered consumer holds back pruning, so their number is limited.
+const MaxConsumers = 32
+
+var (
+	ErrBlockPruned         = errors.New("block was pruned from the WAL")
+	ErrInvalidConsumerName = errors.New("invalid consumer name")
+	ErrTooManyConsumers    = errors.New("too many consumers registered")
+)
+
+var consumerNameRe = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9_.-]*$`)
+
+// RetentionPolicy decides which blocks are kept in the WAL directory.
+type RetentionPolicy struct {
+	// RetainBlocks is the number of most recent blocks that are kept, 0 keeps all blocks.
+	RetainBlocks uint64
+
+	// PruneAcknowledged removes the blocks acknowledged by all registered consumers, while still keeping
+	// the RetainBlocks most recent blocks. Nothing is removed as long as no consumer is registered.
+	PruneAcknowledged bool
+}
+
+// Enabled reports whether the policy removes any blocks.
+func (p RetentionPolicy) Enabled() bool {
+	return p.RetainBlocks > 0 || p.PruneAcknowledged
+}
+
+// Checkpoint is the last block a consumer of the WAL has processed.
+// Consumers register by writing their first checkpoint.
+type Checkpoint struct {
+	BlockNumber uint64      `json:"blockNumber,string"`
+	BlockHash   common.Hash `json:"blockHash"`
+}
+
+func checkpointPath(walDir, consumer string) (string, error) {
+	if !consumerNameRe.MatchString(consumer) {
+		return "", fmt.Errorf("%w: %q", ErrInvalidConsumerName, consumer)
+	}
+	return filepath.Join(walDir, CheckpointsDir, consumer+".json"), nil
+}
+
+// WriteCheckpoint records that the consumer has processed all blocks up to the checkpoint,
+// registering the consumer if it has no checkpoint yet. No more than MaxConsumers consumers
+// can be registered.
+func WriteCheckpoint(walDir, consumer string, cp Checkpoint) error {
+	path, err := checkpointPath(walDir, consumer)
+	if err != nil {
+		return err
+	}
+
+	_, err = os.Stat(path)
+	if errors.Is(err, os.ErrNotExist) {
+		checkpoints, err := ReadCheckpoints(walDir)
+		if err != nil {
+			return err
+		}
+		if len(checkpoints) >= MaxConsumers {
+			return fmt.Errorf("%w: at most %d consumers can be registered", ErrTooManyConsumers, MaxConsumers)
+		}
+	}
+
+	err = os.MkdirAll(filepath.Dir(path), 0755)
+	if err != nil {
+		return fmt.Errorf("failed to create checkpoints dir: %w", err)
+	}
+
+	data, err := json.Marshal(cp)
+	if err != nil {
+		return fmt.Errorf("failed to encode checkpoint: %w", err)
+	}
+
+	err = os.WriteFile(path+".temp", data, 0644)
+	if err != nil {
+		return fmt.Errorf("failed to write checkpoint: %w", err)
+	}
+
+	err = os.Rename(path+".temp", path)
+	if err != nil {
+		return fmt.Errorf("failed to rename checkpoint: %w", err)
+	}
+
+	return nil
+}
+
+// RemoveCheckpoint unregisters the consumer, so that it no longer holds back pruning.
+func RemoveCheckpoint(walDir, consumer string) error {
+	path, err := checkpointPath(walDir, consumer)
+	if err != nil {
+		return err
+	}
+
+	err = os.Remove(path)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("failed to remove checkpoint: %w", err)
+	}
+
+	return nil
+}
+
+// ReadCheckpoints returns the checkpoints of all registered consumers.
+func ReadCheckpoints(walDir string) (map[string]Checkpoint, error) {
+	checkpoints := map[string]Checkpoint{}
+
+	entries, err := os.ReadDir(filepath.Join(walDir, CheckpointsDir))
+	if errors.Is(err, os.ErrNotExist) {
+		return checkpoints, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read checkpoints dir: %w", err)
+	}
+
+	for _, e := range entries {
+		consumer, ok := strings.CutSuffix(e.Name(), ".json")
+		if !ok || e.IsDir() {
+			continue
+		}
+
+		data, err := os.ReadFile(filepath.Join(walDir, CheckpointsDir, e.Name()))
+		if err != nil {
+			return nil, fmt.Errorf("failed to read checkpoint of %s: %w", consumer, err)
+		}
+
+		cp := Checkpoint{}
+		err = json.Unmarshal(data, &cp)
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode checkpoint of %s: %w", consumer, err)
+		}
+
+		checkpoints[consumer] = cp
+	}
+
+	return checkpoints, nil
+}
+
+// pruneLimit returns the highest block number whose files can be removed.
+func pruneLimit(walDir string, headBlockNumber uint64, policy RetentionPolicy) (uint64, bool, error) {
+	if policy.RetainBlocks > 0 && headBlockNumber <= policy.RetainBlocks {
+		return 0, false, nil
+	}
+
+	limit := headBlockNumber - policy.RetainBlocks
+
+	if !policy.PruneAcknowledged {
+		return limit, policy.RetainBlocks > 0, nil
+	}
+
+	checkpoints, err := ReadCheckpoints(walDir)
+	if err != nil {
+		return 0, false, err
+	}
+
+	if len(checkpoints) == 0 {
+		return 0, false, nil
+	}
+
+	for _, cp := range checkpoints {
+		// the acknowledged block itself is kept, its undo operations
+		// are needed if it is abandoned by a reorg
+		if cp.BlockNumber == 0 {
+			return 0, false, nil
+		}
+		limit = min(limit, cp.BlockNumber-1)
+	}
+
+	return limit, true, nil
+}
+
+var prunableRe = regexp.MustCompile(`^(?:block|revert)-(\d{20})[.-]`)
+
+// Prune removes the block, undo and revert files of the blocks that are no longer retained
+// by the policy, given the number of the head block. It returns the number of removed files.
+func Prune(walDir string, headBlockNumber uint64, policy RetentionPolicy) (int, error) {
+	limit, ok, err := pruneLimit(walDir, headBlockNumber, policy)
+	if err != nil || !ok {
+		return 0, err
+	}
+
+	prunedUpTo, _, err := readPruned(walDir)
+	if err != nil {
+		return 0, err
+	}
+
+	if limit <= prunedUpTo {
+		return 0, nil
+	}
+
+	// the marker is written first, so that readers of a removed block never wait for it
+	err = writePruned(walDir, limit)
+	if err != nil {
+		return 0, err
+	}
+
+	entries, err := os.ReadDir(walDir)
+	if err != nil {
+		return 0, fmt.Errorf("failed to read wal dir: %w", err)
+	}
+
+	removed := 0
+
+	for _, e := range entries {
+		matches := prunableRe.FindStringSubmatch(e.Name())
+		if matches == nil {
+			continue
+		}
+
+		number, err := strconv.ParseUint(matches[1], 10, 64)
+		if err != nil || number > limit {
+			continue
+		}
+
+		err = os.Remove(filepath.Join(walDir, e.Name()))
+		if err != nil && !errors.Is(err, os.ErrNotExist) {
+			return removed, fmt.Errorf("failed to remove %s: %w", e.Name(), err)
+		}
+
+		removed++
+	}
+
+	log.Info("pruned the WAL", "upTo", limit, "removedFiles", removed)
+
+	return removed, nil
+}
+
+// Pruner prunes the WAL directory in the background after every PruneInterval blocks, so that
+// writing a block, which happens while the chain is locked, never waits for the files to be removed.
+// Pruning does not fail the block, errors are only logged.
+type Pruner struct {
+	walDir string
+	policy RetentionPolicy
+	heads  chan uint64
+	done   chan struct{}
+}
+
+// NewPruner starts pruning the WAL directory with the policy. It has to be closed with Close.
+func NewPruner(walDir string, policy RetentionPolicy) *Pruner {
+	p := &Pruner{
+		walDir: walDir,
+		policy: policy,
+		heads:  make(chan uint64, 1),
+		done:   make(chan struct{}),
+	}
+
+	go p.loop()
+
+	return p
+}
+
+func (p *Pruner) loop() {
+	defer close(p.done)
+
+	for head := range p.heads {
+		_, err := Prune(p.walDir, head, p.policy)
+		if err != nil {
+			log.Warn("failed to prune the WAL", "block", head, "error", err)
+		}
+	}
+}
+
+// AfterBlock schedules pruning once the block was written, if it is due. If the previous pruning
+// is still running, the block is skipped, the next one prunes its files as well.
+func (p *Pruner) AfterBlock(blockNumber uint64) {
+	if !p.policy.Enabled() || blockNumber%PruneInterval != 0 {
+		return
+	}
+
+	select {
+	case p.heads <- blockNumber:
+	default:
+	}
+}
+
+// Close waits for the running pruning to finish and stops the pruner.
+func (p *Pruner) Close() {
+	close(p.heads)
+	<-p.done
+}
+
+type prunedMarker struct {
+	BlockNumber uint64 `json:"blockNumber,string"`
+}
+
+// readPruned returns the highest pruned block number of the WAL directory.
+func readPruned(walDir string) (uint64, bool, error) {
+	data, err := os.ReadFile(filepath.Join(walDir, PrunedFilename))
+	if errors.Is(err, os.ErrNotExist) {
+		return 0, false, nil
+	}
+	if err != nil {
+		return 0, false, fmt.Errorf("failed to read pruned marker: %w", err)
+	}
+
+	m := prunedMarker{}
+	err = json.Unmarshal(data, &m)
+	if err != nil {
+		return 0, false, fmt.Errorf("failed to decode pruned marker: %w", err)
+	}
+
+	return m.BlockNumber, true, nil
+}
+
+func writePruned(walDir string, blockNumber uint64) error {
+	data, err := json.Marshal(prunedMarker{BlockNumber: blockNumber})
+	if err != nil {
+		return fmt.Errorf("failed to encode pruned marker: %w", err)
+	}
+
+	path := filepath.Join(walDir, PrunedFilename)
+
+	err = os.WriteFile(path+".temp", data, 0644)
+	if err != nil {
+		return fmt.Errorf("failed to write pruned marker: %w", err)
+	}
+
+	err = os.Rename(path+".temp", path)
+	if err != nil {
+		return fmt.Errorf("failed to rename pruned marker: %w", err)
+	}
+
+	return nil
+}
+
+// UnmarkPruned records that the blocks from blockNumber on are available again,
+// after they were written to a pruned WAL directory once more.
+func UnmarkPruned(walDir string, blockNumber uint64) error {
+	prunedUpTo, ok, err := readPruned(walDir)
+	if err != nil || !ok || prunedUpTo < blockNumber {
+		return err
+	}
+
+	if blockNumber <= 1 {
+		err = os.Remove(filepath.Join(walDir, PrunedFilename))
+		if err != nil && !errors.Is(err, os.ErrNotExist) {
+			return fmt.Errorf("failed to remove pruned marker: %w", err)
+		}
+		return nil
+	}
+
+	return writePruned(walDir, blockNumber-1)
+}
+
+// CheckPruned returns ErrBlockPruned if the block was removed from the WAL directory.
+func CheckPruned(walDir string, blockNumber uint64) error {
+	prunedUpTo, ok, err := readPruned(walDir)
+	if err != nil {
+		return err
+	}
+
+	if ok && blockNumber <= prunedUpTo {
+		return fmt.Errorf("%w: block %d, the WAL starts after block %d", ErrBlockPruned, blockNumber, prunedUpTo)
+	}
+
+	return nil
+}
@@ -0,0 +1,174 @@
+package wal_test
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/golem-base/wal"
+	"github.com/stretchr/testify/require"
+)
+
+func blockHash(number uint64) common.Hash {
+	return common.BigToHash(new(big.Int).SetUint64(number + 1))
+}
+
+// writeBlocks writes the block and undo files of the blocks 1 to head.
+func writeBlocks(t *testing.T, dir string, head uint64) {
+	for number := uint64(1); number <= head; number++ {
+		bi := wal.BlockInfo{
+			Number:     number,
+			Hash:       blockHash(number),
+			ParentHash: blockHash(number - 1),
+		}
+
+		require.NoError(t, writeWal(dir, bi, []wal.Operation{}))
+		require.NoError(t, os.WriteFile(filepath.Join(dir, wal.UndoFilename(number)), []byte("{}\n"), 0644))
+	}
+}
+
+func remainingBlocks(t *testing.T, dir string) []uint64 {
+	entries, err := os.ReadDir(dir)
+	require.NoError(t, err)
+
+	blocks := []uint64{}
+	for _, e := range entries {
+		number, err := wal.PathToBlockNumber(e.Name())
+		if err != nil {
+			continue
+		}
+		blocks = append(blocks, number)
+	}
+
+	return blocks
+}
+
+func TestPrune(t *testing.T) {
+
+	t.Run("should keep the most recent blocks", func(t *testing.T) {
+		td := t.TempDir()
+
+		writeBlocks(t, td, 10)
+		require.NoError(t, os.WriteFile(filepath.Join(td, wal.RevertFilename(4, common.HexToHash("0x4"))), []byte("{}\n"), 0644))
+
+		removed, err := wal.Prune(td, 10, wal.RetentionPolicy{RetainBlocks: 3})
+		require.NoError(t, err)
+		require.Equal(t, 7*2+1, removed)
+
+		require.Equal(t, []uint64{8, 9, 10}, remainingBlocks(t, td))
+
+		_, err = os.Stat(filepath.Join(td, wal.UndoFilename(7)))
+		require.ErrorIs(t, err, os.ErrNotExist)
+
+		t.Run("and fail to iterate from a pruned block", func(t *testing.T) {
+			var iterErr error
+			for _, err := range wal.NewIterator(context.Background(), td, 1, blockHash(0), true) {
+				iterErr = err
+			}
+			require.ErrorIs(t, iterErr, wal.ErrBlockPruned)
+		})
+
+		t.Run("and iterate from the first retained block", func(t *testing.T) {
+			blocks := 0
+			for _, err := range wal.NewIterator(context.Background(), td, 8, blockHash(7), false) {
+				require.NoError(t, err)
+				blocks++
+			}
+			require.Equal(t, 3, blocks)
+		})
+	})
+
+	t.Run("should keep all blocks without a policy", func(t *testing.T) {
+		td := t.TempDir()
+
+		writeBlocks(t, td, 5)
+
+		removed, err := wal.Prune(td, 5, wal.RetentionPolicy{})
+		require.NoError(t, err)
+		require.Equal(t, 0, removed)
+		require.Len(t, remainingBlocks(t, td), 5)
+	})
+
+	t.Run("should not prune acknowledged blocks without consumers", func(t *testing.T) {
+		td := t.TempDir()
+
+		writeBlocks(t, td, 5)
+
+		removed, err := wal.Prune(td, 5, wal.RetentionPolicy{PruneAcknowledged: true})
+		require.NoError(t, err)
+		require.Equal(t, 0, removed)
+	})
+
+	t.Run("should keep the blocks not acknowledged by all consumers", func(t *testing.T) {
+		td := t.TempDir()
+
+		writeBlocks(t, td, 10)
+
+		require.NoError(t, wal.WriteCheckpoint(td, "fast", wal.Checkpoint{BlockNumber: 9, BlockHash: blockHash(9)}))
+		require.NoError(t, wal.WriteCheckpoint(td, "slow", wal.Checkpoint{BlockNumber: 6, BlockHash: blockHash(6)}))
+
+		_, err := wal.Prune(td, 10, wal.RetentionPolicy{PruneAcknowledged: true})
+		require.NoError(t, err)
+
+		// the acknowledged block is kept for reorgs
+		require.Equal(t, []uint64{6, 7, 8, 9, 10}, remainingBlocks(t, td))
+
+		t.Run("and prune them once they are acknowledged", func(t *testing.T) {
+			require.NoError(t, wal.WriteCheckpoint(td, "slow", wal.Checkpoint{BlockNumber: 9, BlockHash: blockHash(9)}))
+
+			_, err := wal.Prune(td, 10, wal.RetentionPolicy{PruneAcknowledged: true, RetainBlocks: 3})
+			require.NoError(t, err)
+
+			require.Equal(t, []uint64{8, 9, 10}, remainingBlocks(t, td))
+		})
+
+		t.Run("and ignore removed consumers", func(t *testing.T) {
+			require.NoError(t, wal.RemoveCheckpoint(td, "slow"))
+
+			checkpoints, err := wal.ReadCheckpoints(td)
+			require.NoError(t, err)
+			require.Equal(t, map[string]wal.Checkpoint{
+				"fast": {BlockNumber: 9, BlockHash: blockHash(9)},
+			}, checkpoints)
+		})
+	})
+
+	t.Run("should reject invalid consumer names", func(t *testing.T) {
+		td := t.TempDir()
+
+		err := wal.WriteCheckpoint(td, "../etl", wal.Checkpoint{BlockNumber: 1})
+		require.ErrorIs(t, err, wal.ErrInvalidConsumerName)
+	})
+
+	t.Run("should reject consumers beyond the maximum", func(t *testing.T) {
+		td := t.TempDir()
+
+		for i := range wal.MaxConsumers {
+			require.NoError(t, wal.WriteCheckpoint(td, fmt.Sprintf("etl-%d", i), wal.Checkpoint{BlockNumber: 1}))
+		}
+
+		err := wal.WriteCheckpoint(td, "one-too-many", wal.Checkpoint{BlockNumber: 1})
+		require.ErrorIs(t, err, wal.ErrTooManyConsumers)
+
+		// registered consumers can still acknowledge blocks
+		require.NoError(t, wal.WriteCheckpoint(td, "etl-0", wal.Checkpoint{BlockNumber: 2}))
+	})
+}
+
+func TestPruner(t *testing.T) {
+	td := t.TempDir()
+
+	writeBlocks(t, td, 2*wal.PruneInterval+1)
+
+	p := wal.NewPruner(td, wal.RetentionPolicy{RetainBlocks: 3})
+	p.AfterBlock(2*wal.PruneInterval + 1)
+	p.AfterBlock(2 * wal.PruneInterval)
+	p.Close()
+
+	blocks := remainingBlocks(t, td)
+	require.Equal(t, uint64(2*wal.PruneInterval-2), blocks[0])
+}
@@ -0,0 +1,104 @@
+package wal
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+// BlockOperations holds the operations of one block of the WAL.
+// It is sent by the golembase_subscribe("operations") subscription.
+type BlockOperations struct {
+	BlockInfo  BlockInfo   `json:"blockInfo"`
+	Revert     bool        `json:"revert,omitempty"`
+	Operations []Operation `json:"operations"`
+}
+
+// ReadBlockOperations reads all operations of the block.
+func ReadBlockOperations(bw BlockWal) (BlockOperations, error) {
+	bo := BlockOperations{
+		BlockInfo:  bw.BlockInfo,
+		Revert:     bw.Revert,
+		Operations: []Operation{},
+	}
+
+	for op, err := range bw.OperationsIterator {
+		if err != nil {
+			return BlockOperations{}, fmt.Errorf("failed to read operations of block %d: %w", bw.BlockInfo.Number, err)
+		}
+		bo.Operations = append(bo.Operations, op)
+	}
+
+	return bo, nil
+}
+
+// BlockWal returns the block with an iterator over its operations.
+func (bo BlockOperations) BlockWal() BlockWal {
+	return BlockWal{
+		BlockInfo: bo.BlockInfo,
+		Revert:    bo.Revert,
+		OperationsIterator: func(yield func(operation Operation, err error) bool) {
+			for _, op := range bo.Operations {
+				if !yield(op, nil) {
+					return
+				}
+			}
+		},
+	}
+}
+
+// NewRPCIterator is like NewIterator with waitForNewBlocks, but receives the blocks
+// from the golembase_subscribe("operations") subscription of a node writing the WAL,
+// instead of reading them from a shared WAL directory. The client must support subscriptions.
+func NewRPCIterator(
+	ctx context.Context,
+	client *rpc.Client,
+	nextBlockNumber uint64,
+	prevBlockHash common.Hash,
+) func(yield func(blockWal BlockWal, err error) bool) {
+
+	return func(yield func(blockWal BlockWal, err error) bool) {
+
+		ctx, cancel := context.WithCancel(ctx)
+		defer cancel()
+
+		blocks := make(chan BlockOperations, 16)
+
+		sub, err := client.Subscribe(ctx, "golembase", blocks, "operations", hexutil.Uint64(nextBlockNumber), prevBlockHash)
+		if err != nil {
+			yield(BlockWal{}, fmt.Errorf("failed to subscribe to operations: %w", err))
+			return
+		}
+		defer sub.Unsubscribe()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case err := <-sub.Err():
+				if err == nil {
+					err = fmt.Errorf("subscription closed")
+				}
+				yield(BlockWal{}, fmt.Errorf("operations subscription failed: %w", err))
+				return
+			case bo := <-blocks:
+				if !yield(bo.BlockWal(), nil) {
+					return
+				}
+			}
+		}
+	}
+}
+
+// AcknowledgeOperations records the checkpoint of the consumer on the node writing the WAL with
+// golembaseadmin_acknowledgeOperations, like WriteCheckpoint does for consumers reading the WAL directory.
+func AcknowledgeOperations(ctx context.Context, client *rpc.Client, consumer string, cp Checkpoint) error {
+	err := client.CallContext(ctx, nil, "golembaseadmin_acknowledgeOperations", consumer, hexutil.Uint64(cp.BlockNumber), cp.BlockHash)
+	if err != nil {
+		return fmt.Errorf("failed to acknowledge operations: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,439 @@
+package wal
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strconv"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/rlp"
+)
+
+// The segment format is a compact alternative to the JSON files of the WAL.
+// A segment file holds many consecutive blocks and starts with the segment magic and version.
+// Every block is a block record, followed by one record per operation. A record is
+//
+//	kind (1 byte) | length of the payload (4 bytes) | CRC32-C of kind and payload (4 bytes) | payload
+//
+// with the payload RLP encoded. Reverts of abandoned blocks are block records with the revert flag,
+// so a segment is a log of the blocks as they were written, including the reorgs.
+// Next to every segment, an index file holds the block number and the offset of every block record,
+// as two big endian uint64 values. The index can be rebuilt from the segment.
+
+const (
+	segmentMagic   = "GOLEMWAL"
+	segmentVersion = byte(1)
+
+	segmentHeaderSize = int64(len(segmentMagic) + 1)
+	recordHeaderSize  = 9
+	indexEntrySize    = 16
+
+	// maxRecordSize limits the memory allocated for a corrupted record length.
+	maxRecordSize = 256 << 20
+
+	// DefaultMaxSegmentSize is the size after which a new segment is started.
+	DefaultMaxSegmentSize = int64(64 << 20)
+)
+
+var (
+	ErrChecksumMismatch   = errors.New("record checksum mismatch")
+	ErrInvalidSegment     = errors.New("invalid segment")
+	ErrUnexpectedRecord   = errors.New("unexpected record")
+	ErrRecordSizeTooLarge = errors.New("record size too large")
+)
+
+var crcTable = crc32.MakeTable(crc32.Castagnoli)
+
+type recordKind byte
+
+const (
+	recordBlock recordKind = iota + 1
+	recordCreate
+	recordUpdate
+	recordDelete
+	recordTransferOwnership
+	recordExtend
+	recordPatch
+	recordExpire
+)
+
+type blockRecord struct {
+	Number     uint64
+	Hash       common.Hash
+	ParentHash common.Hash
+	Revert     bool
+	Operations uint64
+}
+
+type indexEntry struct {
+	number uint64
+	offset int64
+}
+
+func SegmentFilename(seq uint64) string {
+	return fmt.Sprintf("segment-%020d.wal", seq)
+}
+
+func SegmentIndexFilename(seq uint64) string {
+	return fmt.Sprintf("segment-%020d.idx", seq)
+}
+
+var segmentRe = regexp.MustCompile(`^segment-(\d+)\.wal$`)
+
+// listSegments returns the sequence numbers of the segments in the directory, in ascending order.
+func listSegments(dir string) ([]uint64, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read segment dir: %w", err)
+	}
+
+	segments := []uint64{}
+	for _, e := range entries {
+		matches := segmentRe.FindStringSubmatch(e.Name())
+		if len(matches) != 2 {
+			continue
+		}
+
+		seq, err := strconv.ParseUint(matches[1], 10, 64)
+		if err != nil {
+			continue
+		}
+
+		segments = append(segments, seq)
+	}
+
+	slices.Sort(segments)
+
+	return segments, nil
+}
+
+func appendRecord(buf []byte, kind recordKind, v any) ([]byte, error) {
+	payload, err := rlp.EncodeToBytes(v)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode record: %w", err)
+	}
+
+	header := [recordHeaderSize]byte{byte(kind)}
+	binary.BigEndian.PutUint32(header[1:5], uint32(len(payload)))
+	crc := crc32.Update(crc32.Update(0, crcTable, header[:1]), crcTable, payload)
+	binary.BigEndian.PutUint32(header[5:9], crc)
+
+	buf = append(buf, header[:]...)
+	return append(buf, payload...), nil
+}
+
+// readRecord reads the next record. It returns io.EOF if there is no record left
+// and io.ErrUnexpectedEOF if the record is incomplete.
+func readRecord(r io.Reader) (recordKind, []byte, error) {
+	header := [recordHeaderSize]byte{}
+	_, err := io.ReadFull(r, header[:])
+	if err != nil {
+		return 0, nil, err
+	}
+
+	length := binary.BigEndian.Uint32(header[1:5])
+	if length > maxRecordSize {
+		return 0, nil, fmt.Errorf("%w: %d bytes", ErrRecordSizeTooLarge, length)
+	}
+
+	payload := make([]byte, length)
+	_, err = io.ReadFull(r, payload)
+	if errors.Is(err, io.EOF) {
+		return 0, nil, io.ErrUnexpectedEOF
+	}
+	if err != nil {
+		return 0, nil, err
+	}
+
+	crc := crc32.Update(crc32.Update(0, crcTable, header[:1]), crcTable, payload)
+	if crc != binary.BigEndian.Uint32(header[5:9]) {
+		return 0, nil, ErrChecksumMismatch
+	}
+
+	return recordKind(header[0]), payload, nil
+}
+
+func appendOperation(buf []byte, op Operation) ([]byte, error) {
+	switch {
+	case op.Create != nil:
+		return appendRecord(buf, recordCreate, op.Create)
+	case op.Update != nil:
+		return appendRecord(buf, recordUpdate, op.Update)
+	case op.Delete != nil:
+		return appendRecord(buf, recordDelete, op.Delete)
+	case op.Expire != nil:
+		return appendRecord(buf, recordExpire, op.Expire)
+	case op.TransferOwnership != nil:
+		return appendRecord(buf, recordTransferOwnership, op.TransferOwnership)
+	case op.Extend != nil:
+		return appendRecord(buf, recordExtend, op.Extend)
+	case op.Patch != nil:
+		return appendRecord(buf, recordPatch, op.Patch)
+	default:
+		return nil, fmt.Errorf("empty operation")
+	}
+}
+
+func decodeOperation(kind recordKind, payload []byte) (Operation, error) {
+	decode := func(v any) error {
+		err := rlp.DecodeBytes(payload, v)
+		if err != nil {
+			return fmt.Errorf("failed to decode operation: %w", err)
+		}
+		return nil
+	}
+
+	op := Operation{}
+	var err error
+
+	switch kind {
+	case recordCreate:
+		op.Create = &Create{}
+		err = decode(op.Create)
+	case recordUpdate:
+		op.Update = &Update{}
+		err = decode(op.Update)
+	case recordDelete:
+		op.Delete = &common.Hash{}
+		err = decode(op.Delete)
+	case recordExpire:
+		op.Expire = &common.Hash{}
+		err = decode(op.Expire)
+	case recordTransferOwnership:
+		op.TransferOwnership = &TransferOwnership{}
+		err = decode(op.TransferOwnership)
+	case recordExtend:
+		op.Extend = &ExtendTTL{}
+		err = decode(op.Extend)
+	case recordPatch:
+		op.Patch = &Patch{}
+		err = decode(op.Patch)
+	default:
+		err = fmt.Errorf("%w: kind %d is not an operation", ErrUnexpectedRecord, kind)
+	}
+
+	return op, err
+}
+
+func encodeBlock(bo BlockOperations) ([]byte, error) {
+	buf, err := appendRecord(nil, recordBlock, blockRecord{
+		Number:     bo.BlockInfo.Number,
+		Hash:       bo.BlockInfo.Hash,
+		ParentHash: bo.BlockInfo.ParentHash,
+		Revert:     bo.Revert,
+		Operations: uint64(len(bo.Operations)),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	for _, op := range bo.Operations {
+		buf, err = appendOperation(buf, op)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return buf, nil
+}
+
+// countingReader counts the bytes read, to know the size of a block.
+type countingReader struct {
+	r io.Reader
+	n int64
+}
+
+func (c *countingReader) Read(p []byte) (int, error) {
+	n, err := c.r.Read(p)
+	c.n += int64(n)
+	return n, err
+}
+
+// readBlock reads the block record and the operations of the block. It returns the size of the block.
+// It returns io.EOF if there is no block left and io.ErrUnexpectedEOF if the block is incomplete.
+func readBlock(r io.Reader) (BlockOperations, int64, error) {
+	cr := &countingReader{r: r}
+
+	kind, payload, err := readRecord(cr)
+	if err != nil {
+		return BlockOperations{}, 0, err
+	}
+
+	if kind != recordBlock {
+		return BlockOperations{}, 0, fmt.Errorf("%w: expected a block record, got kind %d", ErrUnexpectedRecord, kind)
+	}
+
+	br := blockRecord{}
+	err = rlp.DecodeBytes(payload, &br)
+	if err != nil {
+		return BlockOperations{}, 0, fmt.Errorf("failed to decode block record: %w", err)
+	}
+
+	bo := BlockOperations{
+		BlockInfo: BlockInfo{
+			Number:     br.Number,
+			Hash:       br.Hash,
+			ParentHash: br.ParentHash,
+		},
+		Revert:     br.Revert,
+		Operations: []Operation{},
+	}
+
+	for range br.Operations {
+		kind, payload, err := readRecord(cr)
+		if errors.Is(err, io.EOF) {
+			return BlockOperations{}, 0, io.ErrUnexpectedEOF
+		}
+		if err != nil {
+			return BlockOperations{}, 0, err
+		}
+
+		op, err := decodeOperation(kind, payload)
+		if err != nil {
+			return BlockOperations{}, 0, fmt.Errorf("block %d: %w", br.Number, err)
+		}
+
+		bo.Operations = append(bo.Operations, op)
+	}
+
+	return bo, cr.n, nil
+}
+
+func checkSegmentHeader(r io.Reader) error {
+	header := make([]byte, segmentHeaderSize)
+	_, err := io.ReadFull(r, header)
+	if err != nil {
+		return fmt.Errorf("%w: failed to read header: %w", ErrInvalidSegment, err)
+	}
+
+	if string(header[:len(segmentMagic)]) != segmentMagic {
+		return fmt.Errorf("%w: bad magic", ErrInvalidSegment)
+	}
+
+	if header[len(segmentMagic)] != segmentVersion {
+		return fmt.Errorf("%w: unsupported version %d", ErrInvalidSegment, header[len(segmentMagic)])
+	}
+
+	return nil
+}
+
+// scanSegment reads all complete blocks of the segment. It returns the index entries
+// of the blocks and the offset after the last complete block. An incomplete or corrupted
+// block at the end of the segment is reported with the error, together with the blocks before it.
+func scanSegment(path string) ([]indexEntry, int64, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer f.Close()
+
+	err = checkSegmentHeader(f)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	r := newBufferedReader(f)
+	offset := segmentHeaderSize
+	entries := []indexEntry{}
+
+	for {
+		bo, size, err := readBlock(r)
+		if errors.Is(err, io.EOF) {
+			return entries, offset, nil
+		}
+		if err != nil {
+			return entries, offset, err
+		}
+
+		entries = append(entries, indexEntry{number: bo.BlockInfo.Number, offset: offset})
+		offset += size
+	}
+}
+
+func encodeIndex(entries []indexEntry) []byte {
+	buf := make([]byte, 0, len(entries)*indexEntrySize)
+	for _, e := range entries {
+		buf = binary.BigEndian.AppendUint64(buf, e.number)
+		buf = binary.BigEndian.AppendUint64(buf, uint64(e.offset))
+	}
+	return buf
+}
+
+// readIndex reads the index of the segment, rebuilding it from the segment if it is missing or damaged.
+func readIndex(dir string, seq uint64) ([]indexEntry, error) {
+	d, err := os.ReadFile(filepath.Join(dir, SegmentIndexFilename(seq)))
+	if err == nil && len(d)%indexEntrySize == 0 {
+		entries := make([]indexEntry, 0, len(d)/indexEntrySize)
+		for i := 0; i < len(d); i += indexEntrySize {
+			entries = append(entries, indexEntry{
+				number: binary.BigEndian.Uint64(d[i:]),
+				offset: int64(binary.BigEndian.Uint64(d[i+8:])),
+			})
+		}
+		return entries, nil
+	}
+
+	entries, _, err := scanSegment(filepath.Join(dir, SegmentFilename(seq)))
+	if err != nil && !errors.Is(err, io.ErrUnexpectedEOF) {
+		return nil, fmt.Errorf("failed to rebuild index of segment %d: %w", seq, err)
+	}
+
+	return entries, nil
+}
+
+// SegmentHead returns the number and hash of the head of the chain after the last block
+// of the segments. ok is false if the segments contain no blocks.
+func SegmentHead(dir string) (number uint64, hash common.Hash, ok bool, err error) {
+	segments, err := listSegments(dir)
+	if err != nil {
+		return 0, common.Hash{}, false, err
+	}
+
+	for i := len(segments) - 1; i >= 0; i-- {
+		entries, err := readIndex(dir, segments[i])
+		if err != nil {
+			return 0, common.Hash{}, false, err
+		}
+
+		if len(entries) == 0 {
+			continue
+		}
+
+		bo, _, err := readBlockAt(dir, segments[i], entries[len(entries)-1].offset)
+		if err != nil {
+			return 0, common.Hash{}, false, err
+		}
+
+		number, hash := bo.BlockWal().ProcessedBlock()
+		return number, hash, true, nil
+	}
+
+	return 0, common.Hash{}, false, nil
+}
+
+func readBlockAt(dir string, seq uint64, offset int64) (BlockOperations, int64, error) {
+	f, err := os.Open(filepath.Join(dir, SegmentFilename(seq)))
+	if err != nil {
+		return BlockOperations{}, 0, err
+	}
+	defer f.Close()
+
+	// the header is checked when reading the first block of a segment
+	if offset == segmentHeaderSize {
+		err = checkSegmentHeader(f)
+	} else {
+		_, err = f.Seek(offset, io.SeekStart)
+	}
+	if err != nil {
+		return BlockOperations{}, 0, err
+	}
+
+	return readBlock(newBufferedReader(f))
+}
@@ -0,0 +1,225 @@
+package wal
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+var errBlockNotFound = errors.New("block not found in segments")
+
+func newBufferedReader(r io.Reader) *bufio.Reader {
+	return bufio.NewReaderSize(r, 64*1024)
+}
+
+// segmentPosition is the position of the next block to read from the segments.
+type segmentPosition struct {
+	dir    string
+	seq    uint64
+	offset int64
+}
+
+// next reads the next block, continuing with the next segment at the end of a segment.
+// It returns io.EOF if there is no complete block to read yet.
+func (p *segmentPosition) next() (BlockOperations, error) {
+	for {
+		bo, size, err := readBlockAt(p.dir, p.seq, p.offset)
+		if err == nil {
+			p.offset += size
+			return bo, nil
+		}
+
+		if !errors.Is(err, io.EOF) && !errors.Is(err, io.ErrUnexpectedEOF) {
+			return BlockOperations{}, fmt.Errorf("failed to read segment %d at offset %d: %w", p.seq, p.offset, err)
+		}
+
+		_, statErr := os.Stat(filepath.Join(p.dir, SegmentFilename(p.seq+1)))
+		if errors.Is(statErr, os.ErrNotExist) {
+			// the block is still being written
+			return BlockOperations{}, io.EOF
+		}
+
+		if errors.Is(err, io.ErrUnexpectedEOF) {
+			return BlockOperations{}, fmt.Errorf("incomplete block at the end of segment %d", p.seq)
+		}
+
+		p.seq++
+		p.offset = segmentHeaderSize
+	}
+}
+
+// locateBlock returns the position of the block with the given number whose parent has prevBlockHash.
+// If the segments contain the parent, the position after the parent is returned instead,
+// so that a revert of the parent that follows it is read first. The last match in the segments wins.
+func locateBlock(dir string, number uint64, prevBlockHash common.Hash) (*segmentPosition, error) {
+	segments, err := listSegments(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	var found *segmentPosition
+
+	for _, seq := range segments {
+		entries, err := readIndex(dir, seq)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, e := range entries {
+			if e.number != number && e.number+1 != number {
+				continue
+			}
+
+			bo, size, err := readBlockAt(dir, seq, e.offset)
+			if err != nil {
+				return nil, fmt.Errorf("failed to read block %d of segment %d: %w", e.number, seq, err)
+			}
+
+			bi := bo.BlockInfo
+
+			switch {
+			case bo.Revert:
+			case bi.Number+1 == number && bi.Hash == prevBlockHash:
+				found = &segmentPosition{dir: dir, seq: seq, offset: e.offset + size}
+			case bi.Number == number && bi.ParentHash == prevBlockHash:
+				found = &segmentPosition{dir: dir, seq: seq, offset: e.offset}
+			}
+		}
+	}
+
+	if found == nil {
+		return nil, errBlockNotFound
+	}
+
+	return found, nil
+}
+
+func sleep(ctx context.Context, d time.Duration) {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+	case <-ctx.Done():
+	}
+}
+
+// NewSegmentIterator iterates over the blocks of the segments in segmentDir like NewIterator
+// does over the JSON files of the WAL, starting with nextBlockNumber, whose parent must have prevBlockHash.
+// Reverts of abandoned blocks are yielded in the order they were written.
+// If waitForNewBlocks is set, the segments are polled for new blocks, otherwise the iteration
+// stops at the last written block.
+func NewSegmentIterator(
+	ctx context.Context,
+	segmentDir string,
+	nextBlockNumber uint64,
+	prevBlockHash common.Hash,
+	waitForNewBlocks bool,
+) func(yield func(blockWal BlockWal, err error) bool) {
+
+	return func(yield func(blockWal BlockWal, err error) bool) {
+
+		blockNumber := nextBlockNumber
+		prevHash := prevBlockHash
+
+		var pos *segmentPosition
+
+		for ctx.Err() == nil {
+
+			if pos == nil {
+				p, err := locateBlock(segmentDir, blockNumber, prevHash)
+				if errors.Is(err, errBlockNotFound) {
+					if !waitForNewBlocks {
+						return
+					}
+					sleep(ctx, time.Second)
+					continue
+				}
+				if err != nil {
+					yield(BlockWal{}, err)
+					return
+				}
+				pos = p
+			}
+
+			bo, err := pos.next()
+			if errors.Is(err, io.EOF) {
+				if !waitForNewBlocks {
+					return
+				}
+				sleep(ctx, time.Second)
+				continue
+			}
+			if err != nil {
+				yield(BlockWal{}, err)
+				return
+			}
+
+			bi := bo.BlockInfo
+
+			if bo.Revert {
+				if bi.Number+1 != blockNumber || bi.Hash != prevHash {
+					yield(BlockWal{}, fmt.Errorf("revert mismatch: expected block %d %s, got %d %s", blockNumber-1, prevHash.Hex(), bi.Number, bi.Hash.Hex()))
+					return
+				}
+			} else {
+				if bi.Number != blockNumber {
+					yield(BlockWal{}, fmt.Errorf("block number mismatch: expected %d, got %d", blockNumber, bi.Number))
+					return
+				}
+
+				if bi.ParentHash != prevHash {
+					yield(BlockWal{}, fmt.Errorf("block hash mismatch: expected %s, got %s", prevHash.Hex(), bi.ParentHash.Hex()))
+					return
+				}
+			}
+
+			bw := bo.BlockWal()
+
+			if !yield(bw, nil) {
+				return
+			}
+
+			processedNumber, processedHash := bw.ProcessedBlock()
+			blockNumber = processedNumber + 1
+			prevHash = processedHash
+		}
+	}
+}
+
+// iterateSegments reads all blocks of the segments in the order they were written.
+func iterateSegments(ctx context.Context, segmentDir string) func(yield func(bo BlockOperations, err error) bool) {
+
+	return func(yield func(bo BlockOperations, err error) bool) {
+
+		segments, err := listSegments(segmentDir)
+		if err != nil {
+			yield(BlockOperations{}, err)
+			return
+		}
+
+		if len(segments) == 0 {
+			return
+		}
+
+		pos := &segmentPosition{dir: segmentDir, seq: segments[0], offset: segmentHeaderSize}
+
+		for ctx.Err() == nil {
+			bo, err := pos.next()
+			if errors.Is(err, io.EOF) {
+				return
+			}
+
+			if !yield(bo, err) || err != nil {
+				return
+			}
+		}
+	}
+}
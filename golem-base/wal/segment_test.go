@@ -0,0 +1,359 @@
+package wal_test
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/golem-base/storageutil/entity"
+	"github.com/ethereum/go-ethereum/golem-base/wal"
+	"github.com/stretchr/testify/require"
+)
+
+func testBlocks() []wal.BlockOperations {
+	return []wal.BlockOperations{
+		{
+			BlockInfo: wal.BlockInfo{Number: 1, Hash: common.HexToHash("0x1"), ParentHash: common.HexToHash("0x0")},
+			Operations: []wal.Operation{
+				{
+					Create: &wal.Create{
+						EntityKey:          common.HexToHash("0x100"),
+						ExpiresAtBlock:     100,
+						Payload:            []byte("payload"),
+						StringAnnotations:  []entity.StringAnnotation{{Key: "foo", Value: "bar"}},
+						NumericAnnotations: []entity.NumericAnnotation{{Key: "baz", Value: 42}},
+						Owner:              common.HexToAddress("0x1234"),
+					},
+				},
+				{
+					Update: &wal.Update{
+						EntityKey:          common.HexToHash("0x100"),
+						ExpiresAtBlock:     200,
+						Payload:            []byte("new payload"),
+						StringAnnotations:  []entity.StringAnnotation{},
+						NumericAnnotations: []entity.NumericAnnotation{},
+					},
+				},
+			},
+		},
+		{
+			BlockInfo: wal.BlockInfo{Number: 2, Hash: common.HexToHash("0x2"), ParentHash: common.HexToHash("0x1")},
+			Operations: []wal.Operation{
+				{
+					TransferOwnership: &wal.TransferOwnership{
+						EntityKey: common.HexToHash("0x100"),
+						NewOwner:  common.HexToAddress("0x5678"),
+					},
+				},
+				{
+					Extend: &wal.ExtendTTL{
+						EntityKey:      common.HexToHash("0x100"),
+						ExpiresAtBlock: 300,
+					},
+				},
+				{
+					Patch: &wal.Patch{
+						EntityKey:                common.HexToHash("0x100"),
+						ExpiresAtBlock:           300,
+						ReplacePayload:           true,
+						Payload:                  []byte("patched"),
+						SetStringAnnotations:     []entity.StringAnnotation{{Key: "foo", Value: "qux"}},
+						RemoveNumericAnnotations: []string{"baz"},
+					},
+				},
+				{
+					Expire: func() *common.Hash { h := common.HexToHash("0x101"); return &h }(),
+				},
+			},
+		},
+		{
+			BlockInfo:  wal.BlockInfo{Number: 3, Hash: common.HexToHash("0x3"), ParentHash: common.HexToHash("0x2")},
+			Operations: []wal.Operation{},
+		},
+		// a reorg replaces block 2 and 3 with block 2'
+		{
+			BlockInfo:  wal.BlockInfo{Number: 3, Hash: common.HexToHash("0x3"), ParentHash: common.HexToHash("0x2")},
+			Revert:     true,
+			Operations: []wal.Operation{},
+		},
+		{
+			BlockInfo: wal.BlockInfo{Number: 2, Hash: common.HexToHash("0x2"), ParentHash: common.HexToHash("0x1")},
+			Revert:    true,
+			Operations: []wal.Operation{
+				{
+					Delete: func() *common.Hash { h := common.HexToHash("0x100"); return &h }(),
+				},
+			},
+		},
+		{
+			BlockInfo:  wal.BlockInfo{Number: 2, Hash: common.HexToHash("0x22"), ParentHash: common.HexToHash("0x1")},
+			Operations: []wal.Operation{},
+		},
+	}
+}
+
+func writeSegments(t *testing.T, dir string, maxSegmentSize int64, blocks []wal.BlockOperations) {
+	w, err := wal.OpenSegmentWriter(dir, maxSegmentSize)
+	require.NoError(t, err)
+
+	for _, bo := range blocks {
+		require.NoError(t, w.WriteBlock(bo))
+	}
+
+	require.NoError(t, w.Close())
+}
+
+func readSegments(t *testing.T, dir string, nextBlockNumber uint64, prevBlockHash common.Hash) []wal.BlockOperations {
+	blocks := []wal.BlockOperations{}
+
+	for bw, err := range wal.NewSegmentIterator(context.Background(), dir, nextBlockNumber, prevBlockHash, false) {
+		require.NoError(t, err)
+
+		bo, err := wal.ReadBlockOperations(bw)
+		require.NoError(t, err)
+
+		blocks = append(blocks, bo)
+	}
+
+	return blocks
+}
+
+func requireSameBlocks(t *testing.T, expected, actual []wal.BlockOperations) {
+	expectedJSON, err := json.Marshal(expected)
+	require.NoError(t, err)
+
+	actualJSON, err := json.Marshal(actual)
+	require.NoError(t, err)
+
+	require.JSONEq(t, string(expectedJSON), string(actualJSON))
+}
+
+func TestSegments(t *testing.T) {
+
+	blocks := testBlocks()
+
+	t.Run("should read all written blocks", func(t *testing.T) {
+		td := t.TempDir()
+
+		writeSegments(t, td, 0, blocks)
+
+		requireSameBlocks(t, blocks, readSegments(t, td, 1, common.HexToHash("0x0")))
+	})
+
+	t.Run("should start a new segment once the maximum segment size is reached", func(t *testing.T) {
+		td := t.TempDir()
+
+		writeSegments(t, td, 1, blocks)
+
+		segments, err := filepath.Glob(filepath.Join(td, "segment-*.wal"))
+		require.NoError(t, err)
+		require.Len(t, segments, len(blocks))
+
+		requireSameBlocks(t, blocks, readSegments(t, td, 1, common.HexToHash("0x0")))
+
+		t.Run("and start with a block in the middle of the segments", func(t *testing.T) {
+			requireSameBlocks(t, blocks[2:], readSegments(t, td, 3, common.HexToHash("0x2")))
+		})
+
+		t.Run("and start with a block after a reorg", func(t *testing.T) {
+			requireSameBlocks(t, blocks[5:], readSegments(t, td, 2, common.HexToHash("0x1")))
+		})
+	})
+
+	t.Run("should continue after the last block when reopened", func(t *testing.T) {
+		td := t.TempDir()
+
+		writeSegments(t, td, 0, blocks[:2])
+		writeSegments(t, td, 0, blocks[2:])
+
+		requireSameBlocks(t, blocks, readSegments(t, td, 1, common.HexToHash("0x0")))
+
+		number, hash, ok, err := wal.SegmentHead(td)
+		require.NoError(t, err)
+		require.True(t, ok)
+		require.Equal(t, uint64(2), number)
+		require.Equal(t, common.HexToHash("0x22"), hash)
+	})
+
+	t.Run("should detect a corrupted record", func(t *testing.T) {
+		td := t.TempDir()
+
+		writeSegments(t, td, 0, blocks[:2])
+
+		path := filepath.Join(td, wal.SegmentFilename(0))
+		data, err := os.ReadFile(path)
+		require.NoError(t, err)
+
+		data[len(data)-1] ^= 0xff
+		require.NoError(t, os.WriteFile(path, data, 0644))
+
+		var iterErr error
+		for _, err := range wal.NewSegmentIterator(context.Background(), td, 1, common.HexToHash("0x0"), false) {
+			if err != nil {
+				iterErr = err
+			}
+		}
+
+		require.ErrorIs(t, iterErr, wal.ErrChecksumMismatch)
+	})
+
+	t.Run("should truncate an incomplete block when reopened", func(t *testing.T) {
+		td := t.TempDir()
+
+		writeSegments(t, td, 0, blocks[:2])
+
+		path := filepath.Join(td, wal.SegmentFilename(0))
+		fi, err := os.Stat(path)
+		require.NoError(t, err)
+		require.NoError(t, os.Truncate(path, fi.Size()-3))
+
+		requireSameBlocks(t, blocks[:1], readSegments(t, td, 1, common.HexToHash("0x0")))
+
+		writeSegments(t, td, 0, blocks[1:])
+
+		requireSameBlocks(t, blocks, readSegments(t, td, 1, common.HexToHash("0x0")))
+	})
+}
+
+func TestConvert(t *testing.T) {
+
+	t.Run("should convert the JSON files to segments and back", func(t *testing.T) {
+		ctx := context.Background()
+
+		jsonDir := t.TempDir()
+		segmentDir := t.TempDir()
+		convertedDir := t.TempDir()
+
+		blocks := testBlocks()[:3]
+
+		for _, bo := range blocks {
+			require.NoError(t, writeWal(jsonDir, bo.BlockInfo, bo.Operations))
+		}
+
+		converted, err := wal.ConvertJSONToSegments(ctx, jsonDir, segmentDir, 0)
+		require.NoError(t, err)
+		require.Equal(t, 3, converted)
+
+		requireSameBlocks(t, blocks, readSegments(t, segmentDir, 1, common.HexToHash("0x0")))
+
+		t.Run("and only convert new blocks when run again", func(t *testing.T) {
+			converted, err := wal.ConvertJSONToSegments(ctx, jsonDir, segmentDir, 0)
+			require.NoError(t, err)
+			require.Equal(t, 0, converted)
+		})
+
+		converted, err = wal.ConvertSegmentsToJSON(ctx, segmentDir, convertedDir)
+		require.NoError(t, err)
+		require.Equal(t, 3, converted)
+
+		for _, bo := range blocks {
+			filename := wal.BlockNumberToFilename(bo.BlockInfo.Number)
+
+			expected, err := os.ReadFile(filepath.Join(jsonDir, filename))
+			require.NoError(t, err)
+
+			actual, err := os.ReadFile(filepath.Join(convertedDir, filename))
+			require.NoError(t, err)
+
+			require.Equal(t, string(expected), string(actual))
+		}
+	})
+
+	t.Run("should convert a revert to a revert file", func(t *testing.T) {
+		ctx := context.Background()
+
+		segmentDir := t.TempDir()
+		jsonDir := t.TempDir()
+
+		// leave out block 3 and its revert
+		blocks := testBlocks()
+		blocks = append(blocks[:2], blocks[4:]...)
+
+		writeSegments(t, segmentDir, 0, blocks)
+
+		converted, err := wal.ConvertSegmentsToJSON(ctx, segmentDir, jsonDir)
+		require.NoError(t, err)
+		require.Equal(t, 4, converted)
+
+		_, err = os.Stat(filepath.Join(jsonDir, wal.RevertFilename(2, common.HexToHash("0x2"))))
+		require.NoError(t, err)
+
+		// a reader that already processed the abandoned block 2 reads its revert
+		blockWals := []wal.BlockOperations{}
+		for bw, err := range wal.NewIterator(ctx, jsonDir, 3, common.HexToHash("0x2"), false) {
+			require.NoError(t, err)
+
+			bo, err := wal.ReadBlockOperations(bw)
+			require.NoError(t, err)
+
+			blockWals = append(blockWals, bo)
+		}
+
+		requireSameBlocks(t, blocks[2:], blockWals)
+	})
+}
+
+func TestSegmentMirror(t *testing.T) {
+	ctx := context.Background()
+
+	jsonDir := t.TempDir()
+	segmentDir := t.TempDir()
+
+	blocks := testBlocks()
+
+	for _, bo := range blocks[:2] {
+		require.NoError(t, writeWal(jsonDir, bo.BlockInfo, bo.Operations))
+	}
+
+	m, err := wal.OpenSegmentMirror(jsonDir, segmentDir, 0)
+	require.NoError(t, err)
+
+	appended, err := m.CatchUp(ctx)
+	require.NoError(t, err)
+	require.Equal(t, 2, appended)
+
+	t.Run("should append nothing without new blocks", func(t *testing.T) {
+		appended, err := m.CatchUp(ctx)
+		require.NoError(t, err)
+		require.Equal(t, 0, appended)
+	})
+
+	t.Run("should append the reverts of a reorg", func(t *testing.T) {
+		// block 2 is replaced by block 2'
+		reorged := append(append([]wal.BlockOperations{}, blocks[:2]...), blocks[4:]...)
+
+		reorgedSegmentDir := t.TempDir()
+		writeSegments(t, reorgedSegmentDir, 0, reorged)
+		_, err := wal.ConvertSegmentsToJSON(ctx, reorgedSegmentDir, jsonDir)
+		require.NoError(t, err)
+
+		appended, err := m.CatchUp(ctx)
+		require.NoError(t, err)
+		require.Equal(t, 2, appended)
+
+		requireSameBlocks(t, reorged, readSegments(t, segmentDir, 1, common.HexToHash("0x0")))
+	})
+
+	require.NoError(t, m.Close())
+
+	t.Run("should continue after the last block when reopened", func(t *testing.T) {
+		bo := wal.BlockOperations{
+			BlockInfo:  wal.BlockInfo{Number: 3, Hash: common.HexToHash("0x33"), ParentHash: common.HexToHash("0x22")},
+			Operations: []wal.Operation{},
+		}
+		require.NoError(t, writeWal(jsonDir, bo.BlockInfo, bo.Operations))
+
+		m, err := wal.OpenSegmentMirror(jsonDir, segmentDir, 0)
+		require.NoError(t, err)
+		defer m.Close()
+
+		appended, err := m.CatchUp(ctx)
+		require.NoError(t, err)
+		require.Equal(t, 1, appended)
+
+		requireSameBlocks(t, []wal.BlockOperations{bo}, readSegments(t, segmentDir, 3, common.HexToHash("0x22")))
+	})
+}
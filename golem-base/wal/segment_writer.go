@@ -0,0 +1,190 @@
+package wal
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/ethereum/go-ethereum/log"
+)
+
+// SegmentWriter appends blocks to the segments of a directory, starting a new
+// segment once the current one reached the maximum segment size.
+type SegmentWriter struct {
+	dir            string
+	maxSegmentSize int64
+
+	seq     uint64
+	segment *os.File
+	index   *os.File
+	size    int64
+}
+
+// OpenSegmentWriter opens the last segment of the directory for appending, or creates the first one.
+// An incomplete or corrupted block at the end of the last segment, left by an interrupted write,
+// is truncated and the index of the segment is rebuilt.
+func OpenSegmentWriter(dir string, maxSegmentSize int64) (*SegmentWriter, error) {
+	err := os.MkdirAll(dir, 0755)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create segment dir: %w", err)
+	}
+
+	if maxSegmentSize <= 0 {
+		maxSegmentSize = DefaultMaxSegmentSize
+	}
+
+	w := &SegmentWriter{
+		dir:            dir,
+		maxSegmentSize: maxSegmentSize,
+	}
+
+	segments, err := listSegments(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(segments) == 0 {
+		return w, w.createSegment(0)
+	}
+
+	return w, w.recoverSegment(segments[len(segments)-1])
+}
+
+func (w *SegmentWriter) createSegment(seq uint64) error {
+	segment, err := os.OpenFile(filepath.Join(w.dir, SegmentFilename(seq)), os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0644)
+	if err != nil {
+		return fmt.Errorf("failed to create segment: %w", err)
+	}
+
+	_, err = segment.Write(append([]byte(segmentMagic), segmentVersion))
+	if err != nil {
+		segment.Close()
+		return fmt.Errorf("failed to write segment header: %w", err)
+	}
+
+	index, err := os.OpenFile(filepath.Join(w.dir, SegmentIndexFilename(seq)), os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0644)
+	if err != nil {
+		segment.Close()
+		return fmt.Errorf("failed to create segment index: %w", err)
+	}
+
+	w.seq = seq
+	w.segment = segment
+	w.index = index
+	w.size = segmentHeaderSize
+
+	return nil
+}
+
+func (w *SegmentWriter) recoverSegment(seq uint64) error {
+	path := filepath.Join(w.dir, SegmentFilename(seq))
+
+	fi, err := os.Stat(path)
+	if err != nil {
+		return fmt.Errorf("failed to stat segment: %w", err)
+	}
+
+	// the segment was created, but its header was not written completely
+	if fi.Size() < segmentHeaderSize {
+		err = os.Remove(path)
+		if err != nil {
+			return fmt.Errorf("failed to remove incomplete segment: %w", err)
+		}
+		return w.createSegment(seq)
+	}
+
+	entries, end, err := scanSegment(path)
+	switch {
+	case errors.Is(err, io.ErrUnexpectedEOF), errors.Is(err, ErrChecksumMismatch):
+		log.Warn("truncating incomplete block at the end of the segment", "segment", path, "offset", end, "error", err)
+	case err != nil:
+		return fmt.Errorf("failed to scan segment %d: %w", seq, err)
+	}
+
+	segment, err := os.OpenFile(path, os.O_WRONLY, 0644)
+	if err != nil {
+		return fmt.Errorf("failed to open segment: %w", err)
+	}
+
+	err = segment.Truncate(end)
+	if err == nil {
+		_, err = segment.Seek(end, io.SeekStart)
+	}
+	if err != nil {
+		segment.Close()
+		return fmt.Errorf("failed to truncate segment: %w", err)
+	}
+
+	index, err := os.OpenFile(filepath.Join(w.dir, SegmentIndexFilename(seq)), os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0644)
+	if err == nil {
+		_, err = index.Write(encodeIndex(entries))
+	}
+	if err != nil {
+		segment.Close()
+		return fmt.Errorf("failed to rebuild segment index: %w", err)
+	}
+
+	w.seq = seq
+	w.segment = segment
+	w.index = index
+	w.size = end
+
+	return nil
+}
+
+// WriteBlock appends the block to the current segment. Blocks are written whole,
+// a new segment is only started before a block.
+func (w *SegmentWriter) WriteBlock(bo BlockOperations) error {
+	if w.size >= w.maxSegmentSize && w.size > segmentHeaderSize {
+		err := w.closeSegment()
+		if err != nil {
+			return err
+		}
+
+		err = w.createSegment(w.seq + 1)
+		if err != nil {
+			return err
+		}
+	}
+
+	buf, err := encodeBlock(bo)
+	if err != nil {
+		return fmt.Errorf("failed to encode block %d: %w", bo.BlockInfo.Number, err)
+	}
+
+	offset := w.size
+
+	_, err = w.segment.Write(buf)
+	if err != nil {
+		return fmt.Errorf("failed to write block %d: %w", bo.BlockInfo.Number, err)
+	}
+	w.size += int64(len(buf))
+
+	entry := make([]byte, 0, indexEntrySize)
+	entry = binary.BigEndian.AppendUint64(entry, bo.BlockInfo.Number)
+	entry = binary.BigEndian.AppendUint64(entry, uint64(offset))
+
+	_, err = w.index.Write(entry)
+	if err != nil {
+		return fmt.Errorf("failed to write index entry of block %d: %w", bo.BlockInfo.Number, err)
+	}
+
+	return nil
+}
+
+// Sync flushes the current segment and its index to disk.
+func (w *SegmentWriter) Sync() error {
+	return errors.Join(w.segment.Sync(), w.index.Sync())
+}
+
+func (w *SegmentWriter) closeSegment() error {
+	return errors.Join(w.Sync(), w.segment.Close(), w.index.Close())
+}
+
+// Close syncs and closes the current segment.
+func (w *SegmentWriter) Close() error {
+	return w.closeSegment()
+}
@@ -0,0 +1,115 @@
+package wal
+
+import (
+	"fmt"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/golem-base/storageutil"
+	"github.com/ethereum/go-ethereum/golem-base/storageutil/entity"
+	"github.com/ethereum/go-ethereum/golem-base/storageutil/entity/allentities"
+)
+
+// touchedEntity is the net effect of the operations of a block on one entity.
+type touchedEntity struct {
+	key         common.Hash
+	created     bool
+	deleted     bool
+	transferred bool
+}
+
+// UndoOperations returns the operations that bring the entities touched by the operations
+// back to their state in parentState, the state the operations were applied to.
+// The entities are restored in the reverse order in which they were first touched.
+func UndoOperations(operations []Operation, parentState storageutil.StateAccess) ([]Operation, error) {
+
+	touched := []*touchedEntity{}
+	byKey := map[common.Hash]*touchedEntity{}
+
+	touch := func(key common.Hash) *touchedEntity {
+		te, ok := byKey[key]
+		if !ok {
+			te = &touchedEntity{key: key}
+			byKey[key] = te
+			touched = append(touched, te)
+		}
+		return te
+	}
+
+	for _, op := range operations {
+		switch {
+		case op.Create != nil:
+			te := touch(op.Create.EntityKey)
+			te.created = true
+			te.deleted = false
+		case op.Update != nil:
+			touch(op.Update.EntityKey)
+		case op.Delete != nil:
+			touch(*op.Delete).deleted = true
+		case op.Expire != nil:
+			touch(*op.Expire).deleted = true
+		case op.TransferOwnership != nil:
+			touch(op.TransferOwnership.EntityKey).transferred = true
+		case op.Extend != nil:
+			touch(op.Extend.EntityKey)
+		case op.Patch != nil:
+			touch(op.Patch.EntityKey)
+		}
+	}
+
+	undo := []Operation{}
+
+	for i := len(touched) - 1; i >= 0; i-- {
+		te := touched[i]
+
+		if !allentities.Contains(parentState, te.key) {
+			// the entity was created in the block
+			if !te.deleted {
+				key := te.key
+				undo = append(undo, Operation{Delete: &key})
+			}
+			continue
+		}
+
+		emd, err := entity.GetEntityMetaData(parentState, te.key)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get meta data of entity %s: %w", te.key.Hex(), err)
+		}
+
+		payload := entity.GetPayload(parentState, te.key)
+
+		if te.deleted {
+			undo = append(undo, Operation{
+				Create: &Create{
+					EntityKey:          te.key,
+					ExpiresAtBlock:     emd.ExpiresAtBlock,
+					Payload:            payload,
+					StringAnnotations:  emd.StringAnnotations,
+					NumericAnnotations: emd.NumericAnnotations,
+					Owner:              emd.Owner,
+				},
+			})
+			continue
+		}
+
+		undo = append(undo, Operation{
+			Update: &Update{
+				EntityKey:          te.key,
+				ExpiresAtBlock:     emd.ExpiresAtBlock,
+				Payload:            payload,
+				StringAnnotations:  emd.StringAnnotations,
+				NumericAnnotations: emd.NumericAnnotations,
+			},
+		})
+
+		if te.transferred {
+			undo = append(undo, Operation{
+				TransferOwnership: &TransferOwnership{
+					EntityKey: te.key,
+					NewOwner:  emd.Owner,
+				},
+			})
+		}
+	}
+
+	return undo, nil
+}
@@ -0,0 +1,101 @@
+package wal_test
+
+import (
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/golem-base/storageutil/entity"
+	"github.com/ethereum/go-ethereum/golem-base/wal"
+	"github.com/stretchr/testify/require"
+)
+
+type mockStateAccess map[common.Address]map[common.Hash]common.Hash
+
+func (m mockStateAccess) GetState(addr common.Address, key common.Hash) common.Hash {
+	return m[addr][key]
+}
+
+func (m mockStateAccess) SetState(addr common.Address, key common.Hash, value common.Hash) common.Hash {
+	if _, ok := m[addr]; !ok {
+		m[addr] = map[common.Hash]common.Hash{}
+	}
+	m[addr][key] = value
+	return value
+}
+
+func TestUndoOperations(t *testing.T) {
+
+	owner := common.HexToAddress("0x1")
+	newOwner := common.HexToAddress("0x2")
+
+	existingKey := common.HexToHash("0x10")
+	otherKey := common.HexToHash("0x11")
+	createdKey := common.HexToHash("0x20")
+	createdAndDeletedKey := common.HexToHash("0x21")
+	expiredKey := common.HexToHash("0x12")
+
+	parentState := mockStateAccess{}
+
+	emd := entity.EntityMetaData{
+		ExpiresAtBlock:     100,
+		StringAnnotations:  []entity.StringAnnotation{{Key: "type", Value: "test"}},
+		NumericAnnotations: []entity.NumericAnnotation{{Key: "size", Value: 3}},
+		Owner:              owner,
+	}
+
+	require.NoError(t, entity.Store(parentState, existingKey, owner, emd, []byte("existing")))
+	require.NoError(t, entity.Store(parentState, otherKey, owner, emd, []byte("other")))
+	require.NoError(t, entity.Store(parentState, expiredKey, owner, emd, []byte("expired")))
+
+	operations := []wal.Operation{
+		{Expire: &expiredKey},
+		{Create: &wal.Create{EntityKey: createdKey, ExpiresAtBlock: 200, Owner: owner}},
+		{Update: &wal.Update{EntityKey: existingKey, ExpiresAtBlock: 150, Payload: []byte("updated")}},
+		{TransferOwnership: &wal.TransferOwnership{EntityKey: existingKey, NewOwner: newOwner}},
+		{Create: &wal.Create{EntityKey: createdAndDeletedKey, ExpiresAtBlock: 200, Owner: owner}},
+		{Delete: &createdAndDeletedKey},
+		{Delete: &otherKey},
+	}
+
+	undo, err := wal.UndoOperations(operations, parentState)
+	require.NoError(t, err)
+
+	require.Equal(t, []wal.Operation{
+		{
+			Create: &wal.Create{
+				EntityKey:          otherKey,
+				ExpiresAtBlock:     100,
+				Payload:            []byte("other"),
+				StringAnnotations:  emd.StringAnnotations,
+				NumericAnnotations: emd.NumericAnnotations,
+				Owner:              owner,
+			},
+		},
+		{
+			Update: &wal.Update{
+				EntityKey:          existingKey,
+				ExpiresAtBlock:     100,
+				Payload:            []byte("existing"),
+				StringAnnotations:  emd.StringAnnotations,
+				NumericAnnotations: emd.NumericAnnotations,
+			},
+		},
+		{
+			TransferOwnership: &wal.TransferOwnership{
+				EntityKey: existingKey,
+				NewOwner:  owner,
+			},
+		},
+		{Delete: &createdKey},
+		{
+			Create: &wal.Create{
+				EntityKey:          expiredKey,
+				ExpiresAtBlock:     100,
+				Payload:            []byte("expired"),
+				StringAnnotations:  emd.StringAnnotations,
+				NumericAnnotations: emd.NumericAnnotations,
+				Owner:              owner,
+			},
+		},
+	}, undo)
+}
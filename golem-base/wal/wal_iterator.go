@@ -15,8 +15,28 @@ import (
 type BlockWal struct {
 	BlockInfo          BlockInfo
 	OperationsIterator BlockOperationsIterator
+
+	// Revert is set when the block was abandoned by a reorg. The operations
+	// undo the block and the iteration continues with the children of its parent.
+	Revert bool
+}
+
+// ProcessedBlock returns the number and hash of the head of the chain once the
+// block was processed: the block itself, or its parent if the block is reverted.
+func (bw BlockWal) ProcessedBlock() (uint64, common.Hash) {
+	if bw.Revert {
+		return bw.BlockInfo.Number - 1, bw.BlockInfo.ParentHash
+	}
+	return bw.BlockInfo.Number, bw.BlockInfo.Hash
 }
 
+// waitFunc blocks until the next block or a revert may have been written, or the context is done.
+// available reports whether it was.
+type waitFunc func(ctx context.Context, available func() bool)
+
+// NewIterator iterates over the blocks of the WAL directory, starting with nextBlockNumber,
+// whose parent must have prevBlockHash. If waitForNewBlocks is set, the WAL directory is polled
+// for new blocks, otherwise the iteration stops at the last written block.
 func NewIterator(
 	ctx context.Context,
 	walDir string,
@@ -25,25 +45,117 @@ func NewIterator(
 	waitForNewBlocks bool,
 ) func(yield func(blockWal BlockWal, err error) bool) {
 
+	var wait waitFunc
+	if waitForNewBlocks {
+		wait = pollForNewBlocks
+	}
+
+	return newIterator(ctx, walDir, nextBlockNumber, prevBlockHash, wait)
+}
+
+// NewNotifiedIterator is like NewIterator with waitForNewBlocks, but checks for new blocks
+// as soon as a value is received from newBlocks, when the writer of the WAL runs in the same process.
+// The WAL directory is still checked every second in case a notification is missed.
+func NewNotifiedIterator(
+	ctx context.Context,
+	walDir string,
+	nextBlockNumber uint64,
+	prevBlockHash common.Hash,
+	newBlocks <-chan struct{},
+) func(yield func(blockWal BlockWal, err error) bool) {
+
+	wait := func(ctx context.Context, available func() bool) {
+		timer := time.NewTimer(time.Second)
+		defer timer.Stop()
+
+		select {
+		case <-newBlocks:
+		case <-timer.C:
+		case <-ctx.Done():
+		}
+	}
+
+	return newIterator(ctx, walDir, nextBlockNumber, prevBlockHash, wait)
+}
+
+func pollForNewBlocks(ctx context.Context, available func() bool) {
+	bo := backoff.WithContext(backoff.NewConstantBackOff(time.Second), ctx)
+	backoff.Retry(func() error {
+		if !available() {
+			return os.ErrNotExist
+		}
+		return nil
+	}, bo)
+}
+
+func newIterator(
+	ctx context.Context,
+	walDir string,
+	nextBlockNumber uint64,
+	prevBlockHash common.Hash,
+	wait waitFunc,
+) func(yield func(blockWal BlockWal, err error) bool) {
+
 	blockNumber := nextBlockNumber
 
 	return func(yield func(blockWal BlockWal, err error) bool) {
 
 		for ctx.Err() == nil {
 
+			// the last yielded block was abandoned, revert it before continuing
+			// with the block that replaced it
+			if blockNumber > 0 {
+				revertFilename := filepath.Join(walDir, RevertFilename(blockNumber-1, prevBlockHash))
+
+				bi, operationsIterator, err := NewBlockOperationsIterator(ctx, revertFilename)
+				if err == nil {
+					bw := BlockWal{
+						BlockInfo:          bi,
+						OperationsIterator: operationsIterator,
+						Revert:             true,
+					}
+
+					if !yield(bw, nil) {
+						return
+					}
+
+					blockNumber = bi.Number
+					prevBlockHash = bi.ParentHash
+					continue
+				}
+
+				if !errors.Is(err, os.ErrNotExist) {
+					if !yield(BlockWal{}, fmt.Errorf("failed to create revert operations iterator: %w", err)) {
+						return
+					}
+				}
+			}
+
 			filename := filepath.Join(walDir, BlockNumberToFilename(blockNumber))
 
 			bi, operationsIterator, err := NewBlockOperationsIterator(ctx, filename)
 
 			if errors.Is(err, os.ErrNotExist) {
-				if !waitForNewBlocks {
+				err = CheckPruned(walDir, blockNumber)
+				if err != nil {
+					yield(BlockWal{}, err)
+					return
+				}
+
+				if wait == nil {
 					return
 				}
-				bo := backoff.WithContext(backoff.NewConstantBackOff(time.Second), ctx)
-				backoff.Retry(func() error {
+
+				available := func() bool {
 					_, err := os.Stat(filename)
-					return err
-				}, bo)
+					if err != nil && blockNumber > 0 {
+						// a revert of the last block can appear instead of the next block
+						_, err = os.Stat(filepath.Join(walDir, RevertFilename(blockNumber-1, prevBlockHash)))
+					}
+					return err == nil
+				}
+
+				wait(ctx, available)
 
 				continue
 			}
@@ -5,12 +5,14 @@ import (
 	"encoding/json"
 	"fmt"
 	"log/slog"
+	"math/big"
 	"os"
 	"path/filepath"
 	"testing"
 	"time"
 
 	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
 	"github.com/ethereum/go-ethereum/golem-base/wal"
 	"github.com/ethereum/go-ethereum/log"
 	"github.com/stretchr/testify/require"
@@ -138,4 +140,91 @@ func TestWalIterator(t *testing.T) {
 		}
 	})
 
+	t.Run("should revert blocks abandoned by a reorg", func(t *testing.T) {
+
+		log.SetDefault(log.NewLogger(slog.NewTextHandler(os.Stdout, nil)))
+
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		td := t.TempDir()
+
+		newBlock := func(number uint64, parentHash common.Hash, extra string) *types.Block {
+			return types.NewBlockWithHeader(&types.Header{
+				Number:     new(big.Int).SetUint64(number),
+				ParentHash: parentHash,
+				Extra:      []byte(extra),
+			})
+		}
+
+		writeBlock := func(block *types.Block) {
+			err := wal.WriteLogForBlock(td, block, big.NewInt(1), nil, nil)
+			require.NoError(t, err)
+		}
+
+		genesis := newBlock(0, common.Hash{}, "")
+		block1a := newBlock(1, genesis.Hash(), "a")
+		block2a := newBlock(2, block1a.Hash(), "a")
+		block1b := newBlock(1, genesis.Hash(), "b")
+
+		writeBlock(genesis)
+		writeBlock(block1a)
+		writeBlock(block2a)
+		writeBlock(block1b)
+
+		require.FileExists(t, filepath.Join(td, wal.RevertFilename(1, block1a.Hash())))
+		require.FileExists(t, filepath.Join(td, wal.RevertFilename(2, block2a.Hash())))
+		require.NoFileExists(t, filepath.Join(td, wal.BlockNumberToFilename(2)))
+
+		type seenBlock struct {
+			hash   common.Hash
+			revert bool
+		}
+
+		seen := []seenBlock{}
+
+		// a consumer that processed the abandoned blocks
+		for block, err := range wal.NewIterator(ctx, td, 3, block2a.Hash(), false) {
+			require.NoError(t, err)
+			for range block.OperationsIterator {
+			}
+			seen = append(seen, seenBlock{hash: block.BlockInfo.Hash, revert: block.Revert})
+		}
+
+		require.Equal(t, []seenBlock{
+			{hash: block2a.Hash(), revert: true},
+			{hash: block1a.Hash(), revert: true},
+			{hash: block1b.Hash(), revert: false},
+		}, seen)
+	})
+
+	t.Run("should read new blocks when notified", func(t *testing.T) {
+
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+
+		td := t.TempDir()
+
+		newBlocks := make(chan struct{}, 1)
+
+		go func() {
+			time.Sleep(100 * time.Millisecond)
+			err := writeWal(td, wal.BlockInfo{Number: 1, Hash: common.HexToHash("0x2"), ParentHash: common.HexToHash("0x1")}, nil)
+			if err == nil {
+				newBlocks <- struct{}{}
+			}
+		}()
+
+		start := time.Now()
+
+		for block, err := range wal.NewNotifiedIterator(ctx, td, 1, common.HexToHash("0x1"), newBlocks) {
+			require.NoError(t, err)
+			require.Equal(t, uint64(1), block.BlockInfo.Number)
+			break
+		}
+
+		// without the notification, the block would only be seen after polling the directory
+		require.Less(t, time.Since(start), time.Second)
+	})
+
 }
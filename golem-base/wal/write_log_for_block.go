@@ -14,6 +14,7 @@ import (
 	"github.com/ethereum/go-ethereum/core/types"
 	"github.com/ethereum/go-ethereum/golem-base/address"
 	"github.com/ethereum/go-ethereum/golem-base/storagetx"
+	"github.com/ethereum/go-ethereum/golem-base/storageutil"
 	"github.com/ethereum/go-ethereum/golem-base/storageutil/entity"
 	"github.com/ethereum/go-ethereum/log"
 	"github.com/ethereum/go-ethereum/rlp"
@@ -30,6 +31,12 @@ type Operation struct {
 	Create *Create      `json:"create,omitempty"`
 	Update *Update      `json:"update,omitempty"`
 	Delete *common.Hash `json:"delete,omitempty"`
+	// Expire is the removal of an entity by the housekeeping transaction when its TTL ran out.
+	Expire *common.Hash `json:"expire,omitempty"`
+
+	TransferOwnership *TransferOwnership `json:"transferOwnership,omitempty"`
+	Extend            *ExtendTTL         `json:"extend,omitempty"`
+	Patch             *Patch             `json:"patch,omitempty"`
 }
 
 type Create struct {
@@ -49,6 +56,30 @@ type Update struct {
 	NumericAnnotations []entity.NumericAnnotation `json:"numericAnnotations"`
 }
 
+type TransferOwnership struct {
+	EntityKey common.Hash    `json:"entityKey"`
+	NewOwner  common.Address `json:"newOwner"`
+}
+
+type ExtendTTL struct {
+	EntityKey      common.Hash `json:"entityKey"`
+	ExpiresAtBlock uint64      `json:"expiresAtBlock"`
+}
+
+// Patch changes parts of an entity. The annotations are removed before they are set,
+// setting an annotation replaces the value of the annotation with the same type and key.
+// The payload is only changed if ReplacePayload is set.
+type Patch struct {
+	EntityKey                common.Hash                `json:"entityKey"`
+	ExpiresAtBlock           uint64                     `json:"expiresAtBlock"`
+	ReplacePayload           bool                       `json:"replacePayload,omitempty"`
+	Payload                  []byte                     `json:"payload,omitempty"`
+	SetStringAnnotations     []entity.StringAnnotation  `json:"setStringAnnotations,omitempty"`
+	SetNumericAnnotations    []entity.NumericAnnotation `json:"setNumericAnnotations,omitempty"`
+	RemoveStringAnnotations  []string                   `json:"removeStringAnnotations,omitempty"`
+	RemoveNumericAnnotations []string                   `json:"removeNumericAnnotations,omitempty"`
+}
+
 func BlockNumberToFilename(blockNumber uint64) string {
 	return fmt.Sprintf("block-%020d.json", blockNumber)
 }
@@ -69,7 +100,25 @@ func PathToBlockNumber(path string) (uint64, error) {
 	return strconv.ParseUint(matches[1], 10, 64)
 }
 
-func WriteLogForBlock(dir string, block *types.Block, chainID *big.Int, receipts []*types.Receipt) (err error) {
+// UndoFilename is the name of the file holding the operations that undo the block.
+// It is kept next to the block file and becomes a revert file once the block is abandoned.
+func UndoFilename(blockNumber uint64) string {
+	return fmt.Sprintf("block-%020d.undo.json", blockNumber)
+}
+
+// RevertFilename is the name of the file holding the operations that revert
+// the block with the given number and hash, after it was abandoned by a reorg.
+func RevertFilename(blockNumber uint64, blockHash common.Hash) string {
+	return fmt.Sprintf("revert-%020d-%s.json", blockNumber, blockHash.Hex())
+}
+
+// WriteLogForBlock writes the operations of the block to the WAL directory.
+// parentState is the state of the parent block, it is used to write the operations
+// that undo the block in case it is abandoned later. It can be nil, in which case
+// a revert of the block contains no operations.
+// Blocks that were written at or above the block number and are not part of its
+// chain any more are replaced by revert files, so that consumers can unwind them.
+func WriteLogForBlock(dir string, block *types.Block, chainID *big.Int, receipts []*types.Receipt, parentState storageutil.StateAccess) (err error) {
 
 	defer func() {
 		if err != nil {
@@ -77,8 +126,135 @@ func WriteLogForBlock(dir string, block *types.Block, chainID *big.Int, receipts
 		}
 	}()
 
-	tempFilename := BlockNumberToFilename(block.NumberU64()) + ".temp"
-	finalFilename := BlockNumberToFilename(block.NumberU64())
+	operations, err := OperationsForBlock(block, chainID, receipts)
+	if err != nil {
+		return err
+	}
+
+	blockInfo := BlockInfo{
+		Number:     block.NumberU64(),
+		Hash:       block.Hash(),
+		ParentHash: block.ParentHash(),
+	}
+
+	err = revertAbandonedBlocks(dir, blockInfo)
+	if err != nil {
+		return err
+	}
+
+	if parentState != nil {
+		undo, err := UndoOperations(operations, parentState)
+		if err != nil {
+			return fmt.Errorf("failed to compute undo operations: %w", err)
+		}
+
+		err = writeBlockFile(dir, UndoFilename(blockInfo.Number), blockInfo, undo)
+		if err != nil {
+			return err
+		}
+	}
+
+	return writeBlockFile(dir, BlockNumberToFilename(blockInfo.Number), blockInfo, operations)
+}
+
+// revertAbandonedBlocks turns the block files at or above the number of the new block
+// into revert files, starting with the highest one. A block file with the hash
+// of the new block is kept, since it is rewritten.
+func revertAbandonedBlocks(dir string, newBlock BlockInfo) error {
+
+	// the block is canonical (again), so consumers must not revert it
+	err := os.Remove(filepath.Join(dir, RevertFilename(newBlock.Number, newBlock.Hash)))
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("failed to remove revert file: %w", err)
+	}
+
+	abandoned := []BlockInfo{}
+
+	for number := newBlock.Number; ; number++ {
+		bi, err := readBlockInfo(filepath.Join(dir, BlockNumberToFilename(number)))
+		if errors.Is(err, os.ErrNotExist) {
+			break
+		}
+		if err != nil {
+			return err
+		}
+
+		if number == newBlock.Number && bi.Hash == newBlock.Hash {
+			continue
+		}
+
+		abandoned = append(abandoned, bi)
+	}
+
+	for i := len(abandoned) - 1; i >= 0; i-- {
+		bi := abandoned[i]
+
+		log.Info("reverting abandoned block in the WAL", "block", bi.Number, "hash", bi.Hash)
+
+		revertFilename := RevertFilename(bi.Number, bi.Hash)
+
+		err := os.Rename(filepath.Join(dir, UndoFilename(bi.Number)), filepath.Join(dir, revertFilename))
+		if errors.Is(err, os.ErrNotExist) {
+			log.Warn("no undo operations for abandoned block, the revert will not contain any operations", "block", bi.Number, "hash", bi.Hash)
+			err = writeBlockFile(dir, revertFilename, bi, nil)
+		}
+		if err != nil {
+			return fmt.Errorf("failed to write revert file for block %d: %w", bi.Number, err)
+		}
+
+		err = os.Remove(filepath.Join(dir, BlockNumberToFilename(bi.Number)))
+		if err != nil {
+			return fmt.Errorf("failed to remove abandoned block %d: %w", bi.Number, err)
+		}
+	}
+
+	return nil
+}
+
+// ReadBlockInfo reads the block info of the block file with the given number.
+func ReadBlockInfo(walDir string, blockNumber uint64) (BlockInfo, error) {
+	return readBlockInfo(filepath.Join(walDir, BlockNumberToFilename(blockNumber)))
+}
+
+// LastBlockNumber returns the number of the highest block file in the WAL directory.
+func LastBlockNumber(walDir string) (uint64, bool, error) {
+	entries, err := os.ReadDir(walDir)
+	if err != nil {
+		return 0, false, fmt.Errorf("failed to read wal dir: %w", err)
+	}
+
+	// the entries are sorted by filename, and the block numbers are zero padded
+	for i := len(entries) - 1; i >= 0; i-- {
+		number, err := PathToBlockNumber(entries[i].Name())
+		if err == nil {
+			return number, true, nil
+		}
+	}
+
+	return 0, false, nil
+}
+
+func readBlockInfo(path string) (BlockInfo, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return BlockInfo{}, err
+	}
+	defer f.Close()
+
+	bi := BlockInfo{}
+	err = json.NewDecoder(f).Decode(&bi)
+	if err != nil {
+		return BlockInfo{}, fmt.Errorf("failed to decode block info of %s: %w", path, err)
+	}
+
+	return bi, nil
+}
+
+// writeBlockFile writes the block info followed by the operations to a temp file
+// and renames it to the final filename, so that readers never see a partial file.
+func writeBlockFile(dir, filename string, blockInfo BlockInfo, operations []Operation) error {
+
+	tempFilename := filename + ".temp"
 
 	tf, err := os.OpenFile(filepath.Join(dir, tempFilename), os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0644)
 	if err != nil {
@@ -91,11 +267,36 @@ func WriteLogForBlock(dir string, block *types.Block, chainID *big.Int, receipts
 
 	enc := json.NewEncoder(tf)
 
-	enc.Encode(BlockInfo{
-		Number:     block.NumberU64(),
-		Hash:       block.Hash(),
-		ParentHash: block.ParentHash(),
-	})
+	err = enc.Encode(blockInfo)
+	if err != nil {
+		return fmt.Errorf("failed to encode block info: %w", err)
+	}
+
+	for _, operation := range operations {
+		err = enc.Encode(operation)
+		if err != nil {
+			return fmt.Errorf("failed to encode operation: %w", err)
+		}
+	}
+
+	err = tf.Close()
+	if err != nil {
+		return fmt.Errorf("failed to close temp file: %w", err)
+	}
+
+	err = os.Rename(filepath.Join(dir, tempFilename), filepath.Join(dir, filename))
+	if err != nil {
+		return fmt.Errorf("failed to rename temp file: %w", err)
+	}
+
+	return nil
+}
+
+// OperationsForBlock returns the operations applied to the entities by the successful
+// storage and housekeeping transactions of the block.
+func OperationsForBlock(block *types.Block, chainID *big.Int, receipts []*types.Receipt) ([]Operation, error) {
+
+	operations := []Operation{}
 
 	txns := block.Transactions()
 
@@ -119,18 +320,16 @@ func WriteLogForBlock(dir string, block *types.Block, chainID *big.Int, receipts
 					continue
 				}
 
-				if l.Topics[0] != storagetx.GolemBaseStorageEntityDeleted {
+				// blocks before the Golem Base storage rules switch have deleted logs
+				if l.Topics[0] != storagetx.GolemBaseStorageEntityExpired && l.Topics[0] != storagetx.GolemBaseStorageEntityDeleted {
 					continue
 				}
 
 				key := l.Topics[1]
 
-				err := enc.Encode(Operation{
-					Delete: &key,
+				operations = append(operations, Operation{
+					Expire: &key,
 				})
-				if err != nil {
-					return fmt.Errorf("failed to encode delete operation: %w", err)
-				}
 
 			}
 			// create
@@ -139,11 +338,13 @@ func WriteLogForBlock(dir string, block *types.Block, chainID *big.Int, receipts
 			stx := storagetx.StorageTransaction{}
 			err := rlp.DecodeBytes(tx.Data(), &stx)
 			if err != nil {
-				return fmt.Errorf("failed to decode storage transaction: %w", err)
+				return nil, fmt.Errorf("failed to decode storage transaction: %w", err)
 			}
 
 			createdLogs := []*types.Log{}
 			updatedLogs := []*types.Log{}
+			extendedLogs := []*types.Log{}
+			patchedLogs := []*types.Log{}
 
 			for _, log := range receipt.Logs {
 				if len(log.Topics) < 2 {
@@ -158,6 +359,14 @@ func WriteLogForBlock(dir string, block *types.Block, chainID *big.Int, receipts
 					updatedLogs = append(updatedLogs, log)
 				}
 
+				if log.Topics[0] == storagetx.GolemBaseStorageEntityTTLExtended {
+					extendedLogs = append(extendedLogs, log)
+				}
+
+				if log.Topics[0] == storagetx.GolemBaseStorageEntityPatched {
+					patchedLogs = append(patchedLogs, log)
+				}
+
 			}
 
 			for i, create := range stx.Create {
@@ -169,7 +378,7 @@ func WriteLogForBlock(dir string, block *types.Block, chainID *big.Int, receipts
 
 				from, err := types.Sender(signer, tx)
 				if err != nil {
-					return fmt.Errorf("failed to get sender of create transaction %s: %w", tx.Hash().Hex(), err)
+					return nil, fmt.Errorf("failed to get sender of create transaction %s: %w", tx.Hash().Hex(), err)
 				}
 
 				cr := Create{
@@ -181,12 +390,9 @@ func WriteLogForBlock(dir string, block *types.Block, chainID *big.Int, receipts
 					Owner:              from,
 				}
 
-				err = enc.Encode(Operation{
+				operations = append(operations, Operation{
 					Create: &cr,
 				})
-				if err != nil {
-					return fmt.Errorf("failed to encode create operation: %w", err)
-				}
 
 			}
 
@@ -205,37 +411,66 @@ func WriteLogForBlock(dir string, block *types.Block, chainID *big.Int, receipts
 					NumericAnnotations: update.NumericAnnotations,
 				}
 
-				err := enc.Encode(Operation{
+				operations = append(operations, Operation{
 					Update: &ur,
 				})
-				if err != nil {
-					return fmt.Errorf("failed to encode update operation: %w", err)
-				}
 			}
 
 			for _, del := range stx.Delete {
-				err := enc.Encode(Operation{
+				operations = append(operations, Operation{
 					Delete: &del,
 				})
-				if err != nil {
-					return fmt.Errorf("failed to encode delete operation: %w", err)
-				}
 			}
 
-		default:
-		}
+			for _, transfer := range stx.TransferOwnership {
+				operations = append(operations, Operation{
+					TransferOwnership: &TransferOwnership{
+						EntityKey: transfer.EntityKey,
+						NewOwner:  transfer.NewOwner,
+					},
+				})
+			}
 
-	}
+			for i := range stx.Extend {
 
-	err = tf.Close()
-	if err != nil {
-		return fmt.Errorf("failed to close temp file: %w", err)
-	}
+				log := extendedLogs[i]
+				key := log.Topics[1]
+				// the data contains the old and the new expiration block
+				expiresAtBlockU256 := uint256.NewInt(0).SetBytes(log.Data[32:])
+				expiresAtBlock := expiresAtBlockU256.Uint64()
+
+				operations = append(operations, Operation{
+					Extend: &ExtendTTL{
+						EntityKey:      key,
+						ExpiresAtBlock: expiresAtBlock,
+					},
+				})
+			}
+
+			for i, patch := range stx.Patch {
+
+				log := patchedLogs[i]
+				expiresAtBlockU256 := uint256.NewInt(0).SetBytes(log.Data)
+				expiresAtBlock := expiresAtBlockU256.Uint64()
+
+				operations = append(operations, Operation{
+					Patch: &Patch{
+						EntityKey:                patch.EntityKey,
+						ExpiresAtBlock:           expiresAtBlock,
+						ReplacePayload:           patch.ReplacePayload,
+						Payload:                  patch.Payload,
+						SetStringAnnotations:     patch.SetStringAnnotations,
+						SetNumericAnnotations:    patch.SetNumericAnnotations,
+						RemoveStringAnnotations:  patch.RemoveStringAnnotations,
+						RemoveNumericAnnotations: patch.RemoveNumericAnnotations,
+					},
+				})
+			}
+
+		default:
+		}
 
-	err = os.Rename(filepath.Join(dir, tempFilename), filepath.Join(dir, finalFilename))
-	if err != nil {
-		return fmt.Errorf("failed to rename temp file: %w", err)
 	}
 
-	return nil
+	return operations, nil
 }
@@ -214,6 +214,15 @@ type Config struct {
 
 	// GolemBaseWriteAheadLogDir is the path to the write-ahead log file for the Golem Base.
 	GolemBaseWriteAheadLogDir string `toml:",omitempty"`
+
+	// GolemBaseWriteAheadLogRetainBlocks is the number of most recent blocks kept in the write-ahead log, 0 keeps all blocks.
+	GolemBaseWriteAheadLogRetainBlocks uint64 `toml:",omitempty"`
+
+	// GolemBaseWriteAheadLogPruneAcknowledged removes the blocks of the write-ahead log acknowledged by all registered consumers.
+	GolemBaseWriteAheadLogPruneAcknowledged bool `toml:",omitempty"`
+
+	// GolemBaseWriteAheadLogSegmentsDir is the path to the directory the write-ahead log is also written to as segments.
+	GolemBaseWriteAheadLogSegmentsDir string `toml:",omitempty"`
 }
 
 // IPCEndpoint resolves an IPC endpoint based on a configured value, taking into
@@ -185,6 +185,7 @@ var (
 			Cancun: DefaultCancunBlobConfig,
 			Prague: DefaultPragueBlobConfig,
 		},
+		GolemBase: DefaultGolemBaseConfig,
 	}
 
 	// AllCliqueProtocolChanges contains every protocol change (EIPs) introduced
@@ -347,6 +348,16 @@ var (
 		Prague: DefaultPragueBlobConfig,
 		Osaka:  DefaultOsakaBlobConfig,
 	}
+	// DefaultGolemBaseConfig is the default Golem Base storage rules and limits for test chains,
+	// with the storage rules active from genesis.
+	DefaultGolemBaseConfig = &GolemBaseConfig{
+		StorageRulesTime:         newUint64(0),
+		MaxTTL:                   1_296_000, // 30 days of 2 second blocks
+		MaxPayloadSize:           512 * 1024,
+		MaxAnnotations:           32,
+		MaxAnnotationKeyLength:   256,
+		MaxAnnotationValueLength: 1024,
+	}
 )
 
 // NetworkNames are user friendly names to use in the chain spec banner.
@@ -432,6 +443,9 @@ type ChainConfig struct {
 
 	// Optimism config, nil if not active
 	Optimism *OptimismConfig `json:"optimism,omitempty"`
+
+	// Golem Base storage rules and limits, nil if the storage rules are never activated
+	GolemBase *GolemBaseConfig `json:"golemBase,omitempty"`
 }
 
 // EthashConfig is the consensus engine configs for proof-of-work based sealing.
@@ -465,6 +479,39 @@ func (o *OptimismConfig) String() string {
 	return "optimism"
 }
 
+// GolemBaseConfig holds the switch time of the Golem Base storage rules and the limits
+// enforced on storage transactions under them. Before the switch, storage transactions
+// are executed as by the first releases: without ownership checks, gas charges, limits,
+// string prefix and numeric range indexes, and with deletion logs for expired entities.
+// All limits must be set if the storage rules are activated.
+type GolemBaseConfig struct {
+	StorageRulesTime *uint64 `json:"storageRulesTime,omitempty"` // Storage rules switch time (nil = no fork, 0 = already active)
+
+	MaxTTL                   uint64 `json:"maxTTL,omitempty"`                   // Maximum number of blocks an entity can be stored for
+	MaxPayloadSize           uint64 `json:"maxPayloadSize,omitempty"`           // Maximum size of the payload of an entity in bytes
+	MaxAnnotations           uint64 `json:"maxAnnotations,omitempty"`           // Maximum number of string and numeric annotations of an entity
+	MaxAnnotationKeyLength   uint64 `json:"maxAnnotationKeyLength,omitempty"`   // Maximum length of an annotation key in bytes
+	MaxAnnotationValueLength uint64 `json:"maxAnnotationValueLength,omitempty"` // Maximum length of a string annotation value in bytes
+}
+
+// String implements the stringer interface, returning the Golem Base storage rules and limits.
+func (c *GolemBaseConfig) String() string {
+	storageRules := "nil"
+	if c.StorageRulesTime != nil {
+		storageRules = fmt.Sprintf("@%d", *c.StorageRulesTime)
+	}
+	return fmt.Sprintf("golembase(storageRules: %s, maxTTL: %d, maxPayloadSize: %d, maxAnnotations: %d, maxAnnotationKeyLength: %d, maxAnnotationValueLength: %d)",
+		storageRules, c.MaxTTL, c.MaxPayloadSize, c.MaxAnnotations, c.MaxAnnotationKeyLength, c.MaxAnnotationValueLength)
+}
+
+// storageRulesTime returns the switch time of the storage rules, nil if the config is nil.
+func (c *GolemBaseConfig) storageRulesTime() *uint64 {
+	if c == nil {
+		return nil
+	}
+	return c.StorageRulesTime
+}
+
 // Description returns a human-readable description of ChainConfig.
 func (c *ChainConfig) Description() string {
 	var banner string
@@ -569,6 +616,9 @@ func (c *ChainConfig) Description() string {
 	if c.InteropTime != nil {
 		banner += fmt.Sprintf(" - Interop:                     @%-10v\n", *c.InteropTime)
 	}
+	if c.GolemBase != nil && c.GolemBase.StorageRulesTime != nil {
+		banner += fmt.Sprintf(" - Golem Base storage rules:    @%-10v\n", *c.GolemBase.StorageRulesTime)
+	}
 	return banner
 }
 
@@ -752,6 +802,12 @@ func (c *ChainConfig) IsInterop(time uint64) bool {
 	return isTimestampForked(c.InteropTime, time)
 }
 
+// IsGolemBaseStorageRules returns whether time is either equal to the switch time of the
+// Golem Base storage rules or greater.
+func (c *ChainConfig) IsGolemBaseStorageRules(time uint64) bool {
+	return c.GolemBase != nil && isTimestampForked(c.GolemBase.StorageRulesTime, time)
+}
+
 // IsOptimism returns whether the node is an optimism node or not.
 func (c *ChainConfig) IsOptimism() bool {
 	return c.Optimism != nil
@@ -892,6 +948,13 @@ func (c *ChainConfig) CheckConfigForkOrder() error {
 		}
 	}
 
+	// Storage transactions are only bounded by the limits of the Golem Base storage rules.
+	if c.GolemBase != nil && c.GolemBase.StorageRulesTime != nil {
+		if err := c.GolemBase.validate(); err != nil {
+			return fmt.Errorf("invalid Golem Base config: %w", err)
+		}
+	}
+
 	// OP-Stack chains don't support blobs, and must have a nil BlobScheduleConfig.
 	if c.IsOptimism() {
 		if c.BlobScheduleConfig == nil {
@@ -943,6 +1006,25 @@ func (bc *BlobConfig) validate() error {
 	return nil
 }
 
+// validate checks that all limits are set.
+func (c *GolemBaseConfig) validate() error {
+	for _, limit := range []struct {
+		name  string
+		value uint64
+	}{
+		{"maxTTL", c.MaxTTL},
+		{"maxPayloadSize", c.MaxPayloadSize},
+		{"maxAnnotations", c.MaxAnnotations},
+		{"maxAnnotationKeyLength", c.MaxAnnotationKeyLength},
+		{"maxAnnotationValueLength", c.MaxAnnotationValueLength},
+	} {
+		if limit.value == 0 {
+			return fmt.Errorf("%s must be defined and non-zero", limit.name)
+		}
+	}
+	return nil
+}
+
 func (c *ChainConfig) checkCompatible(newcfg *ChainConfig, headNumber *big.Int, headTimestamp uint64, genesisTimestamp *uint64) *ConfigCompatError {
 	if isForkBlockIncompatible(c.HomesteadBlock, newcfg.HomesteadBlock, headNumber) {
 		return newBlockCompatError("Homestead fork block", c.HomesteadBlock, newcfg.HomesteadBlock)
@@ -1044,6 +1126,9 @@ func (c *ChainConfig) checkCompatible(newcfg *ChainConfig, headNumber *big.Int,
 	if isForkTimestampIncompatible(c.InteropTime, newcfg.InteropTime, headTimestamp, genesisTimestamp) {
 		return newTimestampCompatError("Interop fork timestamp", c.InteropTime, newcfg.InteropTime)
 	}
+	if isForkTimestampIncompatible(c.GolemBase.storageRulesTime(), newcfg.GolemBase.storageRulesTime(), headTimestamp, genesisTimestamp) {
+		return newTimestampCompatError("Golem Base storage rules timestamp", c.GolemBase.storageRulesTime(), newcfg.GolemBase.storageRulesTime())
+	}
 	return nil
 }
 
@@ -235,3 +235,34 @@ func TestConfigRulesRegolith(t *testing.T) {
 		t.Errorf("expected %v to be regolith", stamp)
 	}
 }
+
+func TestGolemBaseStorageRules(t *testing.T) {
+	c := &ChainConfig{}
+	require.False(t, c.IsGolemBaseStorageRules(math.MaxInt64))
+
+	c.GolemBase = &GolemBaseConfig{}
+	require.False(t, c.IsGolemBaseStorageRules(math.MaxInt64))
+
+	c.GolemBase.StorageRulesTime = newUint64(500)
+	require.False(t, c.IsGolemBaseStorageRules(499))
+	require.True(t, c.IsGolemBaseStorageRules(500))
+
+	// moving the switch before the head is not compatible with the stored chain
+	moved := &ChainConfig{GolemBase: &GolemBaseConfig{StorageRulesTime: newUint64(100)}}
+	require.NotNil(t, c.CheckCompatible(moved, 0, 200, newUint64(0)))
+	require.Nil(t, c.CheckCompatible(moved, 0, 50, newUint64(0)))
+}
+
+func TestGolemBaseLimitsRequired(t *testing.T) {
+	c := *AllDevChainProtocolChanges
+	require.NoError(t, c.CheckConfigForkOrder())
+
+	limits := *DefaultGolemBaseConfig
+	limits.MaxAnnotationValueLength = 0
+	c.GolemBase = &limits
+	require.ErrorContains(t, c.CheckConfigForkOrder(), "maxAnnotationValueLength must be defined and non-zero")
+
+	// the limits are not used before the storage rules are activated
+	limits.StorageRulesTime = nil
+	require.NoError(t, c.CheckConfigForkOrder())
+}